@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+)
+
+// junitSuite is the JUnit XML root element most CI systems (and cwltest
+// itself) expect: one <testsuite> holding one <testcase> per conformance
+// case, with a <failure> child on any that didn't pass.
+type junitSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes results as JUnit XML to path.
+func WriteJUnit(path, suiteName string, results []CaseResult) error {
+	suite := junitSuite{Name: suiteName, Tests: len(results)}
+	for _, r := range results {
+		tc := junitTestCase{Name: r.ID}
+		if !r.Passed {
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: r.Message, Text: r.Message}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	return os.WriteFile(path, data, 0o644)
+}
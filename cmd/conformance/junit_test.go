@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnitIncludesFailureDetail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.xml")
+	results := []CaseResult{
+		{ID: "pass-case", Passed: true},
+		{ID: "fail-case", Passed: false, Message: "outputs mismatch"},
+	}
+
+	if err := WriteJUnit(path, "cwl-conformance", results); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.Contains(out, `tests="2"`) || !strings.Contains(out, `failures="1"`) {
+		t.Fatalf("expected test/failure counts in output: %s", out)
+	}
+	if !strings.Contains(out, "fail-case") || !strings.Contains(out, "outputs mismatch") {
+		t.Fatalf("expected failing case detail in output: %s", out)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// tagList collects repeated -tag flags into a []string.
+type tagList []string
+
+func (t *tagList) String() string     { return strings.Join(*t, ",") }
+func (t *tagList) Set(v string) error { *t = append(*t, v); return nil }
+
+func main() {
+	manifestPath := flag.String("manifest", "", "path to the conformance test manifest (JSON array of test cases)")
+	serverURL := flag.String("server", envOr("FLOWY_SERVER_URL", "http://localhost:8080"), "base URL of the flowy server to run cases against")
+	junitPath := flag.String("junit", "", "path to write JUnit XML results to (skipped if empty)")
+	resultsPath := flag.String("results", "conformance-results.json", "path to the results cache used by -rerun-failed")
+	rerunFailed := flag.Bool("rerun-failed", false, "only run cases that failed in the last run recorded in -results")
+	mode := flag.String("mode", "", "only run cases whose mode is this or unset (\"local-fs\" or \"s3\")")
+	timeout := flag.Duration("timeout", 5*time.Minute, "how long to wait for each case's job to complete")
+	var tags tagList
+	flag.Var(&tags, "tag", "only run cases carrying this tag (may be repeated; all must match)")
+	flag.Parse()
+
+	if *manifestPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: conformance -manifest <path> [-server url] [-junit path] [-tag feature]...")
+		os.Exit(2)
+	}
+
+	if err := run(*manifestPath, *serverURL, *junitPath, *resultsPath, *rerunFailed, *mode, tags, *timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "conformance: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func run(manifestPath, serverURL, junitPath, resultsPath string, rerunFailed bool, mode string, tags tagList, timeout time.Duration) error {
+	cases, err := LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	prior, err := LoadPriorResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("loading prior results: %w", err)
+	}
+	failedBefore := prior.failedIDs()
+
+	selected := make([]TestCase, 0, len(cases))
+	for _, tc := range cases {
+		if !tc.matchesFilters(tags, mode) {
+			continue
+		}
+		if rerunFailed && !failedBefore[tc.ID] {
+			continue
+		}
+		selected = append(selected, tc)
+	}
+
+	results := make([]CaseResult, 0, len(selected))
+	next := prior
+	if next == nil {
+		next = PriorResults{}
+	}
+	failCount := 0
+	for _, tc := range selected {
+		result := runCase(serverURL, tc, timeout)
+		results = append(results, result)
+		next[tc.ID] = result.Passed
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+			failCount++
+		}
+		fmt.Printf("%-4s %s\n", status, tc.ID)
+		if !result.Passed {
+			fmt.Printf("     %s\n", result.Message)
+		}
+	}
+
+	if err := next.Save(resultsPath); err != nil {
+		return fmt.Errorf("saving results: %w", err)
+	}
+	if junitPath != "" {
+		if err := WriteJUnit(junitPath, "cwl-conformance", results); err != nil {
+			return fmt.Errorf("writing JUnit report: %w", err)
+		}
+	}
+
+	fmt.Printf("%d/%d passed\n", len(selected)-failCount, len(selected))
+	if failCount > 0 {
+		return fmt.Errorf("%d case(s) failed", failCount)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Command conformance runs a manifest of CWL conformance cases against a
+// running flowy server, tags results by feature, records them as JUnit XML,
+// and can rerun only the cases that failed last time. It replaces the
+// ad-hoc bash wrapper previously used to drive the CWL conformance suite.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// TestCase is one manifest entry: a pre-resolved job to submit (conformance
+// cases are expressed directly as ExecutableJob payloads rather than raw
+// CWL, since job graph resolution from CWL documents happens on the server,
+// not in this repo), the CWL features it exercises, and the output object
+// its Outputs must match for the case to pass.
+type TestCase struct {
+	ID       string                 `json:"id"`
+	Tags     []string               `json:"tags,omitempty"`
+	Mode     string                 `json:"mode,omitempty"` // "local-fs" or "s3"; empty means either
+	Job      api.ExecutableJob      `json:"job"`
+	Expected map[string]interface{} `json:"expected"`
+}
+
+// LoadManifest reads a JSON array of TestCase from path.
+func LoadManifest(path string) ([]TestCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %s: %w", path, err)
+	}
+	var cases []TestCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// hasTag reports whether tc carries tag among its Tags.
+func (tc TestCase) hasTag(tag string) bool {
+	for _, t := range tc.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters reports whether tc should run given the requested tags
+// (a case must carry every requested tag; no tags requested matches
+// everything) and mode (empty requestedMode matches every case).
+func (tc TestCase) matchesFilters(tags []string, requestedMode string) bool {
+	if requestedMode != "" && tc.Mode != "" && tc.Mode != requestedMode {
+		return false
+	}
+	for _, tag := range tags {
+		if !tc.hasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
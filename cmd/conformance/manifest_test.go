@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadManifestParsesCases(t *testing.T) {
+	path := writeManifest(t, `[
+		{"id": "basic", "tags": ["core"], "job": {"jobId": "j1", "command": ["echo", "hi"]}, "expected": {}}
+	]`)
+
+	cases, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 1 || cases[0].ID != "basic" || cases[0].Job.JobID != "j1" {
+		t.Fatalf("unexpected cases: %+v", cases)
+	}
+}
+
+func TestMatchesFiltersRequiresEveryTag(t *testing.T) {
+	tc := TestCase{ID: "t", Tags: []string{"core", "docker"}}
+
+	if !tc.matchesFilters([]string{"core"}, "") {
+		t.Fatal("expected a subset of tags to match")
+	}
+	if tc.matchesFilters([]string{"core", "s3"}, "") {
+		t.Fatal("expected a tag the case doesn't carry to fail the match")
+	}
+}
+
+func TestMatchesFiltersHonorsMode(t *testing.T) {
+	tc := TestCase{ID: "t", Mode: "s3"}
+
+	if tc.matchesFilters(nil, "local-fs") {
+		t.Fatal("expected an s3-only case to be excluded from a local-fs run")
+	}
+	if !tc.matchesFilters(nil, "s3") {
+		t.Fatal("expected an s3-only case to be included in an s3 run")
+	}
+	if !tc.matchesFilters(nil, "") {
+		t.Fatal("expected no mode filter to include every case")
+	}
+}
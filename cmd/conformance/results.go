@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// PriorResults records the outcome of the last run of each test case by
+// ID, so --rerun-failed can select just the ones that didn't pass.
+type PriorResults map[string]bool
+
+// LoadPriorResults reads a previously saved results file. A missing file
+// is treated as no prior run, not an error.
+func LoadPriorResults(path string) (PriorResults, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return PriorResults{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var results PriorResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Save writes results to path as JSON.
+func (r PriorResults) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// failedIDs returns the IDs r recorded as not passing.
+func (r PriorResults) failedIDs() map[string]bool {
+	failed := make(map[string]bool, len(r))
+	for id, passed := range r {
+		if !passed {
+			failed[id] = true
+		}
+	}
+	return failed
+}
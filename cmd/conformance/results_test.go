@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPriorResultsMissingFileIsEmpty(t *testing.T) {
+	results, err := LoadPriorResults(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected no prior results, got %v", results)
+	}
+}
+
+func TestSaveAndLoadPriorResultsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	results := PriorResults{"a": true, "b": false}
+	if err := results.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPriorResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded["a"] != true || loaded["b"] != false {
+		t.Fatalf("unexpected round trip: %v", loaded)
+	}
+}
+
+func TestFailedIDsReturnsOnlyFailures(t *testing.T) {
+	results := PriorResults{"a": true, "b": false, "c": false}
+	failed := results.failedIDs()
+	if len(failed) != 2 || !failed["b"] || !failed["c"] {
+		t.Fatalf("unexpected failed set: %v", failed)
+	}
+}
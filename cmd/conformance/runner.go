@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// CaseResult is the outcome of running one TestCase.
+type CaseResult struct {
+	ID      string
+	Passed  bool
+	Message string
+}
+
+// submitJob posts job to the server's job queue, returning its assigned
+// JobID (the server always echoes it back on the same object it accepted).
+func submitJob(serverURL string, job api.ExecutableJob) (string, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.Post(serverURL+"/api/jobs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("submitting job: server returned %s", resp.Status)
+	}
+	return job.JobID, nil
+}
+
+// pollJob polls GET /api/jobs/<jobID> until it reports completion (Success
+// true, or a non-empty ErrorMsg) or timeout elapses.
+func pollJob(serverURL, jobID string, timeout time.Duration) (*api.JobFinishedRequest, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := http.Get(serverURL + "/api/jobs/" + jobID)
+		if err != nil {
+			return nil, err
+		}
+		var result api.JobFinishedRequest
+		decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		if result.Success || result.ErrorMsg != "" {
+			return &result, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("job %s did not complete within %s", jobID, timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// runCase submits tc's job, waits for it to finish, and compares the
+// outputs it reports against tc.Expected.
+func runCase(serverURL string, tc TestCase, timeout time.Duration) CaseResult {
+	jobID, err := submitJob(serverURL, tc.Job)
+	if err != nil {
+		return CaseResult{ID: tc.ID, Passed: false, Message: fmt.Sprintf("submitting: %v", err)}
+	}
+
+	result, err := pollJob(serverURL, jobID, timeout)
+	if err != nil {
+		return CaseResult{ID: tc.ID, Passed: false, Message: fmt.Sprintf("polling: %v", err)}
+	}
+	if !result.Success {
+		return CaseResult{ID: tc.ID, Passed: false, Message: fmt.Sprintf("job failed: %s", result.ErrorMsg)}
+	}
+	if !reflect.DeepEqual(result.Outputs, tc.Expected) {
+		return CaseResult{ID: tc.ID, Passed: false, Message: fmt.Sprintf("outputs mismatch: got %v, want %v", result.Outputs, tc.Expected)}
+	}
+	return CaseResult{ID: tc.ID, Passed: true}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestRunCasePassesWhenOutputsMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		json.NewEncoder(w).Encode(api.JobFinishedRequest{
+			JobID:   "job-1",
+			Success: true,
+			Outputs: map[string]interface{}{"out": "value"},
+		})
+	}))
+	defer server.Close()
+
+	tc := TestCase{
+		ID:       "case-1",
+		Job:      api.ExecutableJob{JobID: "job-1"},
+		Expected: map[string]interface{}{"out": "value"},
+	}
+
+	result := runCase(server.URL, tc, time.Second)
+	if !result.Passed {
+		t.Fatalf("expected the case to pass, got %+v", result)
+	}
+}
+
+func TestRunCaseFailsOnOutputMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		json.NewEncoder(w).Encode(api.JobFinishedRequest{
+			JobID:   "job-1",
+			Success: true,
+			Outputs: map[string]interface{}{"out": "wrong"},
+		})
+	}))
+	defer server.Close()
+
+	tc := TestCase{
+		ID:       "case-1",
+		Job:      api.ExecutableJob{JobID: "job-1"},
+		Expected: map[string]interface{}{"out": "value"},
+	}
+
+	result := runCase(server.URL, tc, time.Second)
+	if result.Passed {
+		t.Fatal("expected the case to fail on an output mismatch")
+	}
+}
+
+func TestRunCaseFailsWhenJobReportsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		json.NewEncoder(w).Encode(api.JobFinishedRequest{
+			JobID:    "job-1",
+			Success:  false,
+			ErrorMsg: "tool exited 1",
+		})
+	}))
+	defer server.Close()
+
+	tc := TestCase{ID: "case-1", Job: api.ExecutableJob{JobID: "job-1"}}
+
+	result := runCase(server.URL, tc, time.Second)
+	if result.Passed {
+		t.Fatal("expected the case to fail")
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/jobtemplate"
+)
+
+// runArray implements `cwlclient array -samples samples.tsv tool.cwl
+// template.yaml`: it expands samples.tsv into one job order per row,
+// rendering template.yaml as a Go text/template against that row's
+// columns, and submits each as a JobOrderSubmission against toolPath,
+// aggregating per-row submission status so fanning a tool out across a
+// whole sample sheet is one command instead of hand-writing one job order
+// per sample.
+func runArray(args []string) error {
+	fs := flag.NewFlagSet("array", flag.ContinueOnError)
+	samplesPath := fs.String("samples", "", "TSV or CSV file of sample metadata, one row per job; its header row names the template's placeholders")
+	serverAddr := fs.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server")
+	project := fs.String("project", os.Getenv("FLOWY_PROJECT"), "project to submit every job order under")
+	strict := fs.Bool("strict", false, "fail on any schema-salad validation warning for tool.cwl, not just a hard parse error")
+	nonStrict := fs.Bool("non-strict", false, "explicitly request the default relaxed validation of tool.cwl (accepted for symmetry with -strict)")
+	datasetCatalog := fs.String("dataset-catalog", os.Getenv("FLOWY_DATASET_CATALOG"), "base URL of a dataset catalog server to resolve \"dataset://\" input references against before submission")
+	label := fs.String("label", "", "comma-separated key=value labels (e.g. \"project=alpha,cost-center=42\") applied to every job order in this array")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *strict && *nonStrict {
+		return fmt.Errorf("-strict and -non-strict are mutually exclusive")
+	}
+	rest := fs.Args()
+	if *samplesPath == "" || len(rest) != 2 {
+		return fmt.Errorf("usage: cwlclient array -samples samples.tsv [-server url] [-project name] [-strict|-non-strict] [-dataset-catalog url] [-label k=v,...] <tool.cwl> <template.yaml>")
+	}
+	toolPath, templatePath := rest[0], rest[1]
+
+	labels, err := parseLabels(*label)
+	if err != nil {
+		return fmt.Errorf("parsing -label: %w", err)
+	}
+
+	addr := *serverAddr
+	if addr == "" {
+		addr = "http://localhost:8080"
+	}
+
+	resolvedToolPath, toolCommitHash, err := resolveToolPath(toolPath, defaultGitCacheDir())
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", toolPath, err)
+	}
+	toolPath = resolvedToolPath
+
+	if err := validateCWLDocument(toolPath, *strict); err != nil {
+		return err
+	}
+
+	rows, err := readSampleRows(*samplesPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", *samplesPath, err)
+	}
+	templateData, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", templatePath, err)
+	}
+	tmpl, err := template.New(filepath.Base(templatePath)).Parse(string(templateData))
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", templatePath, err)
+	}
+
+	var datasetClient DatasetCatalogClient
+	if *datasetCatalog != "" {
+		datasetClient = newHTTPDatasetCatalog(*datasetCatalog)
+	}
+
+	results := make([]arrayRowResult, 0, len(rows))
+	for i, row := range rows {
+		label := row["sample_id"]
+		if label == "" {
+			label = fmt.Sprintf("row-%d", i+1)
+		}
+		inputs, err := jobtemplate.Render(tmpl, row)
+		if err != nil {
+			results = append(results, arrayRowResult{Label: label, Err: fmt.Errorf("rendering template: %w", err)})
+			continue
+		}
+		if datasetClient != nil {
+			if err := resolveDatasetRefs(inputs, datasetClient); err != nil {
+				results = append(results, arrayRowResult{Label: label, Err: fmt.Errorf("resolving dataset references: %w", err)})
+				continue
+			}
+		}
+		jobID, err := submitJobOrder(addr, toolPath, inputs, *project, label, toolCommitHash, labels)
+		results = append(results, arrayRowResult{Label: label, JobID: jobID, Err: err})
+	}
+
+	failures := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failures++
+			fmt.Printf("%-20s FAILED  %v\n", r.Label, r.Err)
+			continue
+		}
+		fmt.Printf("%-20s submitted %s\n", r.Label, r.JobID)
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d job orders failed to submit", failures, len(results))
+	}
+	return nil
+}
+
+// arrayRowResult is one samples.tsv row's submission outcome.
+type arrayRowResult struct {
+	Label string
+	JobID string
+	Err   error
+}
+
+// readSampleRows parses path's header row and data rows into a slice of
+// column-name-to-value maps, one per data row. A path ending in ".tsv" is
+// split on tabs; anything else is treated as comma-separated.
+func readSampleRows(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	if strings.HasSuffix(strings.ToLower(path), ".tsv") {
+		r.Comma = '\t'
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no rows found")
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(record) {
+				row[col] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// submitJobOrder posts a job order (the tool to run plus its resolved
+// input object) to the server, which compiles it into one or more
+// ExecutableJob payloads the way it already does for interactively
+// submitted jobs, returning the JobID it assigned. toolCommitHash is the
+// commit toolPath was resolved from, when it was a "git+" reference; pass
+// "" when toolPath is an ordinary local path.
+func submitJobOrder(serverAddr, toolPath string, inputs map[string]interface{}, project, label, toolCommitHash string, labels map[string]string) (string, error) {
+	body, err := json.Marshal(api.JobOrderSubmission{Tool: toolPath, Inputs: inputs, Project: project, Label: label, ToolCommitHash: toolCommitHash, Labels: labels})
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Post(serverAddr+"/api/job-orders", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var result api.JobOrderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.JobID, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSampleRowsTSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.tsv")
+	content := "sample_id\tfastq\nsample1\t/data/s1.fq\nsample2\t/data/s2.fq\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readSampleRows(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0]["sample_id"] != "sample1" || rows[0]["fastq"] != "/data/s1.fq" {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1]["sample_id"] != "sample2" {
+		t.Fatalf("unexpected second row: %+v", rows[1])
+	}
+}
+
+func TestReadSampleRowsCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.csv")
+	content := "sample_id,fastq\nsample1,/data/s1.fq\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readSampleRows(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["fastq"] != "/data/s1.fq" {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+}
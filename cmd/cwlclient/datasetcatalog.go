@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// datasetRefPrefix marks a job order input value as a symbolic reference
+// into a dataset catalog (e.g. "dataset://project/sample1/reads.fastq.gz")
+// rather than a location the worker can stage directly, the way DNAnexus
+// and Seven Bridges let a project's data be referenced by a stable,
+// environment-independent name instead of a concrete S3 URL or path. This
+// keeps a job order portable across deployments: the same job.json runs
+// against whichever catalog FLOWY_DATASET_CATALOG points at.
+const datasetRefPrefix = "dataset://"
+
+// DatasetCatalogClient resolves a "dataset://" reference to the concrete
+// location a FileManager can stage it from. Declared as an interface, not
+// a concrete client, so a site's own catalog (DNAnexus, Seven Bridges, or
+// an in-house dataset registry) can be wired in without this repo
+// depending on any particular catalog's SDK.
+type DatasetCatalogClient interface {
+	Resolve(ref string) (string, error)
+}
+
+// httpDatasetCatalog resolves references against a catalog server reachable
+// over HTTP, the default DatasetCatalogClient for `cwlclient submit` and
+// `cwlclient array`.
+type httpDatasetCatalog struct {
+	baseURL string
+}
+
+// newHTTPDatasetCatalog returns a DatasetCatalogClient backed by the
+// catalog server at baseURL.
+func newHTTPDatasetCatalog(baseURL string) *httpDatasetCatalog {
+	return &httpDatasetCatalog{baseURL: baseURL}
+}
+
+// datasetCatalogResponse is the assumed REST contract this repo has no
+// vendored client library for: a catalog server resolves a reference to
+// the concrete location a FileManager can stage, mirroring how doiref.go
+// and gitref.go each assume a minimal REST/Git contract for the external
+// registry they talk to.
+type datasetCatalogResponse struct {
+	Location string `json:"location"`
+}
+
+func (c *httpDatasetCatalog) Resolve(ref string) (string, error) {
+	name := ref[len(datasetRefPrefix):]
+	resp, err := http.Get(c.baseURL + "/resolve?ref=" + url.QueryEscape(name))
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("resolving %s: catalog returned %s: %s", ref, resp.Status, body)
+	}
+
+	var result datasetCatalogResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	if result.Location == "" {
+		return "", fmt.Errorf("resolving %s: catalog returned no location", ref)
+	}
+	return result.Location, nil
+}
+
+// resolveDatasetRefs walks inputs (a CWL job order, as built by a
+// -dataset-catalog caller from a template or read from disk) and replaces
+// every string value beginning with datasetRefPrefix, wherever it appears
+// in a nested File/Directory object or array, with client's resolution of
+// it. inputs is mutated in place.
+func resolveDatasetRefs(inputs map[string]interface{}, client DatasetCatalogClient) error {
+	for k, v := range inputs {
+		resolved, err := resolveDatasetRefsInValue(v, client)
+		if err != nil {
+			return fmt.Errorf("input %q: %w", k, err)
+		}
+		inputs[k] = resolved
+	}
+	return nil
+}
+
+// resolveDatasetRefsInValue recurses into v, which may be a CWL File or
+// Directory object (map[string]interface{}), an array of inputs, or a
+// scalar, resolving every "dataset://" string it finds.
+func resolveDatasetRefsInValue(v interface{}, client DatasetCatalogClient) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !hasDatasetRefPrefix(val) {
+			return val, nil
+		}
+		return client.Resolve(val)
+	case map[string]interface{}:
+		for k, child := range val {
+			resolved, err := resolveDatasetRefsInValue(child, client)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = resolved
+		}
+		return val, nil
+	case []interface{}:
+		for i, child := range val {
+			resolved, err := resolveDatasetRefsInValue(child, client)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = resolved
+		}
+		return val, nil
+	default:
+		return v, nil
+	}
+}
+
+func hasDatasetRefPrefix(v string) bool {
+	return len(v) >= len(datasetRefPrefix) && v[:len(datasetRefPrefix)] == datasetRefPrefix
+}
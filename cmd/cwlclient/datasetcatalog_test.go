@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeDatasetCatalog is an in-memory DatasetCatalogClient, standing in for
+// a real catalog server the same way fakeS3API stands in for a bucket.
+type fakeDatasetCatalog struct {
+	locations map[string]string
+}
+
+func (f *fakeDatasetCatalog) Resolve(ref string) (string, error) {
+	loc, ok := f.locations[ref]
+	if !ok {
+		return "", fmt.Errorf("no such dataset reference: %s", ref)
+	}
+	return loc, nil
+}
+
+func TestResolveDatasetRefsRewritesNestedFileLocations(t *testing.T) {
+	client := &fakeDatasetCatalog{locations: map[string]string{
+		"dataset://project/sample1/reads.fastq.gz": "s3://genomics-bucket/project/sample1/reads.fastq.gz",
+	}}
+
+	inputs := map[string]interface{}{
+		"reads": map[string]interface{}{
+			"class":    "File",
+			"location": "dataset://project/sample1/reads.fastq.gz",
+		},
+		"threads": float64(4),
+		"extra": []interface{}{
+			map[string]interface{}{"class": "File", "location": "/already/local/path.txt"},
+		},
+	}
+
+	if err := resolveDatasetRefs(inputs, client); err != nil {
+		t.Fatal(err)
+	}
+
+	reads := inputs["reads"].(map[string]interface{})
+	if got := reads["location"]; got != "s3://genomics-bucket/project/sample1/reads.fastq.gz" {
+		t.Fatalf("reads location = %v, want resolved s3 location", got)
+	}
+	if inputs["threads"] != float64(4) {
+		t.Fatalf("non-reference scalar should be left untouched, got %v", inputs["threads"])
+	}
+	extra := inputs["extra"].([]interface{})[0].(map[string]interface{})
+	if got := extra["location"]; got != "/already/local/path.txt" {
+		t.Fatalf("non-dataset location should be left untouched, got %v", got)
+	}
+}
+
+func TestResolveDatasetRefsSurfacesCatalogError(t *testing.T) {
+	client := &fakeDatasetCatalog{locations: map[string]string{}}
+	inputs := map[string]interface{}{
+		"reads": "dataset://project/missing.fastq.gz",
+	}
+	if err := resolveDatasetRefs(inputs, client); err == nil {
+		t.Fatal("expected an unresolvable dataset reference to error")
+	}
+}
+
+func TestHTTPDatasetCatalogResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ref"); got != "project/sample1/reads.fastq.gz" {
+			t.Errorf("catalog request ref = %q", got)
+		}
+		fmt.Fprint(w, `{"location":"s3://genomics-bucket/project/sample1/reads.fastq.gz"}`)
+	}))
+	defer server.Close()
+
+	client := newHTTPDatasetCatalog(server.URL)
+	loc, err := client.Resolve("dataset://project/sample1/reads.fastq.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc != "s3://genomics-bucket/project/sample1/reads.fastq.gz" {
+		t.Fatalf("Resolve() = %q", loc)
+	}
+}
+
+func TestHasDatasetRefPrefix(t *testing.T) {
+	if !hasDatasetRefPrefix("dataset://project/x") {
+		t.Fatal("expected dataset:// prefix to be recognized")
+	}
+	if hasDatasetRefPrefix("s3://bucket/key") {
+		t.Fatal("expected a non-dataset location to not match")
+	}
+}
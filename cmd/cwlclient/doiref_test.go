@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDOIRef(t *testing.T) {
+	ref, ok := parseDOIRef("doi:10.5281/zenodo.1234567")
+	if !ok || ref.DOI != "10.5281/zenodo.1234567" {
+		t.Fatalf("unexpected ref: %+v, ok=%v", ref, ok)
+	}
+
+	ref, ok = parseDOIRef("https://workflowhub.eu/workflows/42")
+	if !ok || ref.WorkflowHubURL != "https://workflowhub.eu/workflows/42" {
+		t.Fatalf("unexpected ref: %+v, ok=%v", ref, ok)
+	}
+
+	if _, ok := parseDOIRef("tools/align.cwl"); ok {
+		t.Fatal("expected an ordinary local path to not parse as a DOI reference")
+	}
+	if _, ok := parseDOIRef("git+https://example.com/repo.git#main:tool.cwl"); ok {
+		t.Fatal("expected a git+ reference to not parse as a DOI reference")
+	}
+}
+
+func TestPickCWLFile(t *testing.T) {
+	files := []registryFile{{Name: "README.md"}, {Name: "workflow.cwl"}, {Name: "inputs.json"}}
+	if got := pickCWLFile(files); got.Name != "workflow.cwl" {
+		t.Fatalf("pickCWLFile() = %q, want workflow.cwl", got.Name)
+	}
+
+	noCWL := []registryFile{{Name: "README.md"}, {Name: "inputs.json"}}
+	if got := pickCWLFile(noCWL); got.Name != "README.md" {
+		t.Fatalf("pickCWLFile() with no .cwl file = %q, want first file README.md", got.Name)
+	}
+}
+
+func TestNewChecksumHash(t *testing.T) {
+	if _, digest, err := newChecksumHash("md5:abc123"); err != nil || digest != "abc123" {
+		t.Fatalf("newChecksumHash(md5) = digest=%q err=%v", digest, err)
+	}
+	if _, digest, err := newChecksumHash("sha256:def456"); err != nil || digest != "def456" {
+		t.Fatalf("newChecksumHash(sha256) = digest=%q err=%v", digest, err)
+	}
+	if _, _, err := newChecksumHash("crc32:abc123"); err == nil {
+		t.Fatal("expected an unsupported algorithm to error")
+	}
+	if _, _, err := newChecksumHash("no-colon-here"); err == nil {
+		t.Fatal("expected a checksum with no algorithm prefix to error")
+	}
+}
+
+func TestDownloadAndVerify(t *testing.T) {
+	content := []byte("cwlVersion: v1.2\n")
+	sum := md5.Sum(content)
+	digest := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	file := registryFile{Name: "tool.cwl", DownloadURL: server.URL, Checksum: "md5:" + digest}
+	path, err := downloadAndVerify(file, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(content) {
+		t.Fatalf("downloaded content = %q, want %q", data, content)
+	}
+
+	badFile := registryFile{Name: "tool.cwl", DownloadURL: server.URL, Checksum: "md5:0000000000000000000000000000000"}
+	if _, err := downloadAndVerify(badFile, cacheDir); err == nil {
+		t.Fatal("expected a checksum mismatch to error")
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	if got := sanitizeFileName("../../etc/passwd"); got != "passwd" {
+		t.Fatalf("sanitizeFileName() = %q, want passwd", got)
+	}
+	if got := sanitizeFileName("tool.cwl"); got != "tool.cwl" {
+		t.Fatalf("sanitizeFileName() = %q, want tool.cwl", got)
+	}
+}
+
+func TestResolveZenodoDOI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"hits":{"hits":[{"files":[{"key":"workflow.cwl","downloadUrl":"https://zenodo.org/files/workflow.cwl","checksum":"md5:abc"}]}]}}`)
+	}))
+	defer server.Close()
+	orig := zenodoAPIBase
+	zenodoAPIBase = server.URL + "/"
+	defer func() { zenodoAPIBase = orig }()
+
+	file, err := resolveZenodoDOI("10.5281/zenodo.1234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Name != "workflow.cwl" {
+		t.Fatalf("resolveZenodoDOI() file = %+v", file)
+	}
+}
+
+func TestResolveToolPathDOI(t *testing.T) {
+	content := []byte("cwlVersion: v1.2\n")
+	sum := md5.Sum(content)
+	digest := hex.EncodeToString(sum[:])
+
+	fileServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer fileServer.Close()
+
+	recordsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"hits":{"hits":[{"files":[{"key":"workflow.cwl","downloadUrl":%q,"checksum":"md5:%s"}]}]}}`, fileServer.URL, digest)
+	}))
+	defer recordsServer.Close()
+	orig := zenodoAPIBase
+	zenodoAPIBase = recordsServer.URL + "/"
+	defer func() { zenodoAPIBase = orig }()
+
+	cacheDir := t.TempDir()
+	path, hash, err := resolveToolPath("doi:10.5281/zenodo.1234567", cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "" {
+		t.Fatalf("expected no commit hash for a DOI reference, got %q", hash)
+	}
+	if filepath.Base(path) != "workflow.cwl" {
+		t.Fatalf("resolved path = %q", path)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// runExport implements `cwlclient export <jobId>`: it fetches jobId's
+// output JSON from the server and copies every output File to the current
+// directory, printing the output JSON rewritten with the copies'
+// locations. With -export-target, files go to that location instead: a
+// local directory is copied to directly, while an s3://bucket/prefix
+// target is relayed to the server's export endpoint, since only the
+// server holds credentials for its own bucket.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	exportTarget := fs.String("export-target", "", "where to copy outputs: a local directory (default: current directory) or an s3://bucket/prefix URL")
+	serverAddr := fs.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server")
+	allowSymlinks := fs.Bool("allow-symlinks", false, "follow an output location that is itself a local symlink, instead of refusing to export it")
+	symlinkAllowedRoots := fs.String("symlink-allowed-roots", os.Getenv("FLOWY_EXPORT_SYMLINK_ALLOWED_ROOTS"), "comma-separated absolute directories -allow-symlinks may follow a link into (required with -allow-symlinks unless -symlink-denied-roots is set)")
+	symlinkDeniedRoots := fs.String("symlink-denied-roots", os.Getenv("FLOWY_EXPORT_SYMLINK_DENIED_ROOTS"), "comma-separated absolute directories -allow-symlinks must never follow a link into, checked before -symlink-allowed-roots")
+	onCollision := fs.String("on-collision", string(exportCollisionRename), "what to do when an output's basename already exists in the export directory: rename, error, overwrite, or dedupe-by-checksum")
+	requireSignature := fs.Bool("require-signature", false, "fail export if the job's outputs carry no result signature, instead of exporting them unverified")
+	outputFormatFlag := fs.String("output-format", string(outputFormatCWL), "shape of the printed output JSON: cwl, galaxy, or toil")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: cwlclient export <jobId> [-export-target dir|s3://bucket/prefix] [-allow-symlinks] [-on-collision mode] [-output-format cwl|galaxy|toil]")
+	}
+	jobID := fs.Arg(0)
+
+	symlinkPolicy, err := exportSymlinkPolicy(*allowSymlinks, *symlinkAllowedRoots, *symlinkDeniedRoots)
+	if err != nil {
+		return err
+	}
+	collisionMode, err := parseExportCollisionMode(*onCollision)
+	if err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*outputFormatFlag)
+	if err != nil {
+		return err
+	}
+
+	addr := *serverAddr
+	if addr == "" {
+		addr = "http://localhost:8080"
+	}
+
+	if strings.HasPrefix(*exportTarget, "s3://") {
+		rewritten, err := exportOutputsToS3(addr, jobID, *exportTarget)
+		if err != nil {
+			return err
+		}
+		converted, err := convertOutputFormat(rewritten, format)
+		if err != nil {
+			return err
+		}
+		return printOutputsJSON(converted)
+	}
+
+	outputs, err := fetchJobOutputs(addr, jobID, *requireSignature)
+	if err != nil {
+		return err
+	}
+
+	dir := *exportTarget
+	if dir == "" {
+		dir = "."
+	}
+	if err := exportOutputsLocally(outputs, dir, symlinkPolicy, collisionMode); err != nil {
+		return err
+	}
+	converted, err := convertOutputFormat(outputs, format)
+	if err != nil {
+		return err
+	}
+	return printOutputsJSON(converted)
+}
+
+// fetchJobOutputs fetches jobId's completion record from the server,
+// verifies its result signature (see verifyResultSignature), and returns
+// its collected outputs.
+func fetchJobOutputs(serverURL, jobID string, requireSignature bool) (map[string]interface{}, error) {
+	resp, err := http.Get(serverURL + "/api/jobs/" + jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var req api.JobFinishedRequest
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	if !req.Success {
+		return nil, fmt.Errorf("job %s did not complete successfully", jobID)
+	}
+	if err := verifyResultSignature(serverURL, req, requireSignature); err != nil {
+		return nil, err
+	}
+	if req.Attempt > 1 {
+		logger.Info("job succeeded after retrying", "jobId", jobID, "attempt", req.Attempt)
+	}
+	return req.Outputs, nil
+}
+
+// exportOutputsToS3 asks the server to copy jobId's outputs into target
+// and returns the output JSON it responds with, rewritten to the new
+// locations.
+func exportOutputsToS3(serverURL, jobID, target string) (map[string]interface{}, error) {
+	body, err := json.Marshal(api.ExportRequest{Target: target})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(serverURL+"/api/jobs/"+jobID+"/export", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var rewritten map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rewritten); err != nil {
+		return nil, err
+	}
+	return rewritten, nil
+}
+
+// exportCollisionMode controls what exportOutputsLocally does when an
+// output's basename already exists in the export directory. It is applied
+// uniformly to every File encountered by walkOutputFiles, so a primary
+// output, its secondaryFiles, and Files nested in a Directory listing all
+// collide (and get resolved) the same way.
+type exportCollisionMode string
+
+const (
+	// exportCollisionRename appends a numeric suffix until it finds a free
+	// name, matching generateUniqueDistination's historical behavior. This
+	// is the default, since it never loses data, but it means a tool that
+	// expects its output to land at an exact name downstream may not find
+	// it there.
+	exportCollisionRename exportCollisionMode = "rename"
+	// exportCollisionError refuses to export when the destination already
+	// exists, leaving it to the caller to clear the way or choose a
+	// different mode.
+	exportCollisionError exportCollisionMode = "error"
+	// exportCollisionOverwrite replaces an existing file at the exact
+	// basename the tool reported.
+	exportCollisionOverwrite exportCollisionMode = "overwrite"
+	// exportCollisionDedupeByChecksum skips the download when a file
+	// already at the destination has the same checksum as the output
+	// being exported, and falls back to exportCollisionRename when the
+	// checksums differ (so two distinct files are never both called,
+	// say, "out.txt").
+	exportCollisionDedupeByChecksum exportCollisionMode = "dedupe-by-checksum"
+)
+
+func parseExportCollisionMode(v string) (exportCollisionMode, error) {
+	switch exportCollisionMode(v) {
+	case exportCollisionRename, exportCollisionError, exportCollisionOverwrite, exportCollisionDedupeByChecksum:
+		return exportCollisionMode(v), nil
+	default:
+		return "", fmt.Errorf("invalid -on-collision value %q: must be one of rename, error, overwrite, dedupe-by-checksum", v)
+	}
+}
+
+// exportOutputsLocally walks outputs, downloading every File object's
+// location into dir and rewriting its location in place to point at the
+// copy, resolving a basename already present in dir according to mode.
+//
+// A File's "location" can itself be a symlink on the local filesystem
+// (the server reports the path a tool's own output ended up at, and a
+// tool controls what it puts there); without a check, exporting it would
+// silently follow the link and copy whatever host file it points to.
+// policy must allow the link's target for checkExportSource to permit it.
+func exportOutputsLocally(outputs map[string]interface{}, dir string, policy internal.SymlinkPolicy, mode exportCollisionMode) error {
+	fm := internal.NewLocalFileManager()
+	var walkErr error
+	walkOutputFiles(outputs, func(file map[string]interface{}) {
+		if walkErr != nil {
+			return
+		}
+		location, _ := file["location"].(string)
+		basename, _ := file["basename"].(string)
+		if basename == "" {
+			basename = filepath.Base(location)
+		}
+		checksum, _ := file["checksum"].(string)
+		if err := checkExportSource(location, policy); err != nil {
+			walkErr = fmt.Errorf("exporting %s: %w", location, err)
+			return
+		}
+		dest, skip, err := resolveExportDestination(dir, basename, checksum, mode)
+		if err != nil {
+			walkErr = fmt.Errorf("exporting %s: %w", location, err)
+			return
+		}
+		if !skip {
+			if err := fm.Download(location, dest); err != nil {
+				walkErr = fmt.Errorf("exporting %s: %w", location, err)
+				return
+			}
+		}
+		file["location"] = dest
+	})
+	return walkErr
+}
+
+// resolveExportDestination applies mode to decide where basename lands
+// under dir, returning skip=true when the download can be skipped
+// entirely (the destination already holds the exact content being
+// exported).
+func resolveExportDestination(dir, basename, checksum string, mode exportCollisionMode) (dest string, skip bool, err error) {
+	basename = internal.SanitizeBasename(basename)
+	candidate := dir + "/" + basename
+	fm := internal.NewLocalFileManager()
+	_, exists, err := fm.Stat(candidate)
+	if err != nil {
+		return "", false, err
+	}
+	if !exists {
+		return candidate, false, nil
+	}
+
+	switch mode {
+	case exportCollisionOverwrite:
+		return candidate, false, nil
+	case exportCollisionError:
+		return "", false, fmt.Errorf("destination %q already exists", candidate)
+	case exportCollisionDedupeByChecksum:
+		if checksum != "" {
+			if existing, err := sha1FileChecksum(candidate); err == nil && existing == checksum {
+				return candidate, true, nil
+			}
+		}
+		return generateUniqueDistination(dir, basename), false, nil
+	default: // exportCollisionRename
+		return generateUniqueDistination(dir, basename), false, nil
+	}
+}
+
+// exportSymlinkPolicy builds the SymlinkPolicy -allow-symlinks applies,
+// requiring at least one of allowedRoots/deniedRoots when symlinks are
+// allowed at all: an empty SymlinkPolicy.AllowedRoots means "any target
+// not denied is allowed" (see internal.SymlinkPolicy), which would let
+// -allow-symlinks follow a link to anywhere on the host. An operator who
+// really wants that has to say so by passing "/" as -symlink-allowed-roots
+// rather than getting it from leaving both flags unset.
+func exportSymlinkPolicy(allowSymlinks bool, allowedRoots, deniedRoots string) (internal.SymlinkPolicy, error) {
+	allowed := splitExportRoots(allowedRoots)
+	denied := splitExportRoots(deniedRoots)
+	if allowSymlinks && len(allowed) == 0 && len(denied) == 0 {
+		return internal.SymlinkPolicy{}, fmt.Errorf("-allow-symlinks requires -symlink-allowed-roots or -symlink-denied-roots (or the FLOWY_EXPORT_SYMLINK_*_ROOTS env vars); pass -symlink-allowed-roots=/ to explicitly allow any target")
+	}
+	return internal.SymlinkPolicy{AllowSymlinks: allowSymlinks, AllowedRoots: allowed, DeniedRoots: denied}, nil
+}
+
+// splitExportRoots parses a comma-separated directory list, ignoring blank
+// entries so trailing/doubled commas don't produce an empty root.
+func splitExportRoots(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var roots []string
+	for _, r := range strings.Split(v, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			roots = append(roots, r)
+		}
+	}
+	return roots
+}
+
+// checkExportSource rejects a local-path location that is itself a
+// symlink, unless policy allows it, since the CWL Download (and hence
+// export) model has no notion of "follow this link but nowhere else"; a
+// remote location (anything with a URL scheme) is never a local symlink,
+// so it always passes unchecked.
+func checkExportSource(location string, policy internal.SymlinkPolicy) error {
+	if strings.Contains(location, "://") {
+		return nil
+	}
+	info, err := os.Lstat(location)
+	if err != nil {
+		return nil
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	target, err := internal.ResolveSymlinkTarget(location)
+	if err != nil {
+		return err
+	}
+	return policy.Check(target)
+}
+
+// walkOutputFiles calls fn on every File object nested anywhere within
+// value: at the top level, inside arrays, inside Directory listings, and
+// inside secondaryFiles.
+func walkOutputFiles(value interface{}, fn func(file map[string]interface{})) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v["class"] == "File" {
+			fn(v)
+			if secondary, ok := v["secondaryFiles"]; ok {
+				walkOutputFiles(secondary, fn)
+			}
+			return
+		}
+		if v["class"] == "Directory" {
+			if listing, ok := v["listing"]; ok {
+				walkOutputFiles(listing, fn)
+			}
+			return
+		}
+		for _, nested := range v {
+			walkOutputFiles(nested, fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkOutputFiles(item, fn)
+		}
+	}
+}
+
+// sha1FileChecksum returns path's content hashed in CWL's checksum format
+// ("sha1$<hex>"), for comparing an existing export destination's content
+// against an output's reported checksum under exportCollisionDedupeByChecksum.
+func sha1FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha1$" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// printOutputsJSON prints outputs the same way a completed job's results
+// are reported elsewhere in this CLI: indented JSON on stdout.
+func printOutputsJSON(outputs map[string]interface{}) error {
+	encoded, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoded))
+	return nil
+}
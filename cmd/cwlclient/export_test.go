@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestWalkOutputFilesFindsNestedFiles(t *testing.T) {
+	var found []string
+	outputs := map[string]interface{}{
+		"primary": map[string]interface{}{
+			"class":    "File",
+			"basename": "a.txt",
+			"secondaryFiles": []interface{}{
+				map[string]interface{}{"class": "File", "basename": "a.txt.bai"},
+			},
+		},
+		"dir": map[string]interface{}{
+			"class": "Directory",
+			"listing": []interface{}{
+				map[string]interface{}{"class": "File", "basename": "b.txt"},
+			},
+		},
+	}
+	walkOutputFiles(outputs, func(f map[string]interface{}) {
+		found = append(found, f["basename"].(string))
+	})
+	if len(found) != 3 {
+		t.Fatalf("expected 3 files, got %v", found)
+	}
+}
+
+func TestExportOutputsLocallyDownloadsAndRewritesLocation(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := t.TempDir()
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": src},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionRename); err != nil {
+		t.Fatal(err)
+	}
+
+	file := outputs["out"].(map[string]interface{})
+	location := file["location"].(string)
+	data, err := os.ReadFile(location)
+	if err != nil {
+		t.Fatalf("reading exported file at %q: %v", location, err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("got %q", data)
+	}
+}
+
+func TestExportOutputsLocallyRejectsSymlinkByDefault(t *testing.T) {
+	srcDir := t.TempDir()
+	real := filepath.Join(srcDir, "real.txt")
+	if err := os.WriteFile(real, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(srcDir, "a.txt")
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := t.TempDir()
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": link},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionRename); err == nil {
+		t.Fatal("expected exporting a symlinked location to be rejected")
+	}
+
+	allowed := internal.SymlinkPolicy{AllowSymlinks: true, AllowedRoots: []string{srcDir}}
+	if err := exportOutputsLocally(outputs, destDir, allowed, exportCollisionRename); err != nil {
+		t.Fatalf("expected -allow-symlinks to permit the export: %v", err)
+	}
+}
+
+func TestExportCollisionRenameAppendsSuffix(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	os.WriteFile(src, []byte("new"), 0o644)
+
+	destDir := t.TempDir()
+	os.WriteFile(filepath.Join(destDir, "a.txt"), []byte("existing"), 0o644)
+
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": src},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionRename); err != nil {
+		t.Fatal(err)
+	}
+	location := outputs["out"].(map[string]interface{})["location"].(string)
+	if filepath.Base(location) != "a_1.txt" {
+		t.Fatalf("location = %q, want a renamed destination", location)
+	}
+}
+
+func TestExportCollisionErrorRefusesExistingDestination(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	os.WriteFile(src, []byte("new"), 0o644)
+
+	destDir := t.TempDir()
+	os.WriteFile(filepath.Join(destDir, "a.txt"), []byte("existing"), 0o644)
+
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": src},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionError); err == nil {
+		t.Fatal("expected a collision error")
+	}
+}
+
+func TestExportCollisionOverwriteReplacesExistingDestination(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	os.WriteFile(src, []byte("new"), 0o644)
+
+	destDir := t.TempDir()
+	dest := filepath.Join(destDir, "a.txt")
+	os.WriteFile(dest, []byte("existing"), 0o644)
+
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": src},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionOverwrite); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Fatalf("got %q, want overwritten content", data)
+	}
+}
+
+func TestExportCollisionDedupeByChecksumSkipsIdenticalContent(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	os.WriteFile(src, []byte("same"), 0o644)
+	checksum, err := sha1FileChecksum(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := t.TempDir()
+	dest := filepath.Join(destDir, "a.txt")
+	os.WriteFile(dest, []byte("same"), 0o644)
+	before, _ := os.Stat(dest)
+
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": src, "checksum": checksum},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionDedupeByChecksum); err != nil {
+		t.Fatal(err)
+	}
+	location := outputs["out"].(map[string]interface{})["location"].(string)
+	if location != dest {
+		t.Fatalf("location = %q, want the existing destination %q", location, dest)
+	}
+	after, _ := os.Stat(dest)
+	if !before.ModTime().Equal(after.ModTime()) {
+		t.Fatal("expected the existing file to be left untouched when checksums match")
+	}
+}
+
+func TestExportCollisionDedupeByChecksumRenamesOnMismatch(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	os.WriteFile(src, []byte("different"), 0o644)
+	checksum, err := sha1FileChecksum(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := t.TempDir()
+	os.WriteFile(filepath.Join(destDir, "a.txt"), []byte("original"), 0o644)
+
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "basename": "a.txt", "location": src, "checksum": checksum},
+	}
+	if err := exportOutputsLocally(outputs, destDir, internal.SymlinkPolicy{}, exportCollisionDedupeByChecksum); err != nil {
+		t.Fatal(err)
+	}
+	location := outputs["out"].(map[string]interface{})["location"].(string)
+	if filepath.Base(location) != "a_1.txt" {
+		t.Fatalf("location = %q, want a renamed destination on checksum mismatch", location)
+	}
+}
+
+func TestParseExportCollisionModeRejectsUnknownValue(t *testing.T) {
+	if _, err := parseExportCollisionMode("bogus"); err == nil {
+		t.Fatal("expected an error for an invalid -on-collision value")
+	}
+}
+
+func TestExportSymlinkPolicyRequiresRootsWhenAllowed(t *testing.T) {
+	if _, err := exportSymlinkPolicy(true, "", ""); err == nil {
+		t.Fatal("expected -allow-symlinks with no allowed/denied roots to be rejected")
+	}
+	if _, err := exportSymlinkPolicy(false, "", ""); err != nil {
+		t.Fatalf("expected symlinks denied outright to need no roots: %v", err)
+	}
+}
+
+func TestExportSymlinkPolicyParsesConfiguredRoots(t *testing.T) {
+	policy, err := exportSymlinkPolicy(true, "/allowed/a, /allowed/b", "/allowed/a/secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.Check("/allowed/b/out.txt"); err != nil {
+		t.Fatalf("expected a target under an allowed root to pass: %v", err)
+	}
+	if err := policy.Check("/allowed/a/secret/out.txt"); err == nil {
+		t.Fatal("expected a target under a denied root to be rejected even though it's under an allowed root")
+	}
+	if err := policy.Check("/elsewhere/out.txt"); err == nil {
+		t.Fatal("expected a target outside every allowed root to be rejected")
+	}
+}
+
+func TestFetchJobOutputsRejectsFailedJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.JobFinishedRequest{JobID: "job-1", Success: false})
+	}))
+	defer server.Close()
+
+	if _, err := fetchJobOutputs(server.URL, "job-1", false); err == nil {
+		t.Fatal("expected an error for a failed job")
+	}
+}
+
+func TestExportOutputsToS3PostsTargetAndReturnsRewritten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/jobs/job-1/export" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		var req api.ExportRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Target != "s3://bucket/prefix" {
+			t.Fatalf("unexpected target %q", req.Target)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"out": map[string]interface{}{"class": "File", "location": "s3://bucket/prefix/a.txt"},
+		})
+	}))
+	defer server.Close()
+
+	rewritten, err := exportOutputsToS3(server.URL, "job-1", "s3://bucket/prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := rewritten["out"].(map[string]interface{})
+	if out["location"] != "s3://bucket/prefix/a.txt" {
+		t.Fatalf("unexpected rewritten output %+v", out)
+	}
+}
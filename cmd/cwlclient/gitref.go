@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitToolRef is a parsed "git+https://host/repo#ref:path/to/tool.cwl"
+// reference, letting cwlclient submit or validate a tool straight from a
+// pinned commit in version control instead of requiring a pre-checked-out
+// working copy.
+type gitToolRef struct {
+	URL  string // the repository URL, with the "git+" prefix stripped
+	Ref  string // a branch, tag, or commit
+	Path string // the tool's path within the repository
+}
+
+// parseGitToolRef parses ref as a gitToolRef if it carries the "git+"
+// scheme, returning ok=false for any other string (an ordinary local
+// path, left for the caller to use unchanged).
+func parseGitToolRef(ref string) (gitToolRef, bool) {
+	if !strings.HasPrefix(ref, "git+") {
+		return gitToolRef{}, false
+	}
+	rest := strings.TrimPrefix(ref, "git+")
+	hashIdx := strings.LastIndex(rest, "#")
+	if hashIdx < 0 {
+		return gitToolRef{}, false
+	}
+	url, tail := rest[:hashIdx], rest[hashIdx+1:]
+	colonIdx := strings.Index(tail, ":")
+	if colonIdx < 0 {
+		return gitToolRef{}, false
+	}
+	return gitToolRef{URL: url, Ref: tail[:colonIdx], Path: tail[colonIdx+1:]}, true
+}
+
+// resolveToolPath resolves toolPath to a local filesystem path: an
+// ordinary path is returned unchanged with an empty commit hash; a
+// "git+url#ref:path" reference is cloned (or fetched, if already cached)
+// into cacheDir, checked out at ref, and resolved to path inside it,
+// returning the resolved commit hash for provenance; and a "doi:..." or
+// workflowhub.eu URL reference is downloaded (see doiref.go) into
+// cacheDir with no commit hash to report, since a deposition has no
+// concept of one. Relative imports inside a git-resolved tool document
+// resolve correctly since the returned path still lives inside the
+// cloned repository.
+func resolveToolPath(toolPath, cacheDir string) (resolvedPath string, commitHash string, err error) {
+	if doi, ok := parseDOIRef(toolPath); ok {
+		path, err := fetchDOITool(doi, filepath.Join(cacheDir, "doi"))
+		return path, "", err
+	}
+
+	gitRef, ok := parseGitToolRef(toolPath)
+	if !ok {
+		return toolPath, "", nil
+	}
+
+	repoDir, err := fetchGitRepo(gitRef.URL, gitRef.Ref, cacheDir)
+	if err != nil {
+		return "", "", err
+	}
+	commitHash, err = gitCommitHash(repoDir)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(repoDir, gitRef.Path), commitHash, nil
+}
+
+// repoCacheDir derives a stable cache directory name for url under
+// cacheDir, so repeated resolutions of the same repository reuse one
+// clone instead of re-cloning it on every invocation.
+func repoCacheDir(cacheDir, url string) string {
+	safe := strings.NewReplacer("/", "_", ":", "_").Replace(url)
+	return filepath.Join(cacheDir, safe)
+}
+
+// fetchGitRepo clones url into its cache directory under cacheDir (or
+// fetches into it, if already cloned), checks out ref, and returns the
+// repository's local path.
+func fetchGitRepo(url, ref, cacheDir string) (string, error) {
+	repoDir := repoCacheDir(cacheDir, url)
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
+		if err := runGit(repoDir, "fetch", "--quiet", "origin"); err != nil {
+			return "", fmt.Errorf("fetching %s: %w", url, err)
+		}
+	} else {
+		if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+			return "", err
+		}
+		if err := runGit(cacheDir, "clone", "--quiet", url, repoDir); err != nil {
+			return "", fmt.Errorf("cloning %s: %w", url, err)
+		}
+	}
+	if err := runGit(repoDir, "checkout", "--quiet", ref); err != nil {
+		return "", fmt.Errorf("checking out %s at %s: %w", url, ref, err)
+	}
+	return repoDir, nil
+}
+
+// gitCommitHash returns repoDir's currently checked-out commit hash.
+func gitCommitHash(repoDir string) (string, error) {
+	cmd := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("resolving commit hash: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runGit runs `git <args...>` with its working directory set to dir.
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// defaultGitCacheDir returns the directory git-backed tool references are
+// cached under, honoring FLOWY_GIT_CACHE before falling back to a
+// subdirectory of the user's cache directory.
+func defaultGitCacheDir() string {
+	if dir := os.Getenv("FLOWY_GIT_CACHE"); dir != "" {
+		return dir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "flowy", "git")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGitToolRef(t *testing.T) {
+	ref, ok := parseGitToolRef("git+https://example.com/repo.git#main:tools/align.cwl")
+	if !ok {
+		t.Fatal("expected a git+ reference to parse")
+	}
+	if ref.URL != "https://example.com/repo.git" || ref.Ref != "main" || ref.Path != "tools/align.cwl" {
+		t.Fatalf("unexpected ref: %+v", ref)
+	}
+
+	if _, ok := parseGitToolRef("tools/align.cwl"); ok {
+		t.Fatal("expected an ordinary local path to not parse as a git reference")
+	}
+}
+
+func TestResolveToolPathOrdinaryPath(t *testing.T) {
+	path, hash, err := resolveToolPath("tools/align.cwl", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "tools/align.cwl" || hash != "" {
+		t.Fatalf("expected an ordinary path to pass through unchanged, got path=%q hash=%q", path, hash)
+	}
+}
+
+func TestResolveToolPathGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repoDir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(repoDir, "tool.cwl"), []byte("cwlVersion: v1.2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "tool.cwl")
+	run("commit", "--quiet", "-m", "add tool")
+
+	commit, err := gitCommitHash(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+	ref := "git+file://" + repoDir + "#" + commit + ":tool.cwl"
+	resolvedPath, resolvedHash, err := resolveToolPath(ref, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolvedHash != commit {
+		t.Fatalf("resolved hash = %s, want %s", resolvedHash, commit)
+	}
+	data, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "cwlVersion") {
+		t.Fatalf("unexpected tool content: %s", data)
+	}
+
+	// Resolving the same ref again should reuse the cached clone rather
+	// than erroring out on an already-populated directory.
+	if _, _, err := resolveToolPath(ref, cacheDir); err != nil {
+		t.Fatalf("expected a second resolution to reuse the cache, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Command cwlclient submits CWL jobs to a flowy server, tracks their
+// progress, and exports their outputs once they finish.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: cwlclient <outputs|plan|submit|export|array|wdl|validate|serve> ...")
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "outputs":
+		err = runOutputs(args[1:])
+	case "plan":
+		err = runPlan(args[1:])
+	case "submit":
+		err = runSubmit(args[1:])
+	case "export":
+		err = runExport(args[1:])
+	case "array":
+		err = runArray(args[1:])
+	case "wdl":
+		err = runWDL(args[1:])
+	case "validate":
+		err = runValidate(args[1:])
+	case "serve":
+		err = runServe(args[1:])
+	default:
+		err = fmt.Errorf("unknown subcommand %q", args[0])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cwlclient: %v\n", err)
+		os.Exit(1)
+	}
+}
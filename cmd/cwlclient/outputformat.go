@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// outputFormat names the shape `cwlclient export`'s printed output JSON
+// takes, for a downstream engine or portal whose own File object fields
+// differ from CWL's.
+type outputFormat string
+
+const (
+	// outputFormatCWL prints outputs exactly as the server returned them.
+	outputFormatCWL outputFormat = "cwl"
+	// outputFormatGalaxy reshapes File objects into Galaxy's dataset
+	// fields (filename, name, ext, and a synthetic dataset_id).
+	outputFormatGalaxy outputFormat = "galaxy"
+	// outputFormatToil reshapes File objects down to Toil's minimal
+	// path/name pair.
+	outputFormatToil outputFormat = "toil"
+)
+
+// parseOutputFormat validates a -output-format flag value.
+func parseOutputFormat(v string) (outputFormat, error) {
+	switch outputFormat(v) {
+	case outputFormatCWL, outputFormatGalaxy, outputFormatToil:
+		return outputFormat(v), nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (want cwl, galaxy, or toil)", v)
+	}
+}
+
+// convertOutputFormat reshapes every File object in outputs into format's
+// shape, leaving everything else (scalars, Directory listings, nesting)
+// untouched. It exists so a tool downstream of flowy that expects
+// Galaxy's or Toil's own File object fields doesn't need a brittle jq
+// post-processing step between cwlclient and that tool.
+func convertOutputFormat(outputs map[string]interface{}, format outputFormat) (map[string]interface{}, error) {
+	if format == outputFormatCWL {
+		return outputs, nil
+	}
+	rewritten := rewriteOutputFiles(outputs, format)
+	converted, ok := rewritten.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("internal error: rewriting outputs changed the root value's type")
+	}
+	return converted, nil
+}
+
+// rewriteOutputFiles walks an output JSON value, converting every CWL
+// File object (identified by "class": "File") it finds to format's
+// shape. Maps and slices are otherwise walked and rebuilt unchanged.
+func rewriteOutputFiles(value interface{}, format outputFormat) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v["class"] == "File" {
+			return rewriteFileObject(v, format)
+		}
+		result := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			result[k] = rewriteOutputFiles(val, format)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = rewriteOutputFiles(val, format)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// rewriteFileObject converts one CWL File object (class, location, path,
+// basename, ...) into format's shape.
+func rewriteFileObject(file map[string]interface{}, format outputFormat) map[string]interface{} {
+	path, _ := file["path"].(string)
+	if path == "" {
+		if location, ok := file["location"].(string); ok {
+			path = strings.TrimPrefix(location, "file://")
+		}
+	}
+	basename, _ := file["basename"].(string)
+	if basename == "" {
+		basename = filepath.Base(path)
+	}
+
+	switch format {
+	case outputFormatGalaxy:
+		return map[string]interface{}{
+			"dataset_id": galaxyDatasetID(path),
+			"filename":   path,
+			"name":       basename,
+			"ext":        strings.TrimPrefix(filepath.Ext(basename), "."),
+		}
+	case outputFormatToil:
+		return map[string]interface{}{
+			"path": path,
+			"name": basename,
+		}
+	default:
+		return file
+	}
+}
+
+// galaxyDatasetID derives a stable synthetic dataset ID from path, since
+// this CLI has no connection to a running Galaxy instance to mint a real
+// one; a caller that needs Galaxy's actual numeric dataset IDs still has
+// to import the file into Galaxy itself.
+func galaxyDatasetID(path string) string {
+	sum := sha1.Sum([]byte(path))
+	return "flowy_" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, v := range []string{"cwl", "galaxy", "toil"} {
+		if _, err := parseOutputFormat(v); err != nil {
+			t.Fatalf("parseOutputFormat(%q) = %v, want no error", v, err)
+		}
+	}
+	if _, err := parseOutputFormat("bogus"); err == nil {
+		t.Fatal("expected an error for an unknown output format")
+	}
+}
+
+func TestConvertOutputFormatCWLIsUnchanged(t *testing.T) {
+	outputs := map[string]interface{}{
+		"aligned": map[string]interface{}{"class": "File", "location": "file:///out/aligned.bam", "basename": "aligned.bam"},
+	}
+	converted, err := convertOutputFormat(outputs, outputFormatCWL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := converted["aligned"].(map[string]interface{})
+	if file["location"] != "file:///out/aligned.bam" {
+		t.Fatalf("expected cwl format to leave the File object untouched, got %+v", file)
+	}
+}
+
+func TestConvertOutputFormatGalaxy(t *testing.T) {
+	outputs := map[string]interface{}{
+		"aligned": map[string]interface{}{"class": "File", "location": "file:///out/aligned.bam", "basename": "aligned.bam"},
+	}
+	converted, err := convertOutputFormat(outputs, outputFormatGalaxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := converted["aligned"].(map[string]interface{})
+	if file["filename"] != "/out/aligned.bam" {
+		t.Fatalf("filename = %v, want /out/aligned.bam", file["filename"])
+	}
+	if file["ext"] != "bam" {
+		t.Fatalf("ext = %v, want bam", file["ext"])
+	}
+	if file["dataset_id"] == "" {
+		t.Fatal("expected a non-empty synthetic dataset_id")
+	}
+	if _, hasClass := file["class"]; hasClass {
+		t.Fatal("expected the galaxy shape to drop CWL's class field")
+	}
+}
+
+func TestConvertOutputFormatToilNestedInArray(t *testing.T) {
+	outputs := map[string]interface{}{
+		"bams": []interface{}{
+			map[string]interface{}{"class": "File", "path": "/out/a.bam", "basename": "a.bam"},
+			map[string]interface{}{"class": "File", "path": "/out/b.bam", "basename": "b.bam"},
+		},
+	}
+	converted, err := convertOutputFormat(outputs, outputFormatToil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bams := converted["bams"].([]interface{})
+	if len(bams) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(bams))
+	}
+	first := bams[0].(map[string]interface{})
+	if first["path"] != "/out/a.bam" || first["name"] != "a.bam" {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/logging"
+)
+
+// logger is cwlclient's structured logger. Library functions in this
+// package must return errors to their caller rather than calling
+// log.Fatal, which would kill the process mid-export from deep inside a
+// helper; main is the only place allowed to exit non-zero.
+var logger = logging.New(slog.LevelInfo, logging.FormatText)
+
+// listingEntry describes one file or subdirectory returned by get_listing.
+type listingEntry struct {
+	Name  string
+	IsDir bool
+	Size  int64
+}
+
+// get_listing lists dir's immediate children. It used to call log.Fatal on
+// a read error, which is unacceptable for a function called from library
+// code such as export and outputs rendering; callers now receive the error
+// and decide whether it's fatal.
+func get_listing(dir string) ([]listingEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logger.Error("listing directory failed", "dir", dir, "error", err)
+		return nil, fmt.Errorf("get_listing %s: %w", dir, err)
+	}
+
+	listing := make([]listingEntry, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			logger.Warn("stat failed while listing", "dir", dir, "name", e.Name(), "error", err)
+			continue
+		}
+		listing = append(listing, listingEntry{Name: e.Name(), IsDir: e.IsDir(), Size: info.Size()})
+	}
+	return listing, nil
+}
+
+// runOutputs implements `cwlclient outputs`: it lists and prints the
+// contents of the directory named by the first argument, or, with -step,
+// fetches completed scatter shard results for a workflow step from the
+// server instead.
+func runOutputs(args []string) error {
+	fs := flag.NewFlagSet("outputs", flag.ContinueOnError)
+	step := fs.String("step", "", "fetch shard results for this workflow step from the server instead of listing a local directory")
+	partial := fs.Bool("partial", false, "with -step, include shards that finished even while the rest of the step is still running")
+	serverAddr := fs.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server (only used with -step)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *step != "" {
+		addr := *serverAddr
+		if addr == "" {
+			addr = "http://localhost:8080"
+		}
+		return runStepOutputs(addr, *step, *partial)
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return fmt.Errorf("usage: cwlclient outputs <dir> | cwlclient outputs -step <name> [-partial]")
+	}
+	listing, err := get_listing(rest[0])
+	if err != nil {
+		return err
+	}
+	for _, e := range listing {
+		kind := "file"
+		if e.IsDir {
+			kind = "dir"
+		}
+		fmt.Printf("%-4s %10d  %s\n", kind, e.Size, e.Name)
+	}
+	return nil
+}
+
+// generateUniqueDistination appends a numeric suffix to basename until it
+// finds a name that doesn't already exist under dir, so export never
+// overwrites an existing file by accident. basename is sanitized first,
+// since it comes from a File object's "basename" field, which a tool
+// fully controls; without that, a basename like "../../etc/passwd" would
+// let a malicious tool escape dir.
+func generateUniqueDistination(dir, basename string) string {
+	basename = internal.SanitizeBasename(basename)
+	candidate := basename
+	for i := 1; ; i++ {
+		if _, exists, _ := internal.NewLocalFileManager().Stat(dir + "/" + candidate); !exists {
+			return dir + "/" + candidate
+		}
+		ext := ""
+		name := basename
+		if idx := lastDot(basename); idx >= 0 {
+			name, ext = basename[:idx], basename[idx:]
+		}
+		candidate = fmt.Sprintf("%s_%d%s", name, i, ext)
+	}
+}
+
+func lastDot(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '.' {
+			return i
+		}
+	}
+	return -1
+}
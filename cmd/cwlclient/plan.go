@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// runPlan implements `cwlclient plan`: it posts a prepared job's JSON to a
+// worker's /debug/plan endpoint and prints the resolved execution plan
+// (command line, docker invocation, staging operations, env, output
+// globs, and the S3/NFS locations each output is expected to publish to)
+// without the worker executing anything, for debugging a job's staging
+// paths or command line before submitting it for real, or for a
+// downstream system that wants to register expectations against a job's
+// outputs ahead of completion. -priority and -queue-class override the
+// job's scheduling fields before it's sent, so their effect on
+// staging/env resolution can be previewed the same way.
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	priority := fs.Int("priority", 0, "override the job's Priority before sending it")
+	queueClass := fs.String("queue-class", "", "override the job's QueueClass before sending it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	rest := fs.Args()
+	if len(rest) != 2 {
+		return fmt.Errorf("usage: cwlclient plan [-priority n] [-queue-class name] <worker-addr> <job.json>")
+	}
+	workerAddr, jobPath := rest[0], rest[1]
+
+	body, err := os.ReadFile(jobPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", jobPath, err)
+	}
+
+	if *priority != 0 || *queueClass != "" {
+		var job api.ExecutableJob
+		if err := json.Unmarshal(body, &job); err != nil {
+			return fmt.Errorf("parsing %s: %w", jobPath, err)
+		}
+		if *priority != 0 {
+			job.Priority = *priority
+		}
+		if *queueClass != "" {
+			job.QueueClass = *queueClass
+		}
+		if body, err = json.Marshal(job); err != nil {
+			return err
+		}
+	}
+
+	resp, err := http.Post(workerAddr+"/debug/plan", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("requesting plan from %s: %w", workerAddr, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("worker returned %s: %s", resp.Status, respBody)
+	}
+
+	fmt.Println(string(respBody))
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// fetchProjectQuota asks the server for project's current quota limits and
+// consumption. A server that doesn't recognize the project (or doesn't
+// support quotas at all) returns a nil quota and nil error, in which case
+// submission proceeds unchecked rather than being blocked by a feature the
+// server hasn't implemented.
+func fetchProjectQuota(serverURL, project string) (*api.ProjectQuota, error) {
+	resp, err := http.Get(serverURL + "/api/projects/" + project + "/quota")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetchProjectQuota: server returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var quota api.ProjectQuota
+	if err := json.Unmarshal(body, &quota); err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// quotaExceeded reports whether quota's recorded usage has already reached
+// or passed either of its configured limits, along with a message suitable
+// for showing the user. A zero limit means that dimension is unlimited.
+func quotaExceeded(quota *api.ProjectQuota) (bool, string) {
+	if quota.CPUHourLimit > 0 && quota.CPUHoursUsed >= quota.CPUHourLimit {
+		return true, fmt.Sprintf("project %q has used %.1f of its %.1f CPU-hour quota", quota.Project, quota.CPUHoursUsed, quota.CPUHourLimit)
+	}
+	if quota.StorageLimitBytes > 0 && quota.StorageUsedBytes >= quota.StorageLimitBytes {
+		return true, fmt.Sprintf("project %q has used %d of its %d byte storage quota", quota.Project, quota.StorageUsedBytes, quota.StorageLimitBytes)
+	}
+	return false, ""
+}
+
+// checkProjectQuotas fetches and checks the quota for every distinct,
+// non-blank project among jobs, returning an error naming the first project
+// found over quota. Jobs with no project are not subject to quota checks.
+func checkProjectQuotas(serverURL string, jobs []api.ExecutableJob) error {
+	checked := make(map[string]bool)
+	for _, job := range jobs {
+		if job.Project == "" || checked[job.Project] {
+			continue
+		}
+		checked[job.Project] = true
+
+		quota, err := fetchProjectQuota(serverURL, job.Project)
+		if err != nil {
+			return fmt.Errorf("checking quota for project %q: %w", job.Project, err)
+		}
+		if quota == nil {
+			continue
+		}
+		if exceeded, msg := quotaExceeded(quota); exceeded {
+			return fmt.Errorf("submission rejected: %s", msg)
+		}
+	}
+	return nil
+}
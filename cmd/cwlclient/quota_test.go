@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestFetchProjectQuotaParsesResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/projects/lab-a/quota" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(api.ProjectQuota{Project: "lab-a", CPUHourLimit: 10, CPUHoursUsed: 4})
+	}))
+	defer server.Close()
+
+	quota, err := fetchProjectQuota(server.URL, "lab-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota.Project != "lab-a" || quota.CPUHoursUsed != 4 {
+		t.Fatalf("unexpected quota %+v", quota)
+	}
+}
+
+func TestFetchProjectQuotaReturnsNilOnNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	quota, err := fetchProjectQuota(server.URL, "unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota != nil {
+		t.Fatalf("expected nil quota, got %+v", quota)
+	}
+}
+
+func TestQuotaExceededDetectsCPUOverage(t *testing.T) {
+	exceeded, msg := quotaExceeded(&api.ProjectQuota{Project: "lab-a", CPUHourLimit: 10, CPUHoursUsed: 10})
+	if !exceeded {
+		t.Fatal("expected CPU quota to be exceeded")
+	}
+	if !strings.Contains(msg, "lab-a") {
+		t.Fatalf("expected message to name the project, got %q", msg)
+	}
+}
+
+func TestQuotaExceededDetectsStorageOverage(t *testing.T) {
+	exceeded, _ := quotaExceeded(&api.ProjectQuota{Project: "lab-a", StorageLimitBytes: 100, StorageUsedBytes: 200})
+	if !exceeded {
+		t.Fatal("expected storage quota to be exceeded")
+	}
+}
+
+func TestQuotaExceededUnlimitedNeverExceeds(t *testing.T) {
+	exceeded, _ := quotaExceeded(&api.ProjectQuota{Project: "lab-a", CPUHoursUsed: 1000, StorageUsedBytes: 1000})
+	if exceeded {
+		t.Fatal("expected zero limits to mean unlimited")
+	}
+}
+
+func TestCheckProjectQuotasRejectsOverQuotaProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.ProjectQuota{Project: "lab-a", CPUHourLimit: 1, CPUHoursUsed: 2})
+	}))
+	defer server.Close()
+
+	jobs := []api.ExecutableJob{{JobID: "a", Project: "lab-a"}}
+	if err := checkProjectQuotas(server.URL, jobs); err == nil {
+		t.Fatal("expected an over-quota error")
+	}
+}
+
+func TestCheckProjectQuotasSkipsJobsWithoutProject(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	jobs := []api.ExecutableJob{{JobID: "a"}}
+	if err := checkProjectQuotas(server.URL, jobs); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Fatal("expected no quota request for a job with no project")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/signing"
+)
+
+// verifyResultSignature checks req.ResultSignature against the public key
+// the server has registered for req.WorkerName, so outputs read back from
+// intermediate storage (a shared mount, an object storage bucket) that
+// have been tampered with after the worker reported them are caught
+// before being exported. A job with no ResultSignature passes unless
+// requireSignature is set, so this stays compatible with a worker or
+// server that doesn't have result signing turned on.
+func verifyResultSignature(serverURL string, req api.JobFinishedRequest, requireSignature bool) error {
+	if req.ResultSignature == "" {
+		if requireSignature {
+			return fmt.Errorf("job %s has no result signature and -require-signature was set", req.JobID)
+		}
+		return nil
+	}
+	if req.WorkerName == "" {
+		return fmt.Errorf("job %s has a result signature but no workerName to verify it against", req.JobID)
+	}
+
+	pub, err := fetchWorkerKey(serverURL, req.WorkerName)
+	if err != nil {
+		return fmt.Errorf("fetching registered key for worker %q: %w", req.WorkerName, err)
+	}
+	payload, err := api.CanonicalResultsPayload(req.Outputs)
+	if err != nil {
+		return fmt.Errorf("encoding outputs for signature verification: %w", err)
+	}
+	if !signing.VerifyDetached(pub, payload, req.ResultSignature) {
+		return fmt.Errorf("job %s outputs do not match their result signature from worker %q; they may have been tampered with in intermediate storage", req.JobID, req.WorkerName)
+	}
+	return nil
+}
+
+// fetchWorkerKey looks up workerName's registered Ed25519 public key from
+// the server.
+func fetchWorkerKey(serverURL, workerName string) (ed25519.PublicKey, error) {
+	resp, err := http.Get(serverURL + "/api/workers/" + url.PathEscape(workerName) + "/key")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var keyResp api.WorkerKeyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&keyResp); err != nil {
+		return nil, err
+	}
+	if keyResp.ResultSigningKey == "" {
+		return nil, fmt.Errorf("worker %q has no registered result signing key", workerName)
+	}
+	return signing.DecodePublicKey(keyResp.ResultSigningKey)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/signing"
+)
+
+func workerKeyServer(t *testing.T, workerName, publicKey string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/workers/"+workerName+"/key" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(api.WorkerKeyResponse{ResultSigningKey: publicKey})
+	}))
+}
+
+func TestVerifyResultSignatureAcceptsValidSignature(t *testing.T) {
+	pub, priv, err := signing.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	outputs := map[string]interface{}{"out": "value"}
+	payload, err := api.CanonicalResultsPayload(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := workerKeyServer(t, "worker-1", signing.EncodePublicKey(pub))
+	defer srv.Close()
+
+	req := api.JobFinishedRequest{
+		JobID:           "job-1",
+		Outputs:         outputs,
+		WorkerName:      "worker-1",
+		ResultSignature: signing.SignDetached(priv, payload),
+	}
+	if err := verifyResultSignature(srv.URL, req, false); err != nil {
+		t.Fatalf("verifyResultSignature: %v", err)
+	}
+}
+
+func TestVerifyResultSignatureRejectsTamperedOutputs(t *testing.T) {
+	pub, priv, err := signing.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	payload, err := api.CanonicalResultsPayload(map[string]interface{}{"out": "original"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := workerKeyServer(t, "worker-1", signing.EncodePublicKey(pub))
+	defer srv.Close()
+
+	req := api.JobFinishedRequest{
+		JobID:           "job-1",
+		Outputs:         map[string]interface{}{"out": "tampered"},
+		WorkerName:      "worker-1",
+		ResultSignature: signing.SignDetached(priv, payload),
+	}
+	if err := verifyResultSignature(srv.URL, req, false); err == nil {
+		t.Fatal("expected an error verifying tampered outputs, got nil")
+	}
+}
+
+func TestVerifyResultSignatureMissingSignature(t *testing.T) {
+	req := api.JobFinishedRequest{JobID: "job-1", Outputs: map[string]interface{}{"out": "value"}}
+
+	if err := verifyResultSignature("http://unused", req, false); err != nil {
+		t.Fatalf("expected no error for an unsigned job when not required, got %v", err)
+	}
+	if err := verifyResultSignature("http://unused", req, true); err == nil {
+		t.Fatal("expected an error for an unsigned job when -require-signature is set")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/bioflowy/flowy/internal/jobtemplate"
+)
+
+// webhookWorkflow is one entry in a `cwlclient serve` config file: a tool
+// and job order template a POSTed payload is rendered against, reachable
+// at /webhooks/<name>.
+type webhookWorkflow struct {
+	Name     string `json:"name"`
+	Tool     string `json:"tool"`
+	Template string `json:"template"`
+}
+
+// runServe implements `cwlclient serve -workflows workflows.json`: a
+// minimal REST endpoint external systems (a LIMS, a sequencer) can POST a
+// job order payload to, triggering a pre-registered workflow the same way
+// "array" fans one out across a sample sheet, bridging instruments
+// directly to the flowy server without a hand-rolled receiver script.
+// Every request must present -token as an Authorization: Bearer header,
+// and every request is logged regardless of outcome.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	port := fs.Int("port", 8090, "port to listen on")
+	workflowsPath := fs.String("workflows", "", "JSON file listing webhook-triggerable workflows (name, tool, template)")
+	serverAddr := fs.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server")
+	project := fs.String("project", os.Getenv("FLOWY_PROJECT"), "project to submit every job order under")
+	token := fs.String("token", os.Getenv("FLOWY_WEBHOOK_TOKEN"), "bearer token every request must present in its Authorization header")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *workflowsPath == "" {
+		return fmt.Errorf("usage: cwlclient serve -workflows workflows.json [-port N] [-server url] [-project name] [-token secret]")
+	}
+	if *token == "" {
+		return fmt.Errorf("a bearer token is required: set -token or FLOWY_WEBHOOK_TOKEN")
+	}
+
+	addr := *serverAddr
+	if addr == "" {
+		addr = "http://localhost:8080"
+	}
+
+	workflows, err := loadWebhookWorkflows(*workflowsPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", *workflowsPath, err)
+	}
+
+	mux := http.NewServeMux()
+	for name, w := range workflows {
+		mux.HandleFunc("/webhooks/"+name, webhookHandler(addr, *project, *token, w))
+	}
+
+	logger.Info("serving webhooks", "port", *port, "workflows", len(workflows))
+	return http.ListenAndServe(fmt.Sprintf(":%d", *port), mux)
+}
+
+// loadWebhookWorkflows reads a JSON array of webhookWorkflow from path and
+// indexes it by Name.
+func loadWebhookWorkflows(path string) (map[string]webhookWorkflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []webhookWorkflow
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	workflows := make(map[string]webhookWorkflow, len(list))
+	for _, w := range list {
+		if w.Name == "" || w.Tool == "" || w.Template == "" {
+			return nil, fmt.Errorf("webhook workflow missing a required field (name, tool, template): %+v", w)
+		}
+		workflows[w.Name] = w
+	}
+	return workflows, nil
+}
+
+// webhookHandler authenticates a request against token, renders w's
+// template against the request body's JSON object, and submits the
+// result as a job order against w.Tool.
+func webhookHandler(serverAddr, project, token string, w webhookWorkflow) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		logger.Info("webhook request", "workflow", w.Name, "method", req.Method, "remoteAddr", req.RemoteAddr)
+
+		if req.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !validBearerToken(req, token) {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var values map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&values); err != nil {
+			http.Error(rw, fmt.Sprintf("decoding request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		templateData, err := os.ReadFile(w.Template)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("reading template: %v", err), http.StatusInternalServerError)
+			return
+		}
+		tmpl, err := template.New(filepath.Base(w.Template)).Parse(string(templateData))
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("parsing template: %v", err), http.StatusInternalServerError)
+			return
+		}
+		inputs, err := jobtemplate.Render(tmpl, values)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("rendering job order template: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		toolPath, toolCommitHash, err := resolveToolPath(w.Tool, defaultGitCacheDir())
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("resolving tool: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		jobID, err := submitJobOrder(serverAddr, toolPath, inputs, project, w.Name, toolCommitHash, nil)
+		if err != nil {
+			logger.Error("submitting webhook job order", "workflow", w.Name, "error", err)
+			http.Error(rw, fmt.Sprintf("submitting job order: %v", err), http.StatusBadGateway)
+			return
+		}
+
+		logger.Info("submitted webhook job order", "workflow", w.Name, "jobId", jobID)
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]string{"jobId": jobID})
+	}
+}
+
+// validBearerToken reports whether req's Authorization header presents
+// token as a bearer token.
+func validBearerToken(req *http.Request, token string) bool {
+	got := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return got != "" && got == token
+}
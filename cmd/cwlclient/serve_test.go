@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWebhookWorkflows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflows.json")
+	content := `[{"name":"new-run","tool":"align.cwl","template":"align.yaml"}]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	workflows, err := loadWebhookWorkflows(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, ok := workflows["new-run"]
+	if !ok || w.Tool != "align.cwl" || w.Template != "align.yaml" {
+		t.Fatalf("unexpected workflows: %+v", workflows)
+	}
+}
+
+func TestLoadWebhookWorkflowsRejectsMissingField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflows.json")
+	content := `[{"name":"new-run","tool":"align.cwl"}]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadWebhookWorkflows(path); err == nil {
+		t.Fatal("expected an error for a workflow missing its template field")
+	}
+}
+
+func TestValidBearerToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/new-run", nil)
+	req.Header.Set("Authorization", "Bearer secret123")
+	if !validBearerToken(req, "secret123") {
+		t.Fatal("expected a matching bearer token to be accepted")
+	}
+	if validBearerToken(req, "wrong") {
+		t.Fatal("expected a mismatched bearer token to be rejected")
+	}
+
+	noAuth := httptest.NewRequest(http.MethodPost, "/webhooks/new-run", nil)
+	if validBearerToken(noAuth, "secret123") {
+		t.Fatal("expected a missing Authorization header to be rejected")
+	}
+}
+
+func TestWebhookHandlerSubmitsJobOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/job-orders" {
+			t.Fatalf("unexpected path: %s", req.URL.Path)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write([]byte(`{"jobId":"job-42"}`))
+	}))
+	defer server.Close()
+
+	templatePath := filepath.Join(t.TempDir(), "align.yaml")
+	if err := os.WriteFile(templatePath, []byte("sample_id: {{.sample_id}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := webhookWorkflow{Name: "new-run", Tool: "align.cwl", Template: templatePath}
+
+	handler := webhookHandler(server.URL, "genomics", "secret123", w)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/new-run", strings.NewReader(`{"sample_id":"sample1"}`))
+	req.Header.Set("Authorization", "Bearer secret123")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "job-42") {
+		t.Fatalf("expected the response to carry the submitted job ID, got %s", rec.Body.String())
+	}
+}
+
+func TestWebhookHandlerRejectsBadToken(t *testing.T) {
+	w := webhookWorkflow{Name: "new-run", Tool: "align.cwl", Template: "align.yaml"}
+	handler := webhookHandler("http://unused", "", "secret123", w)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/new-run", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
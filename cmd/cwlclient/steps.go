@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StepResult is one shard's outcome, as returned by the server's per-step
+// results endpoint.
+type StepResult struct {
+	ShardIndex int                    `json:"shardIndex"`
+	Complete   bool                   `json:"complete"`
+	Outputs    map[string]interface{} `json:"outputs,omitempty"`
+}
+
+// fetchStepResults calls the server's per-step results API for step,
+// returning every shard it has recorded so far. When partial is true the
+// server is asked to include shards that have finished even while the
+// step as a whole (i.e. the rest of the scatter) is still running;
+// otherwise it only returns results once the whole step is done.
+func fetchStepResults(serverURL, step string, partial bool) ([]StepResult, error) {
+	url := fmt.Sprintf("%s/api/steps/%s/results", serverURL, step)
+	if partial {
+		url += "?partial=true"
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var results []StepResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runStepOutputs implements `cwlclient outputs -step <name> [-partial]`: it
+// prints every shard result the server currently has for the named step,
+// so completed shards of a big scatter can be exported before the rest of
+// the workflow finishes.
+func runStepOutputs(serverURL, step string, partial bool) error {
+	results, err := fetchStepResults(serverURL, step, partial)
+	if err != nil {
+		return fmt.Errorf("fetching results for step %q: %w", step, err)
+	}
+
+	for _, r := range results {
+		status := "running"
+		if r.Complete {
+			status = "complete"
+		}
+		fmt.Printf("shard %-4d %-9s %v\n", r.ShardIndex, status, r.Outputs)
+	}
+	return nil
+}
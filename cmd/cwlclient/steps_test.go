@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFetchStepResultsSetsPartialQueryParam(t *testing.T) {
+	var gotQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		json.NewEncoder(w).Encode([]StepResult{
+			{ShardIndex: 0, Complete: true, Outputs: map[string]interface{}{"out": "a"}},
+			{ShardIndex: 1, Complete: false},
+		})
+	}))
+	defer server.Close()
+
+	results, err := fetchStepResults(server.URL, "align", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotQuery.Get("partial") != "true" {
+		t.Fatalf("expected partial=true in the request, got %v", gotQuery)
+	}
+	if len(results) != 2 || !results[0].Complete || results[1].Complete {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestFetchStepResultsErrorsOnNonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := fetchStepResults(server.URL, "missing", false); err == nil {
+		t.Fatal("expected an error for a non-OK response")
+	}
+}
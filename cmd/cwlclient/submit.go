@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// runSubmit implements `cwlclient submit`: it reads a JSON array of
+// ExecutableJob from jobsPath, each optionally carrying DependsOn parent
+// JobIDs from elsewhere in the same array, and streams them to the
+// server's POST /api/jobs/bulk endpoint in dependency waves (see
+// dependencyWaves) so a DAG of hundreds of jobs can be submitted as a
+// handful of batched HTTP calls, submitted concurrently within a wave,
+// rather than the client making one request per job. A job whose
+// dependency failed to submit is skipped and reported rather than
+// aborting the whole run; every other independent job still goes out.
+// -retry-count and -retryable-exit-codes set a default RetryPolicy on
+// every job that doesn't already carry one of its own, for transient tool
+// failures (e.g. a flaky license server) that are known to succeed on a
+// re-run. -dataset-catalog (or FLOWY_DATASET_CATALOG) resolves any
+// "dataset://" symbolic references in a job's Inputs against a catalog
+// server before submission, so the same job.json stays portable across
+// environments that register the same dataset names against different
+// concrete locations. -label sets a default set of annotation labels on
+// every job that doesn't already carry its own.
+func runSubmit(args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
+	retryCount := fs.Int("retry-count", 0, "default number of additional attempts for a job whose exit code is in -retryable-exit-codes and which does not already specify its own retryPolicy")
+	retryableExitCodes := fs.String("retryable-exit-codes", "", "comma-separated exit codes that trigger a retry (e.g. \"75,111\"); has no effect unless -retry-count is set")
+	batchSize := fs.Int("batch-size", 50, "how many jobs to send per POST /api/jobs/bulk request")
+	concurrency := fs.Int("concurrency", 4, "how many batches to submit at once, within a wave of jobs with no dependencies still in flight")
+	datasetCatalog := fs.String("dataset-catalog", os.Getenv("FLOWY_DATASET_CATALOG"), "base URL of a dataset catalog server to resolve \"dataset://\" input references against before submission")
+	label := fs.String("label", "", "comma-separated key=value labels (e.g. \"project=alpha,cost-center=42\") applied to every job that doesn't already carry its own Labels")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	rest := fs.Args()
+	if len(rest) != 2 {
+		return fmt.Errorf("usage: cwlclient submit [-retry-count n] [-retryable-exit-codes list] [-dataset-catalog url] [-label k=v,...] <server> <jobs.json>")
+	}
+	serverAddr, jobsPath := rest[0], rest[1]
+
+	data, err := os.ReadFile(jobsPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", jobsPath, err)
+	}
+
+	var jobs []api.ExecutableJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("parsing %s: %w", jobsPath, err)
+	}
+
+	if *retryCount > 0 {
+		codes, err := parseExitCodeList(*retryableExitCodes)
+		if err != nil {
+			return fmt.Errorf("parsing -retryable-exit-codes: %w", err)
+		}
+		applyDefaultRetryPolicy(jobs, &api.RetryPolicy{RetryCount: *retryCount, RetryableExitCodes: codes})
+	}
+
+	applyDefaultProject(jobs, os.Getenv("FLOWY_PROJECT"))
+
+	if *label != "" {
+		labels, err := parseLabels(*label)
+		if err != nil {
+			return fmt.Errorf("parsing -label: %w", err)
+		}
+		applyDefaultLabels(jobs, labels)
+	}
+
+	if *datasetCatalog != "" {
+		client := newHTTPDatasetCatalog(*datasetCatalog)
+		for i := range jobs {
+			if jobs[i].Inputs == nil {
+				continue
+			}
+			if err := resolveDatasetRefs(jobs[i].Inputs, client); err != nil {
+				return fmt.Errorf("resolving dataset references for job %q: %w", jobs[i].JobID, err)
+			}
+		}
+	}
+
+	ordered, err := orderJobs(jobs)
+	if err != nil {
+		return fmt.Errorf("validating job dependencies: %w", err)
+	}
+
+	if err := checkProjectQuotas(serverAddr, ordered); err != nil {
+		return err
+	}
+
+	failed := submitInWaves(serverAddr, ordered, *batchSize, *concurrency)
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d jobs failed to submit", len(failed), len(ordered))
+	}
+	return nil
+}
+
+// submitInWaves submits ordered (already topologically sorted by
+// orderJobs) to the server's bulk endpoint a dependency wave at a time:
+// every job in a wave has all of its DependsOn already successfully
+// submitted, so waves themselves must run one after another, but the
+// batches within a wave carry no ordering constraint against each other
+// and are streamed to the server concurrently (bounded by concurrency).
+// A job whose dependency failed to submit is skipped rather than
+// attempted, and reported as its own failure; every other independent
+// job still gets submitted. It returns the set of job IDs that failed or
+// were skipped.
+func submitInWaves(serverAddr string, ordered []api.ExecutableJob, batchSize, concurrency int) map[string]error {
+	failed := make(map[string]error)
+	for _, wave := range dependencyWaves(ordered) {
+		var runnable []api.ExecutableJob
+		for _, job := range wave {
+			if depErr := failedDependency(job, failed); depErr != nil {
+				failed[job.JobID] = depErr
+				fmt.Printf("skipped %s: %v\n", job.JobID, depErr)
+				continue
+			}
+			runnable = append(runnable, job)
+		}
+		for jobID, err := range submitBatchesConcurrently(serverAddr, runnable, batchSize, concurrency) {
+			failed[jobID] = err
+		}
+	}
+	return failed
+}
+
+// failedDependency reports the first of job's DependsOn found in failed,
+// wrapped as the reason job itself cannot be submitted.
+func failedDependency(job api.ExecutableJob, failed map[string]error) error {
+	for _, dep := range job.DependsOn {
+		if err := failed[dep]; err != nil {
+			return fmt.Errorf("dependency %q did not submit: %w", dep, err)
+		}
+	}
+	return nil
+}
+
+// dependencyWaves groups ordered into successive batches where every
+// job's DependsOn are satisfied by an earlier wave, so all jobs within one
+// wave can be submitted without regard to submission order between them.
+func dependencyWaves(ordered []api.ExecutableJob) [][]api.ExecutableJob {
+	level := make(map[string]int, len(ordered))
+	var waves [][]api.ExecutableJob
+	for _, job := range ordered {
+		jobLevel := 0
+		for _, dep := range job.DependsOn {
+			if l := level[dep] + 1; l > jobLevel {
+				jobLevel = l
+			}
+		}
+		level[job.JobID] = jobLevel
+		for len(waves) <= jobLevel {
+			waves = append(waves, nil)
+		}
+		waves[jobLevel] = append(waves[jobLevel], job)
+	}
+	return waves
+}
+
+// submitBatchesConcurrently splits jobs into chunks of at most batchSize
+// and POSTs each chunk to /api/jobs/bulk, running up to concurrency
+// chunks at once. It prints each job's outcome as its batch's response
+// arrives and returns the errors for every job that failed to submit.
+func submitBatchesConcurrently(serverAddr string, jobs []api.ExecutableJob, batchSize, concurrency int) map[string]error {
+	failed := make(map[string]error)
+	if len(jobs) == 0 {
+		return failed
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var batches [][]api.ExecutableJob
+	for start := 0; start < len(jobs); start += batchSize {
+		end := start + batchSize
+		if end > len(jobs) {
+			end = len(jobs)
+		}
+		batches = append(batches, jobs[start:end])
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(batch []api.ExecutableJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results, err := postBulkJobs(serverAddr, batch)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				for _, job := range batch {
+					failed[job.JobID] = err
+					fmt.Printf("failed %s: %v\n", job.JobID, err)
+				}
+				return
+			}
+			for _, r := range results {
+				if r.Success {
+					fmt.Printf("submitted %s\n", r.JobID)
+					continue
+				}
+				err := fmt.Errorf("%s", r.Error)
+				failed[r.JobID] = err
+				fmt.Printf("failed %s: %v\n", r.JobID, err)
+			}
+		}(batch)
+	}
+	wg.Wait()
+	return failed
+}
+
+// postBulkJobs POSTs jobs as one api.BulkJobSubmission to
+// serverAddr+"/api/jobs/bulk" and decodes the per-job results.
+func postBulkJobs(serverAddr string, jobs []api.ExecutableJob) ([]api.BulkJobSubmissionResult, error) {
+	body, err := json.Marshal(api.BulkJobSubmission{Jobs: jobs})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(serverAddr+"/api/jobs/bulk", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, respBody)
+	}
+
+	var batchResp api.BulkJobSubmissionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+		return nil, fmt.Errorf("decoding bulk submission response: %w", err)
+	}
+	return batchResp.Results, nil
+}
+
+// applyDefaultProject fills in Project on every job that doesn't already
+// specify one, so submitting the same job manifest against different
+// FLOWY_PROJECT values doesn't require editing it. A blank activeProject
+// (no active project configured) leaves every job untouched.
+func applyDefaultProject(jobs []api.ExecutableJob, activeProject string) {
+	if activeProject == "" {
+		return
+	}
+	for i := range jobs {
+		if jobs[i].Project == "" {
+			jobs[i].Project = activeProject
+		}
+	}
+}
+
+// applyDefaultRetryPolicy sets policy on every job that doesn't already
+// specify its own RetryPolicy, so a batch of otherwise-identical jobs can
+// share one retry configuration from the command line while a job that
+// came with its own policy (e.g. from a workflow engine that knows a
+// particular step is flaky) keeps it untouched.
+func applyDefaultRetryPolicy(jobs []api.ExecutableJob, policy *api.RetryPolicy) {
+	for i := range jobs {
+		if jobs[i].RetryPolicy == nil {
+			jobs[i].RetryPolicy = policy
+		}
+	}
+}
+
+// applyDefaultLabels sets labels on every job that doesn't already carry
+// its own Labels, so a batch of otherwise-identical jobs can share one set
+// of cost-attribution/search annotations from the command line while a job
+// that came with its own labels keeps them untouched.
+func applyDefaultLabels(jobs []api.ExecutableJob, labels map[string]string) {
+	for i := range jobs {
+		if jobs[i].Labels == nil {
+			jobs[i].Labels = labels
+		}
+	}
+}
+
+// parseLabels parses a comma-separated list of key=value pairs such as
+// "project=alpha,cost-center=42" into a map, returning nil for a blank
+// string.
+func parseLabels(v string) (map[string]string, error) {
+	if v == "" {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		k, val, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label %q: want key=value", pair)
+		}
+		labels[k] = val
+	}
+	return labels, nil
+}
+
+// parseExitCodeList parses a comma-separated list of process exit codes
+// such as "1,75,111" into []int, returning nil for a blank string.
+func parseExitCodeList(v string) ([]int, error) {
+	if v == "" {
+		return nil, nil
+	}
+	parts := strings.Split(v, ",")
+	codes := make([]int, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		code, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code %q: %w", p, err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// orderJobs topologically sorts jobs so that every job appears only after
+// every job listed in its DependsOn, erroring loudly if DependsOn
+// describes a cycle or references a job absent from this same submission.
+func orderJobs(jobs []api.ExecutableJob) ([]api.ExecutableJob, error) {
+	byID := make(map[string]api.ExecutableJob, len(jobs))
+	for _, j := range jobs {
+		byID[j.JobID] = j
+	}
+
+	const (
+		unvisited = 0
+		visiting  = 1
+		done      = 2
+	)
+	state := make(map[string]int, len(jobs))
+	ordered := make([]api.ExecutableJob, 0, len(jobs))
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected at job %q", id)
+		}
+		state[id] = visiting
+
+		job := byID[id]
+		for _, dep := range job.DependsOn {
+			if _, ok := byID[dep]; !ok {
+				return fmt.Errorf("job %q depends on job %q, which is not part of this submission", id, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[id] = done
+		ordered = append(ordered, job)
+		return nil
+	}
+
+	for _, j := range jobs {
+		if err := visit(j.JobID); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
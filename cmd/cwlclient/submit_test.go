@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestOrderJobsPutsDependenciesFirst(t *testing.T) {
+	jobs := []api.ExecutableJob{
+		{JobID: "c", DependsOn: []string{"b"}},
+		{JobID: "a"},
+		{JobID: "b", DependsOn: []string{"a"}},
+	}
+
+	ordered, err := orderJobs(jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	position := make(map[string]int, len(ordered))
+	for i, j := range ordered {
+		position[j.JobID] = i
+	}
+	if position["a"] > position["b"] || position["b"] > position["c"] {
+		t.Fatalf("expected a before b before c, got order %+v", ordered)
+	}
+}
+
+func TestOrderJobsDetectsCycle(t *testing.T) {
+	jobs := []api.ExecutableJob{
+		{JobID: "a", DependsOn: []string{"b"}},
+		{JobID: "b", DependsOn: []string{"a"}},
+	}
+	if _, err := orderJobs(jobs); err == nil {
+		t.Fatal("expected a cycle error")
+	}
+}
+
+func TestOrderJobsRejectsUnknownDependency(t *testing.T) {
+	jobs := []api.ExecutableJob{
+		{JobID: "a", DependsOn: []string{"missing"}},
+	}
+	if _, err := orderJobs(jobs); err == nil {
+		t.Fatal("expected an unknown-dependency error")
+	}
+}
+
+func TestApplyDefaultProjectFillsOnlyBlankProjects(t *testing.T) {
+	jobs := []api.ExecutableJob{
+		{JobID: "a"},
+		{JobID: "b", Project: "lab-b"},
+	}
+	applyDefaultProject(jobs, "lab-a")
+
+	if jobs[0].Project != "lab-a" {
+		t.Fatalf("expected job a to default to lab-a, got %q", jobs[0].Project)
+	}
+	if jobs[1].Project != "lab-b" {
+		t.Fatalf("expected job b to keep its own project, got %q", jobs[1].Project)
+	}
+}
+
+func TestApplyDefaultProjectNoActiveProjectLeavesJobsUntouched(t *testing.T) {
+	jobs := []api.ExecutableJob{{JobID: "a"}}
+	applyDefaultProject(jobs, "")
+	if jobs[0].Project != "" {
+		t.Fatalf("expected project to stay blank, got %q", jobs[0].Project)
+	}
+}
+
+func TestApplyDefaultRetryPolicyFillsOnlyJobsWithoutOne(t *testing.T) {
+	own := &api.RetryPolicy{RetryCount: 5}
+	jobs := []api.ExecutableJob{
+		{JobID: "a"},
+		{JobID: "b", RetryPolicy: own},
+	}
+	def := &api.RetryPolicy{RetryCount: 2, RetryableExitCodes: []int{75}}
+	applyDefaultRetryPolicy(jobs, def)
+
+	if jobs[0].RetryPolicy != def {
+		t.Fatalf("expected job a to get the default policy, got %+v", jobs[0].RetryPolicy)
+	}
+	if jobs[1].RetryPolicy != own {
+		t.Fatalf("expected job b to keep its own policy, got %+v", jobs[1].RetryPolicy)
+	}
+}
+
+func TestParseExitCodeList(t *testing.T) {
+	codes, err := parseExitCodeList("1, 75,111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 75, 111}
+	if len(codes) != len(want) {
+		t.Fatalf("parseExitCodeList = %v, want %v", codes, want)
+	}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Fatalf("parseExitCodeList = %v, want %v", codes, want)
+		}
+	}
+
+	if codes, err := parseExitCodeList(""); err != nil || codes != nil {
+		t.Fatalf("parseExitCodeList(\"\") = %v, %v, want nil, nil", codes, err)
+	}
+
+	if _, err := parseExitCodeList("abc"); err == nil {
+		t.Fatal("expected an error for a non-numeric exit code")
+	}
+}
+
+func TestApplyDefaultLabelsFillsOnlyJobsWithoutOwnLabels(t *testing.T) {
+	own := map[string]string{"project": "lab-b"}
+	jobs := []api.ExecutableJob{
+		{JobID: "a"},
+		{JobID: "b", Labels: own},
+	}
+	def := map[string]string{"project": "lab-a", "cost-center": "42"}
+	applyDefaultLabels(jobs, def)
+
+	if jobs[0].Labels["project"] != "lab-a" || jobs[0].Labels["cost-center"] != "42" {
+		t.Fatalf("expected job a to get the default labels, got %+v", jobs[0].Labels)
+	}
+	if jobs[1].Labels["project"] != "lab-b" {
+		t.Fatalf("expected job b to keep its own labels, got %+v", jobs[1].Labels)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels("project=alpha, cost-center=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"project": "alpha", "cost-center": "42"}
+	if len(labels) != len(want) || labels["project"] != want["project"] || labels["cost-center"] != want["cost-center"] {
+		t.Fatalf("parseLabels = %+v, want %+v", labels, want)
+	}
+
+	if labels, err := parseLabels(""); err != nil || labels != nil {
+		t.Fatalf("parseLabels(\"\") = %+v, %v, want nil, nil", labels, err)
+	}
+
+	if _, err := parseLabels("noequalssign"); err == nil {
+		t.Fatal("expected an error for a pair without \"=\"")
+	}
+}
+
+func TestDependencyWavesGroupsByDepth(t *testing.T) {
+	ordered := []api.ExecutableJob{
+		{JobID: "a"},
+		{JobID: "b"},
+		{JobID: "c", DependsOn: []string{"a", "b"}},
+	}
+
+	waves := dependencyWaves(ordered)
+	if len(waves) != 2 {
+		t.Fatalf("expected 2 waves, got %d: %+v", len(waves), waves)
+	}
+	if len(waves[0]) != 2 {
+		t.Fatalf("expected a and b in the first wave, got %+v", waves[0])
+	}
+	if len(waves[1]) != 1 || waves[1][0].JobID != "c" {
+		t.Fatalf("expected c alone in the second wave, got %+v", waves[1])
+	}
+}
+
+func TestFailedDependencyWrapsDependencyError(t *testing.T) {
+	failed := map[string]error{"a": fmt.Errorf("boom")}
+	job := api.ExecutableJob{JobID: "b", DependsOn: []string{"a"}}
+
+	if err := failedDependency(job, failed); err == nil {
+		t.Fatal("expected an error for a job depending on a failed one")
+	}
+	if err := failedDependency(api.ExecutableJob{JobID: "c"}, failed); err != nil {
+		t.Fatalf("expected no error for a job with no failed dependency, got %v", err)
+	}
+}
+
+func TestSubmitBatchesConcurrentlyReportsPartialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.BulkJobSubmission
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		var resp api.BulkJobSubmissionResponse
+		for _, job := range req.Jobs {
+			if job.JobID == "bad" {
+				resp.Results = append(resp.Results, api.BulkJobSubmissionResult{JobID: job.JobID, Success: false, Error: "rejected"})
+				continue
+			}
+			resp.Results = append(resp.Results, api.BulkJobSubmissionResult{JobID: job.JobID, Success: true})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	jobs := []api.ExecutableJob{{JobID: "good"}, {JobID: "bad"}}
+	failed := submitBatchesConcurrently(server.URL, jobs, 10, 2)
+
+	if len(failed) != 1 {
+		t.Fatalf("expected exactly one failed job, got %+v", failed)
+	}
+	if _, ok := failed["bad"]; !ok {
+		t.Fatalf("expected \"bad\" to be reported as failed, got %+v", failed)
+	}
+}
+
+func TestSubmitInWavesSkipsDependentsOfAFailedJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.BulkJobSubmission
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		var resp api.BulkJobSubmissionResponse
+		for _, job := range req.Jobs {
+			resp.Results = append(resp.Results, api.BulkJobSubmissionResult{JobID: job.JobID, Success: false, Error: "rejected"})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	ordered := []api.ExecutableJob{
+		{JobID: "a"},
+		{JobID: "b", DependsOn: []string{"a"}},
+	}
+	failed := submitInWaves(server.URL, ordered, 10, 2)
+
+	if len(failed) != 2 {
+		t.Fatalf("expected both jobs recorded as failed, got %+v", failed)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runValidate implements `cwlclient validate [-strict] <tool.cwl>`: it
+// shells out to a locally installed cwltool (which itself validates
+// against the CWL spec via schema-salad) so a malformed tool is rejected
+// with cwltool's own spec-referenced error before it is ever registered
+// server-side.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "fail on any schema-salad validation warning, not just a hard parse error")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: cwlclient validate [-strict] <tool.cwl>")
+	}
+	path, _, err := resolveToolPath(fs.Arg(0), defaultGitCacheDir())
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", fs.Arg(0), err)
+	}
+	return validateCWLDocument(path, *strict)
+}
+
+// validateCWLDocument runs `cwltool --validate` (schema-salad's own
+// validator, bundled with cwltool) against path, in --strict or
+// --non-strict mode as requested. When cwltool isn't installed, strict
+// validation fails outright, since there's no other way to honor the
+// caller's request that invalid CWL be rejected; non-strict validation
+// instead logs a warning and lets path through unchecked, the same
+// graceful-degradation behavior this worker already applies when an
+// optional external tool (e.g. docker, for image digest resolution) is
+// unavailable.
+func validateCWLDocument(path string, strict bool) error {
+	cwltoolPath, err := exec.LookPath("cwltool")
+	if err != nil {
+		if strict {
+			return fmt.Errorf("strict validation of %s requires cwltool (schema-salad) on PATH: %w", path, err)
+		}
+		logger.Warn("cwltool not found, skipping CWL validation", "path", path)
+		return nil
+	}
+
+	mode := "--non-strict"
+	if strict {
+		mode = "--strict"
+	}
+	cmd := exec.Command(cwltoolPath, "--validate", mode, path)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("validating %s: %w\n%s", path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
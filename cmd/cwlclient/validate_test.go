@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateCWLDocumentNonStrictSkipsWithoutCwltool(t *testing.T) {
+	if _, err := exec.LookPath("cwltool"); err == nil {
+		t.Skip("cwltool is installed; this test exercises the not-installed fallback")
+	}
+
+	path := filepath.Join(t.TempDir(), "tool.cwl")
+	if err := os.WriteFile(path, []byte("not valid CWL"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateCWLDocument(path, false); err != nil {
+		t.Fatalf("expected non-strict validation to skip cleanly without cwltool, got %v", err)
+	}
+}
+
+func TestValidateCWLDocumentStrictFailsWithoutCwltool(t *testing.T) {
+	if _, err := exec.LookPath("cwltool"); err == nil {
+		t.Skip("cwltool is installed; this test exercises the not-installed fallback")
+	}
+
+	path := filepath.Join(t.TempDir(), "tool.cwl")
+	if err := os.WriteFile(path, []byte("not valid CWL"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateCWLDocument(path, true); err == nil {
+		t.Fatal("expected strict validation to fail when cwltool is unavailable")
+	}
+}
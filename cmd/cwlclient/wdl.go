@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// wdlParam is one typed input or output declaration from a WDL task.
+type wdlParam struct {
+	Name    string
+	WDLType string
+	// Expr is an output's right-hand-side expression (e.g. a quoted
+	// literal filename). Empty for inputs.
+	Expr string
+	// Default is an input's declared default value expression, if any.
+	Default string
+}
+
+// wdlTask is the minimal subset of a WDL task this translator supports:
+// one "command <<< ... >>>" block, typed inputs/outputs, and an optional
+// runtime docker image.
+type wdlTask struct {
+	Name    string
+	Inputs  []wdlParam
+	Outputs []wdlParam
+	Command string
+	Docker  string
+}
+
+// runWDL implements the experimental `cwlclient wdl` subcommand: it
+// translates a single-task WDL file into a packed CWL CommandLineTool
+// document (written in CWL's JSON syntax, since this module has no YAML
+// dependency), so a tool written in WDL can be registered and submitted
+// through the same path as any other CWL tool, without flowy needing a
+// separate WDL execution engine. Only a narrow WDL subset is supported:
+// one task per file, no workflows, no scatter, and commands must use the
+// "<<< ... >>>" heredoc form rather than "{ ... }".
+func runWDL(args []string) error {
+	fs := flag.NewFlagSet("wdl", flag.ContinueOnError)
+	out := fs.String("o", "", "write the translated CWL tool here instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: cwlclient wdl [-o tool.cwl] <task.wdl>")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", fs.Arg(0), err)
+	}
+
+	task, err := parseWDLTask(string(data))
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", fs.Arg(0), err)
+	}
+
+	encoded, err := json.MarshalIndent(translateWDLTaskToCWL(task), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Println(string(encoded))
+		return nil
+	}
+	return os.WriteFile(*out, append(encoded, '\n'), 0o644)
+}
+
+var taskHeaderRe = regexp.MustCompile(`task\s+(\w+)\s*\{`)
+
+// parseWDLTask extracts the first task declaration from src.
+func parseWDLTask(src string) (*wdlTask, error) {
+	headerLoc := taskHeaderRe.FindStringSubmatchIndex(src)
+	if headerLoc == nil {
+		return nil, fmt.Errorf(`no "task <name> {" declaration found`)
+	}
+	name := src[headerLoc[2]:headerLoc[3]]
+	body, err := matchBrace(src, headerLoc[1]-1)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", name, err)
+	}
+
+	task := &wdlTask{Name: name}
+
+	if inputBody, ok := extractBracedBlock(body, "input"); ok {
+		inputs, err := parseWDLParams(inputBody, true)
+		if err != nil {
+			return nil, fmt.Errorf("parsing inputs: %w", err)
+		}
+		task.Inputs = inputs
+	}
+	if outputBody, ok := extractBracedBlock(body, "output"); ok {
+		outputs, err := parseWDLParams(outputBody, false)
+		if err != nil {
+			return nil, fmt.Errorf("parsing outputs: %w", err)
+		}
+		task.Outputs = outputs
+	}
+
+	command, err := extractWDLCommand(body)
+	if err != nil {
+		return nil, err
+	}
+	task.Command = command
+
+	if runtimeBody, ok := extractBracedBlock(body, "runtime"); ok {
+		if m := dockerRuntimeRe.FindStringSubmatch(runtimeBody); m != nil {
+			task.Docker = m[1]
+		}
+	}
+
+	return task, nil
+}
+
+// extractBracedBlock finds "<keyword> {" within src and returns the text
+// between its matching braces. Brace matching ignores braces inside
+// double-quoted strings, which is enough for the declaration blocks
+// input/output/runtime are used for in this translator's supported WDL
+// subset.
+func extractBracedBlock(src, keyword string) (string, bool) {
+	re := regexp.MustCompile(`\b` + keyword + `\s*\{`)
+	loc := re.FindStringIndex(src)
+	if loc == nil {
+		return "", false
+	}
+	body, err := matchBrace(src, loc[1]-1)
+	if err != nil {
+		return "", false
+	}
+	return body, true
+}
+
+// matchBrace returns the text between the '{' at src[openIdx] and its
+// matching '}'.
+func matchBrace(src string, openIdx int) (string, error) {
+	depth := 0
+	inQuote := false
+	for i := openIdx; i < len(src); i++ {
+		switch src[i] {
+		case '"':
+			inQuote = !inQuote
+		case '{':
+			if !inQuote {
+				depth++
+			}
+		case '}':
+			if !inQuote {
+				depth--
+				if depth == 0 {
+					return src[openIdx+1 : i], nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("unterminated block (no matching '}')")
+}
+
+var wdlCommandRe = regexp.MustCompile(`(?s)command\s*<<<(.*?)>>>`)
+
+// extractWDLCommand pulls the body of a "command <<< ... >>>" block, the
+// only command syntax this translator supports; WDL's older "command {
+// ... }" form is not handled, since its closing brace can't be
+// distinguished from braces that are part of the shell command itself.
+func extractWDLCommand(body string) (string, error) {
+	m := wdlCommandRe.FindStringSubmatch(body)
+	if m == nil {
+		return "", fmt.Errorf(`no "command <<< ... >>>" block found`)
+	}
+	return strings.TrimSpace(m[1]), nil
+}
+
+var dockerRuntimeRe = regexp.MustCompile(`docker\s*:\s*"([^"]+)"`)
+
+var wdlParamLineRe = regexp.MustCompile(`^([A-Za-z_][\w\[\]]*\??)\s+([A-Za-z_]\w*)\s*(?:=\s*(.+))?$`)
+
+// parseWDLParams parses one declaration per line of an input or output
+// block, e.g. "File fastq", "Int threads = 4", or "File bam = \"out.bam\"".
+func parseWDLParams(block string, isInput bool) ([]wdlParam, error) {
+	var params []wdlParam
+	for _, rawLine := range strings.Split(block, "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := wdlParamLineRe.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("unrecognized declaration %q", line)
+		}
+		p := wdlParam{Name: m[2], WDLType: m[1]}
+		if isInput {
+			p.Default = m[3]
+		} else {
+			if m[3] == "" {
+				return nil, fmt.Errorf("output %q has no assigned expression", m[2])
+			}
+			p.Expr = m[3]
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// wdlTypeToCWL converts a WDL type ("File", "Int?", "Array[File]", ...)
+// into its CWL equivalent, representing an optional WDL type as a CWL
+// ["null", <type>] union.
+func wdlTypeToCWL(wdlType string) interface{} {
+	optional := strings.HasSuffix(wdlType, "?")
+	base := strings.TrimSuffix(wdlType, "?")
+
+	var cwlType interface{}
+	if strings.HasPrefix(base, "Array[") && strings.HasSuffix(base, "]") {
+		inner := base[len("Array[") : len(base)-1]
+		cwlType = map[string]interface{}{"type": "array", "items": wdlScalarTypeToCWL(inner)}
+	} else {
+		cwlType = wdlScalarTypeToCWL(base)
+	}
+
+	if optional {
+		return []interface{}{"null", cwlType}
+	}
+	return cwlType
+}
+
+func wdlScalarTypeToCWL(t string) string {
+	switch t {
+	case "File":
+		return "File"
+	case "Int":
+		return "int"
+	case "Float":
+		return "float"
+	case "Boolean":
+		return "boolean"
+	default: // String, and anything this translator doesn't specifically know
+		return "string"
+	}
+}
+
+var wdlInterpRe = regexp.MustCompile(`[~$]\{(\w+)\}`)
+
+// rewriteWDLCommandExpr rewrites WDL's "~{var}"/"${var}" interpolation
+// syntax into the CWL/JavaScript expression a CommandLineTool's
+// InlineJavascriptRequirement evaluates against its own inputs, so the
+// translated command and output glob still reference the job's actual
+// input values once it runs under CWL instead of a WDL engine.
+func rewriteWDLCommandExpr(text string, inputs []wdlParam) string {
+	isFile := make(map[string]bool, len(inputs))
+	for _, p := range inputs {
+		if strings.TrimSuffix(p.WDLType, "?") == "File" {
+			isFile[p.Name] = true
+		}
+	}
+	return wdlInterpRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := wdlInterpRe.FindStringSubmatch(match)[1]
+		if isFile[name] {
+			return fmt.Sprintf("$(inputs.%s.path)", name)
+		}
+		return fmt.Sprintf("$(inputs.%s)", name)
+	})
+}
+
+// stripQuotes removes a single pair of surrounding double quotes, for a
+// WDL output expression like "\"out.bam\"" that is really just a literal
+// glob pattern once its interpolations are rewritten.
+func stripQuotes(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// translateWDLTaskToCWL builds the packed CWL CommandLineTool document
+// (as a JSON-marshalable value) equivalent to task.
+func translateWDLTaskToCWL(task *wdlTask) map[string]interface{} {
+	inputs := make([]map[string]interface{}, 0, len(task.Inputs))
+	for _, p := range task.Inputs {
+		in := map[string]interface{}{"id": p.Name, "type": wdlTypeToCWL(p.WDLType)}
+		if p.Default != "" {
+			in["default"] = p.Default
+		}
+		inputs = append(inputs, in)
+	}
+
+	outputs := make([]map[string]interface{}, 0, len(task.Outputs))
+	for _, p := range task.Outputs {
+		outputs = append(outputs, map[string]interface{}{
+			"id":   p.Name,
+			"type": wdlTypeToCWL(p.WDLType),
+			"outputBinding": map[string]interface{}{
+				"glob": rewriteWDLCommandExpr(stripQuotes(p.Expr), task.Inputs),
+			},
+		})
+	}
+
+	requirements := []map[string]interface{}{
+		{"class": "InlineJavascriptRequirement"},
+	}
+	if task.Docker != "" {
+		requirements = append(requirements, map[string]interface{}{"class": "DockerRequirement", "dockerPull": task.Docker})
+	}
+
+	return map[string]interface{}{
+		"cwlVersion":   "v1.2",
+		"class":        "CommandLineTool",
+		"id":           task.Name,
+		"baseCommand":  []string{"bash", "-c"},
+		"requirements": requirements,
+		"arguments": []map[string]interface{}{
+			{"valueFrom": rewriteWDLCommandExpr(task.Command, task.Inputs)},
+		},
+		"inputs":  inputs,
+		"outputs": outputs,
+	}
+}
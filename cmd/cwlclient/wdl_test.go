@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+)
+
+const sampleWDLTask = `
+task align {
+  input {
+    File fastq
+    Int threads = 4
+  }
+
+  command <<<
+    bwa mem -t ~{threads} ref.fa ~{fastq} > out.bam
+  >>>
+
+  output {
+    File bam = "out.bam"
+  }
+
+  runtime {
+    docker: "biocontainers/bwa:0.7.17"
+  }
+}
+`
+
+func TestParseWDLTask(t *testing.T) {
+	task, err := parseWDLTask(sampleWDLTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Name != "align" {
+		t.Fatalf("task.Name = %q, want align", task.Name)
+	}
+	if len(task.Inputs) != 2 || task.Inputs[0].Name != "fastq" || task.Inputs[1].Name != "threads" {
+		t.Fatalf("unexpected inputs: %+v", task.Inputs)
+	}
+	if task.Inputs[1].Default != "4" {
+		t.Fatalf("threads default = %q, want 4", task.Inputs[1].Default)
+	}
+	if len(task.Outputs) != 1 || task.Outputs[0].Name != "bam" || task.Outputs[0].Expr != `"out.bam"` {
+		t.Fatalf("unexpected outputs: %+v", task.Outputs)
+	}
+	if task.Docker != "biocontainers/bwa:0.7.17" {
+		t.Fatalf("task.Docker = %q, want biocontainers/bwa:0.7.17", task.Docker)
+	}
+	wantCommand := `bwa mem -t ~{threads} ref.fa ~{fastq} > out.bam`
+	if task.Command != wantCommand {
+		t.Fatalf("task.Command = %q, want %q", task.Command, wantCommand)
+	}
+}
+
+func TestParseWDLTaskMissingCommand(t *testing.T) {
+	if _, err := parseWDLTask(`task t { input { File f } }`); err == nil {
+		t.Fatal("expected an error for a task with no command block")
+	}
+}
+
+func TestWdlTypeToCWL(t *testing.T) {
+	if got := wdlTypeToCWL("File"); got != "File" {
+		t.Fatalf("wdlTypeToCWL(File) = %v, want File", got)
+	}
+	if got, ok := wdlTypeToCWL("String?").([]interface{}); !ok || got[0] != "null" || got[1] != "string" {
+		t.Fatalf("wdlTypeToCWL(String?) = %v, want [null string]", got)
+	}
+	arr, ok := wdlTypeToCWL("Array[File]").(map[string]interface{})
+	if !ok || arr["type"] != "array" || arr["items"] != "File" {
+		t.Fatalf("wdlTypeToCWL(Array[File]) = %v, want array of File", arr)
+	}
+}
+
+func TestRewriteWDLCommandExpr(t *testing.T) {
+	inputs := []wdlParam{{Name: "fastq", WDLType: "File"}, {Name: "threads", WDLType: "Int"}}
+	got := rewriteWDLCommandExpr("bwa -t ~{threads} ${fastq}", inputs)
+	want := "bwa -t $(inputs.threads) $(inputs.fastq.path)"
+	if got != want {
+		t.Fatalf("rewriteWDLCommandExpr = %q, want %q", got, want)
+	}
+}
+
+func TestTranslateWDLTaskToCWL(t *testing.T) {
+	task, err := parseWDLTask(sampleWDLTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := translateWDLTaskToCWL(task)
+
+	if tool["class"] != "CommandLineTool" {
+		t.Fatalf("class = %v, want CommandLineTool", tool["class"])
+	}
+	outputs, ok := tool["outputs"].([]map[string]interface{})
+	if !ok || len(outputs) != 1 {
+		t.Fatalf("unexpected outputs: %v", tool["outputs"])
+	}
+	binding, ok := outputs[0]["outputBinding"].(map[string]interface{})
+	if !ok || binding["glob"] != "out.bam" {
+		t.Fatalf("output glob = %v, want out.bam", binding["glob"])
+	}
+
+	requirements, ok := tool["requirements"].([]map[string]interface{})
+	if !ok || len(requirements) != 2 {
+		t.Fatalf("unexpected requirements: %v", tool["requirements"])
+	}
+	if requirements[1]["class"] != "DockerRequirement" || requirements[1]["dockerPull"] != "biocontainers/bwa:0.7.17" {
+		t.Fatalf("unexpected docker requirement: %v", requirements[1])
+	}
+}
@@ -0,0 +1,139 @@
+// Command e2eserver is a minimal stand-in for the flowy server's
+// worker-facing API, for driving a real worker through real jobs in
+// test/e2e without the actual server (which lives outside this repo).
+// It holds an in-memory FIFO queue of submitted jobs and their results:
+// POST /api/jobs enqueues a job, GET /api/worker/next-job dequeues the
+// next one for a polling worker, POST /api/worker/job-finished records
+// its outcome, and GET /api/jobs/<id> lets the test harness poll for it.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	s := newServer()
+	log.Printf("e2eserver listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, s.routes()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// server holds the queue of jobs awaiting a worker and the results workers
+// have reported, guarded by a single mutex since the request volume an
+// e2e run generates never justifies anything finer-grained.
+type server struct {
+	mu      sync.Mutex
+	pending []api.ExecutableJob
+	results map[string]api.JobFinishedRequest
+}
+
+func newServer() *server {
+	return &server{results: map[string]api.JobFinishedRequest{}}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/jobs", s.handleSubmitJob)
+	mux.HandleFunc("/api/jobs/", s.handlePollJob)
+	mux.HandleFunc("/api/worker/next-job", s.handleNextJob)
+	mux.HandleFunc("/api/worker/job-finished", s.handleJobFinished)
+	mux.HandleFunc("/api/worker/heartbeat", s.handleHeartbeat)
+	return mux
+}
+
+// handleSubmitJob enqueues a job submitted by the e2e test harness (the
+// same endpoint cmd/conformance's submitJob posts to). The caller is
+// expected to have already set JobID, since this server never generates
+// one of its own.
+func (s *server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var job api.ExecutableJob
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if job.JobID == "" {
+		http.Error(w, "job is missing jobId", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, job)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handlePollJob reports a job's result once a worker has reported it
+// finished, or a zero-value, not-yet-finished result otherwise (the same
+// "neither Success nor ErrorMsg is set" shape cmd/conformance's pollJob
+// treats as still running).
+func (s *server) handlePollJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Path[len("/api/jobs/"):]
+
+	s.mu.Lock()
+	result, ok := s.results[jobID]
+	s.mu.Unlock()
+	if !ok {
+		result = api.JobFinishedRequest{JobID: jobID}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleNextJob pops the oldest pending job for a polling worker, or
+// StatusNoContent when the queue is empty.
+func (s *server) handleNextJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	job := s.pending[0]
+	s.pending = s.pending[1:]
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func (s *server) handleJobFinished(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var result api.JobFinishedRequest
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !result.Success && result.ErrorMsg == "" {
+		result.ErrorMsg = fmt.Sprintf("job %s failed with no error message", result.JobID)
+	}
+
+	s.mu.Lock()
+	s.results[result.JobID] = result
+	s.mu.Unlock()
+}
+
+func (s *server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.WorkerHeartbeatResponse{ServerTime: time.Now().Unix()})
+}
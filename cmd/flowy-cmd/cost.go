@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// runCostExport implements `flowy-cmd cost export [-project X]`, printing
+// a CSV of every project's aggregated estimated job cost to stdout, in the
+// shape a finance team can drop straight into a spreadsheet. With
+// -project, the report is limited to that one project.
+func runCostExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	project := fs.String("project", "", "only report cost for this project")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	reqURL := serverURL + "/api/costs"
+	if *project != "" {
+		reqURL += "?project=" + *project
+	}
+
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var report api.CostReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+	if err := w.Write([]string{"project", "jobCount", "totalEstimatedCostUsd"}); err != nil {
+		return err
+	}
+	for _, p := range report.Projects {
+		if err := w.Write([]string{p.Project, fmt.Sprintf("%d", p.JobCount), fmt.Sprintf("%.4f", p.TotalEstimatedCostUSD)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestRunCostExportPrintsCSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.CostReport{Projects: []api.ProjectCostSummary{
+			{Project: "alpha", JobCount: 3, TotalEstimatedCostUSD: 1.5},
+		}})
+	}))
+	defer server.Close()
+
+	oldServerURL := serverURL
+	serverURL = server.URL
+	defer func() { serverURL = oldServerURL }()
+
+	out := captureStdout(t, func() {
+		if err := runCostExport(nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := "project,jobCount,totalEstimatedCostUsd\nalpha,3,1.5000\n"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// serverURL is the base URL of the flowy server this CLI talks to.
+var serverURL = os.Getenv("FLOWY_SERVER_URL")
+
+func init() {
+	if serverURL == "" {
+		serverURL = "http://localhost:8080"
+	}
+}
+
+// runJobShow implements `flowy-cmd job show <jobId>`, printing the job's
+// status and, once available, its resource usage for capacity planning.
+func runJobShow(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: flowy-cmd job show <jobId>")
+	}
+	jobID := args[0]
+
+	resp, err := http.Get(serverURL + "/api/jobs/" + jobID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var req api.JobFinishedRequest
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	fmt.Printf("job:      %s\n", req.JobID)
+	fmt.Printf("success:  %v\n", req.Success)
+	if req.ErrorMsg != "" {
+		fmt.Printf("error:    %s\n", req.ErrorMsg)
+	}
+	if req.Usage != nil {
+		fmt.Printf("wall:     %.1fs\n", req.Usage.WallSeconds)
+		fmt.Printf("cpu:      %.1fs user, %.1fs sys\n", req.Usage.UserCPUSeconds, req.Usage.SysCPUSeconds)
+		fmt.Printf("max rss:  %d bytes\n", req.Usage.MaxRSSBytes)
+		if req.Usage.EstimatedCostUSD > 0 {
+			fmt.Printf("cost:     $%.4f\n", req.Usage.EstimatedCostUSD)
+		}
+	}
+	return nil
+}
+
+// runJobList implements `flowy-cmd job list [-label key=value] [-project
+// name]`, printing every job matching the given filters, one per line. A
+// filter that is left blank is not sent to the server and matches every
+// job.
+func runJobList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	label := fs.String("label", "", "only list jobs carrying this key=value label")
+	project := fs.String("project", "", "only list jobs submitted under this project")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *label != "" && !strings.Contains(*label, "=") {
+		return fmt.Errorf("usage: flowy-cmd job list [-label key=value] [-project name]")
+	}
+
+	q := url.Values{}
+	if *label != "" {
+		q.Set("label", *label)
+	}
+	if *project != "" {
+		q.Set("project", *project)
+	}
+	reqURL := serverURL + "/api/jobs"
+	if encoded := q.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var listResp api.JobListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		return err
+	}
+
+	for _, j := range listResp.Jobs {
+		fmt.Printf("%s [%s]", j.JobID, j.Status)
+		if j.Project != "" {
+			fmt.Printf("  project=%s", j.Project)
+		}
+		for _, k := range sortedLabelKeys(j.Labels) {
+			fmt.Printf("  %s=%s", k, j.Labels[k])
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// sortedLabelKeys returns labels' keys in sorted order, so job list's
+// output is deterministic from run to run.
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runJobGraph implements `flowy-cmd job graph <jobId>`, printing jobId's
+// full DAG submission with each node's current status, indented to show
+// parent/child dependency relationships.
+func runJobGraph(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: flowy-cmd job graph <jobId>")
+	}
+	jobID := args[0]
+
+	resp, err := http.Get(serverURL + "/api/jobs/" + jobID + "/graph")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var nodes []api.JobGraphNode
+	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+		return err
+	}
+
+	byID := make(map[string]api.JobGraphNode, len(nodes))
+	for _, n := range nodes {
+		byID[n.JobID] = n
+	}
+
+	printJobGraph(jobID, byID, "")
+	return nil
+}
+
+// printJobGraph prints id and, indented beneath it, every job it
+// DependsOn, recursively.
+func printJobGraph(id string, byID map[string]api.JobGraphNode, indent string) {
+	node, ok := byID[id]
+	if !ok {
+		fmt.Printf("%s%s (unknown)\n", indent, id)
+		return
+	}
+	fmt.Printf("%s%s [%s]\n", indent, node.JobID, node.Status)
+	for _, dep := range node.DependsOn {
+		printJobGraph(dep, byID, indent+"  ")
+	}
+}
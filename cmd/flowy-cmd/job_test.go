@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	old := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestPrintJobGraphIndentsDependencies(t *testing.T) {
+	byID := map[string]api.JobGraphNode{
+		"c": {JobID: "c", Status: "succeeded", DependsOn: []string{"b"}},
+		"b": {JobID: "b", Status: "succeeded", DependsOn: []string{"a"}},
+		"a": {JobID: "a", Status: "succeeded"},
+	}
+
+	out := captureStdout(t, func() { printJobGraph("c", byID, "") })
+
+	want := "c [succeeded]\n  b [succeeded]\n    a [succeeded]\n"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestPrintJobGraphHandlesUnknownNode(t *testing.T) {
+	out := captureStdout(t, func() { printJobGraph("missing", map[string]api.JobGraphNode{}, "") })
+	if out != "missing (unknown)\n" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestSortedLabelKeys(t *testing.T) {
+	keys := sortedLabelKeys(map[string]string{"project": "alpha", "cost-center": "42"})
+	want := []string{"cost-center", "project"}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] {
+		t.Fatalf("sortedLabelKeys = %v, want %v", keys, want)
+	}
+}
+
+func TestRunJobListFiltersByLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("label"); got != "project=alpha" {
+			t.Fatalf("expected label query %q, got %q", "project=alpha", got)
+		}
+		json.NewEncoder(w).Encode(api.JobListResponse{Jobs: []api.JobSummary{
+			{JobID: "job-1", Status: "succeeded", Labels: map[string]string{"project": "alpha"}},
+		}})
+	}))
+	defer server.Close()
+
+	oldServerURL := serverURL
+	serverURL = server.URL
+	defer func() { serverURL = oldServerURL }()
+
+	out := captureStdout(t, func() {
+		if err := runJobList([]string{"-label", "project=alpha"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "job-1 [succeeded]  project=alpha\n" {
+		t.Fatalf("got %q", out)
+	}
+}
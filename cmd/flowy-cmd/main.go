@@ -0,0 +1,41 @@
+// Command flowy-cmd is the administrative CLI for a flowy deployment: it
+// queries the server about jobs, outputs, and capacity rather than
+// submitting or executing work itself (that is cwlclient and worker's job).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: flowy-cmd job <show|graph|prefetch> <jobId>\n       flowy-cmd job list [-label key=value] [-project X]\n       flowy-cmd outputs gc [-older-than 30d] [-project X] [-dry-run]\n       flowy-cmd cost export [-project X]")
+		os.Exit(2)
+	}
+
+	var err error
+	switch {
+	case args[0] == "job" && args[1] == "show":
+		err = runJobShow(args[2:])
+	case args[0] == "job" && args[1] == "graph":
+		err = runJobGraph(args[2:])
+	case args[0] == "job" && args[1] == "prefetch":
+		err = runJobPrefetch(args[2:])
+	case args[0] == "job" && args[1] == "list":
+		err = runJobList(args[2:])
+	case args[0] == "outputs" && args[1] == "gc":
+		err = runOutputsGC(args[2:])
+	case args[0] == "cost" && args[1] == "export":
+		err = runCostExport(args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q %q", args[0], args[1])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flowy-cmd: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// parseRetentionAge parses a retention age like "30d", "12h", or "45m" into
+// a time.Duration. time.ParseDuration already handles "h"/"m"/"s"; "d" is
+// added on top since CWL workflows' output retention is usually specified
+// in days, not hours.
+func parseRetentionAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid retention age %q: %w", s, err)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// fetchRetentionCandidates asks the server which job output prefixes are
+// eligible for garbage collection: older than olderThan and, if project is
+// non-empty, belonging to that project.
+func fetchRetentionCandidates(serverURL string, olderThan time.Duration, project string) ([]api.OutputRetentionCandidate, error) {
+	query := url.Values{}
+	query.Set("older-than", olderThan.String())
+	if project != "" {
+		query.Set("project", project)
+	}
+
+	resp, err := http.Get(serverURL + "/api/outputs/gc?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var candidates []api.OutputRetentionCandidate
+	if err := json.NewDecoder(resp.Body).Decode(&candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// deleteOutputPrefix asks the server to remove jobID's output prefix. The
+// server, not this CLI, owns the S3/NFS deletion, since it is also the one
+// tracking which outputs registered datasets still reference.
+func deleteOutputPrefix(serverURL, jobID string) error {
+	req, err := http.NewRequest(http.MethodDelete, serverURL+"/api/outputs/"+jobID, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return nil
+}
+
+// runOutputsGC implements `flowy-cmd outputs gc`: it lists job output
+// prefixes older than -older-than (and, if given, scoped to -project),
+// skips any the server has marked Protected because a registered dataset
+// still references them, and deletes the rest unless -dry-run is set.
+func runOutputsGC(args []string) error {
+	fs := flag.NewFlagSet("outputs gc", flag.ContinueOnError)
+	olderThan := fs.String("older-than", "30d", "minimum output age to collect, e.g. 30d, 12h")
+	project := fs.String("project", "", "restrict collection to this project")
+	dryRun := fs.Bool("dry-run", false, "list what would be removed without deleting anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	age, err := parseRetentionAge(*olderThan)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := fetchRetentionCandidates(serverURL, age, *project)
+	if err != nil {
+		return fmt.Errorf("listing retention candidates: %w", err)
+	}
+
+	var removed, skipped int
+	for _, c := range candidates {
+		if c.Protected {
+			fmt.Printf("skip      %s (protected)\n", c.JobID)
+			skipped++
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("would gc  %s (%s, created %s)\n", c.JobID, c.Prefix, c.CreatedAt)
+			continue
+		}
+		if err := deleteOutputPrefix(serverURL, c.JobID); err != nil {
+			return fmt.Errorf("deleting output for job %s: %w", c.JobID, err)
+		}
+		fmt.Printf("gc        %s\n", c.JobID)
+		removed++
+	}
+
+	if *dryRun {
+		fmt.Printf("%d candidates, %d protected\n", len(candidates), skipped)
+	} else {
+		fmt.Printf("removed %d, skipped %d protected\n", removed, skipped)
+	}
+	return nil
+}
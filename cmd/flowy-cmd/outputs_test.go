@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestParseRetentionAgeAcceptsDaySuffix(t *testing.T) {
+	d, err := parseRetentionAge("30d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 30 * 24 * time.Hour; d != want {
+		t.Fatalf("got %v, want %v", d, want)
+	}
+}
+
+func TestParseRetentionAgeAcceptsStandardDuration(t *testing.T) {
+	d, err := parseRetentionAge("12h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 12*time.Hour {
+		t.Fatalf("got %v", d)
+	}
+}
+
+func TestParseRetentionAgeRejectsGarbage(t *testing.T) {
+	if _, err := parseRetentionAge("not-a-duration"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestFetchRetentionCandidatesSendsFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project") != "lab-a" {
+			t.Fatalf("expected project filter, got query %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode([]api.OutputRetentionCandidate{
+			{JobID: "job-1", Prefix: "lab-a/job-1", CreatedAt: "2026-01-01T00:00:00Z"},
+		})
+	}))
+	defer server.Close()
+
+	candidates, err := fetchRetentionCandidates(server.URL, 30*24*time.Hour, "lab-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].JobID != "job-1" {
+		t.Fatalf("unexpected candidates %+v", candidates)
+	}
+}
+
+func TestDeleteOutputPrefixSendsDelete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/api/outputs/job-1" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := deleteOutputPrefix(server.URL, "job-1"); err != nil {
+		t.Fatal(err)
+	}
+}
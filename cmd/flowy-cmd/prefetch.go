@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// prefetchFileManager downloads remote inputs ahead of execution. It
+// defaults to the local filesystem, matching cmd/worker's own default
+// backend; a deployment backed by object storage would swap this for an
+// S3FileManager the same way cmd/worker does for its output manager.
+var prefetchFileManager internal.FileManager = internal.NewLocalFileManager()
+
+// fetchJobSpec retrieves the ExecutableJob the server submitted for jobID,
+// as opposed to runJobShow's /api/jobs/<id>, which reports its completion
+// status.
+func fetchJobSpec(serverURL, jobID string) (*api.ExecutableJob, error) {
+	resp, err := http.Get(serverURL + "/api/jobs/" + jobID + "/spec")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var job api.ExecutableJob
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// isRemoteLocation reports whether resolved names an object in a remote
+// store rather than a path already on this machine, so prefetching only
+// fetches entries that are actually worth fetching ahead of time.
+func isRemoteLocation(resolved string) bool {
+	for _, scheme := range []string{"s3://", "http://", "https://"} {
+		if strings.HasPrefix(resolved, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteInputs returns the staged mapping entries in job that name a
+// remote location, which are exactly the entries prefetching can usefully
+// warm ahead of the job actually running on a worker.
+func remoteInputs(job *api.ExecutableJob) []api.MapperEnt {
+	var remote []api.MapperEnt
+	for _, ent := range job.Mapping {
+		if ent.Staged && isRemoteLocation(ent.Resolved) {
+			remote = append(remote, ent)
+		}
+	}
+	return remote
+}
+
+// runJobPrefetch implements `flowy-cmd job prefetch <jobId> <cacheDir>`: it
+// fetches jobId's submitted spec, and downloads every remote input it
+// references into cacheDir ahead of time, so a worker that later picks up
+// this job (or a sibling shard of the same scatter) finds its inputs
+// already warm instead of every shard hitting the backing store at once.
+func runJobPrefetch(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: flowy-cmd job prefetch <jobId> <cacheDir>")
+	}
+	jobID, cacheDir := args[0], args[1]
+
+	job, err := fetchJobSpec(serverURL, jobID)
+	if err != nil {
+		return fmt.Errorf("fetching job spec for %s: %w", jobID, err)
+	}
+
+	entries := remoteInputs(job)
+	if len(entries) == 0 {
+		fmt.Println("no remote inputs to prefetch")
+		return nil
+	}
+
+	for _, ent := range entries {
+		dst := filepath.Join(cacheDir, ent.Target)
+		if _, exists, err := prefetchFileManager.Stat(dst); err == nil && exists {
+			fmt.Printf("cached    %s\n", ent.Target)
+			continue
+		}
+		if err := prefetchFileManager.Download(ent.Resolved, dst); err != nil {
+			return fmt.Errorf("prefetching %s: %w", ent.Resolved, err)
+		}
+		fmt.Printf("prefetched %s\n", ent.Target)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestIsRemoteLocationDetectsKnownSchemes(t *testing.T) {
+	cases := map[string]bool{
+		"s3://bucket/key":      true,
+		"http://host/file":     true,
+		"https://host/file":    true,
+		"/local/path/file.txt": false,
+		"file.txt":             false,
+	}
+	for location, want := range cases {
+		if got := isRemoteLocation(location); got != want {
+			t.Errorf("isRemoteLocation(%q) = %v, want %v", location, got, want)
+		}
+	}
+}
+
+func TestRemoteInputsFiltersToStagedRemoteEntries(t *testing.T) {
+	job := &api.ExecutableJob{
+		Mapping: []api.MapperEnt{
+			{Target: "a", Resolved: "s3://bucket/a", Staged: true},
+			{Target: "b", Resolved: "/local/b", Staged: true},
+			{Target: "c", Resolved: "s3://bucket/c", Staged: false},
+		},
+	}
+
+	remote := remoteInputs(job)
+	if len(remote) != 1 || remote[0].Target != "a" {
+		t.Fatalf("expected only entry a, got %+v", remote)
+	}
+}
+
+func TestFetchJobSpecParsesResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/jobs/job-1/spec" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(api.ExecutableJob{JobID: "job-1"})
+	}))
+	defer server.Close()
+
+	job, err := fetchJobSpec(server.URL, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.JobID != "job-1" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+}
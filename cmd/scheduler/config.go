@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// scheduledWorkflow is one entry in the scheduler's config file: a tool
+// and templated job order to submit whenever Cron matches the current
+// minute.
+type scheduledWorkflow struct {
+	Name     string `json:"name"`
+	Cron     string `json:"cron"`
+	Tool     string `json:"tool"`
+	Template string `json:"template"`
+	Project  string `json:"project,omitempty"`
+}
+
+// loadScheduledWorkflows reads a JSON array of scheduledWorkflow from
+// path.
+func loadScheduledWorkflows(path string) ([]scheduledWorkflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var workflows []scheduledWorkflow
+	if err := json.Unmarshal(data, &workflows); err != nil {
+		return nil, err
+	}
+	for _, w := range workflows {
+		if w.Name == "" || w.Cron == "" || w.Tool == "" || w.Template == "" {
+			return nil, fmt.Errorf("scheduled workflow missing a required field (name, cron, tool, template): %+v", w)
+		}
+	}
+	return workflows, nil
+}
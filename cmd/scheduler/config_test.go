@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadScheduledWorkflows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	content := `[{"name":"nightly-qc","cron":"0 2 * * *","tool":"qc.cwl","template":"qc.yaml","project":"genomics"}]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	workflows, err := loadScheduledWorkflows(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workflows) != 1 || workflows[0].Name != "nightly-qc" || workflows[0].Cron != "0 2 * * *" {
+		t.Fatalf("unexpected workflows: %+v", workflows)
+	}
+}
+
+func TestLoadScheduledWorkflowsRejectsMissingField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	content := `[{"name":"nightly-qc","cron":"0 2 * * *","tool":"qc.cwl"}]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadScheduledWorkflows(path); err == nil {
+		t.Fatal("expected an error for a workflow missing its template field")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed 5-field cron expression (minute hour
+// day-of-month month day-of-week), matched against a time.Time the same
+// way cron(8) does: when day-of-month and day-of-week are both
+// restricted (neither is "*"), a match needs only one of the two, not
+// both.
+type cronSchedule struct {
+	minutes, hours, doms, months, dows map[int]bool
+	domRestricted, dowRestricted       bool
+}
+
+// parseCronExpression parses a standard 5-field cron expression. Each
+// field accepts "*", a single value, a comma-separated list, an "a-b"
+// range, or a "*/n" or "a-b/n" step — the subset of cron syntax real job
+// schedules actually use, without pulling in a full cron library.
+func parseCronExpression(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour dom month dow), got %d", expr, len(fields))
+	}
+	minutes, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("minute field: %w", err)
+	}
+	hours, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("hour field: %w", err)
+	}
+	doms, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("day-of-month field: %w", err)
+	}
+	months, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("month field: %w", err)
+	}
+	dows, err := parseCronField(fields[4], 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("day-of-week field: %w", err)
+	}
+	return &cronSchedule{
+		minutes:       minutes,
+		hours:         hours,
+		doms:          doms,
+		months:        months,
+		dows:          dows,
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+// parseCronField expands one cron field into the set of values in
+// [min,max] it matches.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	result := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart = part[:idx]
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			if dash := strings.Index(rangePart, "-"); dash >= 0 {
+				var err error
+				lo, err = strconv.Atoi(rangePart[:dash])
+				if err != nil {
+					return nil, fmt.Errorf("invalid range start in %q", part)
+				}
+				hi, err = strconv.Atoi(rangePart[dash+1:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid range end in %q", part)
+				}
+			} else {
+				v, err := strconv.Atoi(rangePart)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", part)
+				}
+				lo, hi = v, v
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %q out of range [%d,%d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			result[v] = true
+		}
+	}
+	return result, nil
+}
+
+// matches reports whether t falls within s.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+	domMatch := s.doms[t.Day()]
+	dowMatch := s.dows[int(t.Weekday())]
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleEveryMinute(t *testing.T) {
+	s, err := parseCronExpression("* * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.matches(time.Date(2026, 8, 9, 3, 17, 0, 0, time.UTC)) {
+		t.Fatal("expected '* * * * *' to match any minute")
+	}
+}
+
+func TestCronScheduleNightlyAtTwoAM(t *testing.T) {
+	s, err := parseCronExpression("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.matches(time.Date(2026, 8, 9, 2, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected a match at 02:00")
+	}
+	if s.matches(time.Date(2026, 8, 9, 2, 1, 0, 0, time.UTC)) {
+		t.Fatal("expected no match at 02:01")
+	}
+}
+
+func TestCronScheduleStepAndRange(t *testing.T) {
+	s, err := parseCronExpression("*/15 9-17 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Monday 2026-08-10 09:30 is within the 9-17 hour range, on a
+	// weekday, and 30 is a multiple of 15.
+	if !s.matches(time.Date(2026, 8, 10, 9, 30, 0, 0, time.UTC)) {
+		t.Fatal("expected a match at a 15-minute mark within the hour/weekday range")
+	}
+	// 2026-08-08 is a Saturday, outside 1-5 (Mon-Fri).
+	if s.matches(time.Date(2026, 8, 8, 9, 30, 0, 0, time.UTC)) {
+		t.Fatal("expected no match on a Saturday")
+	}
+}
+
+func TestCronScheduleDomDowORRule(t *testing.T) {
+	// "the 1st of the month, or any Monday" - both fields are
+	// restricted, so cron's OR rule applies between them.
+	s, err := parseCronExpression("0 0 1 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-08-10 is a Monday but not the 1st.
+	if !s.matches(time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected a match on a Monday even though it is not the 1st")
+	}
+	// 2026-08-01 is the 1st but a Saturday.
+	if !s.matches(time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected a match on the 1st even though it is not a Monday")
+	}
+}
+
+func TestParseCronExpressionRejectsWrongFieldCount(t *testing.T) {
+	if _, err := parseCronExpression("* * *"); err == nil {
+		t.Fatal("expected an error for a cron expression with too few fields")
+	}
+}
+
+func TestParseCronExpressionRejectsOutOfRangeValue(t *testing.T) {
+	if _, err := parseCronExpression("99 * * * *"); err == nil {
+		t.Fatal("expected an error for a minute value out of range")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// runRecord is one historical firing of a scheduledWorkflow, persisted so
+// the scheduler's run history survives a restart and a still-running
+// previous firing can block a new one of the same schedule from
+// overlapping it.
+type runRecord struct {
+	Name        string    `json:"name"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	JobID       string    `json:"jobId,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Running     bool      `json:"running"`
+}
+
+// runHistory is the scheduler's persisted state: every run it has fired,
+// oldest first.
+type runHistory struct {
+	Runs []runRecord `json:"runs"`
+}
+
+// loadRunHistory reads path, returning an empty history if it does not
+// yet exist (the scheduler's first run).
+func loadRunHistory(path string) (*runHistory, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &runHistory{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h runHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// save writes h to path as indented JSON, overwriting any prior contents.
+func (h *runHistory) save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// isRunning reports whether name's most recent run is still marked
+// Running, guarding against firing an overlapping run of the same
+// schedule before the previous one has been observed to finish.
+func (h *runHistory) isRunning(name string) bool {
+	for i := len(h.Runs) - 1; i >= 0; i-- {
+		if h.Runs[i].Name == name {
+			return h.Runs[i].Running
+		}
+	}
+	return false
+}
+
+// recordStarted appends a new Running run record for a job that was just
+// submitted.
+func (h *runHistory) recordStarted(name string, scheduledAt time.Time, jobID string) {
+	h.Runs = append(h.Runs, runRecord{Name: name, ScheduledAt: scheduledAt, JobID: jobID, Running: true})
+}
+
+// recordFailed appends a new, already-finished run record for a schedule
+// that failed to submit at all (so it never acquired a JobID to track).
+func (h *runHistory) recordFailed(name string, scheduledAt time.Time, err error) {
+	h.Runs = append(h.Runs, runRecord{Name: name, ScheduledAt: scheduledAt, Error: err.Error()})
+}
+
+// finish marks the run record for jobID as no longer Running, once the
+// job has been observed to complete.
+func (h *runHistory) finish(jobID string) {
+	for i := len(h.Runs) - 1; i >= 0; i-- {
+		if h.Runs[i].JobID == jobID {
+			h.Runs[i].Running = false
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunHistoryOverlapGuard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	h, err := loadRunHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.isRunning("nightly-qc") {
+		t.Fatal("a fresh history should report nothing running")
+	}
+
+	now := time.Date(2026, 8, 9, 2, 0, 0, 0, time.UTC)
+	h.recordStarted("nightly-qc", now, "job-1")
+	if !h.isRunning("nightly-qc") {
+		t.Fatal("expected nightly-qc to be running after recordStarted")
+	}
+
+	if err := h.save(path); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := loadRunHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.isRunning("nightly-qc") {
+		t.Fatal("expected the running flag to survive a reload")
+	}
+
+	reloaded.finish("job-1")
+	if reloaded.isRunning("nightly-qc") {
+		t.Fatal("expected finish to clear the running flag")
+	}
+}
+
+func TestRunHistoryRecordFailed(t *testing.T) {
+	h := &runHistory{}
+	now := time.Date(2026, 8, 9, 2, 0, 0, 0, time.UTC)
+	h.recordFailed("nightly-qc", now, errors.New("server returned 500 Internal Server Error"))
+
+	if h.isRunning("nightly-qc") {
+		t.Fatal("a failed submission never started a run, so it should not be marked running")
+	}
+	if len(h.Runs) != 1 || h.Runs[0].Error == "" {
+		t.Fatalf("expected one run record carrying the error, got %+v", h.Runs)
+	}
+}
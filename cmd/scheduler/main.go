@@ -0,0 +1,142 @@
+// Command scheduler fires registered workflows on cron expressions,
+// templating each run's job order from the time it fired, recording run
+// history, and refusing to start a run while a previous firing of the
+// same schedule is still outstanding — so a nightly QC pipeline doesn't
+// need external cron plus hand-written scripts.
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/logging"
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+var logger = logging.New(slog.LevelInfo, logFormat())
+
+func logFormat() logging.Format {
+	if os.Getenv("FLOWY_LOG_FORMAT") == "json" {
+		return logging.FormatJSON
+	}
+	return logging.FormatText
+}
+
+func main() {
+	configPath := flag.String("config", "", "JSON file listing scheduled workflows (name, cron, tool, template, project)")
+	serverAddr := flag.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server")
+	historyFile := flag.String("history-file", "scheduler-history.json", "JSON file recording every fired run and guarding against overlapping runs")
+	checkInterval := flag.Duration("check-interval", 20*time.Second, "how often to check whether a schedule is due")
+	metricsAddr := flag.String("metrics-addr", ":9092", "address the /metrics endpoint listens on")
+	flag.Parse()
+
+	if *configPath == "" {
+		logger.Error("missing required flag", "usage", "scheduler -config schedules.json")
+		os.Exit(1)
+	}
+
+	addr := *serverAddr
+	if addr == "" {
+		addr = "http://localhost:8080"
+	}
+
+	workflows, err := loadScheduledWorkflows(*configPath)
+	if err != nil {
+		logger.Error("loading config", "path", *configPath, "error", err)
+		os.Exit(1)
+	}
+
+	schedules := make(map[string]*cronSchedule, len(workflows))
+	templates := make(map[string]*template.Template, len(workflows))
+	for _, w := range workflows {
+		schedule, err := parseCronExpression(w.Cron)
+		if err != nil {
+			logger.Error("parsing cron expression", "workflow", w.Name, "error", err)
+			os.Exit(1)
+		}
+		schedules[w.Name] = schedule
+
+		data, err := os.ReadFile(w.Template)
+		if err != nil {
+			logger.Error("reading template", "workflow", w.Name, "path", w.Template, "error", err)
+			os.Exit(1)
+		}
+		tmpl, err := template.New(filepath.Base(w.Template)).Parse(string(data))
+		if err != nil {
+			logger.Error("parsing template", "workflow", w.Name, "path", w.Template, "error", err)
+			os.Exit(1)
+		}
+		templates[w.Name] = tmpl
+	}
+
+	history, err := loadRunHistory(*historyFile)
+	if err != nil {
+		logger.Error("loading history file", "path", *historyFile, "error", err)
+		os.Exit(1)
+	}
+
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+			logger.Error("metrics listener exited", "addr", *metricsAddr, "error", err)
+		}
+	}()
+
+	lastFired := map[string]time.Time{}
+	for {
+		now := time.Now().Truncate(time.Minute)
+		refreshRunningJobs(addr, history)
+
+		for _, w := range workflows {
+			if !schedules[w.Name].matches(now) || lastFired[w.Name].Equal(now) {
+				continue
+			}
+			lastFired[w.Name] = now
+
+			if history.isRunning(w.Name) {
+				logger.Warn("skipping overlapping run", "workflow", w.Name, "scheduledAt", now)
+				continue
+			}
+
+			jobID, err := submitScheduledJobOrder(addr, w, templates[w.Name], now)
+			if err != nil {
+				logger.Error("submitting scheduled job order", "workflow", w.Name, "error", err)
+				history.recordFailed(w.Name, now, err)
+			} else {
+				logger.Info("submitted scheduled job order", "workflow", w.Name, "jobId", jobID)
+				history.recordStarted(w.Name, now, jobID)
+			}
+			if err := history.save(*historyFile); err != nil {
+				logger.Error("saving history file", "path", *historyFile, "error", err)
+			}
+		}
+
+		time.Sleep(*checkInterval)
+	}
+}
+
+// refreshRunningJobs polls the server for every run history still marks
+// Running, clearing the flag once the job has completed, so the overlap
+// guard does not block a schedule forever because of a run that finished
+// while this daemon wasn't looking.
+func refreshRunningJobs(serverAddr string, history *runHistory) {
+	for _, r := range history.Runs {
+		if !r.Running || r.JobID == "" {
+			continue
+		}
+		finished, err := jobFinished(serverAddr, r.JobID)
+		if err != nil {
+			logger.Warn("checking scheduled job status", "jobId", r.JobID, "error", err)
+			continue
+		}
+		if finished {
+			history.finish(r.JobID)
+		}
+	}
+}
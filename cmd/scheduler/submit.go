@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/jobtemplate"
+)
+
+// scheduledRunValues is the set of placeholders a scheduled workflow's job
+// order template can reference, naming the minute its schedule fired.
+type scheduledRunValues struct {
+	Date  string // YYYY-MM-DD
+	Time  string // HH:MM:SS
+	Year  string
+	Month string
+	Day   string
+	Hour  string
+}
+
+func newScheduledRunValues(t time.Time) scheduledRunValues {
+	return scheduledRunValues{
+		Date:  t.Format("2006-01-02"),
+		Time:  t.Format("15:04:05"),
+		Year:  t.Format("2006"),
+		Month: t.Format("01"),
+		Day:   t.Format("02"),
+		Hour:  t.Format("15"),
+	}
+}
+
+// submitScheduledJobOrder renders tmpl against the fields of scheduledAt
+// and posts it against w.Tool, the same /api/job-orders contract
+// cwlclient's "array" subcommand and cmd/trigger use, returning the JobID
+// the server assigned.
+func submitScheduledJobOrder(serverAddr string, w scheduledWorkflow, tmpl *template.Template, scheduledAt time.Time) (string, error) {
+	inputs, err := jobtemplate.Render(tmpl, newScheduledRunValues(scheduledAt))
+	if err != nil {
+		return "", fmt.Errorf("rendering job order template: %w", err)
+	}
+
+	body, err := json.Marshal(api.JobOrderSubmission{Tool: w.Tool, Inputs: inputs, Project: w.Project, Label: w.Name})
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Post(serverAddr+"/api/job-orders", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var result api.JobOrderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.JobID, nil
+}
+
+// jobFinished reports whether jobID has a completion record on the
+// server yet, per the same /api/jobs/<id> contract cwlclient's export
+// subcommand polls.
+func jobFinished(serverAddr, jobID string) (bool, error) {
+	resp, err := http.Get(serverAddr + "/api/jobs/" + jobID)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("server returned %s", resp.Status)
+	}
+	return true, nil
+}
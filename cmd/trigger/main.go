@@ -0,0 +1,100 @@
+// Command trigger watches a local directory or an S3 bucket (via SQS
+// notifications) for newly arrived objects and submits a configured CWL
+// tool against each one, templating its job order from the triggering
+// object's path, with dedupe and a state file so a restart does not
+// resubmit anything it already queued — turning flowy into a drop-folder
+// pipeline runner.
+//
+// Only the local-directory watch mode is wired up below. S3/SQS watching
+// is implemented against the SQSAPI interface (see watch_sqs.go) the same
+// way internal.S3API is: declared purely so tests can exercise it with an
+// in-memory fake, with no concrete AWS SDK client constructed anywhere in
+// this module, matching this repo's minimal dependency list. An operator
+// who needs the S3-backed mode can implement SQSAPI against their SDK of
+// choice and call pollSQS from their own main.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/logging"
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+var logger = logging.New(slog.LevelInfo, logFormat())
+
+func logFormat() logging.Format {
+	if os.Getenv("FLOWY_LOG_FORMAT") == "json" {
+		return logging.FormatJSON
+	}
+	return logging.FormatText
+}
+
+func main() {
+	watchDir := flag.String("watch-dir", "", "local directory to poll for newly dropped files")
+	pollInterval := flag.Duration("poll-interval", 10*time.Second, "how often to re-scan -watch-dir")
+	toolPath := flag.String("tool", "", "CWL tool to submit against each newly dropped file")
+	templatePath := flag.String("template", "", "Go text/template job order rendered per file; {{.Path}}, {{.Dir}}, and {{.Name}} name the dropped file")
+	serverAddr := flag.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server")
+	project := flag.String("project", os.Getenv("FLOWY_PROJECT"), "project to submit every job order under")
+	stateFile := flag.String("state-file", "trigger-state.json", "JSON file recording already-submitted files, so a restart does not resubmit them")
+	metricsAddr := flag.String("metrics-addr", ":9091", "address the /metrics endpoint listens on")
+	flag.Parse()
+
+	if *watchDir == "" || *toolPath == "" || *templatePath == "" {
+		logger.Error("missing required flags", "usage", "trigger -watch-dir dir -tool tool.cwl -template template.yaml")
+		os.Exit(1)
+	}
+
+	addr := *serverAddr
+	if addr == "" {
+		addr = "http://localhost:8080"
+	}
+
+	templateData, err := os.ReadFile(*templatePath)
+	if err != nil {
+		logger.Error("reading template", "path", *templatePath, "error", err)
+		os.Exit(1)
+	}
+	tmpl, err := template.New(filepath.Base(*templatePath)).Parse(string(templateData))
+	if err != nil {
+		logger.Error("parsing template", "path", *templatePath, "error", err)
+		os.Exit(1)
+	}
+
+	st, err := loadTriggerState(*stateFile)
+	if err != nil {
+		logger.Error("loading state file", "path", *stateFile, "error", err)
+		os.Exit(1)
+	}
+
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+			logger.Error("metrics listener exited", "addr", *metricsAddr, "error", err)
+		}
+	}()
+
+	handle := func(path string) error {
+		values := triggerValues{Path: path, Dir: filepath.Dir(path), Name: filepath.Base(path)}
+		jobID, err := submitTriggeredJobOrder(addr, *toolPath, tmpl, values, *project, values.Name)
+		if err != nil {
+			return fmt.Errorf("submitting job order for %s: %w", path, err)
+		}
+		logger.Info("submitted job order", "path", path, "jobId", jobID)
+		return nil
+	}
+
+	if err := pollLocalDirectory(*watchDir, *pollInterval, st, *stateFile, handle, nil); err != nil {
+		logger.Error("trigger exiting", "error", err)
+		os.Exit(1)
+	}
+}
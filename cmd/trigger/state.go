@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// triggerState is the dedupe record persisted to -state-file: the set of
+// event keys (a local file's "file://" path, or an S3 "s3://bucket/key")
+// already submitted, so a restart does not resubmit a drop-folder file or
+// S3 object this daemon already handled.
+type triggerState struct {
+	Processed map[string]bool `json:"processed"`
+}
+
+// loadTriggerState reads path, returning a fresh empty state if it does
+// not yet exist (the daemon's first run).
+func loadTriggerState(path string) (*triggerState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &triggerState{Processed: map[string]bool{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st triggerState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	if st.Processed == nil {
+		st.Processed = map[string]bool{}
+	}
+	return &st, nil
+}
+
+// save writes s to path as indented JSON, overwriting any prior contents.
+func (s *triggerState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (s *triggerState) isProcessed(key string) bool {
+	return s.Processed[key]
+}
+
+func (s *triggerState) markProcessed(key string) {
+	s.Processed[key] = true
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTriggerStateMissingFile(t *testing.T) {
+	st, err := loadTriggerState(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.isProcessed("anything") {
+		t.Fatal("a fresh state should report nothing as processed")
+	}
+}
+
+func TestTriggerStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	st, err := loadTriggerState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.markProcessed("file:///tmp/a.txt")
+	if err := st.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := loadTriggerState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.isProcessed("file:///tmp/a.txt") {
+		t.Fatal("expected the saved key to survive a reload")
+	}
+	if reloaded.isProcessed("file:///tmp/b.txt") {
+		t.Fatal("an unrelated key should not be marked processed")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/jobtemplate"
+)
+
+// triggerValues is the set of placeholders a trigger's job-order template
+// can reference. Only the fields for the event's source (local directory
+// vs S3) are populated; the rest are left as the zero value.
+type triggerValues struct {
+	Path string // local mode: the dropped file's full path
+	Dir  string // local mode: its containing directory
+	Name string // local mode: its base name
+
+	Bucket string // S3 mode: the bucket the object landed in
+	Key    string // S3 mode: the object's key
+}
+
+// submitTriggeredJobOrder renders tmpl against values into a job order the
+// same way cwlclient's "array" subcommand does, then posts it against
+// toolPath, returning the JobID the server assigned.
+func submitTriggeredJobOrder(serverAddr, toolPath string, tmpl *template.Template, values triggerValues, project, label string) (string, error) {
+	inputs, err := jobtemplate.Render(tmpl, values)
+	if err != nil {
+		return "", fmt.Errorf("rendering job order template: %w", err)
+	}
+
+	body, err := json.Marshal(api.JobOrderSubmission{Tool: toolPath, Inputs: inputs, Project: project, Label: label})
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Post(serverAddr+"/api/job-orders", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var result api.JobOrderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.JobID, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// pollLocalDirectory polls dir every interval, invoking handle once for
+// each file st has not already marked processed (in deterministic,
+// sorted-by-name order), saving st to statePath after every successful
+// handle so a crash mid-scan only risks re-handling the one file in
+// flight. It loops until stop is closed; a nil stop polls forever.
+//
+// This polls with os.ReadDir rather than watching for filesystem events,
+// since this module carries no fsnotify/inotify dependency — consistent
+// with the rest of this repo's minimal dependency list — and polling
+// keeps this package portable to the darwin build everything else here
+// targets.
+func pollLocalDirectory(dir string, interval time.Duration, st *triggerState, statePath string, handle func(path string) error, stop <-chan struct{}) error {
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			names = append(names, e.Name())
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			key := "file://" + path
+			if st.isProcessed(key) {
+				continue
+			}
+			if err := handle(path); err != nil {
+				return err
+			}
+			st.markProcessed(key)
+			if err := st.save(statePath); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
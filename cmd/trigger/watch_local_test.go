@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPollLocalDirectorySkipsAlreadyProcessed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	st, err := loadTriggerState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handled []string
+	stop := make(chan struct{})
+	close(stop)
+	if err := pollLocalDirectory(dir, time.Millisecond, st, statePath, func(path string) error {
+		handled = append(handled, filepath.Base(path))
+		return nil
+	}, stop); err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 2 {
+		t.Fatalf("expected both files handled once, got %v", handled)
+	}
+
+	// A fresh state reloaded from disk must not re-handle either file.
+	reloaded, err := loadTriggerState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handledAgain []string
+	stop2 := make(chan struct{})
+	close(stop2)
+	if err := pollLocalDirectory(dir, time.Millisecond, reloaded, statePath, func(path string) error {
+		handledAgain = append(handledAgain, filepath.Base(path))
+		return nil
+	}, stop2); err != nil {
+		t.Fatal(err)
+	}
+	if len(handledAgain) != 0 {
+		t.Fatalf("expected no files re-handled on second scan, got %v", handledAgain)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SQSAPI is the subset of the SQS client surface cmd/trigger's S3-backed
+// mode needs. It is declared here, rather than importing the AWS SDK's
+// concrete client, so tests can substitute an in-memory fake — the same
+// interface-seam pattern internal.S3API already uses in this repo.
+type SQSAPI interface {
+	ReceiveMessages(queueURL string, maxMessages int) ([]SQSMessage, error)
+	DeleteMessage(queueURL, receiptHandle string) error
+}
+
+// SQSMessage is one polled SQS message's body and receipt handle.
+type SQSMessage struct {
+	Body          string
+	ReceiptHandle string
+}
+
+// s3ObjectEvent is one new object an S3 event notification named.
+type s3ObjectEvent struct {
+	Bucket string
+	Key    string
+}
+
+// s3EventNotification is the subset of an S3-to-SQS event notification's
+// JSON body this package reads (one Records entry per new object).
+type s3EventNotification struct {
+	Records []struct {
+		S3 struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// parseS3Events extracts the (bucket, key) pairs named by an S3 event
+// notification's JSON body.
+func parseS3Events(body string) ([]s3ObjectEvent, error) {
+	var notification s3EventNotification
+	if err := json.Unmarshal([]byte(body), &notification); err != nil {
+		return nil, err
+	}
+	events := make([]s3ObjectEvent, 0, len(notification.Records))
+	for _, r := range notification.Records {
+		events = append(events, s3ObjectEvent{Bucket: r.S3.Bucket.Name, Key: r.S3.Object.Key})
+	}
+	return events, nil
+}
+
+// pollSQS polls queueURL once per interval, parsing each received message
+// as an S3 event notification and invoking handle once per named object
+// st has not already marked processed, deleting the message only once
+// every object it named has been handled. It loops until stop is closed;
+// a nil stop polls forever.
+func pollSQS(api SQSAPI, queueURL string, interval time.Duration, st *triggerState, statePath string, handle func(s3ObjectEvent) error, stop <-chan struct{}) error {
+	for {
+		messages, err := api.ReceiveMessages(queueURL, 10)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			events, err := parseS3Events(msg.Body)
+			if err != nil {
+				return fmt.Errorf("parsing SQS message as an S3 event notification: %w", err)
+			}
+			for _, ev := range events {
+				key := "s3://" + ev.Bucket + "/" + ev.Key
+				if st.isProcessed(key) {
+					continue
+				}
+				if err := handle(ev); err != nil {
+					return err
+				}
+				st.markProcessed(key)
+				if err := st.save(statePath); err != nil {
+					return err
+				}
+			}
+			if err := api.DeleteMessage(queueURL, msg.ReceiptHandle); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
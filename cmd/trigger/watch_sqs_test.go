@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeSQSAPI struct {
+	messages []SQSMessage
+	deleted  []string
+}
+
+func (f *fakeSQSAPI) ReceiveMessages(queueURL string, maxMessages int) ([]SQSMessage, error) {
+	msgs := f.messages
+	f.messages = nil
+	return msgs, nil
+}
+
+func (f *fakeSQSAPI) DeleteMessage(queueURL, receiptHandle string) error {
+	f.deleted = append(f.deleted, receiptHandle)
+	return nil
+}
+
+func TestParseS3Events(t *testing.T) {
+	body := `{"Records":[{"s3":{"bucket":{"name":"my-bucket"},"object":{"key":"inbox/sample1.fastq"}}}]}`
+	events, err := parseS3Events(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Bucket != "my-bucket" || events[0].Key != "inbox/sample1.fastq" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestPollSQSHandlesAndDeletesOnce(t *testing.T) {
+	body := `{"Records":[{"s3":{"bucket":{"name":"my-bucket"},"object":{"key":"inbox/sample1.fastq"}}}]}`
+	api := &fakeSQSAPI{messages: []SQSMessage{{Body: body, ReceiptHandle: "receipt-1"}}}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	st, err := loadTriggerState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handled []s3ObjectEvent
+	stop := make(chan struct{})
+	close(stop)
+	if err := pollSQS(api, "queue-url", time.Millisecond, st, statePath, func(ev s3ObjectEvent) error {
+		handled = append(handled, ev)
+		return nil
+	}, stop); err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 1 || handled[0].Key != "inbox/sample1.fastq" {
+		t.Fatalf("unexpected handled events: %+v", handled)
+	}
+	if len(api.deleted) != 1 || api.deleted[0] != "receipt-1" {
+		t.Fatalf("expected the message to be deleted once, got %v", api.deleted)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/sysinfo"
+)
+
+// reportWorkerStarted advertises this worker's OS/architecture and memory
+// to the server once at startup, so it can avoid scheduling jobs whose
+// image has no manifest for this worker (e.g. an amd64-only image on an
+// arm64 Graviton/Apple Silicon worker). Failure is logged, not fatal: a
+// server that doesn't support this yet shouldn't block the worker.
+func reportWorkerStarted() {
+	totalMemory, err := sysinfo.TotalMemoryBytes()
+	if err != nil {
+		logger.Warn("reading total host memory for worker-started report", "error", err)
+	}
+
+	body, err := json.Marshal(api.WorkerStartedRequest{
+		Name:        workerName,
+		Os:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		TotalMemory: totalMemory,
+		Queues:      workerQueues,
+	})
+	if err != nil {
+		logger.Warn("encoding worker-started report", "error", err)
+		return
+	}
+	resp, err := http.Post(serverURL+"/api/worker/started", "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("reporting worker started", "error", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// dockerPlatformArgs requests the worker's own platform explicitly, rather
+// than letting docker silently pull and emulate a mismatched-arch image
+// under QEMU, which for CWL tools is usually a sign of misconfiguration.
+func dockerPlatformArgs() []string {
+	return []string{"--platform", runtime.GOOS + "/" + runtime.GOARCH}
+}
+
+// checkImagePlatform fails early with a clear message when image's
+// manifest list exists and does not include the worker's architecture,
+// rather than letting the job run, pull under QEMU emulation, or fail with
+// an opaque docker error partway through staging. It is best-effort: when
+// the manifest can't be inspected (unsupported docker version, private
+// registry needing auth docker manifest inspect doesn't have, offline
+// daemon) it returns nil and leaves the decision to `docker run --platform`.
+func checkImagePlatform(image string) error {
+	out, err := exec.Command("docker", "manifest", "inspect", image).Output()
+	if err != nil {
+		return nil
+	}
+
+	var manifestList struct {
+		Manifests []struct {
+			Platform struct {
+				Architecture string `json:"architecture"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(out, &manifestList); err != nil || len(manifestList.Manifests) == 0 {
+		return nil
+	}
+
+	var available []string
+	for _, m := range manifestList.Manifests {
+		if m.Platform.Architecture == runtime.GOARCH {
+			return nil
+		}
+		available = append(available, m.Platform.Architecture)
+	}
+	return fmt.Errorf("image %q has no manifest for %s (available: %s)", image, runtime.GOARCH, strings.Join(available, ", "))
+}
+
+// resolveImageDigest pulls image and resolves it to the pinned repository
+// digest (e.g. "ubuntu@sha256:...") docker recorded for it, so the job
+// runs against the exact manifest pulled and JobFinishedRequest can record
+// what actually ran, rather than a mutable tag that might be repointed by
+// the time anyone checks. Returns an error if the pull or inspect fails;
+// the caller is expected to fall back to running by tag in that case.
+func resolveImageDigest(image string) (string, error) {
+	if err := exec.Command("docker", "pull", image).Run(); err != nil {
+		return "", fmt.Errorf("pulling %q to resolve its digest: %w", image, err)
+	}
+	out, err := exec.Command("docker", "inspect", "--format", "{{index .RepoDigests 0}}", image).Output()
+	if err != nil {
+		return "", fmt.Errorf("inspecting %q for its digest: %w", image, err)
+	}
+	digest := strings.TrimSpace(string(out))
+	if digest == "" {
+		return "", fmt.Errorf("no repo digest recorded for %q (locally built images have none)", image)
+	}
+	return digest, nil
+}
+
+// warnIfMutableTag logs when image is referenced by a tag that's commonly
+// repointed to different content over time ("latest", or no tag at all,
+// which docker treats the same way), since a job run against one of these
+// is inherently harder to reproduce even with ImageDigest recorded.
+func warnIfMutableTag(jobID, image string) {
+	if isMutableTagRef(image) {
+		logger.Warn("job uses a mutable \"latest\" image tag, results may not be reproducible", "jobId", jobID, "image", image)
+	}
+}
+
+// isMutableTagRef reports whether image names a tag commonly repointed to
+// different content over time: an explicit ":latest" tag, or no tag at
+// all, which docker resolves to "latest" the same way. A reference already
+// pinned to a digest ("image@sha256:...") is never mutable.
+func isMutableTagRef(image string) bool {
+	if strings.LastIndexByte(image, '@') >= 0 {
+		return false
+	}
+	tag := "latest"
+	if colon := strings.LastIndexByte(image, ':'); colon > strings.LastIndexByte(image, '/') {
+		tag = image[colon+1:]
+	}
+	return tag == "latest"
+}
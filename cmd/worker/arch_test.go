@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestIsMutableTagRef(t *testing.T) {
+	cases := []struct {
+		image string
+		want  bool
+	}{
+		{"ubuntu", true},
+		{"ubuntu:latest", true},
+		{"myregistry.example.com:5000/ubuntu", true},
+		{"ubuntu:22.04", false},
+		{"myregistry.example.com:5000/ubuntu:22.04", false},
+		{"ubuntu@sha256:abcd1234", false},
+		{"ubuntu:latest@sha256:abcd1234", false},
+	}
+	for _, c := range cases {
+		if got := isMutableTagRef(c.image); got != c.want {
+			t.Errorf("isMutableTagRef(%q) = %v, want %v", c.image, got, c.want)
+		}
+	}
+}
+
+func TestResolveImageDigestFailsForUnresolvableImage(t *testing.T) {
+	if _, err := resolveImageDigest("flowy-test/definitely-not-a-real-image:does-not-exist"); err == nil {
+		t.Fatal("expected an error resolving a nonexistent image, got nil")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// cacheKeyMaterial is the subset of an ExecutableJob that determines
+// whether two invocations are interchangeable for call-caching purposes:
+// everything that affects what Command actually does, plus whatever extra
+// material a CWL hint contributed. JobID, scheduling fields, and anything
+// else that varies between otherwise-identical invocations are
+// deliberately excluded.
+type cacheKeyMaterial struct {
+	Command       []string          `json:"command"`
+	Mapping       []api.MapperEnt   `json:"mapping"`
+	Env           map[string]string `json:"env"`
+	DockerImage   *string           `json:"dockerImage,omitempty"`
+	CacheKeyExtra string            `json:"cacheKeyExtra,omitempty"`
+}
+
+// computeCacheKey returns the content-addressed cache key for job, or ""
+// when job.Cacheable is explicitly false. encoding/json serializes map
+// keys in sorted order, so the same logical job always hashes the same
+// way regardless of field ordering on the wire.
+func computeCacheKey(job *api.ExecutableJob) string {
+	if job.Cacheable != nil && !*job.Cacheable {
+		return ""
+	}
+
+	material := cacheKeyMaterial{
+		Command:       job.Command,
+		Mapping:       job.Mapping,
+		Env:           job.Env,
+		DockerImage:   job.DockerImage,
+		CacheKeyExtra: job.CacheKeyExtra,
+	}
+	encoded, err := json.Marshal(material)
+	if err != nil {
+		logger.Warn("computing cache key", "jobId", job.JobID, "error", err)
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestComputeCacheKeyIsStableForIdenticalJobs(t *testing.T) {
+	a := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}, Env: map[string]string{"FOO": "bar"}}
+	b := &api.ExecutableJob{JobID: "job-b", Command: []string{"echo", "hi"}, Env: map[string]string{"FOO": "bar"}}
+
+	if computeCacheKey(a) != computeCacheKey(b) {
+		t.Fatalf("expected identical cache keys for jobs differing only in JobID, got %q and %q", computeCacheKey(a), computeCacheKey(b))
+	}
+}
+
+func TestComputeCacheKeyDiffersOnCacheKeyExtra(t *testing.T) {
+	a := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}}
+	b := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}, CacheKeyExtra: "schema-v2"}
+
+	if computeCacheKey(a) == computeCacheKey(b) {
+		t.Fatal("expected CacheKeyExtra to change the computed cache key")
+	}
+}
+
+func TestComputeCacheKeyDiffersOnCommandOrEnv(t *testing.T) {
+	base := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}}
+	differentCommand := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "bye"}}
+	differentEnv := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}, Env: map[string]string{"FOO": "bar"}}
+
+	key := computeCacheKey(base)
+	if computeCacheKey(differentCommand) == key {
+		t.Fatal("expected a different command to change the cache key")
+	}
+	if computeCacheKey(differentEnv) == key {
+		t.Fatal("expected a different env to change the cache key")
+	}
+}
+
+func TestComputeCacheKeyEmptyWhenNotCacheable(t *testing.T) {
+	notCacheable := false
+	job := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}, Cacheable: &notCacheable}
+
+	if got := computeCacheKey(job); got != "" {
+		t.Errorf("computeCacheKey() = %q, want empty for a non-cacheable job", got)
+	}
+}
+
+func TestComputeCacheKeyNilCacheableDefaultsToCacheable(t *testing.T) {
+	job := &api.ExecutableJob{JobID: "job-a", Command: []string{"echo", "hi"}}
+	if got := computeCacheKey(job); got == "" {
+		t.Error("expected a non-empty cache key when Cacheable is nil")
+	}
+}
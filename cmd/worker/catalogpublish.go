@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"text/template"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// outputCatalogURL is the endpoint publishToCatalog POSTs dataset
+// descriptors to after a job's outputs are collected, set via
+// FLOWY_OUTPUT_CATALOG_URL. Empty, the default, disables catalog
+// publication entirely, since most sites don't run one.
+var outputCatalogURL = os.Getenv("FLOWY_OUTPUT_CATALOG_URL")
+
+// outputCatalogProvenanceTemplate renders a datasetDescriptor's
+// ProvenanceRef from job metadata, set via
+// FLOWY_OUTPUT_CATALOG_PROVENANCE_TEMPLATE (Go text/template syntax
+// against a provenanceContext). The default reproduces "<tool>@<jobId>",
+// enough to trace a catalog entry back to the job that produced it
+// without requiring every site to configure one.
+var outputCatalogProvenanceTemplate = envOrDefault("FLOWY_OUTPUT_CATALOG_PROVENANCE_TEMPLATE", "{{.Tool}}@{{.JobID}}")
+
+// provenanceContext is what outputCatalogProvenanceTemplate executes
+// against.
+type provenanceContext struct {
+	JobID      string
+	Tool       string
+	Project    string
+	OutputName string
+}
+
+// datasetDescriptor is one output File or Directory's catalog entry, the
+// request body posted to outputCatalogURL for each one found among a
+// job's outputs.
+type datasetDescriptor struct {
+	Location      string `json:"location"`
+	Class         string `json:"class"`
+	Checksum      string `json:"checksum,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	Format        string `json:"format,omitempty"`
+	JobID         string `json:"jobId"`
+	Tool          string `json:"tool"`
+	Project       string `json:"project,omitempty"`
+	OutputName    string `json:"outputName"`
+	ProvenanceRef string `json:"provenanceRef,omitempty"`
+}
+
+// publishToCatalog posts a datasetDescriptor for every File or Directory
+// object found, at any depth, in job's outputs to outputCatalogURL - a
+// no-op when it isn't configured. A descriptor the catalog rejects, or a
+// catalog the worker can't reach, is logged and otherwise ignored: this
+// integration is a discoverability convenience, and a job that already
+// succeeded must never be retried or failed over it.
+func publishToCatalog(job *api.ExecutableJob, outputs map[string]interface{}) {
+	if outputCatalogURL == "" || len(outputs) == 0 {
+		return
+	}
+	for name, value := range outputs {
+		provenance := renderProvenance(job, name)
+		for _, desc := range collectDatasetDescriptors(value) {
+			desc.JobID = job.JobID
+			desc.Tool = job.Tool
+			desc.Project = job.Project
+			desc.OutputName = name
+			desc.ProvenanceRef = provenance
+			if err := postDatasetDescriptor(desc); err != nil {
+				logger.Warn("publishing output to data catalog", "jobId", job.JobID, "output", name, "error", err)
+			}
+		}
+	}
+}
+
+// collectDatasetDescriptors walks value - an output's collected CWL
+// File, Directory, array, or nested-object structure - and returns a
+// descriptor for every File or Directory object found.
+func collectDatasetDescriptors(value interface{}) []datasetDescriptor {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		class, _ := v["class"].(string)
+		if class != "File" && class != "Directory" {
+			var out []datasetDescriptor
+			for _, nested := range v {
+				out = append(out, collectDatasetDescriptors(nested)...)
+			}
+			return out
+		}
+		desc := datasetDescriptor{Class: class}
+		desc.Location, _ = v["location"].(string)
+		desc.Checksum, _ = v["checksum"].(string)
+		desc.Format, _ = v["format"].(string)
+		switch sz := v["size"].(type) {
+		case int64:
+			desc.Size = sz
+		case float64:
+			desc.Size = int64(sz)
+		}
+		out := []datasetDescriptor{desc}
+		if listing, ok := v["listing"].([]interface{}); ok {
+			for _, child := range listing {
+				out = append(out, collectDatasetDescriptors(child)...)
+			}
+		}
+		return out
+	case []interface{}:
+		var out []datasetDescriptor
+		for _, item := range v {
+			out = append(out, collectDatasetDescriptors(item)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// renderProvenance executes outputCatalogProvenanceTemplate against
+// job's metadata, returning an empty string if the template is
+// malformed rather than failing catalog publication over it.
+func renderProvenance(job *api.ExecutableJob, outputName string) string {
+	tmpl, err := template.New("provenance").Parse(outputCatalogProvenanceTemplate)
+	if err != nil {
+		logger.Warn("invalid FLOWY_OUTPUT_CATALOG_PROVENANCE_TEMPLATE", "error", err)
+		return ""
+	}
+	var buf bytes.Buffer
+	ctx := provenanceContext{JobID: job.JobID, Tool: job.Tool, Project: job.Project, OutputName: outputName}
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		logger.Warn("rendering output catalog provenance ref", "error", err)
+		return ""
+	}
+	return buf.String()
+}
+
+// postDatasetDescriptor sends desc to outputCatalogURL as JSON.
+func postDatasetDescriptor(desc datasetDescriptor) error {
+	body, err := json.Marshal(desc)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(outputCatalogURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("catalog returned %s", resp.Status)
+	}
+	return nil
+}
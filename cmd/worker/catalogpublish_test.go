@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestPublishToCatalogPostsEachFileOutput(t *testing.T) {
+	var mu sync.Mutex
+	var received []datasetDescriptor
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var desc datasetDescriptor
+		if err := json.NewDecoder(r.Body).Decode(&desc); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		received = append(received, desc)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	origURL, origTmpl := outputCatalogURL, outputCatalogProvenanceTemplate
+	defer func() { outputCatalogURL, outputCatalogProvenanceTemplate = origURL, origTmpl }()
+	outputCatalogURL = server.URL
+	outputCatalogProvenanceTemplate = "{{.Tool}}@{{.JobID}}"
+
+	job := &api.ExecutableJob{JobID: "job-1", Tool: "align.cwl", Project: "proj"}
+	outputs := map[string]interface{}{
+		"bam": map[string]interface{}{
+			"class":    "File",
+			"location": "s3://bucket/out.bam",
+			"checksum": "sha1$abc",
+			"size":     float64(1024),
+			"format":   "http://edamontology.org/format_2572",
+		},
+	}
+
+	publishToCatalog(job, outputs)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("got %d descriptors, want 1", len(received))
+	}
+	desc := received[0]
+	if desc.Location != "s3://bucket/out.bam" || desc.Checksum != "sha1$abc" || desc.Size != 1024 {
+		t.Fatalf("unexpected descriptor: %+v", desc)
+	}
+	if desc.OutputName != "bam" || desc.JobID != "job-1" || desc.Project != "proj" {
+		t.Fatalf("unexpected descriptor metadata: %+v", desc)
+	}
+	if desc.ProvenanceRef != "align.cwl@job-1" {
+		t.Fatalf("ProvenanceRef = %q, want %q", desc.ProvenanceRef, "align.cwl@job-1")
+	}
+}
+
+func TestPublishToCatalogDisabledWithoutURL(t *testing.T) {
+	origURL := outputCatalogURL
+	defer func() { outputCatalogURL = origURL }()
+	outputCatalogURL = ""
+
+	// Should not panic or attempt any network call.
+	publishToCatalog(&api.ExecutableJob{JobID: "job-1"}, map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "location": "x"},
+	})
+}
+
+func TestCollectDatasetDescriptorsWalksDirectoryListing(t *testing.T) {
+	value := map[string]interface{}{
+		"class":    "Directory",
+		"location": "s3://bucket/outdir",
+		"listing": []interface{}{
+			map[string]interface{}{"class": "File", "location": "s3://bucket/outdir/a.txt"},
+			map[string]interface{}{"class": "File", "location": "s3://bucket/outdir/b.txt"},
+		},
+	}
+	descs := collectDatasetDescriptors(value)
+	if len(descs) != 3 {
+		t.Fatalf("got %d descriptors, want 3 (directory + 2 files)", len(descs))
+	}
+}
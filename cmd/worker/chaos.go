@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+// chaosConfig is the worker-wide fault injection policy, configured once
+// at startup like hardening and network policy. It is the zero value
+// (every field off) unless FLOWY_CHAOS_SEED is set, so a production
+// worker that never sets these variables behaves exactly as if this file
+// didn't exist.
+var chaosConfig, chaosEnabled = loadChaosConfigFromEnv()
+
+func loadChaosConfigFromEnv() (internal.ChaosConfig, bool) {
+	seedStr := os.Getenv("FLOWY_CHAOS_SEED")
+	if seedStr == "" {
+		return internal.ChaosConfig{}, false
+	}
+	seed, err := strconv.ParseInt(seedStr, 10, 64)
+	if err != nil {
+		logger.Warn("invalid FLOWY_CHAOS_SEED, fault injection disabled", "value", seedStr, "error", err)
+		return internal.ChaosConfig{}, false
+	}
+	return internal.ChaosConfig{
+		Seed:                seed,
+		MaxDelay:            time.Duration(envFloat("FLOWY_CHAOS_MAX_DELAY_MS")) * time.Millisecond,
+		TruncateProbability: envFloat("FLOWY_CHAOS_TRUNCATE_PROBABILITY"),
+		FailProbability:     envFloat("FLOWY_CHAOS_TRANSFER_FAIL_PROBABILITY"),
+	}, true
+}
+
+func envFloat(name string) float64 {
+	v, _ := strconv.ParseFloat(os.Getenv(name), 64)
+	return v
+}
+
+// withChaosIfEnabled wraps fm with chaosConfig's transfer fault
+// injection, when FLOWY_CHAOS_SEED is set. Callers apply it at the same
+// point they'd apply WithAudit or WithDedup, so integration tests can
+// turn on random delays, truncated downloads, and injected transfer
+// errors without the code under test knowing it's being tested.
+func withChaosIfEnabled(fm internal.FileManager) internal.FileManager {
+	if !chaosEnabled {
+		return fm
+	}
+	return internal.WithChaos(fm, chaosConfig)
+}
+
+// execChaosFailProbability is the chance, per job, that executeJob
+// simulates the job's command exiting non-zero instead of actually
+// running it, for verifying the worker's failure-reporting and requeue
+// path without needing a genuinely flaky tool.
+var execChaosFailProbability = envFloat("FLOWY_CHAOS_EXEC_FAIL_PROBABILITY")
+
+// execChaosRand is seeded from chaosConfig.Seed so an exec failure
+// injection run is reproducible the same way transfer fault injection is;
+// it's only ever consulted when chaosEnabled, so an unseeded rand.Rand
+// (zero Seed, chaos disabled) is never used to make a real decision.
+var execChaosRand = rand.New(rand.NewSource(chaosConfig.Seed))
+
+// maybeInjectExecFailure simulates a job's command exiting non-zero, when
+// chaos fault injection is enabled and this call's roll is under
+// execChaosFailProbability.
+func maybeInjectExecFailure(jobID string) error {
+	if !chaosEnabled || execChaosFailProbability <= 0 {
+		return nil
+	}
+	if execChaosRand.Float64() < execChaosFailProbability {
+		return fmt.Errorf("chaos: injected non-zero exit for job %s", jobID)
+	}
+	return nil
+}
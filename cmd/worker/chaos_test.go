@@ -0,0 +1,25 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMaybeInjectExecFailureDisabledByDefault(t *testing.T) {
+	if err := maybeInjectExecFailure("job-1"); err != nil {
+		t.Fatalf("expected no injected failure when chaos is disabled, got %v", err)
+	}
+}
+
+func TestMaybeInjectExecFailureAlwaysFiresAtProbabilityOne(t *testing.T) {
+	origEnabled, origProb, origRand := chaosEnabled, execChaosFailProbability, execChaosRand
+	defer func() { chaosEnabled, execChaosFailProbability, execChaosRand = origEnabled, origProb, origRand }()
+
+	chaosEnabled = true
+	execChaosFailProbability = 1
+	execChaosRand = rand.New(rand.NewSource(1))
+
+	if err := maybeInjectExecFailure("job-1"); err == nil {
+		t.Fatal("expected an injected exec failure")
+	}
+}
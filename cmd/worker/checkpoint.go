@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// checkpointUploadInterval is how often a running job's checkpoint
+// directory is uploaded while its command is still executing, via
+// FLOWY_CHECKPOINT_UPLOAD_INTERVAL_SECONDS.
+var checkpointUploadInterval = time.Duration(envIntOr("FLOWY_CHECKPOINT_UPLOAD_INTERVAL_SECONDS", 60)) * time.Second
+
+// checkpointKey returns the remote location job's checkpoint directory is
+// uploaded to and restored from.
+func checkpointKey(job *api.ExecutableJob) string {
+	return outputBaseURL + outputPrefix(job) + "/checkpoint/"
+}
+
+// restoreCheckpoint downloads job's previously uploaded checkpoint
+// directory (see uploadCheckpoint) into jobDir before its command runs,
+// when job.CheckpointDir is set and the output backend supports restoring
+// a whole directory. A missing checkpoint (the job's first attempt) or an
+// unsupported backend is logged and otherwise not treated as an error: a
+// tool with nothing to resume from just starts fresh.
+func restoreCheckpoint(jobDir string, job *api.ExecutableJob) {
+	if job.CheckpointDir == "" {
+		return
+	}
+	restorer, ok := outputFileManagerFor(job.JobID).(internal.DirectoryRestorer)
+	if !ok {
+		logger.Warn("checkpoint directory configured but output backend cannot restore directories", "jobId", job.JobID)
+		return
+	}
+	local := filepath.Join(jobDir, job.CheckpointDir)
+	if err := restorer.RestoreDirectory(checkpointKey(job), local); err != nil {
+		logger.Debug("no checkpoint to restore", "jobId", job.JobID, "error", err)
+	}
+}
+
+// startCheckpointUploader periodically uploads job's checkpoint directory
+// while its command runs, so a job killed mid-run (a time limit,
+// preemption, or worker crash) loses at most checkpointUploadInterval of
+// its own progress rather than everything since the checkpoint directory
+// was last uploaded. It returns once stop is closed, uploading one final
+// time first so the most recent progress before the command exited isn't
+// left for a retry to redo. Callers run it as a goroutine spanning the
+// whole retry loop, since a checkpoint is meant to survive a retry, not
+// just a single attempt.
+func startCheckpointUploader(jobDir string, job *api.ExecutableJob, stop <-chan struct{}) {
+	if job.CheckpointDir == "" {
+		return
+	}
+	ticker := time.NewTicker(checkpointUploadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			uploadCheckpoint(jobDir, job)
+			return
+		case <-ticker.C:
+			uploadCheckpoint(jobDir, job)
+		}
+	}
+}
+
+// uploadCheckpoint uploads job's checkpoint directory as it currently
+// stands. Best-effort: a directory the tool hasn't written yet, or an
+// output backend that can't replicate a whole directory, is logged and
+// otherwise ignored, since a checkpoint is an optimization a retry can do
+// without.
+func uploadCheckpoint(jobDir string, job *api.ExecutableJob) {
+	local := filepath.Join(jobDir, job.CheckpointDir)
+	if _, err := os.Stat(local); err != nil {
+		return
+	}
+	replicator, ok := outputFileManagerFor(job.JobID).(internal.DirectoryReplicator)
+	if !ok {
+		logger.Warn("checkpoint directory configured but output backend cannot replicate directories", "jobId", job.JobID)
+		return
+	}
+	if err := replicator.ReplicateDirectory(local, checkpointKey(job)); err != nil {
+		logger.Warn("uploading checkpoint", "jobId", job.JobID, "error", err)
+	}
+}
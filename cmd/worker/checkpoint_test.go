@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// fakeCheckpointFileManager implements internal.DirectoryReplicator and
+// internal.DirectoryRestorer against an in-memory map keyed by the
+// destination/source path, standing in for a backend like iRODS that can
+// replicate and restore a whole directory in one call.
+type fakeCheckpointFileManager struct {
+	internal.FileManager
+	stored map[string][]byte
+}
+
+func (f *fakeCheckpointFileManager) Stat(path string) (int64, bool, error) {
+	data, ok := f.stored[path]
+	return int64(len(data)), ok, nil
+}
+
+func (f *fakeCheckpointFileManager) ReplicateDirectory(localDir, dst string) error {
+	return filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f.stored[dst+rel] = data
+		return nil
+	})
+}
+
+func (f *fakeCheckpointFileManager) RestoreDirectory(src, localDir string) error {
+	found := false
+	for key, data := range f.stored {
+		rel := key[len(src):]
+		if len(key) <= len(src) || key[:len(src)] != src {
+			continue
+		}
+		found = true
+		target := filepath.Join(localDir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+func withFakeOutputFileManager(t *testing.T, fm internal.FileManager) {
+	t.Helper()
+	orig := baseOutputFileManager
+	baseOutputFileManager = fm
+	t.Cleanup(func() { baseOutputFileManager = orig })
+}
+
+func TestUploadThenRestoreCheckpointRoundTrips(t *testing.T) {
+	fake := &fakeCheckpointFileManager{stored: map[string][]byte{}}
+	withFakeOutputFileManager(t, fake)
+
+	job := &api.ExecutableJob{JobID: "job-1", CheckpointDir: "checkpoint"}
+	jobDir := t.TempDir()
+	checkpointDir := filepath.Join(jobDir, job.CheckpointDir)
+	if err := os.MkdirAll(checkpointDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(checkpointDir, "state.bin"), []byte("progress"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploadCheckpoint(jobDir, job)
+
+	restoreDir := t.TempDir()
+	restoreCheckpoint(restoreDir, job)
+
+	got, err := os.ReadFile(filepath.Join(restoreDir, job.CheckpointDir, "state.bin"))
+	if err != nil {
+		t.Fatalf("expected restored checkpoint file, got error: %v", err)
+	}
+	if string(got) != "progress" {
+		t.Fatalf("expected restored contents %q, got %q", "progress", got)
+	}
+}
+
+func TestRestoreCheckpointNoopWithoutCheckpointDir(t *testing.T) {
+	fake := &fakeCheckpointFileManager{stored: map[string][]byte{}}
+	withFakeOutputFileManager(t, fake)
+
+	job := &api.ExecutableJob{JobID: "job-1"}
+	restoreCheckpoint(t.TempDir(), job)
+	if len(fake.stored) != 0 {
+		t.Fatal("expected no restore attempt without CheckpointDir set")
+	}
+}
+
+func TestUploadCheckpointSkipsMissingDirectory(t *testing.T) {
+	fake := &fakeCheckpointFileManager{stored: map[string][]byte{}}
+	withFakeOutputFileManager(t, fake)
+
+	job := &api.ExecutableJob{JobID: "job-1", CheckpointDir: "checkpoint"}
+	uploadCheckpoint(t.TempDir(), job)
+	if len(fake.stored) != 0 {
+		t.Fatal("expected no upload attempt when the checkpoint directory doesn't exist yet")
+	}
+}
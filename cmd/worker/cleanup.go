@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// CleanupPolicy controls when a job's on-disk artifacts are removed.
+type CleanupPolicy string
+
+const (
+	CleanupAlways    CleanupPolicy = "always"
+	CleanupOnSuccess CleanupPolicy = "on-success"
+	CleanupNever     CleanupPolicy = "never"
+)
+
+func parseCleanupPolicy(v string, def CleanupPolicy) (CleanupPolicy, error) {
+	if v == "" {
+		return def, nil
+	}
+	switch CleanupPolicy(v) {
+	case CleanupAlways, CleanupOnSuccess, CleanupNever:
+		return CleanupPolicy(v), nil
+	default:
+		return "", fmt.Errorf("invalid cleanup policy %q, want %q, %q or %q", v, CleanupAlways, CleanupOnSuccess, CleanupNever)
+	}
+}
+
+// shouldClean reports whether p permits removing an artifact given whether
+// the job succeeded.
+func (p CleanupPolicy) shouldClean(success bool) bool {
+	switch p {
+	case CleanupAlways:
+		return true
+	case CleanupOnSuccess:
+		return success
+	default:
+		return false
+	}
+}
+
+// workdirCleanup and tmpdirCleanup are the worker-wide defaults, set from
+// FLOWY_WORKDIR_CLEANUP/FLOWY_TMPDIR_CLEANUP or the --leave-outputs/
+// --leave-tmpdir flags in main.go. Staged inputs live under jobDir itself,
+// so they fall under workdirCleanup. The optional download cache
+// (downloadCacheDir, FLOWY_DOWNLOAD_CACHE_DIR) lies outside jobDir and is
+// not removed by either policy; its entries are only evicted under disk
+// pressure (see diskpressure.go).
+var (
+	workdirCleanup = CleanupOnSuccess
+	tmpdirCleanup  = CleanupAlways
+)
+
+// configureCleanupPolicies sets the worker-wide cleanup defaults from
+// FLOWY_WORKDIR_CLEANUP/FLOWY_TMPDIR_CLEANUP, then applies --leave-outputs/
+// --leave-tmpdir on top as a blunter override for debugging a whole run.
+func configureCleanupPolicies(leaveOutputs, leaveTmpdir bool) error {
+	var err error
+	if workdirCleanup, err = parseCleanupPolicy(os.Getenv("FLOWY_WORKDIR_CLEANUP"), CleanupOnSuccess); err != nil {
+		return err
+	}
+	if tmpdirCleanup, err = parseCleanupPolicy(os.Getenv("FLOWY_TMPDIR_CLEANUP"), CleanupAlways); err != nil {
+		return err
+	}
+	if leaveOutputs {
+		workdirCleanup = CleanupNever
+	}
+	if leaveTmpdir {
+		tmpdirCleanup = CleanupNever
+	}
+	return nil
+}
+
+// effectiveCleanupPolicy resolves a job's override (if any) against the
+// worker-wide default, for debugging a single job without changing global
+// behavior.
+func effectiveCleanupPolicy(override string, def CleanupPolicy) CleanupPolicy {
+	policy, err := parseCleanupPolicy(override, def)
+	if err != nil {
+		logger.Warn("ignoring invalid per-job cleanup policy override", "value", override, "error", err)
+		return def
+	}
+	return policy
+}
+
+// cleanupJobDir removes tmpDir and jobDir according to the worker's and
+// job's cleanup policies, tmpDir first since it nests under jobDir.
+func cleanupJobDir(jobDir, tmpDir string, job *api.ExecutableJob, success bool) {
+	tmpPolicy := effectiveCleanupPolicy(job.TmpdirCleanup, tmpdirCleanup)
+	if tmpPolicy.shouldClean(success) {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logger.Warn("removing job tmpdir", "jobId", job.JobID, "path", tmpDir, "error", err)
+		}
+	}
+
+	workPolicy := effectiveCleanupPolicy(job.WorkdirCleanup, workdirCleanup)
+	if workPolicy.shouldClean(success) {
+		unmountOverlayStagedDirs(jobDir, job.Mapping)
+		unmountS3FUSEMounts(jobDir)
+		if err := os.RemoveAll(jobDir); err != nil {
+			logger.Warn("removing job workdir", "jobId", job.JobID, "path", jobDir, "error", err)
+		}
+	}
+}
+
+// unmountOverlayStagedDirs tears down every overlay mount stageEntry may
+// have made for mapping's writable Directory entries, so RemoveAll doesn't
+// fail trying to remove a still-mounted directory (or, worse, recurse into
+// and delete the shared read-only lower layer through the mount).
+func unmountOverlayStagedDirs(jobDir string, mapping []api.MapperEnt) {
+	for _, ent := range mapping {
+		if ent.Staged && ent.Type == "Directory" && ent.Writable {
+			unmountOverlayIfMounted(filepath.Join(jobDir, ent.Target))
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "github.com/bioflowy/flowy/internal/api"
+
+// costPerCPUHourUSD and costPerGBTransferUSD are this worker's pricing
+// model, set from FLOWY_COST_PER_CPU_HOUR_USD and
+// FLOWY_COST_PER_GB_TRANSFER_USD so a site can attach an estimated dollar
+// cost to every job without the worker needing to know anything about
+// actual cloud billing. Both default to 0 (envFloat, chaos.go), in which
+// case estimateJobCost always returns 0 and a site that hasn't priced
+// itself sees no change in behavior.
+var (
+	costPerCPUHourUSD    = envFloat("FLOWY_COST_PER_CPU_HOUR_USD")
+	costPerGBTransferUSD = envFloat("FLOWY_COST_PER_GB_TRANSFER_USD")
+)
+
+// bytesPerGB is the divisor estimateJobCost uses to turn BytesRead/
+// BytesWritten into GB for costPerGBTransferUSD; billing APIs invoices are
+// priced in decimal GB (10^9 bytes), not GiB, so this matches that rather
+// than 1<<30.
+const bytesPerGB = 1e9
+
+// estimateJobCost combines usage's CPU time and transferred bytes with the
+// worker's configured pricing model into a rough dollar estimate, good
+// enough for relative cost attribution and trend-spotting rather than an
+// exact invoice reconciliation (it knows nothing of spot pricing,
+// reserved-instance discounts, or storage costs accrued after the job
+// finished).
+func estimateJobCost(usage *api.ResourceUsage) float64 {
+	if usage == nil {
+		return 0
+	}
+	cpuHours := (usage.UserCPUSeconds + usage.SysCPUSeconds) / 3600
+	transferGB := float64(usage.BytesRead+usage.BytesWritten) / bytesPerGB
+	return cpuHours*costPerCPUHourUSD + transferGB*costPerGBTransferUSD
+}
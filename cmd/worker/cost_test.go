@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestEstimateJobCostZeroWithoutPricingModel(t *testing.T) {
+	usage := &api.ResourceUsage{UserCPUSeconds: 3600, BytesRead: bytesPerGB}
+	if got := estimateJobCost(usage); got != 0 {
+		t.Fatalf("estimateJobCost = %v, want 0 with no pricing model configured", got)
+	}
+}
+
+func TestEstimateJobCostCombinesCPUAndTransfer(t *testing.T) {
+	origCPU, origTransfer := costPerCPUHourUSD, costPerGBTransferUSD
+	defer func() { costPerCPUHourUSD, costPerGBTransferUSD = origCPU, origTransfer }()
+
+	costPerCPUHourUSD = 0.10
+	costPerGBTransferUSD = 0.02
+
+	usage := &api.ResourceUsage{UserCPUSeconds: 1800, SysCPUSeconds: 1800, BytesRead: bytesPerGB, BytesWritten: bytesPerGB}
+	got := estimateJobCost(usage)
+	want := 0.10 + 0.04 // 1 CPU-hour at 0.10/hr, 2 GB transferred at 0.02/GB
+	if got != want {
+		t.Fatalf("estimateJobCost = %v, want %v", got, want)
+	}
+}
+
+func TestEstimateJobCostNilUsage(t *testing.T) {
+	if got := estimateJobCost(nil); got != 0 {
+		t.Fatalf("estimateJobCost(nil) = %v, want 0", got)
+	}
+}
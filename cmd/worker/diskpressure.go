@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/sysinfo"
+)
+
+// diskPressureThresholdBytes is the free-space floor on workdir's
+// filesystem below which the worker pauses accepting new jobs and starts
+// evicting least-recently-used download cache entries. Defaults to 2 GiB,
+// the same order of magnitude as minScratchBytes's one-time doctor check,
+// but enforced continuously rather than only at startup.
+var diskPressureThresholdBytes = diskPressureThresholdFromEnv()
+
+const defaultDiskPressureThresholdBytes = 2 << 30 // 2 GiB
+
+func diskPressureThresholdFromEnv() int64 {
+	v := envOr("FLOWY_DISK_PRESSURE_THRESHOLD_BYTES", "")
+	if v == "" {
+		return defaultDiskPressureThresholdBytes
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		logger.Warn("ignoring invalid FLOWY_DISK_PRESSURE_THRESHOLD_BYTES, using default", "value", v)
+		return defaultDiskPressureThresholdBytes
+	}
+	return n
+}
+
+// diskWatchInterval is how often the disk watcher re-checks free space.
+const diskWatchInterval = 30 * time.Second
+
+// underDiskPressure reports whether the most recent disk watch found
+// workdir's free space below diskPressureThresholdBytes. run's poll loop
+// checks this before fetching new work, and reportHeartbeat includes it
+// in every heartbeat so the server can avoid assigning this worker jobs
+// until it clears.
+var underDiskPressure atomic.Bool
+
+// diskPressureActive reports whether the worker is currently pausing job
+// intake for disk pressure.
+func diskPressureActive() bool {
+	return underDiskPressure.Load()
+}
+
+// startDiskWatcher polls workdir's free space every diskWatchInterval,
+// setting underDiskPressure and evicting least-recently-used entries from
+// the download cache (if one is configured) when free space drops below
+// diskPressureThresholdBytes. It never returns; callers start it as a
+// goroutine.
+func startDiskWatcher(workdir string) {
+	for {
+		checkDiskPressure(workdir)
+		time.Sleep(diskWatchInterval)
+	}
+}
+
+func checkDiskPressure(workdir string) {
+	free, err := sysinfo.FreeDiskBytes(workdir)
+	if err != nil {
+		logger.Warn("checking free disk space for disk pressure", "workdir", workdir, "error", err)
+		return
+	}
+
+	if free >= diskPressureThresholdBytes {
+		if underDiskPressure.CompareAndSwap(true, false) {
+			logger.Info("disk pressure cleared, resuming job intake", "workdir", workdir, "freeBytes", free)
+		}
+		return
+	}
+
+	if underDiskPressure.CompareAndSwap(false, true) {
+		logger.Warn("disk pressure detected, pausing job intake", "workdir", workdir, "freeBytes", free, "thresholdBytes", diskPressureThresholdBytes)
+	}
+
+	if downloadCacheDir == "" {
+		return
+	}
+	freed, err := internal.EvictLRUUntil(downloadCacheDir, diskPressureThresholdBytes, func(string) (int64, error) {
+		return sysinfo.FreeDiskBytes(workdir)
+	})
+	if err != nil {
+		logger.Error("evicting download cache entries under disk pressure", "cacheDir", downloadCacheDir, "error", err)
+		return
+	}
+	if freed > 0 {
+		logger.Info("evicted least-recently-used download cache entries under disk pressure", "cacheDir", downloadCacheDir, "freedBytes", freed)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskPressureThresholdFromEnvDefault(t *testing.T) {
+	t.Setenv("FLOWY_DISK_PRESSURE_THRESHOLD_BYTES", "")
+	if got := diskPressureThresholdFromEnv(); got != defaultDiskPressureThresholdBytes {
+		t.Fatalf("diskPressureThresholdFromEnv() = %d, want default %d", got, defaultDiskPressureThresholdBytes)
+	}
+}
+
+func TestDiskPressureThresholdFromEnvOverride(t *testing.T) {
+	t.Setenv("FLOWY_DISK_PRESSURE_THRESHOLD_BYTES", "1024")
+	if got := diskPressureThresholdFromEnv(); got != 1024 {
+		t.Fatalf("diskPressureThresholdFromEnv() = %d, want 1024", got)
+	}
+}
+
+func TestDiskPressureThresholdFromEnvInvalid(t *testing.T) {
+	t.Setenv("FLOWY_DISK_PRESSURE_THRESHOLD_BYTES", "not-a-number")
+	if got := diskPressureThresholdFromEnv(); got != defaultDiskPressureThresholdBytes {
+		t.Fatalf("diskPressureThresholdFromEnv() = %d, want default %d on invalid input", got, defaultDiskPressureThresholdBytes)
+	}
+}
+
+func TestCheckDiskPressureSetsAndClearsFlag(t *testing.T) {
+	workdir := t.TempDir()
+
+	origThreshold := diskPressureThresholdBytes
+	origCacheDir := downloadCacheDir
+	defer func() {
+		diskPressureThresholdBytes = origThreshold
+		downloadCacheDir = origCacheDir
+		underDiskPressure.Store(false)
+	}()
+
+	cacheDir := filepath.Join(workdir, "cache")
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, "entry"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	downloadCacheDir = cacheDir
+
+	// An unreasonably high threshold no real filesystem clears triggers
+	// pressure deterministically without needing to actually fill a disk.
+	diskPressureThresholdBytes = 1 << 62
+	checkDiskPressure(workdir)
+	if !diskPressureActive() {
+		t.Fatal("expected disk pressure to be detected")
+	}
+
+	diskPressureThresholdBytes = 0
+	checkDiskPressure(workdir)
+	if diskPressureActive() {
+		t.Fatal("expected disk pressure to clear once the threshold is trivially satisfied")
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/sysinfo"
+)
+
+// doctorStatus classifies a single doctor check's outcome.
+type doctorStatus string
+
+const (
+	doctorOK   doctorStatus = "OK"
+	doctorWarn doctorStatus = "WARN"
+	doctorFail doctorStatus = "FAIL"
+)
+
+// doctorResult is one check's verdict plus enough detail for an operator
+// to act on it without reading this file.
+type doctorResult struct {
+	status doctorStatus
+	detail string
+}
+
+// minScratchBytes is the free workdir space below which a job's staged
+// inputs and intermediate outputs are likely to hit ENOSPC mid-run.
+const minScratchBytes = 1 << 30 // 1 GiB
+
+// minOpenFiles is the RLIMIT_NOFILE soft limit below which a job with
+// many secondaryFiles or a wide scatter can exhaust file descriptors.
+const minOpenFiles = 1024
+
+// maxClockSkew is the largest difference between this host's clock and
+// the server's Date header that doctor tolerates before warning; beyond
+// it, signed payload timestamps and log correlation both become
+// unreliable.
+const maxClockSkew = 5 * time.Second
+
+// runDoctor implements `flowy-worker doctor`: it runs a battery of
+// environment checks an operator should clear before trusting a host to
+// take jobs, printing one line of actionable diagnostics per check. It
+// returns an error (and a non-zero exit from main) if any check failed.
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	workdir := fs.String("workdir", "/var/lib/flowy/work", "root directory for staged job workdirs")
+	serverAddr := fs.String("server", os.Getenv("FLOWY_SERVER_URL"), "base URL of the flowy server")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	addr := *serverAddr
+	if addr == "" {
+		addr = "http://localhost:8080"
+	}
+
+	checks := []struct {
+		name string
+		run  func() doctorResult
+	}{
+		{"container runtime", checkContainerRuntime},
+		{"shared file system", checkSharedFileSystem},
+		{"pull-through cache", checkPullThroughCache},
+		{"mkfifo support", func() doctorResult { return checkMkfifo(*workdir) }},
+		{"scratch space", func() doctorResult { return checkScratchSpace(*workdir) }},
+		{"ulimits", checkUlimits},
+		{"clock skew", func() doctorResult { return checkClockSkew(addr) }},
+	}
+
+	failed := false
+	for _, c := range checks {
+		result := c.run()
+		fmt.Printf("[%s] %s: %s\n", result.status, c.name, result.detail)
+		if result.status == doctorFail {
+			failed = true
+		}
+	}
+	if failed {
+		return fmt.Errorf("doctor found problems that should be fixed before this worker takes jobs")
+	}
+	return nil
+}
+
+// checkContainerRuntime looks for docker or podman on PATH. Neither is
+// fatal on its own, since the worker can also execute a tool directly on
+// the host, but a job whose CommandLineTool has no DockerRequirement
+// override will fail at execution time without one.
+func checkContainerRuntime() doctorResult {
+	var found []string
+	for _, name := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(name); err == nil {
+			found = append(found, name)
+		}
+	}
+	if len(found) == 0 {
+		return doctorResult{doctorWarn, "neither docker nor podman found on PATH; jobs requiring a container will fail unless run without DockerRequirement"}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("found: %v", found)}
+}
+
+// SharedFileSystemConfig describes the object storage bucket a worker
+// publishes outputs to. It mirrors the environment variables this
+// package's output FileManager wiring is documented to honor, so doctor
+// checks exactly what a real job run would use.
+type SharedFileSystemConfig struct {
+	Bucket string
+	Region string
+	// PullThroughCache is the registry host every worker in the fleet
+	// should pull container images through, distributed alongside the
+	// bucket configuration since both describe shared infrastructure the
+	// cluster's workers all rely on rather than per-worker settings.
+	PullThroughCache string
+}
+
+func sharedFileSystemConfigFromEnv() SharedFileSystemConfig {
+	return SharedFileSystemConfig{
+		Bucket:           os.Getenv("FLOWY_S3_BUCKET"),
+		Region:           os.Getenv("FLOWY_S3_REGION"),
+		PullThroughCache: os.Getenv("FLOWY_PULL_THROUGH_CACHE"),
+	}
+}
+
+// checkSharedFileSystem validates that object storage is configured
+// consistently when FLOWY_S3_BUCKET is set. It cannot probe the bucket
+// itself: no S3 client is wired into this binary, only the FileManager
+// interface it would be injected through, so the best this check can do
+// is confirm credentials are present for whatever wires one in later.
+func checkSharedFileSystem() doctorResult {
+	cfg := sharedFileSystemConfigFromEnv()
+	if cfg.Bucket == "" {
+		return doctorResult{doctorOK, "no FLOWY_S3_BUCKET configured; outputs use the local filesystem"}
+	}
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" && os.Getenv("AWS_PROFILE") == "" {
+		return doctorResult{doctorWarn, fmt.Sprintf("FLOWY_S3_BUCKET=%s set but no AWS_ACCESS_KEY_ID or AWS_PROFILE found in the environment", cfg.Bucket)}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("bucket %s configured with credentials present; actual reachability is not probed by this check", cfg.Bucket)}
+}
+
+// checkPullThroughCache verifies the configured pull-through cache
+// registry answers the standard Docker Registry HTTP API v2 ping
+// endpoint, so a worker with a stale or unreachable cache host is caught
+// here rather than failing every job's image pull one at a time.
+func checkPullThroughCache() doctorResult {
+	cfg := sharedFileSystemConfigFromEnv()
+	if cfg.PullThroughCache == "" {
+		return doctorResult{doctorOK, "no FLOWY_PULL_THROUGH_CACHE configured; images are pulled directly from their own registries"}
+	}
+
+	scheme := "https"
+	if isInsecureRegistry(cfg.PullThroughCache + "/placeholder") {
+		scheme = "http"
+	}
+	resp, err := http.Get(scheme + "://" + cfg.PullThroughCache + "/v2/")
+	if err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("reaching pull-through cache %s: %v", cfg.PullThroughCache, err)}
+	}
+	defer resp.Body.Close()
+	// The v2 ping endpoint returns 200 (anonymous access) or 401 (auth
+	// required but reachable); anything else means the host isn't a
+	// registry at all, or is down.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return doctorResult{doctorFail, fmt.Sprintf("pull-through cache %s returned unexpected status %s", cfg.PullThroughCache, resp.Status)}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("pull-through cache %s is reachable", cfg.PullThroughCache)}
+}
+
+// checkMkfifo verifies the workdir's filesystem supports named pipes,
+// which CWL streamable File staging relies on.
+func checkMkfifo(workdir string) doctorResult {
+	dir, err := os.MkdirTemp(workdir, "flowy-doctor-")
+	if err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("creating a temp directory under %s: %v", workdir, err)}
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("mkfifo under %s: %v", workdir, err)}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("named pipes supported under %s", workdir)}
+}
+
+// checkScratchSpace warns when workdir's filesystem has little free
+// space left, since staging and intermediate outputs both land there.
+func checkScratchSpace(workdir string) doctorResult {
+	free, err := sysinfo.FreeDiskBytes(workdir)
+	if err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("statting %s: %v", workdir, err)}
+	}
+	if free < minScratchBytes {
+		return doctorResult{doctorWarn, fmt.Sprintf("only %d bytes free under %s (want at least %d)", free, workdir, minScratchBytes)}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("%d bytes free under %s", free, workdir)}
+}
+
+// checkUlimits warns when this process's open-file soft limit is low
+// enough that a job with many secondaryFiles or a wide scatter could
+// exhaust descriptors mid-run.
+func checkUlimits() doctorResult {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("reading RLIMIT_NOFILE: %v", err)}
+	}
+	if rlimit.Cur < minOpenFiles {
+		return doctorResult{doctorWarn, fmt.Sprintf("open file soft limit is %d (want at least %d); raise it with ulimit -n", rlimit.Cur, minOpenFiles)}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("open file soft limit is %d", rlimit.Cur)}
+}
+
+// checkClockSkew compares this host's clock against the server's Date
+// header. A worker whose clock has drifted reports misleading job
+// timings and, once FLOWY_SIGNED_PAYLOADS is on, can have its signed
+// requests rejected by a server enforcing a timestamp window.
+func checkClockSkew(serverAddr string) doctorResult {
+	resp, err := http.Head(serverAddr)
+	if err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("reaching server at %s: %v", serverAddr, err)}
+	}
+	defer resp.Body.Close()
+
+	dateHeader := resp.Header.Get("Date")
+	if dateHeader == "" {
+		return doctorResult{doctorWarn, "server response had no Date header; clock skew could not be checked"}
+	}
+	serverTime, err := http.ParseTime(dateHeader)
+	if err != nil {
+		return doctorResult{doctorWarn, fmt.Sprintf("parsing server Date header %q: %v", dateHeader, err)}
+	}
+
+	skew := time.Since(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxClockSkew {
+		return doctorResult{doctorWarn, fmt.Sprintf("clock differs from server by %s (want under %s)", skew, maxClockSkew)}
+	}
+	return doctorResult{doctorOK, fmt.Sprintf("clock within %s of server", skew)}
+}
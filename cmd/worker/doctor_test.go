@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckMkfifoCreatesAndReportsSuccess(t *testing.T) {
+	result := checkMkfifo(t.TempDir())
+	if result.status != doctorOK {
+		t.Fatalf("status = %s, detail = %q, want OK", result.status, result.detail)
+	}
+}
+
+func TestCheckScratchSpaceReportsFreeBytes(t *testing.T) {
+	result := checkScratchSpace(t.TempDir())
+	if result.status == doctorFail {
+		t.Fatalf("unexpected failure: %s", result.detail)
+	}
+}
+
+func TestCheckUlimitsSucceeds(t *testing.T) {
+	result := checkUlimits()
+	if result.status == doctorFail {
+		t.Fatalf("unexpected failure reading ulimits: %s", result.detail)
+	}
+}
+
+func TestCheckClockSkewOKWhenServerClockMatches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	result := checkClockSkew(server.URL)
+	if result.status != doctorOK {
+		t.Fatalf("status = %s, detail = %q, want OK", result.status, result.detail)
+	}
+}
+
+func TestCheckClockSkewWarnsOnDrift(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	result := checkClockSkew(server.URL)
+	if result.status != doctorWarn {
+		t.Fatalf("status = %s, detail = %q, want WARN", result.status, result.detail)
+	}
+}
+
+func TestCheckClockSkewFailsWhenServerUnreachable(t *testing.T) {
+	result := checkClockSkew("http://127.0.0.1:1")
+	if result.status != doctorFail {
+		t.Fatalf("status = %s, detail = %q, want FAIL", result.status, result.detail)
+	}
+}
+
+func TestCheckSharedFileSystemOKWithNoBucketConfigured(t *testing.T) {
+	t.Setenv("FLOWY_S3_BUCKET", "")
+	result := checkSharedFileSystem()
+	if result.status != doctorOK {
+		t.Fatalf("status = %s, detail = %q, want OK", result.status, result.detail)
+	}
+}
+
+func TestCheckSharedFileSystemWarnsWithoutCredentials(t *testing.T) {
+	t.Setenv("FLOWY_S3_BUCKET", "my-bucket")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_PROFILE", "")
+	result := checkSharedFileSystem()
+	if result.status != doctorWarn {
+		t.Fatalf("status = %s, detail = %q, want WARN", result.status, result.detail)
+	}
+}
+
+func TestCheckPullThroughCacheOKWhenNotConfigured(t *testing.T) {
+	t.Setenv("FLOWY_PULL_THROUGH_CACHE", "")
+	result := checkPullThroughCache()
+	if result.status != doctorOK {
+		t.Fatalf("status = %s, detail = %q, want OK", result.status, result.detail)
+	}
+}
+
+func TestCheckPullThroughCacheOKWhenReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	orig := insecureRegistries
+	defer func() { insecureRegistries = orig }()
+	insecureRegistries = []string{host}
+
+	t.Setenv("FLOWY_PULL_THROUGH_CACHE", host)
+	result := checkPullThroughCache()
+	if result.status != doctorOK {
+		t.Fatalf("status = %s, detail = %q, want OK", result.status, result.detail)
+	}
+}
+
+func TestCheckPullThroughCacheFailsWhenUnreachable(t *testing.T) {
+	t.Setenv("FLOWY_PULL_THROUGH_CACHE", "127.0.0.1:1")
+	result := checkPullThroughCache()
+	if result.status != doctorFail {
+		t.Fatalf("status = %s, detail = %q, want FAIL", result.status, result.detail)
+	}
+}
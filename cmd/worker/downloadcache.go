@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+// downloadCacheDir is the local directory concurrent jobs' downloads of the
+// same source object are deduplicated through, set via
+// FLOWY_DOWNLOAD_CACHE_DIR. Empty (the default) leaves Download
+// undeduplicated, since a shared cache root big enough to hold a worker's
+// inputs is a deployment decision a site must opt into explicitly.
+var downloadCacheDir = os.Getenv("FLOWY_DOWNLOAD_CACHE_DIR")
+
+// withDownloadDedupIfEnabled wraps fm with download-once sharing across
+// concurrent jobs when FLOWY_DOWNLOAD_CACHE_DIR is set, and returns fm
+// unchanged otherwise.
+func withDownloadDedupIfEnabled(fm internal.FileManager) internal.FileManager {
+	if downloadCacheDir == "" {
+		return fm
+	}
+	return internal.WithDownloadDedup(fm, downloadCacheDir)
+}
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+	flowerrors "github.com/bioflowy/flowy/internal/errors"
+	"github.com/bioflowy/flowy/internal/jsexpr"
+	"github.com/bioflowy/flowy/internal/listing"
+	"github.com/bioflowy/flowy/internal/metrics"
+	"github.com/bioflowy/flowy/internal/outputschema"
+	"github.com/bioflowy/flowy/internal/secondaryfiles"
+	"github.com/bioflowy/flowy/internal/secrets"
+)
+
+// executionResult bundles everything executeJob learns about a job run
+// beyond its pass/fail outcome (reported separately as an error), so a new
+// piece of execution provenance can be added without growing executeJob's
+// return list again.
+type executionResult struct {
+	Usage               *api.ResourceUsage
+	Outputs             map[string]interface{}
+	SoftwareEnvironment *api.SoftwareEnvironment
+	ImageDigest         string
+	Reproducibility     *api.ReproducibilityReport
+	// ExitCode is Command's process exit code from its last attempt: -1
+	// when the process was ended by a signal (e.g. killed after exceeding
+	// its time limit), and zero when it never started at all.
+	ExitCode int
+	// Attempt is how many times Command was actually run: 1 unless
+	// job.RetryPolicy allowed (and a retryable exit code triggered) one
+	// or more retries.
+	Attempt int
+}
+
+// executeJob stages job's inputs under workdir, runs its command (in a
+// container when DockerImage is set, directly on the host otherwise), and
+// publishes its outputs. The job's own workdir is named after its JobID so
+// concurrent jobs never collide. The returned executionResult's Usage is
+// valid even when err is non-nil, as long as the command itself started.
+func executeJob(workdir string, job *api.ExecutableJob) (*executionResult, error) {
+	jobDir := jobDirFor(workdir, job.JobID)
+	tmpDir := filepath.Join(jobDir, "tmp")
+
+	if err := validateInputFormats(job.Inputs, job.InputFormats); err != nil {
+		return nil, fmt.Errorf("validating input formats for job %s: %w", job.JobID, err)
+	}
+
+	if err := receivePipeInputs(jobDir, job); err != nil {
+		return nil, fmt.Errorf("receiving streamed inputs for job %s: %w", job.JobID, err)
+	}
+
+	if err := runHook(hooks.PreStage, hookPayload{Job: job}); err != nil {
+		return nil, fmt.Errorf("pre-stage hook for job %s: %w", job.JobID, err)
+	}
+
+	if err := stageInputs(job.JobID, jobDir, job.Mapping); err != nil {
+		return nil, fmt.Errorf("staging inputs for job %s: %w", job.JobID, err)
+	}
+
+	if err := runHook(hooks.PostStage, hookPayload{Job: job}); err != nil {
+		return nil, fmt.Errorf("post-stage hook for job %s: %w", job.JobID, err)
+	}
+
+	inplaceDirs, err := snapshotInplaceUpdateDirs(jobDir, job.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("preparing inplace-update tracking for job %s: %w", job.JobID, err)
+	}
+
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating tmpdir for job %s: %w", job.JobID, err)
+	}
+
+	resolvedEnv, redact, err := secrets.ResolveEnv(job.Env, secretsProvider)
+	if err != nil {
+		return nil, fmt.Errorf("resolving secrets for job %s: %w", job.JobID, err)
+	}
+	resolvedEnv["TMPDIR"] = tmpDir
+
+	bundlePaths, err := stageReferenceBundles(inputFileManagerMetrics, jobDir, job.ReferenceBundles)
+	if err != nil {
+		return nil, fmt.Errorf("staging reference bundles for job %s: %w", job.JobID, err)
+	}
+	for id, path := range bundlePaths {
+		resolvedEnv["FLOWY_REFERENCE_BUNDLE_"+sanitizeBundleID(id)] = path
+	}
+
+	var buildCmd func() (*exec.Cmd, error)
+	var softwareEnv *api.SoftwareEnvironment
+	var imageDigest string
+	var resolvedImage string
+	if job.DockerImage != nil {
+		image := *job.DockerImage
+		if isImageTarballRef(image) {
+			loaded, err := loadImageTarball(outputFileManagerFor(job.JobID), jobDir, image)
+			if err != nil {
+				return nil, fmt.Errorf("loading image tarball for job %s: %w", job.JobID, err)
+			}
+			image = loaded
+		} else {
+			image = applyRegistryMirror(image)
+			if err := checkImagePlatform(image); err != nil {
+				return nil, fmt.Errorf("checking image platform for job %s: %w", job.JobID, err)
+			}
+			warnIfMutableTag(job.JobID, image)
+			if isInsecureRegistry(image) {
+				logger.Debug("skipping digest resolution for insecure registry", "jobId", job.JobID, "image", image)
+			} else if digest, err := resolveImageDigest(image); err != nil {
+				logger.Warn("resolving image digest, running by tag instead", "jobId", job.JobID, "image", image, "error", err)
+			} else {
+				image = digest
+				imageDigest = digest
+			}
+		}
+		resolvedImage = image
+		args := prepareForDocker(jobDir, job, image)
+		buildCmd = func() (*exec.Cmd, error) { return exec.Command("docker", args...), nil }
+	} else {
+		command, err := bubblewrapCommand(jobDir, job.Command)
+		if err != nil {
+			return nil, fmt.Errorf("enforcing filesystem sandboxing for job %s: %w", job.JobID, err)
+		}
+		netCommand, err := nonContainerNetworkCommand(job.Networkaccess, command)
+		if err != nil {
+			return nil, flowerrors.Spec("NetworkAccess", fmt.Errorf("enforcing network policy for job %s: %w", job.JobID, err))
+		}
+		command = netCommand
+		command, softwareEnv, err = applySoftwareEnvironment(job.SoftwareEnvironment, command)
+		if err != nil {
+			return nil, fmt.Errorf("preparing software environment for job %s: %w", job.JobID, err)
+		}
+		buildCmd = func() (*exec.Cmd, error) {
+			c := exec.Command(command[0], command[1:]...)
+			c.Dir = jobDir
+			return c, nil
+		}
+	}
+	env := buildEnv(resolvedEnv)
+
+	if err := writeNextflowCommandScript(jobDir, job.Command); err != nil {
+		return nil, fmt.Errorf("writing .command.sh for job %s: %w", job.JobID, err)
+	}
+	commandLog, err := openNextflowCommandLog(jobDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening .command.log for job %s: %w", job.JobID, err)
+	}
+	if commandLog != nil {
+		defer commandLog.Close()
+	}
+
+	restoreCheckpoint(jobDir, job)
+	finishCheckpoint := func() {}
+	if job.CheckpointDir != "" {
+		stopCheckpoint := make(chan struct{})
+		var checkpointDone sync.WaitGroup
+		checkpointDone.Add(1)
+		go func() {
+			defer checkpointDone.Done()
+			startCheckpointUploader(jobDir, job, stopCheckpoint)
+		}()
+		var once sync.Once
+		// finishCheckpoint stops the uploader and waits for its final
+		// upload (triggered by stopCheckpoint closing) to actually
+		// finish, so it runs before cleanupJobDir can remove jobDir out
+		// from under it. Idempotent since every return path below calls
+		// it explicitly ahead of its own cleanupJobDir call, in addition
+		// to the deferred call here that catches any path that doesn't.
+		finishCheckpoint = func() {
+			once.Do(func() {
+				close(stopCheckpoint)
+				checkpointDone.Wait()
+			})
+		}
+		defer finishCheckpoint()
+	}
+
+	maxAttempts := 1 + retryCountOf(job.RetryPolicy)
+	var usage *api.ResourceUsage
+	var timedOut, cancelled, preempted bool
+	var runErr error
+	var exitCode int
+	attempt := 0
+	for {
+		attempt++
+		cmd, err := buildCmd()
+		if err != nil {
+			return nil, fmt.Errorf("preparing command for job %s: %w", job.JobID, err)
+		}
+		cmd.Env = env
+
+		stdin, stdout, stderr, err := redirectStreams(jobDir, job)
+		if err != nil {
+			return nil, fmt.Errorf("setting up stdio for job %s: %w", job.JobID, err)
+		}
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, teeWriter(stdout, commandLog), teeWriter(stderr, commandLog)
+
+		start := time.Now()
+		if chaosErr := maybeInjectExecFailure(job.JobID); chaosErr != nil {
+			runErr = chaosErr
+		} else {
+			timedOut, cancelled, preempted, runErr = runCommand(cmd, job.Timelimit, job.JobID, job.SpeculativeCopy)
+		}
+		usage = collectResourceUsage(cmd, start)
+		closeStreams(stdin, stdout, stderr)
+		exitCode = exitCodeOf(runErr)
+
+		if !shouldRetryJob(job.RetryPolicy, attempt, maxAttempts, timedOut || cancelled || preempted, runErr) {
+			break
+		}
+		logger.Warn("retrying job after retryable exit code", "jobId", job.JobID, "attempt", attempt, "exitCode", exitCode)
+	}
+
+	if syncErr := syncInplaceUpdateDirs(outputFileManagerFor(job.JobID), inplaceDirs); syncErr != nil {
+		logger.Warn("syncing inplace-update directories", "jobId", job.JobID, "error", syncErr)
+	}
+
+	if job.DockerImage != nil {
+		if err := ensureWritable(jobDir); err != nil {
+			logger.Warn("ensureWritable after container job", "jobId", job.JobID, "error", err)
+		}
+	}
+
+	if preempted {
+		if err := uploadPartialLog(jobDir, job); err != nil {
+			logger.Warn("uploading partial command log after preemption", "jobId", job.JobID, "error", err)
+		}
+		finishCheckpoint()
+		cleanupJobDir(jobDir, tmpDir, job, false)
+		result := &executionResult{Usage: usage, SoftwareEnvironment: softwareEnv, ImageDigest: imageDigest, Reproducibility: captureReproducibilityReport(job, resolvedImage), ExitCode: exitCode, Attempt: attempt}
+		return result, preemptedFail(fmt.Errorf("job %s interrupted: worker is being preempted", job.JobID))
+	}
+
+	if cancelled {
+		finishCheckpoint()
+		cleanupJobDir(jobDir, tmpDir, job, false)
+		result := &executionResult{Usage: usage, SoftwareEnvironment: softwareEnv, ImageDigest: imageDigest, Reproducibility: captureReproducibilityReport(job, resolvedImage), ExitCode: exitCode, Attempt: attempt}
+		return result, cancelledFail(fmt.Errorf("job %s cancelled: another speculative copy finished first", job.JobID))
+	}
+
+	if runErr != nil && !timedOut {
+		finishCheckpoint()
+		cleanupJobDir(jobDir, tmpDir, job, false)
+		result := &executionResult{Usage: usage, SoftwareEnvironment: softwareEnv, ImageDigest: imageDigest, Reproducibility: captureReproducibilityReport(job, resolvedImage), ExitCode: exitCode, Attempt: attempt}
+		return result, fmt.Errorf("running job %s: %w", job.JobID, redactErr(runErr, redact))
+	}
+
+	// A timed-out job is killed mid-run, so it never gets to write its own
+	// cwl.output.json; publishOutputs still runs so any glob-collectible
+	// outputs it did manage to produce, and its logs, aren't lost.
+	outputs, publishErr := publishOutputs(jobDir, tmpDir, job)
+	if publishErr == nil {
+		publishErr = runHook(hooks.PostOutputs, hookPayload{Job: job, Outputs: outputs})
+	}
+	if publishErr == nil {
+		if err := servePipeOutputs(job, outputs); err != nil {
+			logger.Warn("streaming pipe outputs", "jobId", job.JobID, "error", err)
+		}
+		publishToCatalog(job, outputs)
+	}
+	finishCheckpoint()
+	cleanupJobDir(jobDir, tmpDir, job, publishErr == nil && !timedOut)
+
+	result := &executionResult{Usage: usage, Outputs: outputs, SoftwareEnvironment: softwareEnv, ImageDigest: imageDigest, Reproducibility: captureReproducibilityReport(job, resolvedImage), ExitCode: exitCode, Attempt: attempt}
+	if timedOut {
+		msg := fmt.Errorf("job %s exceeded its %ds time limit", job.JobID, job.Timelimit)
+		if publishErr != nil {
+			logger.Warn("collecting partial outputs after time limit", "jobId", job.JobID, "error", publishErr)
+		}
+		return result, timeLimitFail(msg)
+	}
+	return result, publishErr
+}
+
+// retryCountOf returns policy's RetryCount, or 0 when policy is nil
+// (never retry).
+func retryCountOf(policy *api.RetryPolicy) int {
+	if policy == nil {
+		return 0
+	}
+	return policy.RetryCount
+}
+
+// exitCodeOf extracts a command's process exit code from the error
+// cmd.Wait (via runCommand) returned, or 0 when runErr is nil or isn't an
+// *exec.ExitError (e.g. the command never started).
+func exitCodeOf(runErr error) int {
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 0
+}
+
+// shouldRetryJob reports whether executeJob's retry loop should run
+// Command again: only when policy allows another attempt, the job was not
+// killed for exceeding its time limit (retrying that would just time out
+// again), and the exit code runErr carries is one policy designates
+// retryable.
+func shouldRetryJob(policy *api.RetryPolicy, attempt, maxAttempts int, timedOut bool, runErr error) bool {
+	if policy == nil || runErr == nil || timedOut || attempt >= maxAttempts {
+		return false
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(runErr, &exitErr) {
+		return false
+	}
+	exitCode := exitErr.ExitCode()
+	for _, retryable := range policy.RetryableExitCodes {
+		if retryable == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// redactErr rewrites err's message so resolved secret values never reach a
+// failure report or log line.
+func redactErr(err error, secretValues []string) error {
+	if len(secretValues) == 0 {
+		return err
+	}
+	return fmt.Errorf("%s", secrets.Redact(err.Error(), secretValues))
+}
+
+// prepareForDocker builds the `docker run` argument list for job, bind
+// mounting jobDir as the container's working directory and running as
+// containerUser so outputs the container writes are owned by whoever
+// invoked the worker rather than a hard-coded UID.
+func prepareForDocker(jobDir string, job *api.ExecutableJob, image string) []string {
+	args := []string{"run", "--rm",
+		"--user=" + containerUser(),
+		"-v", jobDir + ":" + jobDir + bindMountSuffix(hardening),
+		"-w", jobDir,
+	}
+	if len(job.ReferenceBundles) > 0 && referenceBundleCacheDir != "" {
+		args = append(args, "-v", referenceBundleCacheDir+":"+referenceBundleCacheDir+bindMountSuffix(hardening))
+	}
+	args = append(args, dockerPlatformArgs()...)
+	args = append(args, dockerHardeningArgs(hardening)...)
+	args = append(args, dockerNetworkArgs(job.Networkaccess)...)
+	args = append(args, image)
+	return append(args, job.Command...)
+}
+
+// containerUser returns the "uid:gid" docker should run the container as.
+// It defaults to the worker process's own uid:gid so container-written
+// outputs are readable by whoever is running the worker, and can be
+// overridden (e.g. for user-namespace remapping setups) via
+// FLOWY_CONTAINER_USER.
+func containerUser() string {
+	if v := os.Getenv("FLOWY_CONTAINER_USER"); v != "" {
+		return v
+	}
+	return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+}
+
+// ensureWritable makes every file and directory under dir writable by its
+// owner, for use after a container job that ran as a different uid/gid
+// left behind outputs the worker process cannot otherwise modify or clean
+// up.
+func ensureWritable(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return err
+		}
+		mode := info.Mode().Perm() | 0o200
+		if info.IsDir() {
+			mode |= 0o100
+		}
+		return os.Chmod(path, mode)
+	})
+}
+
+// strictEnvIsolation controls whether a job's environment starts from the
+// worker's own os.Environ() (the default, for backward compatibility) or a
+// minimal whitelist, set via FLOWY_STRICT_ENV=1. CWL's EnvVarRequirement
+// assumes a tool sees only the variables it explicitly declares (plus
+// whatever the runtime itself needs, e.g. PATH); leaking the worker's own
+// environment - AWS credentials, an unrelated PATH, secrets in unrelated
+// env vars - violates that and can leak sensitive worker-side state into
+// arbitrary tool code.
+var strictEnvIsolation = os.Getenv("FLOWY_STRICT_ENV") == "1"
+
+// strictEnvWhitelist is the fixed set of variables carried over from the
+// worker's own environment under strict isolation, when present: enough
+// for a typical tool binary to run (PATH, a home directory, and a scratch
+// directory) without exposing anything worker-specific.
+var strictEnvWhitelist = []string{"HOME", "TMPDIR", "PATH"}
+
+// buildEnv layers job-specific environment variables on top of either the
+// worker's full environment, or (under strictEnvIsolation) a minimal
+// whitelist, so job.Env always has the final say over the same-named
+// variable either way.
+func buildEnv(jobEnv map[string]string) []string {
+	var env []string
+	if strictEnvIsolation {
+		for _, k := range strictEnvWhitelist {
+			if v, ok := os.LookupEnv(k); ok {
+				env = append(env, k+"="+v)
+			}
+		}
+	} else {
+		env = os.Environ()
+	}
+	for k, v := range jobEnv {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// publishOutputs collects job's outputs from jobDir: from a tool-written
+// cwl.output.json when present (processed by loadCwlOutputJson), otherwise
+// by globbing each output's OutputBinding patterns (collectGlobOutputs).
+// Any OutputEval expression for a given output name is then evaluated
+// locally against the collected value, rather than calling back out to the
+// server.
+func publishOutputs(jobDir, tmpDir string, job *api.ExecutableJob) (map[string]interface{}, error) {
+	runtime := map[string]interface{}{"outdir": filepath.Dir(tmpDir), "tmpdir": tmpDir}
+	fm := outputFileManagerFor(job.JobID)
+	ledger, err := loadUploadLedger(jobDir)
+	if err != nil {
+		logger.Warn("loading upload ledger, uploads for this attempt will not be resumable", "jobId", job.JobID, "error", err)
+		ledger = &uploadLedger{path: filepath.Join(jobDir, uploadLedgerFileName), entries: map[string]uploadLedgerEntry{}}
+	}
+	ctx := &publishContext{
+		outdirTarget: outputPrefix(job),
+		inputs:       job.Inputs,
+		runtime:      runtime,
+		upload: resumableUpload(fm, ledger, func(local, key, checksum string) (string, error) {
+			location := outputBaseURL + key
+			start := time.Now()
+			var err error
+			if dedup, ok := fm.(internal.DedupUploader); ok && checksum != "" {
+				location, err = dedup.UploadDedup(local, location, checksum)
+			} else {
+				err = fm.Upload(local, location)
+			}
+			if err != nil {
+				metrics.TransferErrorsTotal.Inc("backend", "upload")
+				return "", err
+			}
+			metrics.UploadSeconds.Add(int64(time.Since(start).Seconds()))
+			if size, _, err := fm.Stat(location); err == nil {
+				metrics.UploadBytesTotal.Add(size)
+			}
+			if tagger, ok := fm.(internal.ObjectTagger); ok && len(job.Labels) > 0 {
+				if err := tagger.TagObject(location, job.Labels); err != nil {
+					logger.Warn("tagging uploaded output", "jobId", job.JobID, "location", location, "error", err)
+				}
+			}
+			return location, nil
+		}),
+	}
+
+	var outputs map[string]interface{}
+	outputJson := filepath.Join(jobDir, "cwl.output.json")
+	if _, statErr := os.Stat(outputJson); statErr == nil {
+		outputs, err = loadCwlOutputJson(outputJson, ctx.outdirTarget, secondaryFilePatterns(job.SecondaryFiles), loadListingModes(job.LoadListing), outputSchemaTypes(job.OutputSchema), ctx.inputs, ctx.runtime, ctx.upload)
+	} else {
+		outputs, err = collectGlobOutputs(ctx, jobDir, job)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if outputs == nil {
+		return nil, nil
+	}
+
+	if err := applyOutputEval(outputs, tmpDir, job); err != nil {
+		return nil, err
+	}
+
+	ledger.verify(fm)
+	if err := ledger.remove(); err != nil {
+		logger.Warn("removing upload ledger", "jobId", job.JobID, "error", err)
+	}
+	return outputs, nil
+}
+
+// secondaryFilePatterns converts the API's SecondaryFilePattern trees into
+// the secondaryfiles package's own Pattern trees.
+func secondaryFilePatterns(patterns map[string][]api.SecondaryFilePattern) map[string][]secondaryfiles.Pattern {
+	if len(patterns) == 0 {
+		return nil
+	}
+	out := make(map[string][]secondaryfiles.Pattern, len(patterns))
+	for name, ps := range patterns {
+		out[name] = convertSecondaryFilePatterns(ps)
+	}
+	return out
+}
+
+func convertSecondaryFilePatterns(patterns []api.SecondaryFilePattern) []secondaryfiles.Pattern {
+	if len(patterns) == 0 {
+		return nil
+	}
+	out := make([]secondaryfiles.Pattern, len(patterns))
+	for i, p := range patterns {
+		out[i] = secondaryfiles.Pattern{
+			Expr:           p.Pattern,
+			Required:       p.Required,
+			SecondaryFiles: convertSecondaryFilePatterns(p.SecondaryFiles),
+		}
+	}
+	return out
+}
+
+// loadListingModes converts job.LoadListing's raw mode strings into
+// listing.Mode, so an unset or unrecognized value falls back to
+// listing.NoListing rather than the worker guessing at a tool's intent.
+func loadListingModes(modes map[string]string) map[string]listing.Mode {
+	if len(modes) == 0 {
+		return nil
+	}
+	out := make(map[string]listing.Mode, len(modes))
+	for name, mode := range modes {
+		out[name] = listing.Mode(mode)
+	}
+	return out
+}
+
+// outputSchemaTypes converts the API's OutputType trees into the
+// outputschema package's own Type trees.
+func outputSchemaTypes(schema map[string]api.OutputType) map[string]outputschema.Type {
+	if len(schema) == 0 {
+		return nil
+	}
+	out := make(map[string]outputschema.Type, len(schema))
+	for name, t := range schema {
+		out[name] = convertOutputType(t)
+	}
+	return out
+}
+
+func convertOutputType(t api.OutputType) outputschema.Type {
+	out := outputschema.Type{Name: t.Type, Optional: t.Optional}
+	if t.Items != nil {
+		items := convertOutputType(*t.Items)
+		out.Items = &items
+	}
+	return out
+}
+
+// applyOutputEval evaluates job.OutputEval's expressions in place against
+// outputs, binding "self" to each named output's current value.
+func applyOutputEval(outputs map[string]interface{}, tmpDir string, job *api.ExecutableJob) error {
+	if len(job.OutputEval) == 0 {
+		return nil
+	}
+	runtime := map[string]interface{}{"outdir": filepath.Dir(tmpDir), "tmpdir": tmpDir}
+	for name, expr := range job.OutputEval {
+		v, err := jsexpr.Evaluate(expr, jsexpr.Context{Inputs: job.Inputs, Self: outputs[name], Runtime: runtime})
+		if err != nil {
+			return flowerrors.Eval(expr, fmt.Errorf("outputEval for %q on job %s: %w", name, job.JobID, err))
+		}
+		outputs[name] = v
+	}
+	return nil
+}
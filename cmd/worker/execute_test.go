@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestBuildEnvStrictIsolationOnlyWhitelistsSurvive(t *testing.T) {
+	prev := strictEnvIsolation
+	strictEnvIsolation = true
+	defer func() { strictEnvIsolation = prev }()
+
+	t.Setenv("HOME", "/home/worker")
+	t.Setenv("TMPDIR", "/tmp/worker")
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "leaked")
+
+	env := buildEnv(map[string]string{"TOOL_FLAG": "1"})
+
+	want := map[string]bool{"HOME=/home/worker": true, "TMPDIR=/tmp/worker": true, "PATH=/usr/bin": true, "TOOL_FLAG=1": true}
+	for _, e := range env {
+		if !want[e] {
+			t.Fatalf("unexpected env entry leaked through strict isolation: %q", e)
+		}
+	}
+	if len(env) != len(want) {
+		t.Fatalf("env = %v, want exactly %v", env, want)
+	}
+}
+
+func TestBuildEnvNonStrictInheritsWorkerEnvironment(t *testing.T) {
+	prev := strictEnvIsolation
+	strictEnvIsolation = false
+	defer func() { strictEnvIsolation = prev }()
+
+	t.Setenv("FLOWY_TEST_MARKER", "present")
+
+	env := buildEnv(nil)
+	found := false
+	for _, e := range env {
+		if e == "FLOWY_TEST_MARKER=present" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected worker environment to be inherited when strict isolation is off")
+	}
+	_ = os.Environ()
+}
+
+// exitErrorWithCode runs a real subprocess that exits with code, so tests
+// get a genuine *exec.ExitError rather than hand-constructing one (its
+// fields are unexported).
+func exitErrorWithCode(t *testing.T, code int) error {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code))
+	err := cmd.Run()
+	if err == nil && code != 0 {
+		t.Fatalf("exit %d unexpectedly succeeded", code)
+	}
+	return err
+}
+
+func TestExitCodeOf(t *testing.T) {
+	if got := exitCodeOf(nil); got != 0 {
+		t.Errorf("exitCodeOf(nil) = %d, want 0", got)
+	}
+	if got := exitCodeOf(exitErrorWithCode(t, 7)); got != 7 {
+		t.Errorf("exitCodeOf(exit 7) = %d, want 7", got)
+	}
+	if got := exitCodeOf(fmt.Errorf("not an exec.ExitError")); got != 0 {
+		t.Errorf("exitCodeOf(plain error) = %d, want 0", got)
+	}
+}
+
+func TestRetryCountOf(t *testing.T) {
+	if got := retryCountOf(nil); got != 0 {
+		t.Errorf("retryCountOf(nil) = %d, want 0", got)
+	}
+	if got := retryCountOf(&api.RetryPolicy{RetryCount: 3}); got != 3 {
+		t.Errorf("retryCountOf(RetryCount: 3) = %d, want 3", got)
+	}
+}
+
+func TestShouldRetryJob(t *testing.T) {
+	policy := &api.RetryPolicy{RetryCount: 2, RetryableExitCodes: []int{3, 42}}
+	retryableErr := exitErrorWithCode(t, 3)
+	nonRetryableErr := exitErrorWithCode(t, 1)
+
+	cases := []struct {
+		name        string
+		policy      *api.RetryPolicy
+		attempt     int
+		maxAttempts int
+		timedOut    bool
+		runErr      error
+		want        bool
+	}{
+		{"no policy", nil, 1, 1, false, retryableErr, false},
+		{"success", policy, 1, 3, false, nil, false},
+		{"exhausted attempts", policy, 3, 3, false, retryableErr, false},
+		{"timed out", policy, 1, 3, true, retryableErr, false},
+		{"non-retryable exit code", policy, 1, 3, false, nonRetryableErr, false},
+		{"retryable exit code", policy, 1, 3, false, retryableErr, true},
+		{"non-exit error", policy, 1, 3, false, fmt.Errorf("never started"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := shouldRetryJob(c.policy, c.attempt, c.maxAttempts, c.timedOut, c.runErr); got != c.want {
+				t.Errorf("shouldRetryJob() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestBuildEnvJobEnvOverridesWhitelistedVariable(t *testing.T) {
+	prev := strictEnvIsolation
+	strictEnvIsolation = true
+	defer func() { strictEnvIsolation = prev }()
+
+	t.Setenv("PATH", "/usr/bin")
+
+	env := buildEnv(map[string]string{"PATH": "/custom/bin"})
+	count := 0
+	for _, e := range env {
+		if e == "PATH=/custom/bin" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected exactly one overriding PATH entry, got %d in %v", count, env)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/secrets"
+)
+
+// outputFileManagerMetrics is the innermost, undecorated output backend,
+// kept as its own var (rather than buried inside newBaseOutputFileManager's
+// decorator chain) so reportHeartbeat can read its Health() after every
+// other decorator has had a chance to call through it.
+var outputFileManagerMetrics = internal.WithMetrics(internal.NewFileManager(pluginFileManagers, internal.NewLocalFileManager()), "output")
+
+// baseOutputFileManager is the backend publishing job outputs. It defaults
+// to the local filesystem and is swapped for an S3FileManager when the
+// worker is configured against object storage. It is wrapped with
+// checksum-addressed upload deduplication when FLOWY_DEDUP_UPLOADS is set,
+// so identical intermediate files produced by many scatter shards are
+// stored once.
+var baseOutputFileManager = newBaseOutputFileManager()
+
+func newBaseOutputFileManager() internal.FileManager {
+	var fm internal.FileManager = outputFileManagerMetrics
+	if os.Getenv("FLOWY_DEDUP_UPLOADS") != "" {
+		fm = internal.WithDedup(fm, outputBaseURL)
+	}
+	return withChaosIfEnabled(fm)
+}
+
+// auditLogger records every download/upload/copy/delete FileManagers
+// perform, as required for tracking where regulated data travelled. It is
+// nil (and WithAudit becomes a no-op-free passthrough) when no audit log
+// path is configured.
+var auditLogger = newAuditLoggerOrNil(os.Getenv("FLOWY_AUDIT_LOG"))
+
+func newAuditLoggerOrNil(path string) *internal.AuditLogger {
+	if path == "" {
+		return nil
+	}
+	l, err := internal.NewFileAuditLogger(path)
+	if err != nil {
+		logger.Error("opening audit log", "path", path, "error", err)
+		return nil
+	}
+	return l
+}
+
+// outputFileManagerFor returns the FileManager a job's output uploads
+// should go through, tagged with jobID in the audit trail.
+func outputFileManagerFor(jobID string) internal.FileManager {
+	return internal.WithAudit(baseOutputFileManager, auditLogger, jobID)
+}
+
+// secretsProvider resolves $secret: references in job environments. It
+// defaults to an empty env-file provider (no secrets configured) and is
+// replaced with a Vault- or Secrets-Manager-backed Provider when the
+// worker is configured with one.
+var secretsProvider = newSecretsProviderOrNop(os.Getenv("FLOWY_SECRETS_FILE"))
+
+type nopProvider struct{}
+
+func (nopProvider) Resolve(name string) (string, error) {
+	return "", fmt.Errorf("no secrets provider configured (requested %q)", name)
+}
+
+func newSecretsProviderOrNop(path string) secrets.Provider {
+	if path == "" {
+		return nopProvider{}
+	}
+	p, err := secrets.NewEnvFileProvider(path)
+	if err != nil {
+		logger.Error("loading secrets file", "path", path, "error", err)
+		return nopProvider{}
+	}
+	return p
+}
+
+// outputBaseURL is prefixed onto an output's key to form both the
+// destination outputFileManager.Upload is given and the location clients
+// see in the final output object, e.g. "s3://bucket/" for S3-backed
+// deployments or "" for a shared local output root.
+var outputBaseURL = ""
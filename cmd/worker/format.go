@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bioflowy/flowy/internal/format"
+)
+
+// validateInputFormats checks every input in allowed that has a File value
+// in inputs declares a "format" satisfying at least one of that input's
+// allowed EDAM IRIs, before the job is run. An input with no allowed
+// entry, or whose value isn't a File object, is not checked.
+func validateInputFormats(inputs map[string]interface{}, allowed map[string][]string) error {
+	for name, formats := range allowed {
+		if len(formats) == 0 {
+			continue
+		}
+		file, ok := inputs[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if class, _ := file["class"].(string); class != "File" {
+			continue
+		}
+		have, _ := file["format"].(string)
+		if !format.Matches(have, formats) {
+			return fmt.Errorf("input %q: format %q does not satisfy required format(s) %v", name, have, formats)
+		}
+	}
+	return nil
+}
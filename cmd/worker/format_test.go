@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestValidateInputFormatsAcceptsMatchingFormat(t *testing.T) {
+	inputs := map[string]interface{}{
+		"reads": map[string]interface{}{"class": "File", "format": "http://edamontology.org/format_1930"},
+	}
+	allowed := map[string][]string{"reads": {"http://edamontology.org/format_1930"}}
+	if err := validateInputFormats(inputs, allowed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateInputFormatsRejectsMismatchedFormat(t *testing.T) {
+	inputs := map[string]interface{}{
+		"reads": map[string]interface{}{"class": "File", "format": "http://edamontology.org/format_2330"},
+	}
+	allowed := map[string][]string{"reads": {"http://edamontology.org/format_1930"}}
+	if err := validateInputFormats(inputs, allowed); err == nil {
+		t.Fatal("expected format mismatch error")
+	}
+}
+
+func TestValidateInputFormatsIgnoresNonFileInputs(t *testing.T) {
+	inputs := map[string]interface{}{"count": float64(3)}
+	allowed := map[string][]string{"count": {"http://edamontology.org/format_1930"}}
+	if err := validateInputFormats(inputs, allowed); err != nil {
+		t.Fatal(err)
+	}
+}
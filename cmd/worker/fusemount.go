@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// s3FUSEMountEnabled turns on FUSE-mount-based staging for S3 File inputs,
+// set via FLOWY_S3_FUSE_MOUNT=1. A File input backed by an S3 location is
+// then exposed to the job by mounting its bucket read-only with an
+// external FUSE helper (mountpoint-s3, goofys, or anything else that
+// accepts "<helper> <bucket> <mountpoint>") and symlinking just that one
+// object into place, instead of downloading it - useful for tools that
+// only read small slices of a huge BAM/CRAM file. It defaults to off
+// since it requires a FUSE helper binary and /dev/fuse access, neither of
+// which every worker host has set up; stageS3InputViaFUSE falls back to
+// an ordinary download whenever the helper isn't available.
+var s3FUSEMountEnabled = os.Getenv("FLOWY_S3_FUSE_MOUNT") == "1"
+
+// s3FUSEHelper is the external FUSE helper binary used to mount a bucket,
+// overridable via FLOWY_S3_FUSE_HELPER for goofys or another
+// mountpoint-s3-compatible tool. It is invoked as "<helper> <bucket>
+// <mountpoint> --read-only", the calling convention mountpoint-s3 and
+// goofys both accept.
+var s3FUSEHelper = envOrDefault("FLOWY_S3_FUSE_HELPER", "mount-s3")
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// s3FUSEHelperAvailable reports whether s3FUSEHelper can be found on
+// PATH, so stageS3InputViaFUSE can fall back to a full download on a
+// worker host that never installed it.
+func s3FUSEHelperAvailable() bool {
+	_, err := exec.LookPath(s3FUSEHelper)
+	return err == nil
+}
+
+// splitS3Location splits an "s3://bucket/key" location into its bucket
+// and key, returning ok=false for anything else.
+func splitS3Location(location string) (bucket, key string, ok bool) {
+	const prefix = "s3://"
+	if !strings.HasPrefix(location, prefix) {
+		return "", "", false
+	}
+	rest := strings.TrimPrefix(location, prefix)
+	idx := strings.Index(rest, "/")
+	if idx < 0 {
+		return rest, "", true
+	}
+	return rest[:idx], rest[idx+1:], true
+}
+
+// s3FUSEMountDir returns the directory a bucket is (or would be) mounted
+// at for jobDir. It lives under jobDir itself, rather than alongside it,
+// so the same "-v jobDir:jobDir" bind mount prepareForDocker already does
+// for the whole job also carries the FUSE mount straight through to the
+// container - without this, a container job would resolve ent.Target's
+// symlink to a path outside its only bind mount and find nothing there.
+func s3FUSEMountDir(jobDir, bucket string) string {
+	return filepath.Join(jobDir, ".s3fuse", bucket)
+}
+
+// stageS3InputViaFUSE attempts to satisfy a File entry backed by an S3
+// location (ent.Resolved) by mounting its bucket read-only and symlinking
+// target at the object's path inside the mount, instead of downloading
+// it. It reports whether the mount was used; false with a non-nil error
+// means the caller should log it and fall back to stageEntry's ordinary
+// Download path. False with a nil error means FUSE staging wasn't
+// attempted at all (disabled, not an S3 location, or no helper
+// installed).
+func stageS3InputViaFUSE(jobDir string, resolved, target string) (bool, error) {
+	if !s3FUSEMountEnabled {
+		return false, nil
+	}
+	bucket, key, ok := splitS3Location(resolved)
+	if !ok || key == "" {
+		return false, nil
+	}
+	if !s3FUSEHelperAvailable() {
+		return false, nil
+	}
+
+	mountDir := s3FUSEMountDir(jobDir, bucket)
+	mounted, err := isMounted(mountDir)
+	if err != nil {
+		return false, err
+	}
+	if !mounted {
+		if err := mountS3Bucket(bucket, mountDir); err != nil {
+			return false, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.Symlink(filepath.Join(mountDir, key), target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// mountS3Bucket mounts bucket read-only at mountDir using s3FUSEHelper,
+// waiting for the mount to become visible before returning.
+func mountS3Bucket(bucket, mountDir string) error {
+	if err := os.MkdirAll(mountDir, 0o755); err != nil {
+		return err
+	}
+	cmd := exec.Command(s3FUSEHelper, bucket, mountDir, "--read-only")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mounting s3://%s at %s: %w: %s", bucket, mountDir, err, strings.TrimSpace(string(out)))
+	}
+	return waitForMount(mountDir, 10*time.Second)
+}
+
+// waitForMount polls mountDir until it appears as a mount point or
+// timeout elapses, since a FUSE helper may daemonize and return before
+// the mount is fully visible.
+func waitForMount(mountDir string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if mounted, err := isMounted(mountDir); err == nil && mounted {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for mount at %s", mountDir)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// isMounted reports whether mountDir appears as a mount point in
+// /proc/mounts.
+func isMounted(mountDir string) (bool, error) {
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == mountDir {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// unmountS3FUSEMounts unmounts and removes every bucket mount
+// stageS3InputViaFUSE made for jobDir, globbing for its
+// "<jobDir>/.s3fuse/<bucket>" directories rather than tracking them in a
+// separate registry, since there is never more than one worker process
+// staging a given jobDir at a time.
+func unmountS3FUSEMounts(jobDir string) {
+	if !s3FUSEMountEnabled {
+		return
+	}
+	matches, err := filepath.Glob(filepath.Join(jobDir, ".s3fuse", "*"))
+	if err != nil {
+		return
+	}
+	for _, mountDir := range matches {
+		if err := unmountS3Bucket(mountDir); err != nil {
+			logger.Warn("unmounting S3 FUSE mount", "path", mountDir, "error", err)
+			continue
+		}
+		os.RemoveAll(mountDir)
+	}
+}
+
+// unmountS3Bucket unmounts a bucket previously mounted by mountS3Bucket,
+// trying fusermount (the conventional way to release a FUSE mount without
+// root) before falling back to umount.
+func unmountS3Bucket(mountDir string) error {
+	if err := exec.Command("fusermount", "-u", mountDir).Run(); err == nil {
+		return nil
+	}
+	return exec.Command("umount", mountDir).Run()
+}
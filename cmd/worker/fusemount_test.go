@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitS3Location(t *testing.T) {
+	bucket, key, ok := splitS3Location("s3://my-bucket/path/to/reads.bam")
+	if !ok || bucket != "my-bucket" || key != "path/to/reads.bam" {
+		t.Fatalf("splitS3Location() = (%q, %q, %v)", bucket, key, ok)
+	}
+
+	if _, _, ok := splitS3Location("/local/path"); ok {
+		t.Fatal("expected a local path to not parse as an S3 location")
+	}
+
+	bucket, key, ok = splitS3Location("s3://my-bucket")
+	if !ok || bucket != "my-bucket" || key != "" {
+		t.Fatalf("splitS3Location() with no key = (%q, %q, %v)", bucket, key, ok)
+	}
+}
+
+func TestS3FUSEMountDirLivesUnderJobDir(t *testing.T) {
+	jobDir := "/work/job-123"
+	got := s3FUSEMountDir(jobDir, "my-bucket")
+	want := filepath.Join(jobDir, ".s3fuse", "my-bucket")
+	if got != want {
+		t.Fatalf("s3FUSEMountDir() = %q, want %q", got, want)
+	}
+}
+
+func TestStageS3InputViaFUSEDisabledByDefault(t *testing.T) {
+	prev := s3FUSEMountEnabled
+	s3FUSEMountEnabled = false
+	defer func() { s3FUSEMountEnabled = prev }()
+
+	mounted, err := stageS3InputViaFUSE(t.TempDir(), "s3://bucket/key", "/tmp/nonexistent-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounted {
+		t.Fatal("expected FUSE staging to stay off by default")
+	}
+}
+
+func TestStageS3InputViaFUSESkipsNonS3Locations(t *testing.T) {
+	prev := s3FUSEMountEnabled
+	s3FUSEMountEnabled = true
+	defer func() { s3FUSEMountEnabled = prev }()
+
+	mounted, err := stageS3InputViaFUSE(t.TempDir(), "/local/path/reads.bam", "/tmp/nonexistent-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounted {
+		t.Fatal("expected a non-S3 location to skip FUSE staging")
+	}
+}
+
+func TestStageS3InputViaFUSESkipsWithoutHelperInstalled(t *testing.T) {
+	prev, prevHelper := s3FUSEMountEnabled, s3FUSEHelper
+	s3FUSEMountEnabled = true
+	s3FUSEHelper = "flowy-nonexistent-fuse-helper"
+	defer func() { s3FUSEMountEnabled, s3FUSEHelper = prev, prevHelper }()
+
+	mounted, err := stageS3InputViaFUSE(t.TempDir(), "s3://bucket/reads.bam", "/tmp/nonexistent-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounted {
+		t.Fatal("expected FUSE staging to fall back when the helper binary is missing")
+	}
+}
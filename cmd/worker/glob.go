@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/api"
+	flowerrors "github.com/bioflowy/flowy/internal/errors"
+	edamformat "github.com/bioflowy/flowy/internal/format"
+	"github.com/bioflowy/flowy/internal/glob"
+	"github.com/bioflowy/flowy/internal/jsexpr"
+	"github.com/bioflowy/flowy/internal/listing"
+	"github.com/bioflowy/flowy/internal/secondaryfiles"
+)
+
+// collectGlobOutputs builds job's outputs from each OutputBinding's glob
+// patterns against jobDir, for tools that don't write their own
+// cwl.output.json. Every matched File or Directory is published through
+// the same publishOutputFile/publishOutputDirectory path loadCwlOutputJson
+// uses, so uploading, checksums, secondaryFiles, and listing all behave
+// identically regardless of which way an output was collected.
+func collectGlobOutputs(ctx *publishContext, jobDir string, job *api.ExecutableJob) (map[string]interface{}, error) {
+	if len(job.OutputBindings) == 0 {
+		return nil, nil
+	}
+
+	secondaryFilePatterns := secondaryFilePatterns(job.SecondaryFiles)
+	loadListing := loadListingModes(job.LoadListing)
+
+	outputs := make(map[string]interface{}, len(job.OutputBindings))
+	for name, binding := range job.OutputBindings {
+		patterns, err := resolveGlobPatterns(binding.Glob, ctx.inputs, ctx.runtime)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating glob for output %q: %w", name, err)
+		}
+
+		matches, err := glob.Match(jobDir, patterns)
+		if err != nil {
+			return nil, fmt.Errorf("globbing output %q: %w", name, err)
+		}
+
+		if len(matches) == 0 {
+			if binding.Required {
+				return nil, fmt.Errorf("required output %q: no files matched %v", name, binding.Glob)
+			}
+			if binding.Array {
+				outputs[name] = []interface{}{}
+			}
+			continue
+		}
+
+		values := make([]interface{}, 0, len(matches))
+		for _, m := range matches {
+			value, err := publishGlobMatch(ctx, m, secondaryFilePatterns[name], loadListing[name], binding.Format)
+			if err != nil {
+				return nil, fmt.Errorf("publishing output %q: %w", name, err)
+			}
+			values = append(values, value)
+		}
+		if binding.Array {
+			outputs[name] = values
+		} else {
+			outputs[name] = values[0]
+		}
+	}
+	return outputs, nil
+}
+
+// resolveGlobPatterns evaluates each of globs as a CWL/JavaScript
+// expression when it looks like one, in the order given, so a server
+// payload can send glob entries it could not fully resolve itself (e.g. a
+// pattern built from an input parameter). A literal pattern is passed
+// through unchanged; an expression may evaluate to a single string or an
+// array of strings, each of which is kept as its own pattern so multiple
+// patterns' matches still concatenate in the order resolveGlobPatterns
+// returns them.
+func resolveGlobPatterns(globs []string, inputs, runtimeCtx map[string]interface{}) ([]string, error) {
+	var out []string
+	for _, g := range globs {
+		if !strings.Contains(g, "$(") && !strings.Contains(g, "${") {
+			out = append(out, g)
+			continue
+		}
+		v, err := jsexpr.Evaluate(g, jsexpr.Context{Inputs: inputs, Runtime: runtimeCtx})
+		if err != nil {
+			return nil, flowerrors.Eval(g, err)
+		}
+		switch t := v.(type) {
+		case string:
+			out = append(out, t)
+		case []interface{}:
+			for _, item := range t {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("glob expression %q returned a non-string array element %#v", g, item)
+				}
+				out = append(out, s)
+			}
+		default:
+			return nil, fmt.Errorf("glob expression %q returned %#v, want a string or array of strings", g, v)
+		}
+	}
+	return out, nil
+}
+
+// publishGlobMatch publishes one glob-matched path as a File or Directory
+// object, by building the same raw field map streamOutputValue would have
+// decoded from a tool-written cwl.output.json and feeding it through the
+// same publishOutputFile/publishOutputDirectory functions. format, when
+// set, is only applied to File matches (CWL's "format" field doesn't apply
+// to Directory objects).
+func publishGlobMatch(ctx *publishContext, path string, patterns []secondaryfiles.Pattern, listMode listing.Mode, format string) (map[string]interface{}, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return publishOutputDirectory(toRawFields(map[string]interface{}{
+			"class":    "Directory",
+			"path":     path,
+			"basename": filepath.Base(path),
+		}), ctx, listMode)
+	}
+
+	result, err := publishOutputFile(toRawFields(map[string]interface{}{
+		"class":    "File",
+		"path":     path,
+		"basename": filepath.Base(path),
+	}), ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+	if format != "" {
+		resolved, err := resolveOutputFormat(format, result, ctx)
+		if err != nil {
+			return nil, err
+		}
+		result["format"] = resolved
+	}
+	return result, nil
+}
+
+// resolveOutputFormat evaluates format against the just-published File
+// object result (bound as "self") when it looks like a CWL/JavaScript
+// expression, and validates a literal value directly. Either way the
+// resolved value must be a well-formed EDAM ontology IRI.
+func resolveOutputFormat(format string, result map[string]interface{}, ctx *publishContext) (string, error) {
+	if !strings.Contains(format, "$(") && !strings.Contains(format, "${") {
+		if err := edamformat.Validate(format); err != nil {
+			return "", err
+		}
+		return format, nil
+	}
+
+	v, err := jsexpr.Evaluate(format, jsexpr.Context{Self: result, Inputs: ctx.inputs, Runtime: ctx.runtime})
+	if err != nil {
+		return "", flowerrors.Eval(format, err)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", flowerrors.Eval(format, fmt.Errorf("returned %#v, want a string", v))
+	}
+	if err := edamformat.Validate(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// toRawFields marshals each of fields' values individually, mirroring the
+// map[string]json.RawMessage shape streamOutputValue decodes a tool's
+// cwl.output.json entries into.
+func toRawFields(fields map[string]interface{}) map[string]json.RawMessage {
+	raw := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		b, _ := json.Marshal(v)
+		raw[k] = b
+	}
+	return raw
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestCollectGlobOutputsSingleMatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "result.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{"*.txt"}},
+		},
+	}
+	ctx := &publishContext{
+		outdirTarget: job.JobID,
+		upload: func(local, key, checksum string) (string, error) {
+			return "file://" + key, nil
+		},
+	}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, ok := outputs["out"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("outputs[\"out\"] = %#v", outputs["out"])
+	}
+	if file["class"] != "File" || file["basename"] != "result.txt" {
+		t.Fatalf("file = %#v", file)
+	}
+}
+
+func TestCollectGlobOutputsArrayNoMatches(t *testing.T) {
+	dir := t.TempDir()
+
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{"*.missing"}, Array: true},
+		},
+	}
+	ctx := &publishContext{outdirTarget: job.JobID}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr, ok := outputs["out"].([]interface{})
+	if !ok || len(arr) != 0 {
+		t.Fatalf("outputs[\"out\"] = %#v", outputs["out"])
+	}
+}
+
+func TestCollectGlobOutputsRequiredNoMatchesErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{"*.missing"}, Required: true},
+		},
+	}
+	ctx := &publishContext{outdirTarget: job.JobID}
+
+	if _, err := collectGlobOutputs(ctx, dir, job); err == nil {
+		t.Fatal("expected error for required output with no matches")
+	}
+}
+
+func TestCollectGlobOutputsEvaluatesExpressionGlob(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sample.bam"), []byte("bam"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{
+		JobID:  "job-1",
+		Inputs: map[string]interface{}{"name": "sample"},
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{`$(inputs.name).bam`}},
+		},
+	}
+	ctx := &publishContext{
+		outdirTarget: job.JobID,
+		inputs:       job.Inputs,
+		upload: func(local, key, checksum string) (string, error) {
+			return "file://" + key, nil
+		},
+	}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, ok := outputs["out"].(map[string]interface{})
+	if !ok || file["basename"] != "sample.bam" {
+		t.Fatalf("outputs[\"out\"] = %#v", outputs["out"])
+	}
+}
+
+func TestCollectGlobOutputsExpressionArrayConcatenatesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{`${return ["b.txt", "a.txt"]}`}, Array: true},
+		},
+	}
+	ctx := &publishContext{outdirTarget: job.JobID, upload: func(local, key, checksum string) (string, error) {
+		return "file://" + key, nil
+	}}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr, ok := outputs["out"].([]interface{})
+	if !ok || len(arr) != 2 {
+		t.Fatalf("outputs[\"out\"] = %#v", outputs["out"])
+	}
+	first := arr[0].(map[string]interface{})
+	second := arr[1].(map[string]interface{})
+	if first["basename"] != "b.txt" || second["basename"] != "a.txt" {
+		t.Fatalf("order = %v, %v", first["basename"], second["basename"])
+	}
+}
+
+func TestCollectGlobOutputsSetsLiteralFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "result.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{"*.txt"}, Format: "http://edamontology.org/format_1964"},
+		},
+	}
+	ctx := &publishContext{outdirTarget: job.JobID, upload: func(local, key, checksum string) (string, error) {
+		return "file://" + key, nil
+	}}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := outputs["out"].(map[string]interface{})
+	if file["format"] != "http://edamontology.org/format_1964" {
+		t.Fatalf("format = %v", file["format"])
+	}
+}
+
+func TestCollectGlobOutputsRejectsInvalidFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "result.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{"*.txt"}, Format: "text/plain"},
+		},
+	}
+	ctx := &publishContext{outdirTarget: job.JobID, upload: func(local, key, checksum string) (string, error) {
+		return "file://" + key, nil
+	}}
+
+	if _, err := collectGlobOutputs(ctx, dir, job); err == nil {
+		t.Fatal("expected error for non-EDAM format")
+	}
+}
+
+func TestCollectGlobOutputsEvaluatesFormatExpression(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "result.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{
+		JobID:  "job-1",
+		Inputs: map[string]interface{}{"iri": "http://edamontology.org/format_1964"},
+		OutputBindings: map[string]api.OutputBinding{
+			"out": {Glob: []string{"*.txt"}, Format: "$(inputs.iri)"},
+		},
+	}
+	ctx := &publishContext{
+		outdirTarget: job.JobID,
+		inputs:       job.Inputs,
+		upload: func(local, key, checksum string) (string, error) {
+			return "file://" + key, nil
+		},
+	}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := outputs["out"].(map[string]interface{})
+	if file["format"] != "http://edamontology.org/format_1964" {
+		t.Fatalf("format = %v", file["format"])
+	}
+}
+
+func TestCollectGlobOutputsNoBindingsReturnsNil(t *testing.T) {
+	dir := t.TempDir()
+	job := &api.ExecutableJob{JobID: "job-1"}
+	ctx := &publishContext{outdirTarget: job.JobID}
+
+	outputs, err := collectGlobOutputs(ctx, dir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs != nil {
+		t.Fatalf("outputs = %#v, want nil", outputs)
+	}
+}
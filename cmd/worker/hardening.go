@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// HardeningOptions are worker-level container hardening settings, applied
+// to every job regardless of what the tool itself requests, so cluster
+// admins can lock down untrusted CWL tools without trusting each job's
+// payload.
+type HardeningOptions struct {
+	// SELinuxLabel, when set, is appended to every bind mount
+	// ("z" for shared, "Z" for private), e.g. "z".
+	SELinuxLabel string
+	// AppArmorProfile names a profile to pass via --security-opt.
+	AppArmorProfile string
+	// SeccompProfile names a seccomp profile (a path or "unconfined")
+	// to pass via --security-opt.
+	SeccompProfile string
+	// DropCapabilities lists capabilities to drop with --cap-drop, set
+	// from FLOWY_DROP_CAPABILITIES (comma-separated, e.g. "NET_RAW,SYS_PTRACE").
+	DropCapabilities []string
+	// ReadOnlyRootfs enforces --read-only, mounting jobDir's tmp
+	// subdirectory as an explicit writable tmpfs so tools that need
+	// scratch space under /tmp still work.
+	ReadOnlyRootfs bool
+}
+
+// hardening is the worker-wide hardening policy, configured once at
+// startup rather than per job.
+var hardening = loadHardeningFromEnv()
+
+func loadHardeningFromEnv() HardeningOptions {
+	return HardeningOptions{
+		SELinuxLabel:     os.Getenv("FLOWY_SELINUX_LABEL"),
+		AppArmorProfile:  os.Getenv("FLOWY_APPARMOR_PROFILE"),
+		SeccompProfile:   os.Getenv("FLOWY_SECCOMP_PROFILE"),
+		DropCapabilities: splitCapabilities(os.Getenv("FLOWY_DROP_CAPABILITIES")),
+		ReadOnlyRootfs:   os.Getenv("FLOWY_READONLY_ROOTFS") == "1",
+	}
+}
+
+// splitCapabilities parses a comma-separated capability list, ignoring
+// blank entries so trailing/doubled commas don't produce an empty
+// --cap-drop value.
+func splitCapabilities(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var caps []string
+	for _, c := range strings.Split(v, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			caps = append(caps, c)
+		}
+	}
+	return caps
+}
+
+// dockerHardeningArgs renders opts as `docker run` flags.
+func dockerHardeningArgs(opts HardeningOptions) []string {
+	var args []string
+	if opts.AppArmorProfile != "" {
+		args = append(args, "--security-opt", "apparmor="+opts.AppArmorProfile)
+	}
+	if opts.SeccompProfile != "" {
+		args = append(args, "--security-opt", "seccomp="+opts.SeccompProfile)
+	}
+	for _, cap := range opts.DropCapabilities {
+		args = append(args, "--cap-drop", cap)
+	}
+	if opts.ReadOnlyRootfs {
+		args = append(args, "--read-only", "--tmpfs", "/tmp:rw,exec")
+	}
+	return args
+}
+
+// bindMountSuffix returns the ":z"/":Z" suffix to append to a bind mount's
+// docker -v spec, or "" when no SELinux label is configured.
+func bindMountSuffix(opts HardeningOptions) string {
+	if opts.SELinuxLabel == "" {
+		return ""
+	}
+	return ":" + opts.SELinuxLabel
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// heartbeatInterval is how often the worker reports its liveness and
+// current conditions (e.g. disk pressure) to the server, independent of
+// whatever job it is or isn't currently running.
+const heartbeatInterval = 30 * time.Second
+
+// clockSkewNanos holds the most recently measured difference between this
+// host's clock and the server's, as reported in a heartbeat response:
+// positive means this host's clock is ahead. Stored as int64 nanoseconds
+// for lock-free access from any goroutine that needs to correct a
+// locally-taken timestamp against the server's view of time.
+var clockSkewNanos atomic.Int64
+
+// clockSkew returns the most recently measured clock skew against the
+// server. Zero until the first successful heartbeat.
+func clockSkew() time.Duration {
+	return time.Duration(clockSkewNanos.Load())
+}
+
+// serverAdjustedNow returns this host's current time corrected by the
+// most recently measured clockSkew, for code that needs to reason about
+// time the way the server would (e.g. comparing against a
+// server-issued expiry) without round-tripping to it on every call.
+func serverAdjustedNow() time.Time {
+	return time.Now().Add(-clockSkew())
+}
+
+// startHeartbeat posts a WorkerHeartbeatRequest every heartbeatInterval
+// until the process exits. Failure is logged, not fatal, the same
+// best-effort handling reportWorkerStarted already applies to a server
+// that doesn't support this yet.
+func startHeartbeat() {
+	for {
+		reportHeartbeat()
+		time.Sleep(heartbeatInterval)
+	}
+}
+
+func reportHeartbeat() {
+	body, err := json.Marshal(api.WorkerHeartbeatRequest{
+		Name:          workerName,
+		DiskPressure:  diskPressureActive(),
+		BackendHealth: backendHealthReports(),
+		Preemptible:   workerPreemptible,
+	})
+	if err != nil {
+		logger.Warn("encoding heartbeat report", "error", err)
+		return
+	}
+	resp, err := http.Post(serverURL+"/api/worker/heartbeat", "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("reporting heartbeat", "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var heartbeatResp api.WorkerHeartbeatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&heartbeatResp); err != nil {
+		// A server that doesn't yet return ServerTime in its heartbeat
+		// response shouldn't stop the worker from heartbeating.
+		return
+	}
+	recordClockSkew(heartbeatResp.ServerTime)
+}
+
+// backendHealthReports collects the current Health of every instrumented
+// FileManager this worker uses, for inclusion in a heartbeat.
+func backendHealthReports() []api.BackendHealthReport {
+	healths := []internal.BackendHealth{
+		inputFileManagerMetrics.Health(),
+		outputFileManagerMetrics.Health(),
+	}
+	reports := make([]api.BackendHealthReport, 0, len(healths))
+	for _, h := range healths {
+		var lastSuccess int64
+		if !h.LastSuccess.IsZero() {
+			lastSuccess = h.LastSuccess.Unix()
+		}
+		reports = append(reports, api.BackendHealthReport{
+			Backend:     h.Backend,
+			Calls:       h.Calls,
+			Errors:      h.Errors,
+			LastSuccess: lastSuccess,
+			LastError:   h.LastError,
+		})
+	}
+	return reports
+}
+
+// recordClockSkew updates clockSkewNanos from a server-reported Unix
+// timestamp and warns when the measured skew exceeds maxClockSkew, the
+// same threshold doctor's one-time startup check applies.
+func recordClockSkew(serverUnixSeconds int64) {
+	if serverUnixSeconds == 0 {
+		return
+	}
+	skew := time.Since(time.Unix(serverUnixSeconds, 0))
+	clockSkewNanos.Store(int64(skew))
+
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs > maxClockSkew {
+		logger.Warn("clock skew against server exceeds tolerance", "skew", skew, "threshold", maxClockSkew)
+	}
+}
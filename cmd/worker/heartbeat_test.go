@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestReportHeartbeatPostsCurrentDiskPressure(t *testing.T) {
+	var received api.WorkerHeartbeatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(api.WorkerHeartbeatResponse{ServerTime: time.Now().Unix()})
+	}))
+	defer server.Close()
+
+	origAddr := serverURL
+	defer func() { serverURL = origAddr }()
+	serverURL = server.URL
+
+	underDiskPressure.Store(true)
+	defer underDiskPressure.Store(false)
+
+	reportHeartbeat()
+
+	if received.Name != workerName {
+		t.Errorf("received.Name = %q, want %q", received.Name, workerName)
+	}
+	if !received.DiskPressure {
+		t.Error("expected DiskPressure to be reported true")
+	}
+}
+
+func TestRecordClockSkewWarnsOnDrift(t *testing.T) {
+	defer clockSkewNanos.Store(0)
+
+	recordClockSkew(time.Now().Add(-time.Hour).Unix())
+	if skew := clockSkew(); skew < 59*time.Minute || skew > 61*time.Minute {
+		t.Fatalf("clockSkew() = %s, want roughly 1h", skew)
+	}
+}
+
+func TestRecordClockSkewIgnoresZero(t *testing.T) {
+	clockSkewNanos.Store(42)
+	defer clockSkewNanos.Store(0)
+
+	recordClockSkew(0)
+	if got := clockSkewNanos.Load(); got != 42 {
+		t.Fatalf("recordClockSkew(0) changed clockSkewNanos to %d, want unchanged 42", got)
+	}
+}
+
+func TestServerAdjustedNowAppliesSkew(t *testing.T) {
+	defer clockSkewNanos.Store(0)
+	clockSkewNanos.Store(int64(time.Hour))
+
+	adjusted := serverAdjustedNow()
+	if diff := time.Since(adjusted); diff < 59*time.Minute || diff > 61*time.Minute {
+		t.Fatalf("serverAdjustedNow() is %s behind now, want roughly 1h", diff)
+	}
+}
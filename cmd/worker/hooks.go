@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// stagingHooks are external scripts a site admin can configure to run at
+// three points in a job's lifecycle: before staging, after staging, and
+// after output collection, e.g. to warm a cache, register data in an
+// external catalog, or scrub PHI from logs before it's ever written
+// anywhere the worker doesn't control. Each is a path to an executable;
+// a site wanting Go-plugin behavior instead can point one at a small
+// wrapper binary that loads and runs its plugin, since that wrapper looks
+// identical to a script from this package's point of view.
+type stagingHooks struct {
+	PreStage    string
+	PostStage   string
+	PostOutputs string
+}
+
+// hooks is the worker-wide hook configuration, read once at startup like
+// hardening and chaos policy.
+var hooks = stagingHooksFromEnv()
+
+func stagingHooksFromEnv() stagingHooks {
+	return stagingHooks{
+		PreStage:    os.Getenv("FLOWY_PRESTAGE_HOOK"),
+		PostStage:   os.Getenv("FLOWY_POSTSTAGE_HOOK"),
+		PostOutputs: os.Getenv("FLOWY_POSTOUTPUT_HOOK"),
+	}
+}
+
+// hookPayload is what a hook receives as JSON on stdin: the job it's
+// running for and, for the post-output hook, the outputs collected for
+// it.
+type hookPayload struct {
+	Job     *api.ExecutableJob     `json:"job"`
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+}
+
+// runHook runs script (a no-op when empty) with payload marshaled to its
+// stdin. A non-zero exit is mapped to a permanentError, since a failing
+// or misconfigured site hook is an infrastructure problem retrying the
+// job won't fix, with the hook's own stderr folded into the failure
+// reason so an admin can see why it rejected the job.
+func runHook(script string, payload hookPayload) error {
+	if script == "" {
+		return nil
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload for hook %s: %w", script, err)
+	}
+
+	cmd := exec.Command(script)
+	cmd.Stdin = bytes.NewReader(body)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return permanentFail(fmt.Errorf("hook %s failed: %w: %s", script, err, bytes.TrimSpace(stderr.Bytes())))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestRunHookNoopWhenScriptUnset(t *testing.T) {
+	if err := runHook("", hookPayload{Job: &api.ExecutableJob{JobID: "job-1"}}); err != nil {
+		t.Fatalf("expected no error for an unconfigured hook, got %v", err)
+	}
+}
+
+func TestRunHookFeedsPayloadOnStdin(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "hook.sh")
+	out := filepath.Join(t.TempDir(), "captured.json")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > "+out+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runHook(script, hookPayload{Job: &api.ExecutableJob{JobID: "job-1", Tool: "echo"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"jobId":"job-1"`) {
+		t.Fatalf("hook did not receive the job payload on stdin: %s", data)
+	}
+}
+
+func TestRunHookMapsNonZeroExitToPermanentFailure(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'catalog unreachable' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runHook(script, hookPayload{Job: &api.ExecutableJob{JobID: "job-1"}})
+	if err == nil {
+		t.Fatal("expected an error from a failing hook")
+	}
+	var permErr *permanentError
+	if !errors.As(err, &permErr) {
+		t.Fatalf("expected a permanentError, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "catalog unreachable") {
+		t.Fatalf("expected the hook's stderr in the failure reason, got %q", err.Error())
+	}
+}
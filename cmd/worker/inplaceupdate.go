@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// inplaceUpdateDir tracks one InplaceUpdate Directory entry's staged
+// location and its pre-execution snapshot, so syncInplaceUpdateDirs can
+// later diff against it to find what the job's command actually changed.
+type inplaceUpdateDir struct {
+	ent    api.MapperEnt
+	target string
+	before map[string]internal.FileSnapshot
+}
+
+// snapshotInplaceUpdateDirs snapshots every Staged, Writable Directory
+// entry in mapping that has InplaceUpdate set, for syncInplaceUpdateDirs to
+// diff against once the job's command has run. It must be called after
+// stageInputs so the snapshot reflects the directory's staged starting
+// state, not its state at the remote source.
+func snapshotInplaceUpdateDirs(jobDir string, mapping []api.MapperEnt) ([]inplaceUpdateDir, error) {
+	var dirs []inplaceUpdateDir
+	for _, ent := range mapping {
+		if !ent.Staged || !ent.Writable || !ent.InplaceUpdate || ent.Type != "Directory" {
+			continue
+		}
+		target := filepath.Join(jobDir, ent.Target)
+		before, err := internal.SnapshotTree(target)
+		if err != nil {
+			return nil, fmt.Errorf("snapshotting inplace-update directory %q: %w", target, err)
+		}
+		dirs = append(dirs, inplaceUpdateDir{ent: ent, target: target, before: before})
+	}
+	return dirs, nil
+}
+
+// syncInplaceUpdateDirs re-snapshots each of dirs' targets and uploads only
+// the files the job's command added or modified, and removes only the
+// files it deleted, instead of re-uploading an entire large writable
+// directory back to its source for every job that barely touches it.
+func syncInplaceUpdateDirs(fm internal.FileManager, dirs []inplaceUpdateDir) error {
+	for _, dir := range dirs {
+		after, err := internal.SnapshotTree(dir.target)
+		if err != nil {
+			return fmt.Errorf("re-snapshotting inplace-update directory %q: %w", dir.target, err)
+		}
+		changed, removed := internal.DiffSnapshots(dir.before, after)
+		for _, rel := range changed {
+			local := filepath.Join(dir.target, rel)
+			dest := joinResolvedPath(dir.ent.Resolved, rel)
+			if err := fm.Upload(local, dest); err != nil {
+				return fmt.Errorf("syncing changed file %q back to %q: %w", local, dest, err)
+			}
+		}
+		for _, rel := range removed {
+			dest := joinResolvedPath(dir.ent.Resolved, rel)
+			if err := fm.Remove(dest); err != nil {
+				return fmt.Errorf("removing deleted file %q from %q: %w", rel, dest, err)
+			}
+		}
+	}
+	return nil
+}
+
+// joinResolvedPath appends rel onto base, a Resolved location that may be
+// a local path or a URL such as "s3://bucket/key", without letting
+// filepath.Join's Clean mangle a URL's "//" scheme separator.
+func joinResolvedPath(base, rel string) string {
+	return strings.TrimSuffix(base, "/") + "/" + filepath.ToSlash(rel)
+}
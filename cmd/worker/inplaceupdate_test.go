@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestSnapshotInplaceUpdateDirsSkipsEntriesNotMarked(t *testing.T) {
+	jobDir := t.TempDir()
+	for _, name := range []string{"plain", "writable-not-inplace", "inplace"} {
+		if err := os.MkdirAll(filepath.Join(jobDir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mapping := []api.MapperEnt{
+		{Staged: true, Type: "Directory", Target: "plain", Resolved: "/src/plain"},
+		{Staged: true, Type: "Directory", Target: "writable-not-inplace", Writable: true, Resolved: "/src/writable"},
+		{Staged: true, Type: "Directory", Target: "inplace", Writable: true, InplaceUpdate: true, Resolved: "/src/inplace"},
+	}
+
+	dirs, err := snapshotInplaceUpdateDirs(jobDir, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 1 {
+		t.Fatalf("expected exactly one tracked inplace-update directory, got %d", len(dirs))
+	}
+	if dirs[0].ent.Target != "inplace" {
+		t.Fatalf("expected the inplace entry to be tracked, got %+v", dirs[0].ent)
+	}
+}
+
+type recordingFileManager struct {
+	internal.FileManager
+	uploaded []string
+	removed  []string
+}
+
+func (r *recordingFileManager) Upload(local, dst string) error {
+	r.uploaded = append(r.uploaded, dst)
+	return nil
+}
+
+func (r *recordingFileManager) Remove(path string) error {
+	r.removed = append(r.removed, path)
+	return nil
+}
+
+func TestSyncInplaceUpdateDirsUploadsOnlyChangedAndRemovesOnlyDeleted(t *testing.T) {
+	jobDir := t.TempDir()
+	target := filepath.Join(jobDir, "workdir")
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"unchanged.txt", "to-delete.txt"} {
+		if err := os.WriteFile(filepath.Join(target, name), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mapping := []api.MapperEnt{
+		{Staged: true, Type: "Directory", Target: "workdir", Writable: true, InplaceUpdate: true, Resolved: "s3://bucket/workdir"},
+	}
+	dirs, err := snapshotInplaceUpdateDirs(jobDir, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(target, "to-delete.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "new.txt"), []byte("fresh"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fm := &recordingFileManager{}
+	if err := syncInplaceUpdateDirs(fm, dirs); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fm.uploaded) != 1 || fm.uploaded[0] != "s3://bucket/workdir/new.txt" {
+		t.Fatalf("expected only new.txt to be uploaded, got %v", fm.uploaded)
+	}
+	if len(fm.removed) != 1 || fm.removed[0] != "s3://bucket/workdir/to-delete.txt" {
+		t.Fatalf("expected only to-delete.txt to be removed, got %v", fm.removed)
+	}
+}
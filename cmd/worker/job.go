@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/logging"
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// workerName identifies this worker in per-job log fields.
+var workerName = envOr("FLOWY_WORKER_NAME", hostnameOrUnknown())
+
+func hostnameOrUnknown() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
+
+// pollInterval is how often the worker asks the server for new work when
+// its queue is empty.
+const pollInterval = 5 * time.Second
+
+// workerBatchSize controls how many queued jobs run asks for at once via
+// FLOWY_WORKER_BATCH_SIZE. The default, 1, keeps the original one-job-at-
+// a-time polling behavior; a larger value lets run's batch fetch detect
+// fileitems shared across an array/scatter step's shards (see
+// stageSharedInputs) before any of them are staged.
+var workerBatchSize = envIntOr("FLOWY_WORKER_BATCH_SIZE", 1)
+
+func envIntOr(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
+
+// metricsLabelKey names the single job label, if any, that is surfaced as a
+// metrics dimension on JobsSucceededTotal/JobsFailedTotal via
+// FLOWY_METRICS_LABEL_KEY. Jobs carry arbitrary free-form labels (see
+// api.ExecutableJob.Labels), and exposing all of them as Prometheus label
+// dimensions would give every distinct label value its own time series; a
+// site instead opts a single bounded key (e.g. "project") into the metric.
+var metricsLabelKey = os.Getenv("FLOWY_METRICS_LABEL_KEY")
+
+// jobMetricLabels returns the Counter.Inc label arguments for job, or none
+// when metricsLabelKey isn't configured or job doesn't carry that label.
+func jobMetricLabels(job *api.ExecutableJob) []string {
+	if metricsLabelKey == "" {
+		return nil
+	}
+	v, ok := job.Labels[metricsLabelKey]
+	if !ok {
+		return nil
+	}
+	return []string{"label", v}
+}
+
+// run starts the worker's main poll/execute loop against workdir as the
+// root for staged job directories. It returns only on unrecoverable setup
+// errors; job-level failures are reported to the server and do not stop the
+// loop.
+func run(workdir string) error {
+	for {
+		if diskPressureActive() || preemptionActive() {
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		jobs, err := fetchJobs(workdir)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		for _, job := range jobs {
+			runOneJob(workdir, job)
+		}
+	}
+}
+
+// fetchJobs asks the server for work, in single-job or batch form
+// depending on workerBatchSize, and - for a batch - stages any fileitems
+// shared across the returned jobs before the caller executes any of
+// them. A nil (or empty) slice with a nil error means the queue is
+// currently empty.
+func fetchJobs(workdir string) ([]*api.ExecutableJob, error) {
+	if workerBatchSize <= 1 {
+		job, err := fetchNextJob()
+		if err != nil || job == nil {
+			return nil, err
+		}
+		return []*api.ExecutableJob{job}, nil
+	}
+
+	jobs, err := fetchJobBatch(workerBatchSize)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	if err := stageSharedInputs(inputFileManagerMetrics, workdir, jobs); err != nil {
+		logger.Warn("staging shared scatter inputs for batch", "error", err)
+	}
+	return jobs, nil
+}
+
+// runOneJob executes job and reports its outcome to the server, the body
+// of run's per-job work pulled out so fetchJobs can hand it either a
+// single job or one drawn from a batch.
+func runOneJob(workdir string, job *api.ExecutableJob) {
+	jobLogger := logging.WithJob(logger, job.JobID, job.Tool, workerName)
+	jobLogger.Info("job started")
+
+	metrics.JobsRunning.Inc()
+	result, err := executeJob(workdir, job)
+	metrics.JobsRunning.Dec()
+
+	if err != nil {
+		metrics.JobsFailedTotal.Inc(jobMetricLabels(job)...)
+		jobLogger.Error("job failed", "error", err)
+		reportJobFailed(job, err, result)
+		return
+	}
+	metrics.JobsSucceededTotal.Inc(jobMetricLabels(job)...)
+	jobLogger.Info("job finished", "wallSeconds", result.Usage.WallSeconds, "maxRssBytes", result.Usage.MaxRSSBytes, "attempt", result.Attempt)
+	reportJobFinished(job, result)
+}
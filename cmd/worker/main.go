@@ -0,0 +1,151 @@
+// Command worker polls the flowy server for jobs, stages their inputs,
+// executes the tool (in a container or directly on the host), and publishes
+// outputs back to shared storage.
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/logging"
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// logger is the worker's structured logger. Per-job fields are attached
+// with logging.WithJob at the point a job is fetched; library code below
+// main must never call os.Exit or slog's Fatal-equivalents, since a single
+// job's failure must not take the whole worker down.
+var logger = logging.New(slog.LevelInfo, logFormat())
+
+func logFormat() logging.Format {
+	if os.Getenv("FLOWY_LOG_FORMAT") == "json" {
+		return logging.FormatJSON
+	}
+	return logging.FormatText
+}
+
+// defaultLogMaxBytes is how large the worker's own log file (FLOWY_LOG_FILE)
+// is allowed to grow before it's rotated, chosen to hold a few hours of a
+// busy worker's output without needing an external log rotation daemon.
+const defaultLogMaxBytes = 100 << 20 // 100 MiB
+
+// defaultLogMaxBackups is how many rotated copies of the worker's own log
+// file are kept before the oldest is discarded.
+const defaultLogMaxBackups = 5
+
+// configureLogRotation points logger at a rotating file when FLOWY_LOG_FILE
+// is set, leaving the default stderr destination alone otherwise. It's
+// called from main (rather than during logger's own package-level
+// initialization) so a failure to open the file can be reported through
+// the stderr logger already in place instead of being silently swallowed.
+func configureLogRotation() {
+	path := os.Getenv("FLOWY_LOG_FILE")
+	if path == "" {
+		return
+	}
+	maxBytes := int64(defaultLogMaxBytes)
+	if v := os.Getenv("FLOWY_LOG_MAX_BYTES"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			maxBytes = parsed
+		} else {
+			logger.Warn("invalid FLOWY_LOG_MAX_BYTES, using default", "value", v, "default", defaultLogMaxBytes)
+		}
+	}
+	maxBackups := defaultLogMaxBackups
+	if v := os.Getenv("FLOWY_LOG_MAX_BACKUPS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			maxBackups = parsed
+		} else {
+			logger.Warn("invalid FLOWY_LOG_MAX_BACKUPS, using default", "value", v, "default", defaultLogMaxBackups)
+		}
+	}
+
+	rf, err := logging.NewRotatingFile(path, maxBytes, maxBackups)
+	if err != nil {
+		logger.Error("opening log file, continuing to log to stderr", "path", path, "error", err)
+		return
+	}
+	logger = logging.NewWithWriter(slog.LevelInfo, logFormat(), rf)
+}
+
+func main() {
+	configureLogRotation()
+
+	// `flowy-worker doctor` is a one-shot diagnostic run, not the daemon;
+	// dispatch it before the daemon's own flag set is defined so its
+	// flags (e.g. -workdir) don't collide with this one's.
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		if err := runDoctor(os.Args[2:]); err != nil {
+			logger.Error("doctor", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			logger.Error("replay", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	workdir := flag.String("workdir", "/var/lib/flowy/work", "root directory for staged job workdirs")
+	metricsAddr := flag.String("metrics-addr", ":9090", "address the /metrics endpoint listens on")
+	leaveOutputs := flag.Bool("leave-outputs", false, "never remove a job's workdir, even on success")
+	leaveTmpdir := flag.Bool("leave-tmpdir", false, "never remove a job's tmpdir, even on success")
+	queues := flag.String("queues", "", "comma-separated queue classes this worker serves (overrides FLOWY_WORKER_QUEUES; empty means the default queue only)")
+	recordJobs := flag.String("record-jobs", "", "directory to dump every fetched job payload and its resulting report to, for later `flowy-worker replay`")
+	flag.Parse()
+
+	recordDir = *recordJobs
+
+	if *queues != "" {
+		workerQueues = splitQueues(*queues)
+	}
+
+	if err := configureCleanupPolicies(*leaveOutputs, *leaveTmpdir); err != nil {
+		logger.Error("configuring cleanup policies", "error", err)
+		os.Exit(1)
+	}
+
+	if signedPayloads {
+		if err := registerWorker(); err != nil {
+			logger.Error("registering with server for signed job payloads", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	reportTotalMemory()
+	checkDockerDesktopSharing(*workdir)
+	reportWorkerStarted()
+
+	go startDiskWatcher(*workdir)
+	go startHeartbeat()
+	go startPreemptionWatcher()
+
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		mux.Handle("/debug/plan", planHandler(*workdir))
+		if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+			logger.Error("metrics listener exited", "addr", *metricsAddr, "error", err)
+		}
+	}()
+
+	// Artifacts left behind by a crash mid-staging or mid-publication carry
+	// an IncompleteMarker and must never be mistaken for valid data by this
+	// or any later run.
+	if err := internal.CleanupIncomplete(*workdir); err != nil {
+		logger.Error("cleaning up incomplete artifacts", "workdir", *workdir, "error", err)
+		os.Exit(1)
+	}
+
+	if err := run(*workdir); err != nil {
+		logger.Error("worker exiting", "error", err)
+		os.Exit(1)
+	}
+}
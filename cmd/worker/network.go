@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// networkAllowlist restricts network-enabled jobs to a configured set of
+// hosts/CIDRs, set via FLOWY_NETWORK_ALLOWLIST (comma-separated). An empty
+// allowlist means no restriction beyond CWL's own Networkaccess flag.
+var networkAllowlist = parseAllowlist(os.Getenv("FLOWY_NETWORK_ALLOWLIST"))
+
+func parseAllowlist(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var entries []string
+	for _, e := range strings.Split(v, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// dockerNetworkArgs returns the `docker run` network flags for a job:
+// "none" when the tool did not request network access, the default bridge
+// network otherwise. Allowlist enforcement for containerized jobs requires
+// iptables rules keyed off the container's assigned IP and is out of scope
+// here; configure it at the docker network/firewall layer instead.
+func dockerNetworkArgs(networkaccess bool) []string {
+	if !networkaccess {
+		return []string{"--network", "none"}
+	}
+	return nil
+}
+
+// Fixed identifiers for the namespace/veth pair set up for allowlist-
+// restricted jobs. These are reused rather than generated per job: the
+// worker only ever runs one non-container job at a time (see job.go's run
+// loop), and allowlistNamespaceScript always tears down and recreates
+// them from scratch, so there's no collision risk in reusing the names
+// across successive jobs.
+const (
+	allowlistNamespace = "flowy-job-net"
+	allowlistHostVeth  = "flowy-veth-h"
+	allowlistNsVeth    = "flowy-veth-n"
+	allowlistHostIP    = "169.254.100.1"
+	allowlistNsIP      = "169.254.100.2"
+	allowlistLinkBits  = "30"
+)
+
+// nonContainerNetworkCommand wraps command so it runs with no container
+// runtime involved, honoring the job's requested network access: no
+// network interfaces at all besides loopback when the job did not request
+// access, or - when it did, and FLOWY_NETWORK_ALLOWLIST is configured -
+// inside a dedicated network namespace whose egress is restricted to
+// networkAllowlist's resolved addresses. The returned command line is
+// self-contained: it sets up whatever namespace/veth/iptables state it
+// needs before running command and tears it down again once command
+// exits, so callers (including plan.go's dry-run preview, which never
+// actually runs the result) don't need a separate teardown step.
+func nonContainerNetworkCommand(networkaccess bool, command []string) ([]string, error) {
+	if networkaccess && len(networkAllowlist) == 0 {
+		return command, nil
+	}
+	if _, err := os.Stat("/usr/bin/unshare"); err != nil {
+		return nil, fmt.Errorf("network sandboxing requires unshare(1): %w", err)
+	}
+	if !networkaccess {
+		wrapper := []string{"unshare", "--net", "--", "sh", "-c", "ip link set lo up && exec \"$@\"", "sh"}
+		return append(wrapper, command...), nil
+	}
+
+	if _, err := exec.LookPath("ip"); err != nil {
+		return nil, fmt.Errorf("restricted network access requires ip(8): %w", err)
+	}
+	if _, err := exec.LookPath("iptables"); err != nil {
+		return nil, fmt.Errorf("restricted network access requires iptables(8): %w", err)
+	}
+	script := allowlistNamespaceScript()
+	wrapper := []string{"sh", "-c", script, "sh"}
+	return append(wrapper, command...), nil
+}
+
+// allowlistNamespaceScript renders a shell script that gives an
+// allowlist-restricted job real (if limited) connectivity, unlike a bare
+// `unshare --net`, which leaves its namespace with no route out at all:
+// it creates a fresh network namespace wired to the host over a
+// point-to-point veth pair, NATs the namespace's traffic out through the
+// host, restricts its egress via iptables to networkAllowlist's resolved
+// addresses (dropping and logging everything else), runs "$@" inside it,
+// and removes all of that again once "$@" exits.
+//
+// The script forwards SIGTERM/SIGINT to the job process so the worker's
+// own timeout and cancellation handling still reaches it. A SIGKILL sent
+// to this script's own process (e.g. past the worker's SIGTERM grace
+// period) can't be trapped and would leave the job process running as an
+// orphan until it exits on its own - the same risk any wrapper shell that
+// isn't the job's direct parent via exec carries.
+func allowlistNamespaceScript() string {
+	nsHostOnly := allowlistNsIP + "/32"
+
+	var steps []string
+	steps = append(steps,
+		"ip netns delete "+allowlistNamespace+" 2>/dev/null",
+		"iptables -t nat -D POSTROUTING -s "+nsHostOnly+" -j MASQUERADE 2>/dev/null",
+		"set -e",
+		"ip netns add "+allowlistNamespace,
+		"ip link add "+allowlistHostVeth+" type veth peer name "+allowlistNsVeth,
+		"ip link set "+allowlistNsVeth+" netns "+allowlistNamespace,
+		"ip addr add "+allowlistHostIP+"/"+allowlistLinkBits+" dev "+allowlistHostVeth,
+		"ip link set "+allowlistHostVeth+" up",
+		"ip netns exec "+allowlistNamespace+" ip addr add "+allowlistNsIP+"/"+allowlistLinkBits+" dev "+allowlistNsVeth,
+		"ip netns exec "+allowlistNamespace+" ip link set "+allowlistNsVeth+" up",
+		"ip netns exec "+allowlistNamespace+" ip link set lo up",
+		"ip netns exec "+allowlistNamespace+" ip route add default via "+allowlistHostIP,
+		"iptables -t nat -A POSTROUTING -s "+nsHostOnly+" -j MASQUERADE",
+	)
+	for _, rule := range allowlistIptablesRules() {
+		steps = append(steps, "ip netns exec "+allowlistNamespace+" "+rule)
+	}
+	steps = append(steps,
+		`trap 'kill -TERM "$child" 2>/dev/null' TERM INT`,
+		"ip netns exec "+allowlistNamespace+` "$@" &`,
+		`child=$!`,
+		`wait "$child"`,
+		`code=$?`,
+		"ip netns delete "+allowlistNamespace,
+		"iptables -t nat -D POSTROUTING -s "+nsHostOnly+" -j MASQUERADE",
+		`exit $code`,
+	)
+	return strings.Join(steps, "\n")
+}
+
+// allowlistIptablesRules renders the iptables rules that drop all
+// outbound traffic except to networkAllowlist's resolved addresses,
+// logging every dropped packet to the kernel log so denied destinations
+// show up in dmesg/journalctl for operators to investigate.
+func allowlistIptablesRules() []string {
+	var rules []string
+	for _, entry := range networkAllowlist {
+		for _, cidr := range resolveAllowlistEntry(entry) {
+			rules = append(rules, fmt.Sprintf("iptables -A OUTPUT -d %s -j ACCEPT", cidr))
+		}
+	}
+	rules = append(rules,
+		"iptables -A OUTPUT -j LOG --log-prefix 'flowy-network-denied: '",
+		"iptables -A OUTPUT -j DROP",
+	)
+	return rules
+}
+
+// resolveAllowlistEntry expands a single FLOWY_NETWORK_ALLOWLIST entry into
+// one or more CIDRs suitable for an iptables rule. Entries that are already
+// a CIDR pass through unchanged; bare hostnames are resolved via DNS, with
+// every returned address allowed since a worker has no way to know ahead of
+// time which one the job will actually connect to. Resolution failures are
+// logged and the entry is skipped rather than failing the job outright.
+func resolveAllowlistEntry(entry string) []string {
+	if _, _, err := net.ParseCIDR(entry); err == nil {
+		return []string{entry}
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		return []string{entry}
+	}
+	ips, err := net.LookupHost(entry)
+	if err != nil {
+		logger.Warn("could not resolve network allowlist entry, it will be denied", "host", entry, "error", err)
+		return nil
+	}
+	return ips
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAllowlistSplitsAndTrims(t *testing.T) {
+	got := parseAllowlist(" example.com ,10.0.0.0/8,, internal.svc ")
+	want := []string{"example.com", "10.0.0.0/8", "internal.svc"}
+	if len(got) != len(want) {
+		t.Fatalf("parseAllowlist() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("parseAllowlist() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseAllowlistEmpty(t *testing.T) {
+	if got := parseAllowlist(""); got != nil {
+		t.Fatalf("parseAllowlist(\"\") = %v, want nil", got)
+	}
+}
+
+func TestDockerNetworkArgsDeniesByDefault(t *testing.T) {
+	got := dockerNetworkArgs(false)
+	if len(got) != 2 || got[0] != "--network" || got[1] != "none" {
+		t.Fatalf("dockerNetworkArgs(false) = %v, want [--network none]", got)
+	}
+}
+
+func TestDockerNetworkArgsAllowsDefaultBridge(t *testing.T) {
+	if got := dockerNetworkArgs(true); got != nil {
+		t.Fatalf("dockerNetworkArgs(true) = %v, want nil", got)
+	}
+}
+
+func TestResolveAllowlistEntryPassesThroughCIDR(t *testing.T) {
+	got := resolveAllowlistEntry("10.0.0.0/8")
+	if len(got) != 1 || got[0] != "10.0.0.0/8" {
+		t.Fatalf("resolveAllowlistEntry(CIDR) = %v, want [10.0.0.0/8]", got)
+	}
+}
+
+func TestResolveAllowlistEntryPassesThroughIP(t *testing.T) {
+	got := resolveAllowlistEntry("192.0.2.1")
+	if len(got) != 1 || got[0] != "192.0.2.1" {
+		t.Fatalf("resolveAllowlistEntry(IP) = %v, want [192.0.2.1]", got)
+	}
+}
+
+func TestResolveAllowlistEntrySkipsUnresolvableHost(t *testing.T) {
+	got := resolveAllowlistEntry("this-host-does-not-resolve.invalid")
+	if got != nil {
+		t.Fatalf("resolveAllowlistEntry(unresolvable) = %v, want nil", got)
+	}
+}
+
+func TestAllowlistIptablesRulesAcceptsEveryResolvedCIDR(t *testing.T) {
+	old := networkAllowlist
+	defer func() { networkAllowlist = old }()
+	networkAllowlist = []string{"10.0.0.0/8", "192.0.2.5"}
+
+	rules := allowlistIptablesRules()
+	if !containsRule(rules, "iptables -A OUTPUT -d 10.0.0.0/8 -j ACCEPT") {
+		t.Fatalf("expected an ACCEPT rule for 10.0.0.0/8, got %v", rules)
+	}
+	if !containsRule(rules, "iptables -A OUTPUT -d 192.0.2.5 -j ACCEPT") {
+		t.Fatalf("expected an ACCEPT rule for 192.0.2.5, got %v", rules)
+	}
+	if rules[len(rules)-1] != "iptables -A OUTPUT -j DROP" {
+		t.Fatalf("expected the final rule to drop everything else, got %v", rules)
+	}
+}
+
+func containsRule(rules []string, want string) bool {
+	for _, r := range rules {
+		if r == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNonContainerNetworkCommandPassesThroughUnrestrictedAccess(t *testing.T) {
+	old := networkAllowlist
+	defer func() { networkAllowlist = old }()
+	networkAllowlist = nil
+
+	command := []string{"echo", "hi"}
+	got, err := nonContainerNetworkCommand(true, command)
+	if err != nil {
+		t.Fatalf("nonContainerNetworkCommand: %v", err)
+	}
+	if len(got) != len(command) || got[0] != "echo" {
+		t.Fatalf("nonContainerNetworkCommand(true, no allowlist) = %v, want command passed through unchanged", got)
+	}
+}
+
+func TestAllowlistNamespaceScriptWiresNamespaceToTheHost(t *testing.T) {
+	old := networkAllowlist
+	defer func() { networkAllowlist = old }()
+	networkAllowlist = []string{"192.0.2.5"}
+
+	script := allowlistNamespaceScript()
+
+	for _, want := range []string{
+		"ip netns add " + allowlistNamespace,
+		"ip link add " + allowlistHostVeth + " type veth peer name " + allowlistNsVeth,
+		"ip route add default via " + allowlistHostIP,
+		"iptables -t nat -A POSTROUTING -s " + allowlistNsIP + "/32 -j MASQUERADE",
+		"iptables -A OUTPUT -d 192.0.2.5 -j ACCEPT",
+		"ip netns delete " + allowlistNamespace,
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("allowlistNamespaceScript() missing %q in:\n%s", want, script)
+		}
+	}
+}
+
+func TestAllowlistNamespaceScriptForwardsSignalsToTheJob(t *testing.T) {
+	script := allowlistNamespaceScript()
+	if !strings.Contains(script, `trap 'kill -TERM "$child" 2>/dev/null' TERM INT`) {
+		t.Fatalf("allowlistNamespaceScript() does not forward TERM/INT to the job process:\n%s", script)
+	}
+	if !strings.Contains(script, `wait "$child"`) {
+		t.Fatalf("allowlistNamespaceScript() does not wait for the job process before tearing down:\n%s", script)
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bioflowy/flowy/internal"
+	flowerrors "github.com/bioflowy/flowy/internal/errors"
+	"github.com/bioflowy/flowy/internal/listing"
+	"github.com/bioflowy/flowy/internal/outputschema"
+	"github.com/bioflowy/flowy/internal/secondaryfiles"
+)
+
+// publishContext carries the state loadCwlOutputJson's helpers need but
+// that stays the same across an entire output value's decode, so adding
+// one doesn't mean growing every function's parameter list again.
+type publishContext struct {
+	outdirTarget string
+	inputs       map[string]interface{}
+	runtime      map[string]interface{}
+	// upload uploads local to key, and, when the destination backend
+	// supports it, deduplicates by checksum (e.g. "sha1$<hex>").
+	upload func(local, key, checksum string) (string, error)
+}
+
+// loadCwlOutputJson reads the cwl.output.json a tool wrote at path,
+// uploading every File it references and rewriting each one's location and
+// checksum in place. Each top-level output value is streamed with a
+// json.Decoder token reader rather than fully unmarshalled, so a tool that
+// emits hundreds of thousands of File entries in one array does not require
+// holding them all in memory at once. secondaryFilePatterns and loadListing
+// supply the secondaryFiles patterns and LoadListingRequirement mode (if
+// any) declared for each top-level output name; both are applied to every
+// File or Directory collected for that output, including each element of
+// an array-typed output. When schema is non-empty, each named output's
+// value (after upload) is validated and coerced against its declared type;
+// a mismatch is returned wrapped in permanentFail, since retrying the same
+// tool invocation cannot fix a malformed cwl.output.json.
+func loadCwlOutputJson(path, outdirTarget string, secondaryFilePatterns map[string][]secondaryfiles.Pattern, loadListing map[string]listing.Mode, schema map[string]outputschema.Type, inputs, runtimeCtx map[string]interface{}, upload func(local, key, checksum string) (string, error)) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, flowerrors.OutputCollection("", fmt.Errorf("%s is not a JSON object", path))
+	}
+
+	ctx := &publishContext{outdirTarget: outdirTarget, inputs: inputs, runtime: runtimeCtx, upload: upload}
+
+	result := make(map[string]interface{})
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := keyTok.(string)
+
+		value, err := streamOutputValue(dec, ctx, secondaryFilePatterns[key], loadListing[key])
+		if err != nil {
+			return nil, flowerrors.OutputCollection(key, err)
+		}
+		result[key] = value
+	}
+
+	for name, t := range schema {
+		coerced, err := outputschema.Validate(result[name], t, name)
+		if err != nil {
+			return nil, permanentFail(flowerrors.OutputCollection(name, err))
+		}
+		result[name] = coerced
+	}
+	return result, nil
+}
+
+// streamOutputValue decodes one output value from dec. Arrays are streamed
+// element-by-element so a huge array of File entries never lives in memory
+// as a whole; every other shape is small enough to decode directly.
+// patterns and listMode carry down into array elements unchanged, since
+// CWL applies an output's secondaryFiles patterns and loadListing mode to
+// every element of an array-typed output.
+func streamOutputValue(dec *json.Decoder, ctx *publishContext, patterns []secondaryfiles.Pattern, listMode listing.Mode) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := tok.(json.Delim); ok && delim == '[' {
+		var items []interface{}
+		for dec.More() {
+			item, err := streamOutputValue(dec, ctx, patterns, listMode)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if _, err := dec.Token(); err != nil { // consume ']'
+			return nil, err
+		}
+		return items, nil
+	}
+
+	if delim, ok := tok.(json.Delim); ok && delim == '{' {
+		raw := map[string]json.RawMessage{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			var rawVal json.RawMessage
+			if err := dec.Decode(&rawVal); err != nil {
+				return nil, err
+			}
+			raw[key] = rawVal
+		}
+		if _, err := dec.Token(); err != nil { // consume '}'
+			return nil, err
+		}
+
+		var classMsg string
+		json.Unmarshal(raw["class"], &classMsg)
+		switch classMsg {
+		case "File":
+			return publishOutputFile(raw, ctx, patterns)
+		case "Directory":
+			return publishOutputDirectory(raw, ctx, listMode)
+		}
+		out := make(map[string]interface{}, len(raw))
+		for k, v := range raw {
+			var decoded interface{}
+			json.Unmarshal(v, &decoded)
+			out[k] = decoded
+		}
+		return out, nil
+	}
+
+	return tok, nil
+}
+
+// publishOutputFile uploads the file described by raw (a decoded File
+// object's fields) to ctx.outdirTarget, computes its checksum by streaming
+// the upload rather than buffering the whole file, and returns the
+// rewritten File object. When patterns is non-empty, its secondaryFiles are
+// collected from the local filesystem first (see internal/secondaryfiles)
+// and published the same way, nested under the "secondaryFiles" key.
+func publishOutputFile(raw map[string]json.RawMessage, ctx *publishContext, patterns []secondaryfiles.Pattern) (map[string]interface{}, error) {
+	var localPath, basename string
+	json.Unmarshal(raw["path"], &localPath)
+	json.Unmarshal(raw["basename"], &basename)
+	if basename == "" {
+		basename = filepath.Base(localPath)
+	}
+	basename = internal.SanitizeBasename(basename)
+
+	checksum, size, err := sha1File(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := filepath.Join(ctx.outdirTarget, basename)
+	location, err := ctx.upload(localPath, key, "sha1$"+checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"class":    "File",
+		"location": location,
+		"basename": basename,
+		"checksum": "sha1$" + checksum,
+		"size":     size,
+	}
+
+	if len(patterns) > 0 {
+		entries, err := secondaryfiles.Collect(localPath, patterns, ctx.inputs, ctx.runtime)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			secondary := make([]interface{}, 0, len(entries))
+			for _, entry := range entries {
+				obj, err := publishSecondaryEntry(entry, ctx)
+				if err != nil {
+					return nil, err
+				}
+				secondary = append(secondary, obj)
+			}
+			result["secondaryFiles"] = secondary
+		}
+	}
+
+	return result, nil
+}
+
+// publishOutputDirectory rewrites raw (a decoded Directory object's fields)
+// as-is, except for its listing. A Directory the tool wrote with its own
+// "listing" (including a Directory literal with no backing "path" at all)
+// has every File entry in that listing published through publishOutputFile,
+// the same as a top-level File output, so nested Files are uploaded and get
+// a real location and checksum rather than passing through with the tool's
+// local path; nested Directory entries recurse the same way. A Directory
+// with no "listing" of its own has one filled in by walking its local path,
+// when listMode isn't NoListing. Other than a published listing, Directory
+// contents are not otherwise uploaded by this worker (a separate, larger
+// piece of work), so entries discovered by the walk are reported by their
+// local path rather than a location under outdirTarget.
+func publishOutputDirectory(raw map[string]json.RawMessage, ctx *publishContext, listMode listing.Mode) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(raw)+1)
+	for k, v := range raw {
+		if k == "listing" {
+			continue
+		}
+		var decoded interface{}
+		json.Unmarshal(v, &decoded)
+		out[k] = decoded
+	}
+
+	if rawListing, hasListing := raw["listing"]; hasListing {
+		published, err := publishOutputListing(rawListing, ctx)
+		if err != nil {
+			return nil, err
+		}
+		out["listing"] = published
+		return out, nil
+	}
+	if listMode == "" || listMode == listing.NoListing {
+		return out, nil
+	}
+
+	var path string
+	json.Unmarshal(raw["path"], &path)
+	if path == "" {
+		return out, nil
+	}
+
+	entries, err := listing.Collect(path, listMode, 0)
+	if err != nil {
+		return nil, err
+	}
+	out["listing"] = listingEntriesToOutputs(entries)
+	if reportDirectorySize {
+		out["flowy:size"] = directorySize(entries)
+	}
+	return out, nil
+}
+
+// publishOutputListing publishes a Directory's own "listing" array, which a
+// tool can populate directly in cwl.output.json (most notably for a
+// Directory literal, whose nested Files have no backing directory on disk
+// for publishOutputDirectory to walk). Each File entry is uploaded through
+// publishOutputFile and each Directory entry recurses through
+// publishOutputDirectory; secondaryFiles patterns are not applied, matching
+// CWL's secondaryFiles, which only attaches to top-level output parameters.
+func publishOutputListing(raw json.RawMessage, ctx *publishContext) ([]interface{}, error) {
+	var rawItems []json.RawMessage
+	if err := json.Unmarshal(raw, &rawItems); err != nil {
+		return nil, err
+	}
+
+	out := make([]interface{}, 0, len(rawItems))
+	for _, item := range rawItems {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(item, &fields); err != nil {
+			return nil, err
+		}
+		var class string
+		json.Unmarshal(fields["class"], &class)
+
+		switch class {
+		case "File":
+			obj, err := publishOutputFile(fields, ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, obj)
+		case "Directory":
+			obj, err := publishOutputDirectory(fields, ctx, listing.NoListing)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, obj)
+		default:
+			var decoded interface{}
+			json.Unmarshal(item, &decoded)
+			out = append(out, decoded)
+		}
+	}
+	return out, nil
+}
+
+// reportDirectorySize controls whether publishOutputDirectory computes and
+// attaches an aggregate "flowy:size" extension field. CWL's Directory class
+// has no size of its own, and walking the whole listing to sum one up is
+// wasted work for callers that don't need it, so it is opt-in.
+var reportDirectorySize = envOr("FLOWY_REPORT_DIRECTORY_SIZE", "") != ""
+
+// directorySize sums the sizes of every File entry under entries,
+// recursing into nested Directory entries. It reuses the sizes listing.Collect
+// already gathered rather than re-statting the filesystem.
+func directorySize(entries []listing.Entry) int64 {
+	var total int64
+	for _, e := range entries {
+		if e.Class == "File" {
+			total += e.Size
+			continue
+		}
+		total += directorySize(e.Listing)
+	}
+	return total
+}
+
+// listingEntriesToOutputs renders listing.Entry values as the CWL
+// File/Directory objects a Directory's "listing" field is made of.
+func listingEntriesToOutputs(entries []listing.Entry) []interface{} {
+	out := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		obj := map[string]interface{}{
+			"class":    e.Class,
+			"path":     e.Path,
+			"basename": e.Basename,
+		}
+		if e.Class == "File" {
+			obj["size"] = e.Size
+		}
+		if e.Listing != nil {
+			obj["listing"] = listingEntriesToOutputs(e.Listing)
+		}
+		out = append(out, obj)
+	}
+	return out
+}
+
+// publishSecondaryEntry publishes one secondaryfiles.Entry the same way
+// publishOutputFile publishes a primary File. Secondary directories are
+// reported by their local path rather than uploaded: unlike File outputs,
+// Directory outputs are not currently uploaded as a whole by this worker
+// (see publishOutputDirectory), so there is nowhere to upload a secondary
+// directory to either.
+func publishSecondaryEntry(entry secondaryfiles.Entry, ctx *publishContext) (map[string]interface{}, error) {
+	basename := internal.SanitizeBasename(entry.Basename)
+
+	if entry.IsDir {
+		return map[string]interface{}{
+			"class":    "Directory",
+			"location": entry.Path,
+			"basename": basename,
+		}, nil
+	}
+
+	checksum, size, err := sha1File(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := filepath.Join(ctx.outdirTarget, basename)
+	location, err := ctx.upload(entry.Path, key, "sha1$"+checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"class":    "File",
+		"location": location,
+		"basename": basename,
+		"checksum": "sha1$" + checksum,
+		"size":     size,
+	}
+
+	if len(entry.SecondaryFiles) > 0 {
+		nested := make([]interface{}, 0, len(entry.SecondaryFiles))
+		for _, e := range entry.SecondaryFiles {
+			obj, err := publishSecondaryEntry(e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			nested = append(nested, obj)
+		}
+		result["secondaryFiles"] = nested
+	}
+
+	return result, nil
+}
+
+// sha1File streams path through a SHA-1 hash without holding its contents
+// in memory, matching the CWL checksum format ("sha1$<hex>").
+func sha1File(path string) (checksum string, size int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/listing"
+	"github.com/bioflowy/flowy/internal/outputschema"
+	"github.com/bioflowy/flowy/internal/secondaryfiles"
+)
+
+func TestLoadCwlOutputJsonStreamsFileArray(t *testing.T) {
+	dir := t.TempDir()
+	f1 := filepath.Join(dir, "a.txt")
+	f2 := filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(f1, []byte("aaa"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f2, []byte("bbbb"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	content := `{"files": [` +
+		`{"class":"File","path":"` + f1 + `","basename":"a.txt"},` +
+		`{"class":"File","path":"` + f2 + `","basename":"b.txt"}` +
+		`], "count": 2}`
+	if err := os.WriteFile(outputJson, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var uploaded []string
+	result, err := loadCwlOutputJson(outputJson, "job-1", nil, nil, nil, nil, nil, func(local, key, checksum string) (string, error) {
+		uploaded = append(uploaded, local)
+		return "file://" + key, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uploaded) != 2 {
+		t.Fatalf("uploaded %d files, want 2", len(uploaded))
+	}
+	files, ok := result["files"].([]interface{})
+	if !ok || len(files) != 2 {
+		t.Fatalf("result[\"files\"] = %#v", result["files"])
+	}
+	first := files[0].(map[string]interface{})
+	if first["checksum"] != "sha1$7e240de74fb1ed08fa08d38063f6a6a91462a815" {
+		t.Fatalf("checksum = %v", first["checksum"])
+	}
+	if result["count"] != float64(2) {
+		t.Fatalf("count = %v", result["count"])
+	}
+}
+
+func TestLoadCwlOutputJsonPublishesSecondaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "aligned.bam")
+	if err := os.WriteFile(primary, []byte("bam"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(primary+".bai", []byte("bai"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	content := `{"bam":{"class":"File","path":"` + primary + `","basename":"aligned.bam"}}`
+	if err := os.WriteFile(outputJson, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := map[string][]secondaryfiles.Pattern{
+		"bam": {{Expr: ".bai", Required: true}},
+	}
+	var uploaded []string
+	result, err := loadCwlOutputJson(outputJson, "job-1", patterns, nil, nil, nil, nil, func(local, key, checksum string) (string, error) {
+		uploaded = append(uploaded, local)
+		return "file://" + key, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 2 {
+		t.Fatalf("uploaded %d files, want 2", len(uploaded))
+	}
+
+	bam := result["bam"].(map[string]interface{})
+	secondary, ok := bam["secondaryFiles"].([]interface{})
+	if !ok || len(secondary) != 1 {
+		t.Fatalf("bam[\"secondaryFiles\"] = %#v", bam["secondaryFiles"])
+	}
+	bai := secondary[0].(map[string]interface{})
+	if bai["basename"] != "aligned.bam.bai" {
+		t.Fatalf("bai = %+v", bai)
+	}
+}
+
+func TestLoadCwlOutputJsonCoercesIntSchema(t *testing.T) {
+	dir := t.TempDir()
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	if err := os.WriteFile(outputJson, []byte(`{"count": 3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := map[string]outputschema.Type{"count": {Name: "int"}}
+	result, err := loadCwlOutputJson(outputJson, "job-1", nil, nil, schema, nil, nil, func(local, key, checksum string) (string, error) {
+		t.Fatal("no files to upload")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["count"] != int64(3) {
+		t.Fatalf("count = %#v, want int64(3)", result["count"])
+	}
+}
+
+func TestLoadCwlOutputJsonSchemaMismatchIsPermanentFail(t *testing.T) {
+	dir := t.TempDir()
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	if err := os.WriteFile(outputJson, []byte(`{"count": "not-a-number"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := map[string]outputschema.Type{"count": {Name: "int"}}
+	_, err := loadCwlOutputJson(outputJson, "job-1", nil, nil, schema, nil, nil, func(local, key, checksum string) (string, error) {
+		t.Fatal("no files to upload")
+		return "", nil
+	})
+	if err == nil {
+		t.Fatal("expected schema validation error")
+	}
+	var permErr *permanentError
+	if !errors.As(err, &permErr) {
+		t.Fatalf("error %v is not a permanentError", err)
+	}
+}
+
+func TestLoadCwlOutputJsonFillsDeepListing(t *testing.T) {
+	dir := t.TempDir()
+	resultsDir := filepath.Join(dir, "results")
+	sub := filepath.Join(resultsDir, "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "nested.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	content := `{"outdir":{"class":"Directory","path":"` + resultsDir + `","basename":"results"}}`
+	if err := os.WriteFile(outputJson, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadListing := map[string]listing.Mode{"outdir": listing.DeepListing}
+	result, err := loadCwlOutputJson(outputJson, "job-1", nil, loadListing, nil, nil, nil, func(local, key, checksum string) (string, error) {
+		t.Fatal("directory outputs should not be uploaded")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := result["outdir"].(map[string]interface{})
+	topLevel, ok := outdir["listing"].([]interface{})
+	if !ok || len(topLevel) != 1 {
+		t.Fatalf("outdir[\"listing\"] = %#v", outdir["listing"])
+	}
+	subEntry := topLevel[0].(map[string]interface{})
+	if subEntry["class"] != "Directory" || subEntry["basename"] != "sub" {
+		t.Fatalf("subEntry = %+v", subEntry)
+	}
+	nested, ok := subEntry["listing"].([]interface{})
+	if !ok || len(nested) != 1 {
+		t.Fatalf("subEntry[\"listing\"] = %#v", subEntry["listing"])
+	}
+	if nested[0].(map[string]interface{})["basename"] != "nested.txt" {
+		t.Fatalf("nested = %+v", nested[0])
+	}
+}
+
+func TestDirectorySizeSumsNestedFiles(t *testing.T) {
+	entries := []listing.Entry{
+		{Class: "File", Size: 10},
+		{Class: "Directory", Listing: []listing.Entry{
+			{Class: "File", Size: 5},
+			{Class: "File", Size: 7},
+		}},
+	}
+	if got, want := directorySize(entries), int64(22); got != want {
+		t.Fatalf("directorySize = %d, want %d", got, want)
+	}
+}
+
+func TestPublishOutputDirectoryReportsSizeWhenEnabled(t *testing.T) {
+	old := reportDirectorySize
+	reportDirectorySize = true
+	defer func() { reportDirectorySize = old }()
+
+	dir := t.TempDir()
+	outdirPath := filepath.Join(dir, "outdir")
+	if err := os.Mkdir(outdirPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outdirPath, "a.txt"), []byte("aaaa"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	content := `{"outdir": {"class":"Directory","path":"` + outdirPath + `"}}`
+	if err := os.WriteFile(outputJson, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadListing := map[string]listing.Mode{"outdir": listing.ShallowListing}
+	result, err := loadCwlOutputJson(outputJson, "job-1", nil, loadListing, nil, nil, nil, func(local, key, checksum string) (string, error) {
+		t.Fatal("directory outputs should not be uploaded")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := result["outdir"].(map[string]interface{})
+	if got, want := outdir["flowy:size"], int64(4); got != want {
+		t.Fatalf("outdir[\"flowy:size\"] = %v, want %v", got, want)
+	}
+}
+
+func TestLoadCwlOutputJsonPublishesDirectoryLiteralListing(t *testing.T) {
+	dir := t.TempDir()
+	child := filepath.Join(dir, "child.txt")
+	if err := os.WriteFile(child, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputJson := filepath.Join(dir, "cwl.output.json")
+	content := `{"outdir": {"class":"Directory","basename":"outdir","listing":[` +
+		`{"class":"File","path":"` + child + `","basename":"child.txt"},` +
+		`{"class":"Directory","basename":"sub","listing":[]}` +
+		`]}}`
+	if err := os.WriteFile(outputJson, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var uploaded []string
+	result, err := loadCwlOutputJson(outputJson, "job-1", nil, nil, nil, nil, nil, func(local, key, checksum string) (string, error) {
+		uploaded = append(uploaded, local)
+		return "file://" + key, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uploaded) != 1 || uploaded[0] != child {
+		t.Fatalf("uploaded = %v, want [%s]", uploaded, child)
+	}
+
+	outdir := result["outdir"].(map[string]interface{})
+	listingEntries, ok := outdir["listing"].([]interface{})
+	if !ok || len(listingEntries) != 2 {
+		t.Fatalf("outdir[\"listing\"] = %#v", outdir["listing"])
+	}
+	file := listingEntries[0].(map[string]interface{})
+	if file["location"] != "file://job-1/child.txt" {
+		t.Fatalf("file[\"location\"] = %v", file["location"])
+	}
+	if file["checksum"] != "sha1$"+"11f6ad8ec52a2984abaafd7c3b516503785c2072" {
+		t.Fatalf("file[\"checksum\"] = %v", file["checksum"])
+	}
+	sub := listingEntries[1].(map[string]interface{})
+	if sub["class"] != "Directory" || sub["basename"] != "sub" {
+		t.Fatalf("sub = %#v", sub)
+	}
+	if subListing, ok := sub["listing"].([]interface{}); !ok || len(subListing) != 0 {
+		t.Fatalf("sub[\"listing\"] = %#v", sub["listing"])
+	}
+}
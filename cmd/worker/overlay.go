@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// overlayWorkReuseEnabled turns on overlayfs-backed staging for writable
+// Directory inputs, set via FLOWY_OVERLAY_WORKDIR=1. It defaults to off
+// since mounting overlayfs requires CAP_SYS_ADMIN (or a user namespace
+// configured for it) and isn't available on every worker host.
+var overlayWorkReuseEnabled = os.Getenv("FLOWY_OVERLAY_WORKDIR") == "1"
+
+// overlayDirs returns the upper and work directories an overlay mount at
+// target needs, as siblings of target so they get removed along with the
+// rest of the job's own directory once the mount is torn down.
+func overlayDirs(target string) (upper, work string) {
+	return target + ".overlay-upper", target + ".overlay-work"
+}
+
+// stageWritableDirectoryOverlay attempts to satisfy a writable Directory
+// entry backed by an existing path (ent.Resolved) with a copy-on-write
+// overlay mount instead of a full recursive copy: ent.Resolved is mounted
+// read-only as the overlay's lower layer, writes go to a per-job upper
+// layer, and the job sees the merged result at target. It reports whether
+// the overlay was mounted; false with a non-nil error means the caller
+// should log it and fall back to stageEntry's ordinary CopyDir path.
+func stageWritableDirectoryOverlay(ent api.MapperEnt, target string) (bool, error) {
+	if !overlayWorkReuseEnabled || ent.Type != "Directory" || !ent.Writable || ent.Resolved == "" {
+		return false, nil
+	}
+
+	upper, work := overlayDirs(target)
+	for _, dir := range []string{upper, work, target} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return false, err
+		}
+	}
+
+	if err := mountOverlay(ent.Resolved, upper, work, target); err != nil {
+		os.RemoveAll(upper)
+		os.RemoveAll(work)
+		return false, fmt.Errorf("mounting overlay for %s: %w", target, err)
+	}
+	return true, nil
+}
+
+// unmountOverlayIfMounted tears down an overlay stageWritableDirectoryOverlay
+// mounted at target and removes its upper/work scratch directories. It is
+// always safe to call on a target that was never overlay-mounted.
+func unmountOverlayIfMounted(target string) {
+	if !overlayWorkReuseEnabled {
+		return
+	}
+	upper, work := overlayDirs(target)
+	if _, err := os.Stat(upper); err != nil {
+		return
+	}
+	if err := unmountOverlay(target); err != nil {
+		logger.Warn("unmounting overlay", "target", target, "error", err)
+		return
+	}
+	os.RemoveAll(upper)
+	os.RemoveAll(work)
+}
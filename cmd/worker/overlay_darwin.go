@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+// mountOverlay always fails on Darwin: overlayfs is a Linux-only
+// filesystem, and Darwin has no equivalent kernel-level copy-on-write
+// mount this worker can use in its place.
+func mountOverlay(lower, upper, work, target string) error {
+	return fmt.Errorf("overlayfs staging is not supported on this platform")
+}
+
+// unmountOverlay is never reachable on Darwin, since mountOverlay always
+// fails there and stageWritableDirectoryOverlay only mounts something
+// unmountOverlayIfMounted later needs to tear down.
+func unmountOverlay(target string) error {
+	return fmt.Errorf("overlayfs staging is not supported on this platform")
+}
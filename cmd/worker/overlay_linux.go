@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// mountOverlay mounts an overlayfs filesystem at target with lower as its
+// read-only lower layer and upper/work as its writable upper layer and
+// required workdir, per the overlay(8) mount options.
+func mountOverlay(lower, upper, work, target string) error {
+	opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", lower, upper, work)
+	return syscall.Mount("overlay", target, "overlay", 0, opts)
+}
+
+// unmountOverlay unmounts the overlay previously mounted at target by
+// mountOverlay.
+func unmountOverlay(target string) error {
+	return syscall.Unmount(target, 0)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestStageWritableDirectoryOverlayDisabledByDefault(t *testing.T) {
+	prev := overlayWorkReuseEnabled
+	overlayWorkReuseEnabled = false
+	defer func() { overlayWorkReuseEnabled = prev }()
+
+	mounted, err := stageWritableDirectoryOverlay(api.MapperEnt{Type: "Directory", Writable: true, Resolved: "/src"}, "/tmp/nonexistent-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounted {
+		t.Fatal("expected overlay staging to stay off by default")
+	}
+}
+
+func TestStageEntryFallsBackToCopyWhenOverlayUnavailable(t *testing.T) {
+	prev := overlayWorkReuseEnabled
+	overlayWorkReuseEnabled = true
+	defer func() { overlayWorkReuseEnabled = prev }()
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(t.TempDir(), "workdir")
+
+	ent := api.MapperEnt{Type: "Directory", Writable: true, Resolved: src, Target: "workdir"}
+	fm := internal.NewLocalFileManager()
+	if err := stageEntry(fm, filepath.Dir(target), ent, target); err != nil {
+		t.Fatal(err)
+	}
+	// Running as root can make the overlay mount itself succeed rather
+	// than fall back, in which case target must be unmounted before
+	// t.TempDir's own cleanup can remove it.
+	defer func() { _ = unmountOverlay(target) }()
+
+	if _, err := os.Stat(filepath.Join(target, "a.txt")); err != nil {
+		t.Fatalf("expected a.txt to be visible at target either way: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+// permanentError marks a job failure that retrying cannot fix (e.g. the
+// tool's own output doesn't match its declared schema), so the server can
+// tell not to requeue it the way it would a transient infrastructure
+// error. See reportJobFailed.
+type permanentError struct {
+	err error
+}
+
+func permanentFail(err error) error {
+	return &permanentError{err: err}
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
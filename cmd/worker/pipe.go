@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/pipe"
+)
+
+// receivePipeInputs dials every consumer PipeEndpoint in job.Pipes and
+// writes what it streams to a file named after the parameter directly
+// under jobDir, before stageInputs runs. The server is responsible for
+// pointing the matching Mapping entry's Target at that same name, so the
+// tool finds the streamed input exactly where any other staged input
+// would be - or, for a tool that reads the stream from standard input
+// rather than a named file, setting job.Stdin to that same name, since
+// redirectStreams opens it the same way it would any other file under
+// jobDir.
+func receivePipeInputs(jobDir string, job *api.ExecutableJob) error {
+	for name, endpoint := range job.Pipes {
+		if endpoint.Role != "consumer" {
+			continue
+		}
+		if err := receivePipeInput(jobDir, name, endpoint); err != nil {
+			return fmt.Errorf("receiving streamed input %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func receivePipeInput(jobDir, name string, endpoint api.PipeEndpoint) error {
+	if err := os.MkdirAll(jobDir, 0o755); err != nil {
+		return err
+	}
+
+	conn, err := pipe.Dial(endpoint.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	f, err := os.Create(filepath.Join(jobDir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, conn)
+	return err
+}
+
+// servePipeOutputs streams every producer PipeEndpoint in job.Pipes to its
+// consumers from the file collected for that output. It only starts once
+// the whole file has been written, not concurrently with the command
+// producing it, because the worker runs one job to completion before
+// starting the next; overlapping a producer with its consumers would need
+// the worker to execute jobs concurrently, which this does not attempt.
+func servePipeOutputs(job *api.ExecutableJob, outputs map[string]interface{}) error {
+	for name, endpoint := range job.Pipes {
+		if endpoint.Role != "producer" {
+			continue
+		}
+		path, ok := pipeOutputPath(outputs, name)
+		if !ok {
+			return fmt.Errorf("streaming output %q: no File value found in outputs", name)
+		}
+		if err := servePipeOutput(path, endpoint); err != nil {
+			return fmt.Errorf("streaming output %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// pipeOutputPath extracts the local path of the File object outputs holds
+// for name, the same shape publishOutputs builds for every File it
+// collects.
+func pipeOutputPath(outputs map[string]interface{}, name string) (string, bool) {
+	value, ok := outputs[name]
+	if !ok {
+		return "", false
+	}
+	file, ok := value.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	path, ok := file["path"].(string)
+	return path, ok
+}
+
+func servePipeOutput(path string, endpoint api.PipeEndpoint) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ln, err := net.Listen("tcp", endpoint.Address)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	consumers := endpoint.Consumers
+	if consumers < 1 {
+		consumers = 1
+	}
+	return pipe.Serve(ln, f, consumers)
+}
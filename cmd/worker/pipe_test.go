@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/pipe"
+)
+
+func TestServePipeOutputsStreamsToConsumer(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(outputPath, []byte("payload"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	job := &api.ExecutableJob{
+		Pipes: map[string]api.PipeEndpoint{
+			"out": {Role: "producer", Address: addr, Consumers: 1},
+		},
+	}
+	outputs := map[string]interface{}{
+		"out": map[string]interface{}{"class": "File", "path": outputPath},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- servePipeOutputs(job, outputs) }()
+
+	var conn net.Conn
+	for i := 0; i < 100; i++ {
+		conn, err = pipe.Dial(addr)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, len("payload"))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "payload" {
+		t.Fatalf("got %q, want %q", buf, "payload")
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReceivePipeInputWritesStreamToTarget(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("streamed"))
+	}()
+
+	jobDir := t.TempDir()
+	endpoint := api.PipeEndpoint{Role: "consumer", Address: ln.Addr().String()}
+	if err := receivePipeInput(jobDir, "in", endpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(jobDir, "in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "streamed" {
+		t.Fatalf("got %q, want %q", got, "streamed")
+	}
+}
+
+func TestReceivePipeInputsFeedsStdin(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("tool-a output"))
+	}()
+
+	jobDir := t.TempDir()
+	job := &api.ExecutableJob{
+		Stdin: "upstream",
+		Pipes: map[string]api.PipeEndpoint{
+			"upstream": {Role: "consumer", Address: ln.Addr().String()},
+		},
+	}
+	if err := receivePipeInputs(jobDir, job); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin, stdout, stderr, err := redirectStreams(jobDir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeStreams(stdin, stdout, stderr)
+
+	got, err := io.ReadAll(stdin)
+	if err != nil || string(got) != "tool-a output" {
+		t.Fatalf("stdin = %q, %v", got, err)
+	}
+}
+
+func TestPipeOutputPathMissingOutputReturnsFalse(t *testing.T) {
+	if _, ok := pipeOutputPath(map[string]interface{}{}, "missing"); ok {
+		t.Fatal("expected no path for a missing output")
+	}
+}
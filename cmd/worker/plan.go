@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// ExecutionPlan describes what executeJob would do for a job without
+// actually staging, running, or publishing anything: the resolved command
+// line, the staging operations it would perform (in the order stageInputs
+// would perform them), the environment it would set, and the glob
+// patterns it would collect outputs from. It backs the /debug/plan
+// endpoint, for diagnosing a job's staging paths or command line without
+// spending time actually executing it.
+type ExecutionPlan struct {
+	Command     []string            `json:"command"`
+	DockerImage *string             `json:"dockerImage,omitempty"`
+	DockerArgs  []string            `json:"dockerArgs,omitempty"`
+	Env         []string            `json:"env"`
+	Staging     []StagingStep       `json:"staging"`
+	OutputGlobs map[string][]string `json:"outputGlobs,omitempty"`
+	// OutputLocations is where publishOutputs would upload each
+	// OutputBindings entry to, computed the same way outputPrefix and
+	// outputBaseURL are applied at actual publish time.
+	OutputLocations []api.PlannedOutputLocation `json:"outputLocations,omitempty"`
+	// CacheKey is the same content-addressed key reportJobFinished would
+	// attach to job's JobFinishedRequest, computed without running
+	// anything, so a call-caching subsystem's key for a job can be
+	// previewed before submitting it. Empty when job.Cacheable is false.
+	CacheKey string `json:"cacheKey,omitempty"`
+}
+
+// StagingStep describes one operation stageInputs would perform, in order.
+type StagingStep struct {
+	Target   string `json:"target"`
+	Resolved string `json:"resolved,omitempty"`
+	Type     string `json:"type"`
+	Writable bool   `json:"writable,omitempty"`
+}
+
+// buildExecutionPlan resolves everything executeJob would need to run job
+// under workdir, without staging, executing, or publishing anything.
+func buildExecutionPlan(workdir string, job *api.ExecutableJob) (*ExecutionPlan, error) {
+	jobDir := filepath.Join(workdir, job.JobID)
+
+	ordered, err := orderStagingEntries(job.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("ordering staging for job %s: %w", job.JobID, err)
+	}
+	staging := make([]StagingStep, 0, len(ordered))
+	for _, ent := range ordered {
+		staging = append(staging, StagingStep{
+			Target:   filepath.Join(jobDir, ent.Target),
+			Resolved: ent.Resolved,
+			Type:     ent.Type,
+			Writable: ent.Writable,
+		})
+	}
+
+	plan := &ExecutionPlan{
+		Env:         buildEnv(job.Env),
+		Staging:     staging,
+		DockerImage: job.DockerImage,
+		CacheKey:    computeCacheKey(job),
+	}
+
+	if job.DockerImage != nil {
+		plan.Command = job.Command
+		plan.DockerArgs = prepareForDocker(jobDir, job, *job.DockerImage)
+	} else {
+		command, err := bubblewrapCommand(jobDir, job.Command)
+		if err != nil {
+			command = job.Command
+		}
+		if netCommand, err := nonContainerNetworkCommand(job.Networkaccess, command); err == nil {
+			command = netCommand
+		}
+		plan.Command = command
+	}
+
+	if len(job.OutputBindings) > 0 {
+		plan.OutputGlobs = make(map[string][]string, len(job.OutputBindings))
+		plan.OutputLocations = make([]api.PlannedOutputLocation, 0, len(job.OutputBindings))
+		for name, binding := range job.OutputBindings {
+			plan.OutputGlobs[name] = binding.Glob
+			plan.OutputLocations = append(plan.OutputLocations, plannedOutputLocation(name, binding, job))
+		}
+	}
+
+	return plan, nil
+}
+
+// plannedOutputLocation computes where name's value would be published,
+// the same way publishOutputs' upload key (outputBaseURL + outputPrefix +
+// basename) is built, except the basename is only knowable ahead of
+// execution when binding has exactly one glob pattern with no wildcard
+// metacharacters. Otherwise Location falls back to the destination
+// directory alone, as a best-effort hint.
+func plannedOutputLocation(name string, binding api.OutputBinding, job *api.ExecutableJob) api.PlannedOutputLocation {
+	prefix := outputPrefix(job)
+	if len(binding.Glob) == 1 && isLiteralGlob(binding.Glob[0]) {
+		return api.PlannedOutputLocation{
+			Name:     name,
+			Location: outputBaseURL + filepath.Join(prefix, binding.Glob[0]),
+			Exact:    true,
+		}
+	}
+	return api.PlannedOutputLocation{
+		Name:     name,
+		Location: outputBaseURL + prefix + "/",
+		Exact:    false,
+	}
+}
+
+// isLiteralGlob reports whether pattern names exactly one file rather
+// than matching a variable set of them: no wildcard metacharacters and
+// not itself a CWL/JavaScript expression (those are wrapped in $(...) or
+// ${...}).
+func isLiteralGlob(pattern string) bool {
+	if strings.ContainsAny(pattern, "*?[{") {
+		return false
+	}
+	if strings.Contains(pattern, "$(") || strings.Contains(pattern, "${") {
+		return false
+	}
+	return true
+}
+
+// planHandler serves POST /debug/plan: the request body is an
+// api.ExecutableJob, and the response is the ExecutionPlan buildExecutionPlan
+// resolves for it, without ever staging or executing the job.
+func planHandler(workdir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var job api.ExecutableJob
+		if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+			http.Error(w, fmt.Sprintf("decoding job: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		plan, err := buildExecutionPlan(workdir, &job)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(plan); err != nil {
+			logger.Warn("encoding execution plan response", "jobId", job.JobID, "error", err)
+		}
+	}
+}
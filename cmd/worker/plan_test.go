@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestBuildExecutionPlanOrdersStagingAndListsOutputGlobs(t *testing.T) {
+	job := &api.ExecutableJob{
+		JobID:   "job-1",
+		Command: []string{"echo", "hi"},
+		Mapping: []api.MapperEnt{
+			{Resolved: "/src/extra.txt", Target: "out/extra.txt", Type: "File", Staged: true, Writable: true},
+			{Resolved: "/src/out", Target: "out", Type: "Directory", Staged: true, Writable: true},
+		},
+		OutputBindings: map[string]api.OutputBinding{
+			"result": {Glob: []string{"*.txt"}},
+		},
+	}
+
+	plan, err := buildExecutionPlan("/var/lib/flowy/work", job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Staging) != 2 || plan.Staging[0].Target != "/var/lib/flowy/work/job-1/out" {
+		t.Fatalf("expected the directory staged before its contents, got %+v", plan.Staging)
+	}
+	if got := plan.OutputGlobs["result"]; len(got) != 1 || got[0] != "*.txt" {
+		t.Fatalf("expected result's glob patterns to be listed, got %v", plan.OutputGlobs)
+	}
+	if len(plan.OutputLocations) != 1 || plan.OutputLocations[0].Exact {
+		t.Fatalf("expected one non-exact planned location for a wildcard glob, got %+v", plan.OutputLocations)
+	}
+}
+
+func TestBuildExecutionPlanMarksLiteralGlobLocationsExact(t *testing.T) {
+	job := &api.ExecutableJob{
+		JobID:   "job-1",
+		Command: []string{"echo", "hi"},
+		OutputBindings: map[string]api.OutputBinding{
+			"report": {Glob: []string{"report.txt"}},
+		},
+	}
+
+	plan, err := buildExecutionPlan("/var/lib/flowy/work", job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.OutputLocations) != 1 {
+		t.Fatalf("expected one planned location, got %+v", plan.OutputLocations)
+	}
+	loc := plan.OutputLocations[0]
+	if !loc.Exact {
+		t.Fatalf("expected a literal glob's location to be exact, got %+v", loc)
+	}
+	if loc.Location != "job-1/report.txt" {
+		t.Errorf("Location = %q, want %q", loc.Location, "job-1/report.txt")
+	}
+}
+
+func TestBuildExecutionPlanPropagatesStagingConflictError(t *testing.T) {
+	job := &api.ExecutableJob{
+		JobID: "job-1",
+		Mapping: []api.MapperEnt{
+			{Target: "leaf", Type: "File", Staged: true},
+			{Target: "leaf/inner.txt", Type: "File", Staged: true},
+		},
+	}
+	if _, err := buildExecutionPlan("/var/lib/flowy/work", job); err == nil {
+		t.Fatal("expected a staging conflict error")
+	}
+}
+
+func TestPlanHandlerReturnsPlanForPostedJob(t *testing.T) {
+	body, err := json.Marshal(api.ExecutableJob{JobID: "job-1", Command: []string{"echo", "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/debug/plan", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	planHandler(t.TempDir())(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var plan ExecutionPlan
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Command) == 0 {
+		t.Fatal("expected a resolved command in the plan")
+	}
+}
+
+func TestPlanHandlerRejectsNonPost(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/debug/plan", nil)
+	rec := httptest.NewRecorder()
+	planHandler(t.TempDir())(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/metrics"
+	"github.com/bioflowy/flowy/internal/sysinfo"
+)
+
+// reportTotalMemory publishes the host's total physical memory as a gauge,
+// used for capacity-aware scheduling decisions made elsewhere. Failure to
+// read it (e.g. sysctl missing on a minimal Darwin install) is not fatal.
+func reportTotalMemory() {
+	total, err := sysinfo.TotalMemoryBytes()
+	if err != nil {
+		logger.Warn("reading total host memory", "error", err)
+		return
+	}
+	metrics.TotalMemoryBytes.Set(total)
+}
+
+// dockerDesktopShareRoots are the host paths Docker Desktop for Mac shares
+// into its VM by default. A workdir outside all of them can be staged into
+// and read by the worker, but bind-mounting it into a container will fail
+// or silently show an empty directory.
+var dockerDesktopShareRoots = []string{"/Users", "/Volumes", "/private", "/tmp", "/var/folders"}
+
+// checkDockerDesktopSharing warns when workdir sits outside the paths
+// Docker Desktop shares into its VM on Darwin, where jobs would otherwise
+// fail to see their staged inputs once bind-mounted into a container.
+func checkDockerDesktopSharing(workdir string) {
+	if runtime.GOOS != "darwin" {
+		return
+	}
+	for _, root := range dockerDesktopShareRoots {
+		if workdir == root || strings.HasPrefix(workdir, root+"/") {
+			return
+		}
+	}
+	logger.Warn("workdir is outside Docker Desktop's default file sharing roots; "+
+		"containerized jobs may not see their staged inputs unless this path is "+
+		"added under Docker Desktop's Settings > Resources > File Sharing",
+		"workdir", workdir, "shareableRoots", dockerDesktopShareRoots)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+// pluginFileManagers holds the external-process FileManagers configured via
+// FLOWY_FILEMANAGER_PLUGINS, keyed by the URL scheme each one handles, so
+// the worker's FileManager chains can route to a proprietary storage
+// backend (iRODS, an internal object store) through internal.NewFileManager
+// instead of only ever knowing about the local filesystem and S3.
+var pluginFileManagers = loadPluginFileManagers(os.Getenv("FLOWY_FILEMANAGER_PLUGINS"))
+
+// loadPluginFileManagers parses a ";"-separated list of
+// "scheme=command arg1 arg2" entries, starting one PluginFileManager
+// subprocess per entry. A plugin that fails to start is logged and
+// skipped rather than failing worker startup entirely, the same
+// best-effort handling newAuditLoggerOrNil and newSecretsProviderOrNop
+// already apply to their own optional configuration.
+func loadPluginFileManagers(spec string) map[string]internal.FileManager {
+	backends := map[string]internal.FileManager{}
+	if spec == "" {
+		return backends
+	}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scheme, commandLine, ok := strings.Cut(entry, "=")
+		if !ok {
+			logger.Error("invalid FLOWY_FILEMANAGER_PLUGINS entry, expected scheme=command", "entry", entry)
+			continue
+		}
+		fields := strings.Fields(commandLine)
+		if len(fields) == 0 {
+			logger.Error("invalid FLOWY_FILEMANAGER_PLUGINS entry, empty command", "scheme", scheme)
+			continue
+		}
+		fm, err := internal.NewPluginFileManager(fields[0], fields[1:]...)
+		if err != nil {
+			logger.Error("starting FileManager plugin", "scheme", scheme, "error", err)
+			continue
+		}
+		backends[scheme] = fm
+	}
+	return backends
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// workerPreemptible reports whether this worker runs on a spot/preemptible
+// node that the cloud provider can reclaim with little notice, set once at
+// startup via FLOWY_WORKER_PREEMPTIBLE. Advertised to the server in every
+// heartbeat so it can weigh that when assigning jobs a preemption would be
+// especially costly to lose.
+var workerPreemptible = os.Getenv("FLOWY_WORKER_PREEMPTIBLE") != ""
+
+// preemptionCheckURL is the cloud provider's preemption-notice endpoint
+// this worker polls, via FLOWY_PREEMPTION_CHECK_URL (e.g. a cloud metadata
+// server's termination-notice attribute). Left unset, startPreemptionWatcher
+// does nothing, the same opt-out startDiskWatcher has none of since disk
+// pressure applies to every worker but a preemption notice endpoint is
+// meaningful only on preemptible capacity.
+var preemptionCheckURL = envOr("FLOWY_PREEMPTION_CHECK_URL", "")
+
+// preemptionWatchInterval is how often the preemption watcher polls
+// preemptionCheckURL. Shorter than diskWatchInterval because cloud
+// providers typically give a preemptible node only tens of seconds'
+// notice before reclaiming it.
+const preemptionWatchInterval = 5 * time.Second
+
+// underPreemptionNotice reports whether the most recent poll of
+// preemptionCheckURL found a preemption notice pending. run's poll loop
+// checks this before fetching new work, the same way it does
+// diskPressureActive.
+var underPreemptionNotice atomic.Bool
+
+// preemptionActive reports whether this worker has received a preemption
+// notice and should stop accepting new jobs and wind down its currently
+// running one.
+func preemptionActive() bool {
+	return underPreemptionNotice.Load()
+}
+
+// startPreemptionWatcher polls preemptionCheckURL every
+// preemptionWatchInterval, setting underPreemptionNotice once a notice is
+// observed, and returns once it has been, since the notice is never
+// cleared; callers start it as a goroutine. A worker without
+// FLOWY_PREEMPTION_CHECK_URL configured returns immediately, leaving
+// preemptionActive permanently false.
+func startPreemptionWatcher() {
+	if preemptionCheckURL == "" {
+		return
+	}
+	for {
+		checkPreemptionNotice()
+		if preemptionActive() {
+			return
+		}
+		time.Sleep(preemptionWatchInterval)
+	}
+}
+
+// checkPreemptionNotice polls preemptionCheckURL once, setting
+// underPreemptionNotice when it reports a notice is pending. This mirrors
+// the contract cloud metadata servers already use for preemption notices
+// (e.g. GCE's instance/preempted attribute): a 200 response whose body,
+// trimmed, is "TRUE" (case-insensitively) means the node is being
+// reclaimed; anything else means it isn't yet. A request error is logged
+// and otherwise ignored, the same as checkDiskPressure's handling of a
+// failed free-space check - a transient network blip here must not be
+// mistaken for an imminent reclaim.
+func checkPreemptionNotice() {
+	resp, err := http.Get(preemptionCheckURL)
+	if err != nil {
+		logger.Warn("checking preemption notice", "url", preemptionCheckURL, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+	body := make([]byte, 16)
+	n, _ := resp.Body.Read(body)
+	if !strings.EqualFold(strings.TrimSpace(string(body[:n])), "TRUE") {
+		return
+	}
+
+	if underPreemptionNotice.CompareAndSwap(false, true) {
+		logger.Warn("preemption notice received, winding down and will requeue the running job", "url", preemptionCheckURL)
+	}
+}
+
+// preemptedError marks a job failure caused by this worker being
+// preempted mid-run, rather than the tool itself failing, so callers can
+// report it distinctly (see reportJobFailed) instead of as an ordinary run
+// error, and have the server requeue it onto another worker rather than
+// charging it toward RetryPolicy or reporting it as a failure.
+type preemptedError struct {
+	err error
+}
+
+func preemptedFail(err error) error {
+	return &preemptedError{err: err}
+}
+
+func (e *preemptedError) Error() string { return e.err.Error() }
+func (e *preemptedError) Unwrap() error { return e.err }
+
+// uploadPartialLog best-effort uploads whatever of job's log output
+// already exists on disk - its configured Stdout/Stderr files and, under
+// the nextflow workdir layout, .command.log - before the job's directory
+// is torn down, so a preempted job's progress isn't lost even though the
+// job itself never reached publishOutputs. A file that doesn't exist (e.g.
+// the job was killed before it ever wrote one) is skipped, and a failed
+// upload doesn't stop the remaining ones; the last upload error, if any, is
+// returned for the caller to log.
+func uploadPartialLog(jobDir string, job *api.ExecutableJob) error {
+	fm := outputFileManagerFor(job.JobID)
+	prefix := outputBaseURL + outputPrefix(job) + "/partial/"
+
+	var uploadErr error
+	for _, name := range []string{job.Stdout, job.Stderr, ".command.log"} {
+		if name == "" {
+			continue
+		}
+		local := filepath.Join(jobDir, name)
+		if _, err := os.Stat(local); err != nil {
+			continue
+		}
+		if err := fm.Upload(local, prefix+filepath.Base(name)); err != nil {
+			uploadErr = err
+		}
+	}
+	return uploadErr
+}
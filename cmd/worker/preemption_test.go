@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunCommandKillsProcessWhenPreempted(t *testing.T) {
+	origGrace := timeLimitGrace
+	timeLimitGrace = 200 * time.Millisecond
+	underPreemptionNotice.Store(true)
+	defer func() {
+		timeLimitGrace = origGrace
+		underPreemptionNotice.Store(false)
+	}()
+
+	speculativeCancelPollInterval = 10 * time.Millisecond
+	defer func() { speculativeCancelPollInterval = 10 * time.Second }()
+
+	cmd := exec.Command("sh", "-c", "sleep 5")
+	start := time.Now()
+	timedOut, cancelled, preempted, err := runCommand(cmd, 0, "job-1", false)
+	elapsed := time.Since(start)
+
+	if timedOut {
+		t.Fatal("expected timedOut = false for a preemption, not a time limit")
+	}
+	if cancelled {
+		t.Fatal("expected cancelled = false for a preemption")
+	}
+	if !preempted {
+		t.Fatal("expected preempted = true")
+	}
+	if err == nil {
+		t.Fatal("expected an error from a signaled process")
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("runCommand took %v, expected the preemption watcher to end the sleep quickly", elapsed)
+	}
+}
+
+func TestCheckPreemptionNoticeSetsFlagOnTrueBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("TRUE"))
+	}))
+	defer server.Close()
+
+	origURL := preemptionCheckURL
+	preemptionCheckURL = server.URL
+	underPreemptionNotice.Store(false)
+	defer func() {
+		preemptionCheckURL = origURL
+		underPreemptionNotice.Store(false)
+	}()
+
+	checkPreemptionNotice()
+
+	if !preemptionActive() {
+		t.Fatal("expected preemptionActive() = true after a TRUE notice")
+	}
+}
+
+func TestCheckPreemptionNoticeLeavesFlagUnsetOnFalseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("FALSE"))
+	}))
+	defer server.Close()
+
+	origURL := preemptionCheckURL
+	preemptionCheckURL = server.URL
+	underPreemptionNotice.Store(false)
+	defer func() {
+		preemptionCheckURL = origURL
+		underPreemptionNotice.Store(false)
+	}()
+
+	checkPreemptionNotice()
+
+	if preemptionActive() {
+		t.Fatal("expected preemptionActive() = false after a FALSE notice")
+	}
+}
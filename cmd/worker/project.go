@@ -0,0 +1,18 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// outputPrefix returns the key prefix job's outputs are uploaded under:
+// job.Project joined with job.JobID when Project is set, so two projects
+// sharing one output bucket never collide on JobID alone, or just JobID
+// for the default (unnamespaced) project.
+func outputPrefix(job *api.ExecutableJob) string {
+	if job.Project == "" {
+		return job.JobID
+	}
+	return filepath.Join(job.Project, job.JobID)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestOutputPrefixIncludesProjectWhenSet(t *testing.T) {
+	job := &api.ExecutableJob{JobID: "job-1", Project: "lab-a"}
+	if got, want := outputPrefix(job), "lab-a/job-1"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestOutputPrefixIsJobIDWithoutProject(t *testing.T) {
+	job := &api.ExecutableJob{JobID: "job-1"}
+	if got, want := outputPrefix(job), "job-1"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
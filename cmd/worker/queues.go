@@ -0,0 +1,24 @@
+package main
+
+import "strings"
+
+// workerQueues lists the queue classes this worker advertises to the
+// server via reportWorkerStarted, set from FLOWY_WORKER_QUEUES or
+// overridden by the --queues flag. Empty means this worker serves only the
+// default queue.
+var workerQueues = splitQueues(envOr("FLOWY_WORKER_QUEUES", ""))
+
+// splitQueues parses a comma-separated queue class list, ignoring blank
+// entries so trailing/doubled commas don't produce an empty queue name.
+func splitQueues(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var queues []string
+	for _, q := range strings.Split(v, ",") {
+		if q = strings.TrimSpace(q); q != "" {
+			queues = append(queues, q)
+		}
+	}
+	return queues
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestSplitQueuesIgnoresBlankEntries(t *testing.T) {
+	got := splitQueues("clinical,, batch ,")
+	want := []string{"clinical", "batch"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSplitQueuesEmptyStringIsNil(t *testing.T) {
+	if got := splitQueues(""); got != nil {
+		t.Fatalf("expected nil for an empty string, got %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// recordDir, when non-empty (set via -record-jobs), makes the worker dump
+// every job payload it fetches and the report it ultimately sends back to
+// disk, so `flowy-worker replay` can re-run the same payload offline to
+// reproduce a staging or execution bug seen in production.
+var recordDir string
+
+// recordJobPayload writes job's payload to recordDir, named after its
+// JobID, unchanged from what the server sent, so it can later be fed to
+// `flowy-worker replay` byte-for-byte.
+func recordJobPayload(job *api.ExecutableJob) {
+	if recordDir == "" {
+		return
+	}
+	writeRecordFile(filepath.Join(recordDir, job.JobID+".job.json"), job)
+}
+
+// recordJobReport writes the report the worker sent the server for a job
+// to recordDir, alongside its payload, so a replay's result can be
+// compared against what actually happened in production.
+func recordJobReport(req api.JobFinishedRequest) {
+	if recordDir == "" {
+		return
+	}
+	writeRecordFile(filepath.Join(recordDir, req.JobID+".report.json"), req)
+}
+
+func writeRecordFile(path string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		logger.Error("encoding recorded job artifact", "path", path, "error", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		logger.Error("writing recorded job artifact", "path", path, "error", err)
+	}
+}
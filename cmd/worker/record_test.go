@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestRecordJobPayloadNoopWhenRecordDirUnset(t *testing.T) {
+	prev := recordDir
+	recordDir = ""
+	defer func() { recordDir = prev }()
+
+	recordJobPayload(&api.ExecutableJob{JobID: "job-1"})
+}
+
+func TestRecordJobPayloadWritesJobFile(t *testing.T) {
+	prev := recordDir
+	recordDir = t.TempDir()
+	defer func() { recordDir = prev }()
+
+	recordJobPayload(&api.ExecutableJob{JobID: "job-1", Tool: "echo"})
+
+	data, err := os.ReadFile(filepath.Join(recordDir, "job-1.job.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job api.ExecutableJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		t.Fatal(err)
+	}
+	if job.JobID != "job-1" || job.Tool != "echo" {
+		t.Fatalf("got %+v", job)
+	}
+}
+
+func TestRecordJobReportWritesReportFile(t *testing.T) {
+	prev := recordDir
+	recordDir = t.TempDir()
+	defer func() { recordDir = prev }()
+
+	recordJobReport(api.JobFinishedRequest{JobID: "job-1", Success: true})
+
+	data, err := os.ReadFile(filepath.Join(recordDir, "job-1.report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req api.JobFinishedRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.JobID != "job-1" || !req.Success {
+		t.Fatalf("got %+v", req)
+	}
+}
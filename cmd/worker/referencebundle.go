@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// referenceBundleCacheDir is where reference bundles are staged, shared
+// across every job this worker process runs, set via
+// FLOWY_REFERENCE_BUNDLE_CACHE_DIR. Empty (the default) means a job
+// requesting a bundle fails outright: a worker with no configured cache
+// root has nowhere durable to keep something meant to be fetched once
+// and reused indefinitely, unlike an ordinary per-job input.
+var referenceBundleCacheDir = os.Getenv("FLOWY_REFERENCE_BUNDLE_CACHE_DIR")
+
+// verifiedMarkerName is written into a bundle's cache directory once
+// every file in its manifest has downloaded and verified successfully,
+// so a later job referencing the same bundle can reuse it without
+// re-fetching the manifest or re-hashing its files.
+const verifiedMarkerName = ".flowy-verified"
+
+// bundleFetch tracks one in-flight stageReferenceBundle call, the same
+// single-flight shape downloadDedupFileManager uses for ordinary inputs,
+// so two jobs requesting the same bundle at once don't download and
+// verify it twice.
+type bundleFetch struct {
+	done chan struct{}
+	err  error
+}
+
+var (
+	referenceBundleMu       sync.Mutex
+	referenceBundleInFlight = map[string]*bundleFetch{}
+)
+
+// stageReferenceBundles resolves every bundle job references by ID into
+// a local, verified directory under referenceBundleCacheDir, symlinking
+// each one into jobDir (prepareForDocker bind-mounts referenceBundleCacheDir
+// at its own path next to jobDir, so the link resolves inside a container
+// too), and returns a
+// map from bundle ID to that symlink's path for the caller to expose to
+// the job (e.g. as an environment variable). It returns early with no
+// error for a job that references no bundles.
+func stageReferenceBundles(fm internal.FileManager, jobDir string, bundleIDs []string) (map[string]string, error) {
+	if len(bundleIDs) == 0 {
+		return nil, nil
+	}
+
+	paths := make(map[string]string, len(bundleIDs))
+	linkRoot := filepath.Join(jobDir, ".refdata")
+	for _, id := range bundleIDs {
+		cacheDir, err := ensureReferenceBundle(fm, id)
+		if err != nil {
+			return nil, fmt.Errorf("staging reference bundle %q: %w", id, err)
+		}
+		link := filepath.Join(linkRoot, sanitizeBundleID(id))
+		if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(link); err != nil {
+			if err := os.Symlink(cacheDir, link); err != nil {
+				return nil, fmt.Errorf("linking reference bundle %q into job directory: %w", id, err)
+			}
+		}
+		paths[id] = link
+	}
+	return paths, nil
+}
+
+// ensureReferenceBundle makes sure bundle id is present and
+// checksum-verified under referenceBundleCacheDir, fetching its manifest
+// from the server and downloading through fm whatever isn't already
+// cached, and returns the bundle's cache directory.
+func ensureReferenceBundle(fm internal.FileManager, id string) (string, error) {
+	if referenceBundleCacheDir == "" {
+		return "", fmt.Errorf("FLOWY_REFERENCE_BUNDLE_CACHE_DIR is not configured")
+	}
+	bundleDir := filepath.Join(referenceBundleCacheDir, sanitizeBundleID(id))
+	marker := filepath.Join(bundleDir, verifiedMarkerName)
+
+	referenceBundleMu.Lock()
+	if fetch, loading := referenceBundleInFlight[id]; loading {
+		referenceBundleMu.Unlock()
+		<-fetch.done
+		if fetch.err != nil {
+			return "", fetch.err
+		}
+		return bundleDir, nil
+	}
+	if _, err := os.Stat(marker); err == nil {
+		referenceBundleMu.Unlock()
+		return bundleDir, nil
+	}
+	fetch := &bundleFetch{done: make(chan struct{})}
+	referenceBundleInFlight[id] = fetch
+	referenceBundleMu.Unlock()
+
+	fetch.err = downloadAndVerifyBundle(fm, id, bundleDir)
+	if fetch.err == nil {
+		fetch.err = os.WriteFile(marker, nil, 0o644)
+	}
+	close(fetch.done)
+
+	referenceBundleMu.Lock()
+	delete(referenceBundleInFlight, id)
+	referenceBundleMu.Unlock()
+
+	if fetch.err != nil {
+		return "", fetch.err
+	}
+	return bundleDir, nil
+}
+
+// downloadAndVerifyBundle fetches id's manifest and downloads and
+// verifies every file it lists into bundleDir.
+func downloadAndVerifyBundle(fm internal.FileManager, id, bundleDir string) error {
+	manifest, err := fetchBundleManifest(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(bundleDir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range manifest.Files {
+		target, err := bundleFilePath(bundleDir, file.RelPath)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := fm.Download(file.Location, target); err != nil {
+			return fmt.Errorf("downloading %s: %w", file.RelPath, err)
+		}
+		if err := verifyChecksum(target, file.Checksum); err != nil {
+			return fmt.Errorf("verifying %s: %w", file.RelPath, err)
+		}
+	}
+	return nil
+}
+
+// fetchBundleManifest retrieves id's manifest from the server's
+// reference-bundle registry.
+func fetchBundleManifest(id string) (api.ReferenceBundleManifest, error) {
+	resp, err := http.Get(serverURL + "/api/reference-bundles/" + url.PathEscape(id))
+	if err != nil {
+		return api.ReferenceBundleManifest{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return api.ReferenceBundleManifest{}, fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var manifest api.ReferenceBundleManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return api.ReferenceBundleManifest{}, err
+	}
+	return manifest, nil
+}
+
+// bundleFilePath resolves relPath against bundleDir, keeping anything that
+// would escape it inside instead (an absolute path or a "../" component is
+// cleaned as if rooted at bundleDir), since relPath names come from the
+// server's manifest rather than from a trusted local source. Only a path
+// that cleans to nothing is rejected.
+func bundleFilePath(bundleDir, relPath string) (string, error) {
+	cleaned := filepath.Clean("/" + relPath)[1:]
+	if cleaned == "" || cleaned == "." {
+		return "", fmt.Errorf("invalid manifest file path %q", relPath)
+	}
+	return filepath.Join(bundleDir, cleaned), nil
+}
+
+// bundleIDPattern restricts a sanitized bundle ID to characters safe to
+// use as a single path component, so a malformed or malicious ID can
+// never be read as containing a path separator.
+var bundleIDPattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// sanitizeBundleID rewrites id into something safe to use as a single
+// path component under referenceBundleCacheDir.
+func sanitizeBundleID(id string) string {
+	return bundleIDPattern.ReplaceAllString(id, "_")
+}
+
+// verifyChecksum hashes the file at path with the algorithm named in
+// checksum ("<algorithm>$<hex digest>", the same format CASKey parses)
+// and fails if it doesn't match.
+func verifyChecksum(path, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	algo, digest, ok := strings.Cut(checksum, "$")
+	if !ok {
+		return fmt.Errorf("invalid checksum %q, want \"algorithm$digest\"", checksum)
+	}
+	var h hash.Hash
+	switch algo {
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != digest {
+		os.Remove(path)
+		return fmt.Errorf("checksum mismatch: manifest says %s, downloaded file hashes to %s", digest, got)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// startBundleServer serves one reference bundle manifest backed by a
+// single file written under contentDir, whose checksum is computed from
+// its actual contents unless corruptChecksum is set.
+func startBundleServer(t *testing.T, contentDir string, corruptChecksum bool) *httptest.Server {
+	t.Helper()
+	content := []byte("reference genome contents")
+	srcPath := filepath.Join(contentDir, "genome.fa")
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	checksum := "sha256$" + hex.EncodeToString(sum[:])
+	if corruptChecksum {
+		checksum = "sha256$0000000000000000000000000000000000000000000000000000000000000000"
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.ReferenceBundleManifest{
+			ID: "hg38",
+			Files: []api.ReferenceBundleFile{
+				{RelPath: "genome.fa", Location: srcPath, Checksum: checksum},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func withServerAndCacheDir(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	origServer, origCache := serverURL, referenceBundleCacheDir
+	t.Cleanup(func() { serverURL, referenceBundleCacheDir = origServer, origCache })
+	serverURL = server.URL
+	referenceBundleCacheDir = t.TempDir()
+	return referenceBundleCacheDir
+}
+
+func TestEnsureReferenceBundleDownloadsAndVerifies(t *testing.T) {
+	server := startBundleServer(t, t.TempDir(), false)
+	withServerAndCacheDir(t, server)
+
+	fm := internal.NewLocalFileManager()
+	dir, err := ensureReferenceBundle(fm, "hg38")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "genome.fa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "reference genome contents" {
+		t.Fatalf("staged file contents = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, verifiedMarkerName)); err != nil {
+		t.Fatalf("expected verified marker to be written: %v", err)
+	}
+}
+
+func TestEnsureReferenceBundleIsCacheHitOnSecondCall(t *testing.T) {
+	contentDir := t.TempDir()
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	withServerAndCacheDir(t, server)
+
+	// Seed the cache directly, as if a prior call had already verified it.
+	bundleDir := filepath.Join(referenceBundleCacheDir, "hg38")
+	if err := os.MkdirAll(bundleDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, verifiedMarkerName), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = contentDir
+
+	fm := internal.NewLocalFileManager()
+	if _, err := ensureReferenceBundle(fm, "hg38"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected a cached bundle to skip the manifest fetch, got %d requests", requests)
+	}
+}
+
+func TestEnsureReferenceBundleFailsOnChecksumMismatch(t *testing.T) {
+	server := startBundleServer(t, t.TempDir(), true)
+	withServerAndCacheDir(t, server)
+
+	fm := internal.NewLocalFileManager()
+	if _, err := ensureReferenceBundle(fm, "hg38"); err == nil {
+		t.Fatal("expected a checksum mismatch to fail")
+	}
+}
+
+func TestEnsureReferenceBundleRequiresCacheDir(t *testing.T) {
+	origCache := referenceBundleCacheDir
+	defer func() { referenceBundleCacheDir = origCache }()
+	referenceBundleCacheDir = ""
+
+	fm := internal.NewLocalFileManager()
+	if _, err := ensureReferenceBundle(fm, "hg38"); err == nil {
+		t.Fatal("expected an unconfigured cache dir to fail")
+	}
+}
+
+func TestStageReferenceBundlesSymlinksIntoJobDir(t *testing.T) {
+	server := startBundleServer(t, t.TempDir(), false)
+	withServerAndCacheDir(t, server)
+
+	jobDir := t.TempDir()
+	fm := internal.NewLocalFileManager()
+	paths, err := stageReferenceBundles(fm, jobDir, []string{"hg38"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, ok := paths["hg38"]
+	if !ok {
+		t.Fatal("expected a path for bundle hg38")
+	}
+	if _, err := os.Stat(filepath.Join(link, "genome.fa")); err != nil {
+		t.Fatalf("expected genome.fa to be reachable through the symlink: %v", err)
+	}
+}
+
+func TestStageReferenceBundlesNoBundlesIsNoop(t *testing.T) {
+	paths, err := stageReferenceBundles(internal.NewLocalFileManager(), t.TempDir(), nil)
+	if err != nil || paths != nil {
+		t.Fatalf("stageReferenceBundles(nil) = (%v, %v), want (nil, nil)", paths, err)
+	}
+}
+
+func TestSanitizeBundleIDRejectsPathSeparators(t *testing.T) {
+	if got := sanitizeBundleID("../../etc/passwd"); filepath.Base(got) != got {
+		t.Fatalf("sanitizeBundleID(%q) = %q, still contains a path separator", "../../etc/passwd", got)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+// registryMirrors maps a source registry host to a mirror host that should
+// be pulled from instead, so an air-gapped or bandwidth-constrained site
+// can point the worker at a local pull-through cache without rewriting
+// every DockerImage reference in every job.
+var registryMirrors = parseRegistryMirrors(os.Getenv("FLOWY_REGISTRY_MIRRORS"))
+
+// pullThroughCache is a single registry host every pull falls through to
+// when no more specific FLOWY_REGISTRY_MIRRORS entry matches the image's
+// own registry, so a fleet of workers can share one cache without listing
+// every upstream registry a job might reference.
+var pullThroughCache = os.Getenv("FLOWY_PULL_THROUGH_CACHE")
+
+// parseRegistryMirrors parses a "source=mirror,source2=mirror2" list into a
+// lookup table. Malformed entries (missing "=") are skipped with a warning
+// rather than failing worker startup over a typo.
+func parseRegistryMirrors(v string) map[string]string {
+	if v == "" {
+		return nil
+	}
+	mirrors := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		source, mirror, ok := strings.Cut(pair, "=")
+		if !ok || source == "" || mirror == "" {
+			logger.Warn("ignoring malformed FLOWY_REGISTRY_MIRRORS entry", "entry", pair)
+			continue
+		}
+		mirrors[source] = mirror
+	}
+	return mirrors
+}
+
+// applyRegistryMirror rewrites image's registry host to its configured
+// mirror, if any. Images with no registry host (implicitly docker.io, e.g.
+// "ubuntu:22.04") are matched under the "docker.io" key, matching how other
+// registry-mirroring tools treat the default registry.
+func applyRegistryMirror(image string) string {
+	if len(registryMirrors) == 0 && pullThroughCache == "" {
+		return image
+	}
+	host, rest := splitRegistryHost(image)
+	if mirror, ok := registryMirrors[host]; ok {
+		return mirror + "/" + rest
+	}
+	if pullThroughCache != "" {
+		return pullThroughCache + "/" + rest
+	}
+	return image
+}
+
+// splitRegistryHost separates image's registry host from the rest of the
+// reference (repository[:tag][@digest]). A segment before the first "/" is
+// treated as a host only when it looks like one (contains "." or ":", or is
+// "localhost"), mirroring docker's own reference-parsing heuristic;
+// otherwise the image is implicitly on docker.io.
+func splitRegistryHost(image string) (host, rest string) {
+	first, remainder, found := strings.Cut(image, "/")
+	if !found || !looksLikeRegistryHost(first) {
+		return "docker.io", image
+	}
+	return first, remainder
+}
+
+func looksLikeRegistryHost(segment string) bool {
+	return segment == "localhost" || strings.ContainsAny(segment, ".:")
+}
+
+// isInsecureRegistry reports whether image's registry host was listed in
+// FLOWY_INSECURE_REGISTRIES, i.e. it is only reachable over plain HTTP or
+// with a self-signed certificate docker itself has been configured (via its
+// own insecure-registries daemon setting) to tolerate. The worker cannot
+// make docker trust such a registry from a single `docker run` invocation,
+// but it uses this to skip manifest/digest preflight checks that are
+// expected to fail noisily against it rather than treating that failure as
+// informative.
+var insecureRegistries = parseAllowlist(os.Getenv("FLOWY_INSECURE_REGISTRIES"))
+
+func isInsecureRegistry(image string) bool {
+	host, _ := splitRegistryHost(image)
+	for _, r := range insecureRegistries {
+		if r == host {
+			return true
+		}
+	}
+	return false
+}
+
+// isImageTarballRef reports whether ref names a tarball to load with
+// `docker load` rather than an image to pull: a local path or an
+// S3-hosted object ending in one of docker's recognized tar extensions.
+func isImageTarballRef(ref string) bool {
+	return strings.HasSuffix(ref, ".tar") || strings.HasSuffix(ref, ".tar.gz") || strings.HasSuffix(ref, ".tgz")
+}
+
+// loadImageTarball makes ref (a local path or an "s3://bucket/key" object)
+// available to docker and returns the image reference `docker load`
+// reported loading, for air-gapped clusters that ship images as tarballs
+// rather than pulling them from a registry. The tarball is fetched through
+// fm first when ref is a remote URL.
+func loadImageTarball(fm internal.FileManager, jobDir, ref string) (string, error) {
+	path := ref
+	if strings.Contains(ref, "://") {
+		local := filepath.Join(jobDir, "tmp", filepath.Base(ref))
+		if err := fm.Download(ref, local); err != nil {
+			return "", fmt.Errorf("downloading image tarball %q: %w", ref, err)
+		}
+		path = local
+	}
+
+	out, err := exec.Command("docker", "load", "-i", path).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker load -i %q: %w", path, err)
+	}
+	loaded := parseDockerLoadOutput(string(out))
+	if loaded == "" {
+		return "", fmt.Errorf("docker load -i %q did not report a loaded image", path)
+	}
+	return loaded, nil
+}
+
+// parseDockerLoadOutput extracts the image reference from `docker load`'s
+// "Loaded image: <ref>" (or older "Loaded image ID: <digest>") output line.
+func parseDockerLoadOutput(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "Loaded image: "); ok {
+			return strings.TrimSpace(rest)
+		}
+		if rest, ok := strings.CutPrefix(line, "Loaded image ID: "); ok {
+			return strings.TrimSpace(rest)
+		}
+	}
+	return ""
+}
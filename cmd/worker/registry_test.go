@@ -0,0 +1,110 @@
+package main
+
+import "testing"
+
+func TestParseRegistryMirrors(t *testing.T) {
+	got := parseRegistryMirrors("docker.io=mirror.internal,quay.io=quay-mirror.internal, ,bad-entry")
+	want := map[string]string{
+		"docker.io": "mirror.internal",
+		"quay.io":   "quay-mirror.internal",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseRegistryMirrors() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("parseRegistryMirrors()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestSplitRegistryHost(t *testing.T) {
+	cases := []struct {
+		image    string
+		wantHost string
+		wantRest string
+	}{
+		{"ubuntu:22.04", "docker.io", "ubuntu:22.04"},
+		{"myorg/mytool:1.0", "docker.io", "myorg/mytool:1.0"},
+		{"quay.io/myorg/mytool:1.0", "quay.io", "myorg/mytool:1.0"},
+		{"localhost:5000/mytool", "localhost:5000", "mytool"},
+		{"registry.example.com/mytool@sha256:abcd", "registry.example.com", "mytool@sha256:abcd"},
+	}
+	for _, c := range cases {
+		host, rest := splitRegistryHost(c.image)
+		if host != c.wantHost || rest != c.wantRest {
+			t.Errorf("splitRegistryHost(%q) = (%q, %q), want (%q, %q)", c.image, host, rest, c.wantHost, c.wantRest)
+		}
+	}
+}
+
+func TestApplyRegistryMirror(t *testing.T) {
+	orig := registryMirrors
+	defer func() { registryMirrors = orig }()
+	registryMirrors = map[string]string{"docker.io": "mirror.internal"}
+
+	if got := applyRegistryMirror("ubuntu:22.04"); got != "mirror.internal/ubuntu:22.04" {
+		t.Errorf("applyRegistryMirror() = %q, want mirror.internal/ubuntu:22.04", got)
+	}
+	if got := applyRegistryMirror("quay.io/myorg/mytool:1.0"); got != "quay.io/myorg/mytool:1.0" {
+		t.Errorf("applyRegistryMirror() rewrote an unconfigured registry: %q", got)
+	}
+}
+
+func TestApplyRegistryMirrorFallsBackToPullThroughCache(t *testing.T) {
+	origMirrors := registryMirrors
+	origCache := pullThroughCache
+	defer func() { registryMirrors = origMirrors; pullThroughCache = origCache }()
+	registryMirrors = map[string]string{"docker.io": "mirror.internal"}
+	pullThroughCache = "cache.internal:5000"
+
+	if got := applyRegistryMirror("ubuntu:22.04"); got != "mirror.internal/ubuntu:22.04" {
+		t.Errorf("applyRegistryMirror() = %q, want the more specific mirror.internal/ubuntu:22.04", got)
+	}
+	if got := applyRegistryMirror("quay.io/myorg/mytool:1.0"); got != "cache.internal:5000/myorg/mytool:1.0" {
+		t.Errorf("applyRegistryMirror() = %q, want fallback cache.internal:5000/myorg/mytool:1.0", got)
+	}
+}
+
+func TestIsInsecureRegistry(t *testing.T) {
+	orig := insecureRegistries
+	defer func() { insecureRegistries = orig }()
+	insecureRegistries = []string{"registry.internal:5000"}
+
+	if !isInsecureRegistry("registry.internal:5000/myorg/mytool:1.0") {
+		t.Error("expected registry.internal:5000 to be treated as insecure")
+	}
+	if isInsecureRegistry("quay.io/myorg/mytool:1.0") {
+		t.Error("expected quay.io not to be treated as insecure")
+	}
+}
+
+func TestIsImageTarballRef(t *testing.T) {
+	cases := map[string]bool{
+		"ubuntu:22.04":               false,
+		"/data/images/mytool.tar":    true,
+		"/data/images/mytool.tar.gz": true,
+		"s3://bucket/images/img.tgz": true,
+	}
+	for image, want := range cases {
+		if got := isImageTarballRef(image); got != want {
+			t.Errorf("isImageTarballRef(%q) = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestParseDockerLoadOutput(t *testing.T) {
+	cases := []struct {
+		output string
+		want   string
+	}{
+		{"Loaded image: mytool:1.0\n", "mytool:1.0"},
+		{"Loaded image ID: sha256:abcd1234\n", "sha256:abcd1234"},
+		{"no recognizable output\n", ""},
+	}
+	for _, c := range cases {
+		if got := parseDockerLoadOutput(c.output); got != c.want {
+			t.Errorf("parseDockerLoadOutput(%q) = %q, want %q", c.output, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// runReplay implements `flowy-worker replay <payload.json>`: it
+// re-executes a job payload recorded by -record-jobs locally, without
+// contacting a server, so a staging or execution bug reported from
+// production can be reproduced from the artifacts it left behind.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	workdir := fs.String("workdir", "/var/lib/flowy/work", "root directory to stage the replayed job under")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: flowy-worker replay <payload.json> [-workdir dir]")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("reading recorded payload: %w", err)
+	}
+	var job api.ExecutableJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return fmt.Errorf("decoding recorded payload: %w", err)
+	}
+
+	result, runErr := executeJob(*workdir, &job)
+	report := api.JobFinishedRequest{
+		JobID:   job.JobID,
+		Success: runErr == nil,
+		Project: job.Project,
+	}
+	applyExecutionResult(&report, result)
+	if runErr != nil {
+		report.ErrorMsg = runErr.Error()
+	}
+	attachResultSignature(&report)
+
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoded))
+
+	if runErr != nil {
+		return fmt.Errorf("replayed job %s failed: %w", job.JobID, runErr)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunReplayRequiresExactlyOneArgument(t *testing.T) {
+	if err := runReplay(nil); err == nil {
+		t.Fatal("expected a usage error with no arguments")
+	}
+	if err := runReplay([]string{"a.json", "b.json"}); err == nil {
+		t.Fatal("expected a usage error with too many arguments")
+	}
+}
+
+func TestRunReplayRejectsUnreadablePayload(t *testing.T) {
+	if err := runReplay([]string{filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Fatal("expected an error for a missing payload file")
+	}
+}
+
+func TestRunReplayRejectsInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runReplay([]string{path}); err == nil {
+		t.Fatal("expected an error for malformed recorded payload JSON")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/sysinfo"
+)
+
+// captureReproducibilityReport collects what this host and, if job ran in
+// one, container actually provided for job: the host's OS/arch, kernel,
+// CPU model, and locale, plus the output of any job.VersionProbes. image
+// is the resolved image reference the job's Command actually ran under
+// (empty for a job that ran directly on the host), used both as the
+// report's ContainerDigest and, when set, as where version probes run so
+// they report the tool version the container actually has rather than
+// whatever happens to be on the worker host's PATH.
+func captureReproducibilityReport(job *api.ExecutableJob, image string) *api.ReproducibilityReport {
+	report := &api.ReproducibilityReport{
+		ContainerDigest: image,
+		OS:              runtime.GOOS + "/" + runtime.GOARCH,
+		Locale:          localeFromEnv(),
+	}
+	if kernel, err := exec.Command("uname", "-r").Output(); err == nil {
+		report.Kernel = strings.TrimSpace(string(kernel))
+	} else {
+		logger.Debug("capturing kernel version for reproducibility report", "jobId", job.JobID, "error", err)
+	}
+	if cpu, err := sysinfo.CPUModel(); err == nil {
+		report.CPUModel = cpu
+	} else {
+		logger.Debug("capturing CPU model for reproducibility report", "jobId", job.JobID, "error", err)
+	}
+	if len(job.VersionProbes) > 0 {
+		report.ToolVersions = runVersionProbes(job.JobID, job.VersionProbes, image)
+	}
+	return report
+}
+
+// runVersionProbes runs each of probes' commands and collects its
+// trimmed combined output, keyed by the probe's label. When image is set,
+// a probe runs inside that container (via `docker run --rm`) rather than
+// on the worker host, so the captured version matches what Command itself
+// actually ran against. A probe that fails to run is logged and omitted,
+// never failing the job it's attached to.
+func runVersionProbes(jobID string, probes map[string][]string, image string) map[string]string {
+	versions := make(map[string]string, len(probes))
+	for label, probe := range probes {
+		if len(probe) == 0 {
+			continue
+		}
+		var cmd *exec.Cmd
+		if image != "" {
+			args := append([]string{"run", "--rm", image}, probe...)
+			cmd = exec.Command("docker", args...)
+		} else {
+			cmd = exec.Command(probe[0], probe[1:]...)
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			logger.Warn("version probe failed", "jobId", jobID, "probe", label, "error", err)
+			continue
+		}
+		versions[label] = strings.TrimSpace(string(out))
+	}
+	return versions
+}
+
+// localeFromEnv reports the locale a job ran under, the same precedence
+// glibc itself uses (LC_ALL overrides LANG), defaulting to "C" when
+// neither is set rather than leaving the report's Locale field empty and
+// ambiguous between "unset" and "failed to read".
+func localeFromEnv() string {
+	if v := envOr("LC_ALL", ""); v != "" {
+		return v
+	}
+	if v := envOr("LANG", ""); v != "" {
+		return v
+	}
+	return "C"
+}
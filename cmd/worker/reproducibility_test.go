@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestCaptureReproducibilityReportIncludesHostInfo(t *testing.T) {
+	job := &api.ExecutableJob{JobID: "job-1"}
+	report := captureReproducibilityReport(job, "")
+
+	if report.OS == "" {
+		t.Error("expected OS to be populated")
+	}
+	if report.ContainerDigest != "" {
+		t.Errorf("ContainerDigest = %q, want empty for a non-container job", report.ContainerDigest)
+	}
+}
+
+func TestCaptureReproducibilityReportSetsContainerDigest(t *testing.T) {
+	job := &api.ExecutableJob{JobID: "job-1"}
+	report := captureReproducibilityReport(job, "ubuntu@sha256:abcd")
+
+	if report.ContainerDigest != "ubuntu@sha256:abcd" {
+		t.Errorf("ContainerDigest = %q, want ubuntu@sha256:abcd", report.ContainerDigest)
+	}
+}
+
+func TestRunVersionProbesOnHost(t *testing.T) {
+	probes := map[string][]string{
+		"echo": {"echo", "v1.2.3"},
+	}
+	versions := runVersionProbes("job-1", probes, "")
+	if versions["echo"] != "v1.2.3" {
+		t.Errorf("versions[echo] = %q, want v1.2.3", versions["echo"])
+	}
+}
+
+func TestRunVersionProbesOmitsFailures(t *testing.T) {
+	probes := map[string][]string{
+		"missing": {"definitely-not-a-real-command-xyz"},
+	}
+	versions := runVersionProbes("job-1", probes, "")
+	if _, ok := versions["missing"]; ok {
+		t.Error("expected a failed probe to be omitted from the results")
+	}
+}
+
+func TestLocaleFromEnvPrefersLCAll(t *testing.T) {
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	t.Setenv("LANG", "C")
+	if got := localeFromEnv(); got != "en_US.UTF-8" {
+		t.Errorf("localeFromEnv() = %q, want en_US.UTF-8", got)
+	}
+}
+
+func TestLocaleFromEnvFallsBackToLANG(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "en_GB.UTF-8")
+	if got := localeFromEnv(); got != "en_GB.UTF-8" {
+		t.Errorf("localeFromEnv() = %q, want en_GB.UTF-8", got)
+	}
+}
+
+func TestLocaleFromEnvDefaultsToC(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "")
+	if got := localeFromEnv(); got != "C" {
+		t.Errorf("localeFromEnv() = %q, want C", got)
+	}
+}
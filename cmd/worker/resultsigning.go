@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/signing"
+)
+
+// resultSigningEnabled is on when FLOWY_SIGN_RESULTS=1, causing every
+// JobFinishedRequest to carry an Ed25519 signature over its Outputs that
+// cwlclient can verify against this worker's registered key, defending a
+// client reading outputs back from intermediate storage (e.g. a shared
+// NFS mount or object storage bucket) against tampering that happened
+// after the job finished.
+var resultSigningEnabled = os.Getenv("FLOWY_SIGN_RESULTS") == "1"
+
+// resultSigningKeyFile is where this worker's Ed25519 private key is
+// persisted across restarts. Without persistence, a worker restart would
+// invalidate every key a client has cached from a prior registration.
+var resultSigningKeyFile = os.Getenv("FLOWY_RESULT_SIGNING_KEY_FILE")
+
+var (
+	resultSigningKeyOnce sync.Once
+	resultSigningKey     ed25519.PrivateKey
+	resultSigningKeyErr  error
+)
+
+// loadOrCreateResultSigningKey returns this worker's Ed25519 private key,
+// loading it from resultSigningKeyFile if present and generating (and
+// persisting) a new one otherwise. The key is cached for the life of the
+// process: it is read/generated at most once.
+func loadOrCreateResultSigningKey() (ed25519.PrivateKey, error) {
+	resultSigningKeyOnce.Do(func() {
+		resultSigningKey, resultSigningKeyErr = loadOrCreateResultSigningKeyFile(resultSigningKeyFile)
+	})
+	return resultSigningKey, resultSigningKeyErr
+}
+
+func loadOrCreateResultSigningKeyFile(path string) (ed25519.PrivateKey, error) {
+	if path != "" {
+		if encoded, err := os.ReadFile(path); err == nil {
+			return signing.DecodePrivateKey(string(encoded))
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading result signing key %s: %w", path, err)
+		}
+	}
+
+	_, priv, err := signing.GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("generating result signing key: %w", err)
+	}
+	if path != "" {
+		if err := os.WriteFile(path, []byte(signing.EncodePrivateKey(priv)), 0o600); err != nil {
+			return nil, fmt.Errorf("persisting result signing key %s: %w", path, err)
+		}
+	}
+	return priv, nil
+}
+
+// resultSigningPublicKey returns this worker's base64-encoded Ed25519
+// public key, for inclusion in its registration request.
+func resultSigningPublicKey() (string, error) {
+	priv, err := loadOrCreateResultSigningKey()
+	if err != nil {
+		return "", err
+	}
+	return signing.EncodePublicKey(priv.Public().(ed25519.PublicKey)), nil
+}
+
+// attachResultSignature sets req.WorkerName and req.ResultSignature when
+// resultSigningEnabled, so the server (and ultimately cwlclient) can
+// verify req.Outputs were not altered after this worker reported them. It
+// is a no-op, leaving both fields empty, when result signing is disabled
+// or req carries no outputs to sign.
+func attachResultSignature(req *api.JobFinishedRequest) {
+	if !resultSigningEnabled || req.Outputs == nil {
+		return
+	}
+	priv, err := loadOrCreateResultSigningKey()
+	if err != nil {
+		logger.Error("loading result signing key", "jobId", req.JobID, "error", err)
+		return
+	}
+	payload, err := api.CanonicalResultsPayload(req.Outputs)
+	if err != nil {
+		logger.Error("encoding results for signing", "jobId", req.JobID, "error", err)
+		return
+	}
+	req.WorkerName = workerName
+	req.ResultSignature = signing.SignDetached(priv, payload)
+}
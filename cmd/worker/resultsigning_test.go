@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+	"github.com/bioflowy/flowy/internal/signing"
+)
+
+func resetResultSigningState(t *testing.T) {
+	t.Helper()
+	origEnabled, origKeyFile := resultSigningEnabled, resultSigningKeyFile
+	origKey, origErr := resultSigningKey, resultSigningKeyErr
+	t.Cleanup(func() {
+		resultSigningEnabled, resultSigningKeyFile = origEnabled, origKeyFile
+		resultSigningKeyOnce, resultSigningKey, resultSigningKeyErr = sync.Once{}, origKey, origErr
+	})
+	resultSigningKeyOnce = sync.Once{}
+}
+
+func TestAttachResultSignatureDisabledIsNoop(t *testing.T) {
+	resetResultSigningState(t)
+	resultSigningEnabled = false
+
+	req := api.JobFinishedRequest{JobID: "job-1", Outputs: map[string]interface{}{"out": "value"}}
+	attachResultSignature(&req)
+
+	if req.ResultSignature != "" || req.WorkerName != "" {
+		t.Fatalf("expected no signature attached when disabled, got %+v", req)
+	}
+}
+
+func TestAttachResultSignatureSignsOutputs(t *testing.T) {
+	resetResultSigningState(t)
+	resultSigningEnabled = true
+	resultSigningKeyFile = filepath.Join(t.TempDir(), "worker.key")
+
+	req := api.JobFinishedRequest{JobID: "job-1", Outputs: map[string]interface{}{"out": "value"}}
+	attachResultSignature(&req)
+
+	if req.ResultSignature == "" {
+		t.Fatal("expected a result signature to be attached")
+	}
+	if req.WorkerName != workerName {
+		t.Fatalf("WorkerName = %q, want %q", req.WorkerName, workerName)
+	}
+
+	priv, err := loadOrCreateResultSigningKey()
+	if err != nil {
+		t.Fatalf("loadOrCreateResultSigningKey: %v", err)
+	}
+	payload, err := api.CanonicalResultsPayload(req.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signing.VerifyDetached(priv.Public().(ed25519.PublicKey), payload, req.ResultSignature) {
+		t.Fatal("attached signature does not verify against the worker's own key")
+	}
+}
+
+func TestAttachResultSignatureNilOutputsIsNoop(t *testing.T) {
+	resetResultSigningState(t)
+	resultSigningEnabled = true
+	resultSigningKeyFile = filepath.Join(t.TempDir(), "worker.key")
+
+	req := api.JobFinishedRequest{JobID: "job-1"}
+	attachResultSignature(&req)
+
+	if req.ResultSignature != "" {
+		t.Fatal("expected no signature attached when Outputs is nil")
+	}
+}
+
+func TestLoadOrCreateResultSigningKeyPersistsAcrossCalls(t *testing.T) {
+	resetResultSigningState(t)
+	resultSigningKeyFile = filepath.Join(t.TempDir(), "worker.key")
+
+	first, err := loadOrCreateResultSigningKeyFile(resultSigningKeyFile)
+	if err != nil {
+		t.Fatalf("loadOrCreateResultSigningKeyFile: %v", err)
+	}
+	second, err := loadOrCreateResultSigningKeyFile(resultSigningKeyFile)
+	if err != nil {
+		t.Fatalf("loadOrCreateResultSigningKeyFile: %v", err)
+	}
+	if !first.Equal(second) {
+		t.Fatal("expected the key to be reloaded from disk unchanged, got a different key")
+	}
+}
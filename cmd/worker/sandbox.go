@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// sandboxEnabled controls whether non-container jobs run under bubblewrap,
+// set via FLOWY_SANDBOX=bwrap. It defaults to off since bubblewrap isn't
+// installed everywhere direct-exec mode is used.
+var sandboxEnabled = os.Getenv("FLOWY_SANDBOX") == "bwrap"
+
+// bubblewrapCommand wraps command so it runs under bwrap confined to jobDir
+// and its own tmpdir, mirroring the single bind mount docker jobs get via
+// prepareForDocker: the rest of the host filesystem is visible read-only so
+// the tool's own binaries and libraries still resolve, but nothing outside
+// jobDir is writable and no other job's workdir is reachable.
+func bubblewrapCommand(jobDir string, command []string) ([]string, error) {
+	if !sandboxEnabled {
+		return command, nil
+	}
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		return nil, fmt.Errorf("sandboxing requires bubblewrap (bwrap): %w", err)
+	}
+
+	wrapper := []string{
+		"bwrap",
+		"--ro-bind", "/", "/",
+		"--bind", jobDir, jobDir,
+		"--tmpfs", "/tmp",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--unshare-pid",
+		"--unshare-ipc",
+		"--unshare-uts",
+		"--die-with-parent",
+		"--chdir", jobDir,
+		"--",
+	}
+	return append(wrapper, command...), nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+// scratchDir is FLOWY_SCRATCH_DIR, applied to internal's FileManager
+// helpers at startup so every temp file they create (a dedup pointer
+// object, an S3 cross-bucket copy's local round trip) lands under it
+// instead of the OS's default temp directory - useful for pointing
+// scratch I/O at a larger disk, or a tmpfs mount for small files. It is
+// separate from -workdir, which already governs where each job's own
+// files are staged and cleaned up.
+var scratchDir = applyScratchDirFromEnv()
+
+func applyScratchDirFromEnv() string {
+	dir := os.Getenv("FLOWY_SCRATCH_DIR")
+	internal.SetScratchDir(dir)
+	return dir
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/api"
+	flowerrors "github.com/bioflowy/flowy/internal/errors"
+)
+
+// serverURL is the base URL of the flowy server this worker polls.
+var serverURL = envOr("FLOWY_SERVER_URL", "http://localhost:8080")
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// fetchNextJob asks the server for the next queued job for this worker. A
+// nil job with a nil error means the queue is currently empty.
+func fetchNextJob() (*api.ExecutableJob, error) {
+	resp, err := http.Get(serverURL + "/api/worker/next-job")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetchNextJob: server returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyPayload(body, resp.Header); err != nil {
+		return nil, fmt.Errorf("fetchNextJob: %w", err)
+	}
+
+	job, err := api.DecodeExecutableJob(body)
+	if err != nil {
+		return nil, fmt.Errorf("fetchNextJob: %w", err)
+	}
+	recordJobPayload(job)
+	return job, nil
+}
+
+// fetchJobBatch asks the server for up to limit queued jobs at once, the
+// batch counterpart to fetchNextJob used when workerBatchSize > 1. A nil
+// slice with a nil error means the queue is currently empty.
+func fetchJobBatch(limit int) ([]*api.ExecutableJob, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/worker/next-jobs?limit=%d", serverURL, limit))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetchJobBatch: server returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyPayload(body, resp.Header); err != nil {
+		return nil, fmt.Errorf("fetchJobBatch: %w", err)
+	}
+
+	var batch api.JobBatchResponse
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, fmt.Errorf("fetchJobBatch: %w", err)
+	}
+	jobs := make([]*api.ExecutableJob, len(batch.Jobs))
+	for i := range batch.Jobs {
+		if err := api.ValidateExecutableJob(&batch.Jobs[i]); err != nil {
+			return nil, fmt.Errorf("fetchJobBatch: %w", err)
+		}
+		jobs[i] = &batch.Jobs[i]
+		recordJobPayload(jobs[i])
+	}
+	return jobs, nil
+}
+
+// reportJobFailed notifies the server that job failed, for every error
+// executeJob returns: a job that could not be executed at all, or one that
+// ran and exited non-zero, hit its time limit, lost a speculative race or
+// was preempted. result carries whatever partial
+// results were collectible (e.g. after a ToolTimeLimit kill), and may be
+// nil when the job failed before executeJob ever ran Command.
+func reportJobFailed(job *api.ExecutableJob, cause error, result *executionResult) {
+	var timeErr *timeLimitError
+	var preemptErr *preemptedError
+	req := api.JobFinishedRequest{
+		JobID:             job.JobID,
+		Success:           false,
+		ErrorMsg:          cause.Error(),
+		ErrorCode:         errorCode(cause),
+		PermanentFail:     errorIsPermanent(cause),
+		TimeLimitExceeded: errors.As(cause, &timeErr),
+		Requeue:           errors.As(cause, &preemptErr),
+		Project:           job.Project,
+		Labels:            job.Labels,
+	}
+	applyExecutionResult(&req, result)
+	postJobFinished(req)
+}
+
+// errorIsPermanent reports whether cause is a failure retrying the job
+// cannot fix: the worker's own permanentError and cancelledError, plus a
+// flowerrors.SpecViolation, since a job definition that violates the CWL
+// spec violates it identically on every retry.
+func errorIsPermanent(cause error) bool {
+	var permErr *permanentError
+	var cancelErr *cancelledError
+	var specErr *flowerrors.SpecViolation
+	return errors.As(cause, &permErr) || errors.As(cause, &cancelErr) || errors.As(cause, &specErr)
+}
+
+// errorCode returns cause's internal/errors Code as a string for
+// JobFinishedRequest.ErrorCode, so the server can facet failures by
+// category (staging, transfer, eval, ...) without parsing ErrorMsg. "" if
+// cause isn't one of the typed categories in internal/errors.
+func errorCode(cause error) string {
+	var coded flowerrors.Coded
+	if !errors.As(cause, &coded) {
+		return ""
+	}
+	return string(coded.Code())
+}
+
+// reportJobFinished notifies the server that job completed successfully,
+// attaching its resource usage for capacity planning and its collected
+// outputs.
+func reportJobFinished(job *api.ExecutableJob, result *executionResult) {
+	req := api.JobFinishedRequest{
+		JobID:    job.JobID,
+		Success:  true,
+		Project:  job.Project,
+		Labels:   job.Labels,
+		CacheKey: computeCacheKey(job),
+	}
+	applyExecutionResult(&req, result)
+	postJobFinished(req)
+}
+
+// applyExecutionResult copies result's fields onto req, left untouched
+// when result is nil (a job that failed before executeJob produced one).
+func applyExecutionResult(req *api.JobFinishedRequest, result *executionResult) {
+	if result == nil {
+		return
+	}
+	req.Usage = result.Usage
+	req.Outputs = result.Outputs
+	req.SoftwareEnvironment = result.SoftwareEnvironment
+	req.ImageDigest = result.ImageDigest
+	req.ExitCode = result.ExitCode
+	req.Attempt = result.Attempt
+	req.Reproducibility = result.Reproducibility
+}
+
+func postJobFinished(req api.JobFinishedRequest) {
+	attachResultSignature(&req)
+	recordJobReport(req)
+
+	body, _ := json.Marshal(req)
+	httpReq, err := http.NewRequest(http.MethodPost, serverURL+"/api/worker/job-finished", bytes.NewReader(body))
+	if err != nil {
+		logger.Error("building job completion report", "jobId", req.JobID, "error", err)
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	signRequest(httpReq, body)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		logger.Error("reporting job completion to server", "jobId", req.JobID, "error", err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	flowerrors "github.com/bioflowy/flowy/internal/errors"
+)
+
+func TestErrorCodeClassifiesTypedErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"staging", flowerrors.Staging("in.txt", fmt.Errorf("boom")), "staging"},
+		{"transfer", flowerrors.Transfer("s3", "download", "k", fmt.Errorf("boom")), "transfer"},
+		{"eval", flowerrors.Eval("$(1)", fmt.Errorf("boom")), "eval"},
+		{"outputCollection", flowerrors.OutputCollection("out", fmt.Errorf("boom")), "output_collection"},
+		{"specViolation", flowerrors.Spec("InitialWorkDirRequirement", fmt.Errorf("boom")), "spec_violation"},
+		{"wrapped", fmt.Errorf("context: %w", flowerrors.Eval("$(1)", fmt.Errorf("boom"))), "eval"},
+		{"untyped", fmt.Errorf("plain failure"), ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := errorCode(c.err); got != c.want {
+				t.Fatalf("errorCode() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestReportJobFailedMarksSpecViolationPermanent(t *testing.T) {
+	err := flowerrors.Spec("InitialWorkDirRequirement", fmt.Errorf("duplicate entryname"))
+	if !errorIsPermanent(err) {
+		t.Fatal("expected a SpecViolation to be classified as a permanent failure")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// sharedInputsDirName is the subdirectory of workdir stageSharedInputs
+// downloads deduplicated fileitems into, kept read-only and shared across
+// every job in a batch rather than under any one job's own jobDir, since
+// it must outlive each individual job's own cleanup.
+const sharedInputsDirName = ".shared-inputs"
+
+// stageSharedInputs detects File mapping entries whose Resolved location
+// is identical across two or more jobs in the batch (the common case for
+// an array/scatter step's shared reference inputs) and downloads each one
+// exactly once into workdir's shared-inputs cache, rewriting every
+// matching entry's Resolved to that cached path and marking it
+// SharedInput so stageEntry symlinks straight to it instead of
+// downloading or copying it again per job. Jobs are mutated in place;
+// entries used by only one job in the batch are left untouched, since
+// there is nothing to amortize, and so are Writable entries, which a job
+// may modify.
+func stageSharedInputs(fm internal.FileManager, workdir string, jobs []*api.ExecutableJob) error {
+	counts := make(map[string]int)
+	for _, job := range jobs {
+		seen := make(map[string]bool)
+		forEachFileEntry(job.Mapping, func(ent *api.MapperEnt) {
+			if ent.Resolved != "" && !ent.Writable && !seen[ent.Resolved] {
+				seen[ent.Resolved] = true
+				counts[ent.Resolved]++
+			}
+		})
+	}
+
+	cacheDir := filepath.Join(workdir, sharedInputsDirName)
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return err
+	}
+	staged := make(map[string]string)
+	var mu sync.Mutex
+
+	for _, job := range jobs {
+		var stageErr error
+		forEachFileEntry(job.Mapping, func(ent *api.MapperEnt) {
+			if stageErr != nil || ent.Resolved == "" || ent.Writable || counts[ent.Resolved] < 2 {
+				return
+			}
+			mu.Lock()
+			cached, ok := staged[ent.Resolved]
+			if !ok {
+				cached = filepath.Join(cacheDir, sharedCacheKey(ent.Resolved))
+				if err := fm.Download(ent.Resolved, cached); err != nil {
+					mu.Unlock()
+					stageErr = fmt.Errorf("staging shared input %q: %w", ent.Resolved, err)
+					return
+				}
+				staged[ent.Resolved] = cached
+			}
+			mu.Unlock()
+
+			ent.Resolved = cached
+			ent.SharedInput = true
+		})
+		if stageErr != nil {
+			return stageErr
+		}
+	}
+	return nil
+}
+
+// forEachFileEntry calls fn for every File entry in mapping, including
+// ones nested inside a Directory literal's Listing, so a shared input
+// buried in a Directory literal's contents is deduplicated the same as a
+// top-level one.
+func forEachFileEntry(mapping []api.MapperEnt, fn func(*api.MapperEnt)) {
+	for i := range mapping {
+		if mapping[i].Type == "File" {
+			fn(&mapping[i])
+			continue
+		}
+		forEachFileEntry(mapping[i].Listing, fn)
+	}
+}
+
+// sharedCacheKey returns the shared-inputs cache filename for resolved,
+// keyed by its own hash rather than its basename so two inputs with the
+// same filename but different locations never collide.
+func sharedCacheKey(resolved string) string {
+	sum := sha256.Sum256([]byte(resolved))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestStageSharedInputsDeduplicatesAcrossJobs(t *testing.T) {
+	workdir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "reference.fa")
+	if err := os.WriteFile(src, []byte("shared genome"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := []*api.ExecutableJob{
+		{JobID: "shard-1", Mapping: []api.MapperEnt{{Type: "File", Staged: true, Resolved: src, Target: "reference.fa"}}},
+		{JobID: "shard-2", Mapping: []api.MapperEnt{{Type: "File", Staged: true, Resolved: src, Target: "reference.fa"}}},
+	}
+
+	fm := internal.NewLocalFileManager()
+	if err := stageSharedInputs(fm, workdir, jobs); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, job := range jobs {
+		ent := job.Mapping[0]
+		if !ent.SharedInput {
+			t.Fatalf("job %s: expected SharedInput to be set", job.JobID)
+		}
+		if ent.Resolved == src {
+			t.Fatalf("job %s: expected Resolved to be rewritten to the shared cache", job.JobID)
+		}
+	}
+	if jobs[0].Mapping[0].Resolved != jobs[1].Mapping[0].Resolved {
+		t.Fatal("expected both jobs to share the same cached path")
+	}
+	data, err := os.ReadFile(jobs[0].Mapping[0].Resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "shared genome" {
+		t.Fatalf("cached content = %q", data)
+	}
+}
+
+func TestStageSharedInputsLeavesSingletonsAlone(t *testing.T) {
+	workdir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "unique.txt")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := []*api.ExecutableJob{
+		{JobID: "only", Mapping: []api.MapperEnt{{Type: "File", Staged: true, Resolved: src, Target: "unique.txt"}}},
+	}
+
+	fm := internal.NewLocalFileManager()
+	if err := stageSharedInputs(fm, workdir, jobs); err != nil {
+		t.Fatal(err)
+	}
+	if jobs[0].Mapping[0].SharedInput || jobs[0].Mapping[0].Resolved != src {
+		t.Fatal("expected a singleton input to be left untouched")
+	}
+}
+
+func TestStageEntrySymlinksSharedInput(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "cached.txt")
+	if err := os.WriteFile(src, []byte("cached"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(t.TempDir(), "out.txt")
+
+	ent := api.MapperEnt{Type: "File", Resolved: src, SharedInput: true}
+	fm := internal.NewLocalFileManager()
+	if err := stageEntry(fm, filepath.Dir(target), ent, target); err != nil {
+		t.Fatal(err)
+	}
+	link, err := os.Readlink(target)
+	if err != nil {
+		t.Fatalf("expected target to be a symlink: %v", err)
+	}
+	if link != src {
+		t.Fatalf("symlink target = %q, want %q", link, src)
+	}
+}
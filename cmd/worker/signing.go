@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bioflowy/flowy/internal/signing"
+)
+
+// signatureHeader carries the hex-encoded HMAC-SHA256 of a request/response
+// body, computed under signingKey.
+const signatureHeader = "X-Flowy-Signature"
+
+// signedPayloads is on when FLOWY_SIGNED_PAYLOADS=1, requiring every job
+// payload fetched from the server and every result report sent to it to
+// carry a valid signatureHeader. It defaults to off so workers talking to
+// a server that doesn't support the registration handshake yet keep
+// working unmodified.
+var signedPayloads = os.Getenv("FLOWY_SIGNED_PAYLOADS") == "1"
+
+// signingKey is shared with the server during worker registration and used
+// to sign outgoing payloads and verify incoming ones. It is nil until
+// registerWorker succeeds.
+var signingKey []byte
+
+type registerWorkerRequest struct {
+	Name string `json:"name"`
+	// ResultSigningKey is this worker's base64-encoded Ed25519 public
+	// key, included so the server can hand it out to clients verifying
+	// ResultSignature. Empty when result signing is disabled.
+	ResultSigningKey string `json:"resultSigningKey,omitempty"`
+}
+
+type registerWorkerResponse struct {
+	Key string `json:"key"`
+}
+
+// registerWorker exchanges this worker's name for the HMAC key the server
+// will use to sign job payloads and expects on result reports. It must
+// succeed before the worker fetches or reports any job when signedPayloads
+// is on, since an unsigned handshake would defeat the point of signing.
+func registerWorker() error {
+	req := registerWorkerRequest{Name: workerName}
+	if resultSigningEnabled {
+		pub, err := resultSigningPublicKey()
+		if err != nil {
+			return fmt.Errorf("preparing result signing key for registration: %w", err)
+		}
+		req.ResultSigningKey = pub
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(serverURL+"/api/worker/register", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("registering with server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registering with server: server returned %s", resp.Status)
+	}
+
+	var reg registerWorkerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reg); err != nil {
+		return fmt.Errorf("decoding registration response: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(reg.Key)
+	if err != nil {
+		return fmt.Errorf("decoding signing key: %w", err)
+	}
+	signingKey = key
+	return nil
+}
+
+// verifyPayload checks body against the signature in header, when
+// signedPayloads is on. A missing or mismatched signature is always a
+// hard failure; there is no "unsigned but accepted" fallback once signing
+// is enabled.
+func verifyPayload(body []byte, header http.Header) error {
+	if !signedPayloads {
+		return nil
+	}
+	sig := header.Get(signatureHeader)
+	if sig == "" {
+		return fmt.Errorf("missing %s header on signed payload", signatureHeader)
+	}
+	if !signing.Verify(signingKey, body, sig) {
+		return fmt.Errorf("invalid %s on payload", signatureHeader)
+	}
+	return nil
+}
+
+// signRequest attaches a signatureHeader to req when signedPayloads is on.
+func signRequest(req *http.Request, body []byte) {
+	if !signedPayloads {
+		return
+	}
+	req.Header.Set(signatureHeader, signing.Sign(signingKey, body))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// condaEnvCacheDir is where conda environments built from an inline
+// environment.yml (api.SoftwareEnvironment.CondaYAML) are cached across
+// jobs, keyed by a hash of the YAML content, so two jobs requesting the
+// same software environment don't each pay to rebuild it. Set via
+// FLOWY_CONDA_ENV_CACHE; CondaYAML jobs fail without it, since there is
+// nowhere to put the environment.
+var condaEnvCacheDir = os.Getenv("FLOWY_CONDA_ENV_CACHE")
+
+// applySoftwareEnvironment wraps command so it runs inside the software
+// environment env describes, for sites running jobs directly on the host
+// rather than in a container. It returns the wrapped command and what it
+// actually resolved to, for the caller to report as provenance; a nil env
+// returns command and a nil resolution unchanged.
+func applySoftwareEnvironment(env *api.SoftwareEnvironment, command []string) ([]string, *api.SoftwareEnvironment, error) {
+	if env == nil {
+		return command, nil, nil
+	}
+	switch {
+	case env.CondaYAML != "":
+		envPath, err := ensureCondaEnv(env.CondaYAML)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving conda environment: %w", err)
+		}
+		return condaRunCommand(envPath, command), &api.SoftwareEnvironment{CondaEnv: envPath}, nil
+	case env.CondaEnv != "":
+		return condaRunCommand(env.CondaEnv, command), &api.SoftwareEnvironment{CondaEnv: env.CondaEnv}, nil
+	case len(env.Modules) > 0:
+		return moduleLoadCommand(env.Modules, command), &api.SoftwareEnvironment{Modules: env.Modules}, nil
+	default:
+		return command, nil, nil
+	}
+}
+
+// condaRunCommand wraps command to run inside the named or pathed conda
+// environment via `conda run`, which activates the environment for the
+// duration of the wrapped command without leaking activation state into
+// the worker process itself or any job that runs after it.
+func condaRunCommand(nameOrPath string, command []string) []string {
+	flag := "-n"
+	if strings.ContainsRune(nameOrPath, filepath.Separator) {
+		flag = "-p"
+	}
+	args := []string{"conda", "run", flag, nameOrPath, "--no-capture-output"}
+	return append(args, command...)
+}
+
+// moduleLoadCommand wraps command in a shell that sources Lmod's init
+// script and loads modules, in order, before exec'ing command: `module
+// load` is a shell function Lmod installs, not a binary, so it can't be
+// exec'd directly the way `conda run` can.
+func moduleLoadCommand(modules []string, command []string) []string {
+	var b strings.Builder
+	b.WriteString("source /etc/profile.d/lmod.sh 2>/dev/null || source /usr/share/lmod/lmod/init/sh; ")
+	for _, m := range modules {
+		fmt.Fprintf(&b, "module load %s && ", shellQuoteSingle(m))
+	}
+	b.WriteString(`exec "$@"`)
+	return append([]string{"sh", "-c", b.String(), "sh"}, command...)
+}
+
+// shellQuoteSingle single-quotes s for interpolation into the sh -c
+// script moduleLoadCommand builds, so a module name is never interpreted
+// as shell syntax.
+func shellQuoteSingle(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ensureCondaEnv materializes a conda environment from yamlSpec under
+// condaEnvCacheDir, keyed by a hash of its content, and returns its path.
+// An environment already cached from an earlier job with the same spec is
+// reused as-is, since a SoftwareRequirement environment is expected to be
+// immutable once created.
+func ensureCondaEnv(yamlSpec string) (string, error) {
+	if condaEnvCacheDir == "" {
+		return "", fmt.Errorf("no conda environment cache configured (set FLOWY_CONDA_ENV_CACHE)")
+	}
+	sum := sha256.Sum256([]byte(yamlSpec))
+	envPath := filepath.Join(condaEnvCacheDir, hex.EncodeToString(sum[:]))
+	if _, err := os.Stat(envPath); err == nil {
+		return envPath, nil
+	}
+
+	specFile, err := os.CreateTemp(scratchDir, "flowy-conda-env-*.yml")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(specFile.Name())
+	if _, err := specFile.WriteString(yamlSpec); err != nil {
+		specFile.Close()
+		return "", err
+	}
+	if err := specFile.Close(); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("conda", "env", "create", "-p", envPath, "-f", specFile.Name())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(envPath)
+		return "", fmt.Errorf("conda env create: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return envPath, nil
+}
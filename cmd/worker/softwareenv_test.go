@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestApplySoftwareEnvironmentNilIsNoop(t *testing.T) {
+	command := []string{"echo", "hi"}
+	got, resolved, err := applySoftwareEnvironment(nil, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != nil {
+		t.Fatalf("expected no resolution for a nil environment, got %+v", resolved)
+	}
+	if len(got) != len(command) || got[0] != command[0] {
+		t.Fatalf("expected command unchanged, got %v", got)
+	}
+}
+
+func TestApplySoftwareEnvironmentCondaEnvWrapsWithCondaRun(t *testing.T) {
+	env := &api.SoftwareEnvironment{CondaEnv: "my-tool-env"}
+	got, resolved, err := applySoftwareEnvironment(env, []string{"mytool", "--flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"conda", "run", "-n", "my-tool-env", "--no-capture-output", "mytool", "--flag"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if resolved == nil || resolved.CondaEnv != "my-tool-env" {
+		t.Fatalf("expected resolution to report the activated env, got %+v", resolved)
+	}
+}
+
+func TestApplySoftwareEnvironmentModulesWrapsWithModuleLoad(t *testing.T) {
+	env := &api.SoftwareEnvironment{Modules: []string{"samtools/1.17", "bwa/0.7.17"}}
+	got, resolved, err := applySoftwareEnvironment(env, []string{"samtools", "view"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != "sh" || got[1] != "-c" {
+		t.Fatalf("expected a shell wrapper, got %v", got)
+	}
+	if got[len(got)-2] != "samtools" || got[len(got)-1] != "view" {
+		t.Fatalf("expected the original command appended as sh -c's positional args, got %v", got)
+	}
+	if resolved == nil || len(resolved.Modules) != 2 || resolved.Modules[0] != "samtools/1.17" {
+		t.Fatalf("expected resolution to report the loaded modules, got %+v", resolved)
+	}
+}
+
+func TestApplySoftwareEnvironmentCondaYAMLWithoutCacheFails(t *testing.T) {
+	old := condaEnvCacheDir
+	condaEnvCacheDir = ""
+	defer func() { condaEnvCacheDir = old }()
+
+	_, _, err := applySoftwareEnvironment(&api.SoftwareEnvironment{CondaYAML: "name: x"}, []string{"x"})
+	if err == nil {
+		t.Fatal("expected an error when no conda environment cache is configured")
+	}
+}
+
+func TestEnsureCondaEnvReusesCachedEnvironment(t *testing.T) {
+	old := condaEnvCacheDir
+	condaEnvCacheDir = t.TempDir()
+	defer func() { condaEnvCacheDir = old }()
+
+	yaml := "name: cached-env\ndependencies:\n  - python=3.11\n"
+
+	// Pre-seed the cache entry directly, bypassing the `conda env create`
+	// shell-out entirely, and confirm ensureCondaEnv recognizes it as
+	// cached rather than invoking conda at all.
+	sum := sha256.Sum256([]byte(yaml))
+	expected := filepath.Join(condaEnvCacheDir, hex.EncodeToString(sum[:]))
+	if err := os.MkdirAll(expected, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	envPath2, err := ensureCondaEnv(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envPath2 != expected {
+		t.Fatalf("got %q, want %q", envPath2, expected)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// cancelledError marks a job failure caused by losing a speculative race
+// to another copy of the same shard, rather than the tool itself failing,
+// so callers can report it distinctly (see reportJobFailed) instead of as
+// an ordinary run error, and never retry it.
+type cancelledError struct {
+	err error
+}
+
+func cancelledFail(err error) error {
+	return &cancelledError{err: err}
+}
+
+func (e *cancelledError) Error() string { return e.err.Error() }
+func (e *cancelledError) Unwrap() error { return e.err }
+
+// speculativeCancelPollInterval is how often a speculative shard copy
+// polls the server to see whether another copy of the same shard has
+// already finished, via FLOWY_SPECULATIVE_POLL_INTERVAL_SECONDS.
+var speculativeCancelPollInterval = time.Duration(envIntOr("FLOWY_SPECULATIVE_POLL_INTERVAL_SECONDS", 10)) * time.Second
+
+// cancellationStatus is the server's reply to a speculative cancellation
+// poll.
+type cancellationStatus struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+// pollJobCancelled asks the server whether jobID has been cancelled - the
+// outcome of losing a speculative race to another copy of the same shard.
+func pollJobCancelled(jobID string) (bool, error) {
+	resp, err := http.Get(serverURL + "/api/worker/jobs/" + jobID + "/cancellation")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pollJobCancelled: server returned %s", resp.Status)
+	}
+
+	var status cancellationStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return false, err
+	}
+	return status.Cancelled, nil
+}
+
+// watchForCancellation ticks every speculativeCancelPollInterval, checking
+// first whether this worker is being preempted (see preemptionActive) and,
+// only when speculative, whether pollJobCancelled reports jobID has lost
+// its race to another copy of the same shard. Either condition sets the
+// corresponding flag and kills cmd via killGracefully; stop being closed
+// first (the job finished on its own) ends the watcher with neither flag
+// set. A cancellation poll error is logged and otherwise ignored: a
+// transient network blip here must not kill a job that is making fine
+// progress.
+func watchForCancellation(cmd *exec.Cmd, jobID string, speculative bool, stop <-chan struct{}, cancelled, preempted *atomic.Bool) {
+	ticker := time.NewTicker(speculativeCancelPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if preemptionActive() {
+				logger.Info("job killed: worker is being preempted, will report for requeue", "jobId", jobID)
+				preempted.Store(true)
+				killGracefully(cmd, stop)
+				return
+			}
+			if !speculative {
+				continue
+			}
+			isCancelled, err := pollJobCancelled(jobID)
+			if err != nil {
+				logger.Warn("polling speculative cancellation status", "jobId", jobID, "error", err)
+				continue
+			}
+			if !isCancelled {
+				continue
+			}
+			logger.Info("job cancelled: another speculative copy finished first", "jobId", jobID)
+			cancelled.Store(true)
+			killGracefully(cmd, stop)
+			return
+		}
+	}
+}
+
+// killGracefully sends cmd's process SIGTERM and escalates to SIGKILL
+// after timeLimitGrace if it hasn't exited by then (stop closing first
+// means cmd.Wait's caller already observed it exit, so there is nothing
+// left to escalate).
+func killGracefully(cmd *exec.Cmd, stop <-chan struct{}) {
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-stop:
+	case <-time.After(timeLimitGrace):
+		_ = cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunCommandKillsProcessWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(cancellationStatus{Cancelled: true})
+	}))
+	defer server.Close()
+
+	origServerURL, origInterval, origGrace := serverURL, speculativeCancelPollInterval, timeLimitGrace
+	serverURL = server.URL
+	speculativeCancelPollInterval = 10 * time.Millisecond
+	timeLimitGrace = 200 * time.Millisecond
+	defer func() {
+		serverURL, speculativeCancelPollInterval, timeLimitGrace = origServerURL, origInterval, origGrace
+	}()
+
+	cmd := exec.Command("sh", "-c", "sleep 5")
+	start := time.Now()
+	timedOut, cancelled, _, err := runCommand(cmd, 0, "job-1", true)
+	elapsed := time.Since(start)
+
+	if timedOut {
+		t.Fatal("expected timedOut = false for a cancellation, not a time limit")
+	}
+	if !cancelled {
+		t.Fatal("expected cancelled = true")
+	}
+	if err == nil {
+		t.Fatal("expected an error from a signaled process")
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("runCommand took %v, expected the cancellation watcher to end the sleep quickly", elapsed)
+	}
+}
+
+func TestRunCommandNotCancelledWhenServerReportsNotCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(cancellationStatus{Cancelled: false})
+	}))
+	defer server.Close()
+
+	origServerURL, origInterval := serverURL, speculativeCancelPollInterval
+	serverURL = server.URL
+	speculativeCancelPollInterval = 10 * time.Millisecond
+	defer func() { serverURL, speculativeCancelPollInterval = origServerURL, origInterval }()
+
+	cmd := exec.Command("sh", "-c", "sleep 0.1; exit 0")
+	_, cancelled, _, err := runCommand(cmd, 0, "job-1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled {
+		t.Fatal("expected cancelled = false")
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bioflowy/flowy/internal"
+	"github.com/bioflowy/flowy/internal/api"
+	flowerrors "github.com/bioflowy/flowy/internal/errors"
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// initialWorkDirCollisionMode controls what stageInputs does when two
+// staged entries resolve to the same Target path inside a job's directory.
+type initialWorkDirCollisionMode string
+
+const (
+	collisionError  initialWorkDirCollisionMode = "error"
+	collisionRename initialWorkDirCollisionMode = "rename"
+)
+
+// initialWorkDirCollision is the worker's default collision policy for
+// InitialWorkDirRequirement entries. The CWL spec says implementations
+// "must raise an error if [...] the same entryname is used more than
+// once"; cwltool instead offers an opt-in rename behavior, which this
+// worker supports via FLOWY_INITWORKDIR_COLLISION for pipelines that rely
+// on it.
+var initialWorkDirCollision = parseInitialWorkDirCollision(os.Getenv("FLOWY_INITWORKDIR_COLLISION"), collisionError)
+
+// inputFileManagerMetrics is the innermost, undecorated input/staging
+// backend, kept as its own var for the same reason outputFileManagerMetrics
+// is in filemanager.go: reportHeartbeat reads its Health() after every
+// other decorator in stageInputs' chain has had a chance to call through
+// it.
+var inputFileManagerMetrics = internal.WithMetrics(internal.NewFileManager(pluginFileManagers, internal.NewLocalFileManager()), "input")
+
+func parseInitialWorkDirCollision(v string, def initialWorkDirCollisionMode) initialWorkDirCollisionMode {
+	switch initialWorkDirCollisionMode(v) {
+	case collisionError, collisionRename:
+		return initialWorkDirCollisionMode(v)
+	case "":
+		return def
+	default:
+		logger.Warn("invalid FLOWY_INITWORKDIR_COLLISION value, using default", "value", v, "default", def)
+		return def
+	}
+}
+
+// stageInputs copies every MapperEnt marked Staged from its resolved
+// location to its target path inside jobDir, using CopyFile/CopyDir so a
+// crash mid-copy never leaves a partially written input behind. Entries
+// are staged in the order orderStagingEntries returns, so a directory is
+// always in place before anything nested inside it. A Target already used
+// by an earlier entry in mapping is a collision, handled per
+// initialWorkDirCollision. Entries not marked Writable are made read-only
+// after staging, matching InitialWorkDirRequirement's default that staged
+// File and Directory entries are read-only unless explicitly writable.
+func stageInputs(jobID, jobDir string, mapping []api.MapperEnt) error {
+	start := time.Now()
+	defer func() { metrics.StagingSeconds.Add(int64(time.Since(start).Seconds())) }()
+
+	ordered, err := orderStagingEntries(mapping)
+	if err != nil {
+		return err
+	}
+
+	fm := internal.WithAudit(withDownloadDedupIfEnabled(withChaosIfEnabled(inputFileManagerMetrics)), auditLogger, jobID)
+	usedTargets := make(map[string]bool, len(ordered))
+	lastCheckpoint := time.Now()
+	for i, ent := range ordered {
+		target, err := resolveCollision(filepath.Join(jobDir, ent.Target), usedTargets)
+		if err != nil {
+			return err
+		}
+		usedTargets[target] = true
+
+		if err := stageEntry(fm, jobDir, ent, target); err != nil {
+			return err
+		}
+		if !ent.Writable {
+			if err := makeReadOnly(target); err != nil {
+				return err
+			}
+		}
+
+		staged := i + 1
+		if staged == len(ordered) || time.Since(lastCheckpoint) >= stagingCheckpointInterval {
+			reportStagingCheckpoint(jobID, staged, len(ordered))
+			lastCheckpoint = time.Now()
+		}
+	}
+	return nil
+}
+
+// stagingCheckpointInterval throttles how often stageInputs reports its
+// progress to the server: checkpointing after every entry would flood it
+// for a job with thousands of small staged inputs, but a job preempted
+// mid-staging should still have a reasonably fresh checkpoint on record.
+const stagingCheckpointInterval = 5 * time.Second
+
+// stagingCheckpoint reports how many of a job's staging entries have
+// completed so far.
+type stagingCheckpoint struct {
+	Staged int `json:"staged"`
+	Total  int `json:"total"`
+}
+
+// reportStagingCheckpoint best-effort posts jobID's staging progress to the
+// server, so a worker preempted mid-staging (see preemptionActive) leaves
+// behind a record of how far it got even though the job itself, staged
+// into this worker's now-unreachable local workdir, will need to be
+// re-staged from scratch wherever it's requeued to. A failed report is
+// logged and otherwise ignored: it must never fail the staging it is only
+// reporting on.
+func reportStagingCheckpoint(jobID string, staged, total int) {
+	body, err := json.Marshal(stagingCheckpoint{Staged: staged, Total: total})
+	if err != nil {
+		return
+	}
+	resp, err := http.Post(serverURL+"/api/worker/jobs/"+jobID+"/staging-checkpoint", "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("reporting staging checkpoint", "jobId", jobID, "error", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// orderStagingEntries returns mapping's Staged entries sorted so that any
+// entry whose Target is an ancestor directory of another entry's Target is
+// staged first, and errors loudly if a Target's ancestor path is claimed
+// by a different entry staged as a File rather than a Directory. Without
+// this check, staging a File entry at "out/report.txt" before a Directory
+// entry at "out" would have the directory copy silently clobber (or
+// conflict with) the file already staged inside it.
+func orderStagingEntries(mapping []api.MapperEnt) ([]api.MapperEnt, error) {
+	staged := make([]api.MapperEnt, 0, len(mapping))
+	for _, ent := range mapping {
+		if ent.Staged {
+			staged = append(staged, ent)
+		}
+	}
+
+	sort.SliceStable(staged, func(i, j int) bool {
+		return targetDepth(staged[i].Target) < targetDepth(staged[j].Target)
+	})
+
+	targetType := make(map[string]string, len(staged))
+	for _, ent := range staged {
+		targetType[filepath.Clean(ent.Target)] = ent.Type
+	}
+	for _, ent := range staged {
+		for dir := filepath.Dir(filepath.Clean(ent.Target)); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			if kind, ok := targetType[dir]; ok && kind != "Directory" {
+				return nil, flowerrors.Staging(ent.Target, fmt.Errorf("conflicts with %q, which is staged as a %s, not a Directory", dir, kind))
+			}
+		}
+	}
+	return staged, nil
+}
+
+// targetDepth counts target's path separators, so shallower targets (an
+// ancestor directory) sort before deeper ones (its contents).
+func targetDepth(target string) int {
+	return strings.Count(filepath.Clean(target), string(filepath.Separator))
+}
+
+// stageEntry materializes one MapperEnt at target: it copies Resolved
+// directly for a File or a Directory backed by an existing path; for a
+// Directory literal (Resolved empty), it creates target and recurses into
+// Listing to build it up entry by entry; for a File literal (Resolved
+// empty), it writes Contents to target directly.
+func stageEntry(fm internal.FileManager, jobDir string, ent api.MapperEnt, target string) error {
+	if ent.Type == "Directory" {
+		if ent.Resolved != "" {
+			if mounted, err := stageWritableDirectoryOverlay(ent, target); mounted {
+				return nil
+			} else if err != nil {
+				logger.Warn("overlay staging failed, falling back to a full copy", "target", target, "error", err)
+			}
+			copyOpts := internal.CopyOptions{SymlinkPolicy: stagingSymlinkPolicy(ent.Resolved)}
+			if err := internal.CopyDirWithOptions(ent.Resolved, target, copyOpts); err != nil {
+				metrics.TransferErrorsTotal.Inc("backend", "stage")
+				return flowerrors.Transfer("local", "copy", ent.Resolved, err)
+			}
+			return nil
+		}
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+		for _, child := range ent.Listing {
+			if err := stageEntry(fm, jobDir, child, filepath.Join(target, filepath.Base(child.Target))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if ent.Resolved == "" {
+		return stageFileLiteral(ent, target)
+	}
+	if ent.SharedInput {
+		return os.Symlink(ent.Resolved, target)
+	}
+	if mounted, err := stageS3InputViaFUSE(jobDir, ent.Resolved, target); mounted {
+		return nil
+	} else if err != nil {
+		logger.Warn("S3 FUSE staging failed, falling back to a download", "target", target, "error", err)
+	}
+	if err := fm.Download(ent.Resolved, target); err != nil {
+		metrics.TransferErrorsTotal.Inc("backend", "stage")
+		return flowerrors.Transfer("stage", "download", ent.Resolved, err)
+	}
+	if size, _, err := fm.Stat(target); err == nil {
+		metrics.StagingBytesTotal.Add(size)
+	}
+	return nil
+}
+
+// stageFileLiteral writes ent's inline Contents to target, for a File
+// literal with no backing location to copy or download from. Writing
+// through a temp-then-rename, like CopyFile does for staged copies, keeps
+// a crash mid-write from leaving a partially written file at target.
+func stageFileLiteral(ent api.MapperEnt, target string) error {
+	tmp := target + ".flowy-part"
+	if err := os.WriteFile(tmp, []byte(ent.Contents), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	metrics.StagingBytesTotal.Add(int64(len(ent.Contents)))
+	return nil
+}
+
+// symlinkAllowedRoots lists extra absolute directories, beyond the input
+// Directory being staged itself, a symlink inside a staged input tree is
+// allowed to point into. Set via FLOWY_SYMLINK_ALLOWED_ROOTS
+// (comma-separated); most sites never need this, since a symlink has no
+// legitimate reason to reach outside the tree it came from.
+var symlinkAllowedRoots = parseAllowlist(os.Getenv("FLOWY_SYMLINK_ALLOWED_ROOTS"))
+
+// stagingSymlinkPolicy returns the policy applied to symlinks found while
+// copying root (an input Directory's resolved, already-downloaded local
+// path) into a job's workdir. By default a symlink may only point
+// somewhere inside root or symlinkAllowedRoots, so a malicious or buggy
+// tool cannot plant a link that escapes the staged input tree and reaches
+// the rest of the worker host once the job reads through it.
+// FLOWY_ALLOW_UNSAFE_SYMLINKS=1 restores the old, unrestricted behavior
+// for sites that already trust every input source.
+func stagingSymlinkPolicy(root string) *internal.SymlinkPolicy {
+	if os.Getenv("FLOWY_ALLOW_UNSAFE_SYMLINKS") == "1" {
+		return nil
+	}
+	allowed := append([]string{root}, symlinkAllowedRoots...)
+	return &internal.SymlinkPolicy{AllowSymlinks: true, AllowedRoots: allowed}
+}
+
+// resolveCollision returns target unchanged if it hasn't been used by an
+// earlier staged entry in this job, or handles the collision per
+// initialWorkDirCollision otherwise.
+func resolveCollision(target string, used map[string]bool) (string, error) {
+	if !used[target] {
+		return target, nil
+	}
+	if initialWorkDirCollision != collisionRename {
+		return "", flowerrors.Spec("InitialWorkDirRequirement", fmt.Errorf("entry %q collides with an earlier entry", target))
+	}
+
+	dir, base := filepath.Dir(target), filepath.Base(target)
+	ext := filepath.Ext(base)
+	name := base[:len(base)-len(ext)]
+	for i := 1; ; i++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s_%d%s", name, i, ext))
+		if !used[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+// makeReadOnly recursively strips write permission from path, matching
+// InitialWorkDirRequirement's default that staged entries are read-only
+// unless Writable is set.
+func makeReadOnly(path string) error {
+	return filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return err
+		}
+		return os.Chmod(p, info.Mode().Perm()&^0o222)
+	})
+}
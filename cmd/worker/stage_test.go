@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestStageInputsEntrynameSubdirectory(t *testing.T) {
+	src := t.TempDir()
+	input := filepath.Join(src, "in.txt")
+	if err := os.WriteFile(input, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jobDir := t.TempDir()
+
+	mapping := []api.MapperEnt{
+		{Resolved: input, Target: "nested/dir/in.txt", Type: "File", Staged: true, Writable: true},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(jobDir, "nested/dir/in.txt")); err != nil {
+		t.Fatalf("staged file missing: %v", err)
+	}
+}
+
+func TestStageInputsNonWritableIsReadOnly(t *testing.T) {
+	src := t.TempDir()
+	input := filepath.Join(src, "in.txt")
+	if err := os.WriteFile(input, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jobDir := t.TempDir()
+
+	mapping := []api.MapperEnt{
+		{Resolved: input, Target: "in.txt", Type: "File", Staged: true},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(jobDir, "in.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o222 != 0 {
+		t.Fatalf("mode = %v, want read-only", info.Mode())
+	}
+}
+
+func TestStageInputsCollisionErrors(t *testing.T) {
+	src := t.TempDir()
+	a := filepath.Join(src, "a.txt")
+	b := filepath.Join(src, "b.txt")
+	os.WriteFile(a, []byte("a"), 0o644)
+	os.WriteFile(b, []byte("b"), 0o644)
+	jobDir := t.TempDir()
+
+	mapping := []api.MapperEnt{
+		{Resolved: a, Target: "out.txt", Type: "File", Staged: true, Writable: true},
+		{Resolved: b, Target: "out.txt", Type: "File", Staged: true, Writable: true},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err == nil {
+		t.Fatal("expected a collision error")
+	}
+}
+
+func TestStageInputsDirectoryLiteral(t *testing.T) {
+	src := t.TempDir()
+	child := filepath.Join(src, "child.txt")
+	if err := os.WriteFile(child, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jobDir := t.TempDir()
+
+	mapping := []api.MapperEnt{
+		{
+			Target: "workdir", Type: "Directory", Staged: true, Writable: true,
+			Listing: []api.MapperEnt{
+				{Resolved: child, Target: "child.txt", Type: "File", Staged: true, Writable: true},
+			},
+		},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(jobDir, "workdir", "child.txt")); err != nil {
+		t.Fatalf("staged directory literal child missing: %v", err)
+	}
+}
+
+func TestStageInputsFileLiteral(t *testing.T) {
+	jobDir := t.TempDir()
+
+	mapping := []api.MapperEnt{
+		{Target: "literal.txt", Type: "File", Staged: true, Writable: true, Contents: "hello literal"},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(jobDir, "literal.txt"))
+	if err != nil {
+		t.Fatalf("staged file literal missing: %v", err)
+	}
+	if string(data) != "hello literal" {
+		t.Fatalf("got %q, want %q", data, "hello literal")
+	}
+}
+
+func TestStageInputsFileLiteralNestedInDirectoryLiteral(t *testing.T) {
+	jobDir := t.TempDir()
+
+	mapping := []api.MapperEnt{
+		{
+			Target: "workdir", Type: "Directory", Staged: true, Writable: true,
+			Listing: []api.MapperEnt{
+				{Target: "note.txt", Type: "File", Staged: true, Writable: true, Contents: "nested literal"},
+			},
+		},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(jobDir, "workdir", "note.txt"))
+	if err != nil {
+		t.Fatalf("staged nested file literal missing: %v", err)
+	}
+	if string(data) != "nested literal" {
+		t.Fatalf("got %q, want %q", data, "nested literal")
+	}
+}
+
+func TestStageInputsStagesDirectoryBeforeFilesInsideIt(t *testing.T) {
+	src := t.TempDir()
+	dir := filepath.Join(src, "outdir")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(src, "extra.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jobDir := t.TempDir()
+
+	// Listed out of order: the file nested under "out" appears before the
+	// Directory entry for "out" itself.
+	mapping := []api.MapperEnt{
+		{Resolved: file, Target: "out/extra.txt", Type: "File", Staged: true, Writable: true},
+		{Resolved: dir, Target: "out", Type: "Directory", Staged: true, Writable: true},
+	}
+	if err := stageInputs("job-1", jobDir, mapping); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(jobDir, "out", "extra.txt")); err != nil {
+		t.Fatalf("expected file staged after the directory copy to survive: %v", err)
+	}
+}
+
+func TestOrderStagingEntriesRejectsFileTreatedAsDirectory(t *testing.T) {
+	mapping := []api.MapperEnt{
+		{Target: "leaf", Type: "File", Staged: true},
+		{Target: "leaf/inner.txt", Type: "File", Staged: true},
+	}
+	if _, err := orderStagingEntries(mapping); err == nil {
+		t.Fatal("expected an error for nesting under a File target")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// defaultMaxStdioBytes caps how much of a job's stdout or stderr the
+// worker will write to disk, so a tool that runs away writing output
+// can't fill the workdir's filesystem and cascade into failing every
+// other job staged there. It's deliberately generous: this is a backstop
+// against runaway tools, not a limit on normal verbose logging.
+const defaultMaxStdioBytes = 256 << 20 // 256 MiB
+
+// maxStdioBytes is the effective cap, overridable via
+// FLOWY_MAX_STDIO_BYTES for sites whose tools are known to be chattier or
+// quieter than the default assumes.
+var maxStdioBytes = maxStdioBytesFromEnv()
+
+func maxStdioBytesFromEnv() int64 {
+	v := os.Getenv("FLOWY_MAX_STDIO_BYTES")
+	if v == "" {
+		return defaultMaxStdioBytes
+	}
+	parsed, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || parsed <= 0 {
+		logger.Warn("invalid FLOWY_MAX_STDIO_BYTES, using default", "value", v, "default", defaultMaxStdioBytes)
+		return defaultMaxStdioBytes
+	}
+	return parsed
+}
+
+// stdioTruncationMarker is appended once a capped stdout/stderr file hits
+// maxStdioBytes, so a truncated capture is never mistaken for a tool that
+// simply produced less output than it actually did.
+const stdioTruncationMarker = "\n[flowy: output truncated after %d bytes]\n"
+
+// redirectStreams opens job's Stdin, Stdout, and Stderr files, each given
+// relative to jobDir as staged by the server (e.g. a random generated name
+// when the tool didn't request one, or a File literal's contents already
+// materialized to a local path), ready to assign to a command's stdio. An
+// empty field leaves that stream nil, matching exec.Cmd's own default:
+// nil Stdin reads as EOF, nil Stdout/Stderr are discarded.
+//
+// This applies the same way whether the job runs in a container or on the
+// host: cmd is either the tool process itself or the docker CLI's own
+// foreground `docker run`, and a foreground `docker run`'s stdout/stderr
+// already mirror the container's attached streams regardless of the
+// container's --log-driver, which only affects the daemon's separate log
+// storage, not the client's live attachment.
+func redirectStreams(jobDir string, job *api.ExecutableJob) (stdin io.Reader, stdout, stderr io.Writer, err error) {
+	if job.Stdin != "" {
+		f, err := os.Open(filepath.Join(jobDir, job.Stdin))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		stdin = f
+	}
+
+	if stdout, err = openStdioTarget(jobDir, job.Stdout); err != nil {
+		return nil, nil, nil, err
+	}
+	if stderr, err = openStdioTarget(jobDir, job.Stderr); err != nil {
+		return nil, nil, nil, err
+	}
+	return stdin, stdout, stderr, nil
+}
+
+// openStdioTarget creates the file name names (relative to jobDir) for a
+// command's stdout or stderr to be written to, or returns a nil Writer
+// when name is empty. The returned Writer stops persisting bytes past
+// maxStdioBytes rather than letting an unbounded tool fill the workdir's
+// filesystem.
+func openStdioTarget(jobDir, name string) (io.Writer, error) {
+	if name == "" {
+		return nil, nil
+	}
+	target := filepath.Join(jobDir, name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return nil, err
+	}
+	return &cappedWriter{f: f, limit: maxStdioBytes}, nil
+}
+
+// cappedWriter writes to f until limit bytes have been written, then
+// silently drops the rest of that write and every write after it except
+// for the one-time truncation marker appended as it crosses the limit.
+// The command whose output this captures is never interrupted by the
+// cap; only what the worker persists to disk is affected.
+type cappedWriter struct {
+	f         *os.File
+	limit     int64
+	written   int64
+	truncated bool
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	if w.written >= w.limit {
+		if !w.truncated {
+			w.truncated = true
+			fmt.Fprintf(w.f, stdioTruncationMarker, w.limit)
+		}
+		return len(p), nil
+	}
+
+	remaining := w.limit - w.written
+	toWrite := p
+	if int64(len(toWrite)) > remaining {
+		toWrite = toWrite[:remaining]
+	}
+	n, err := w.f.Write(toWrite)
+	w.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if int64(len(p)) > remaining {
+		w.truncated = true
+		fmt.Fprintf(w.f, stdioTruncationMarker, w.limit)
+	}
+	return len(p), nil
+}
+
+func (w *cappedWriter) Close() error {
+	return w.f.Close()
+}
+
+// closeStreams closes whichever of stdin, stdout, stderr redirectStreams
+// opened as files, ignoring the ones it left nil.
+func closeStreams(stdin io.Reader, stdout, stderr io.Writer) {
+	for _, s := range []interface{}{stdin, stdout, stderr} {
+		if c, ok := s.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+func TestRedirectStreamsWiresStdinStdoutStderr(t *testing.T) {
+	jobDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(jobDir, "in.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &api.ExecutableJob{Stdin: "in.txt", Stdout: "out/stdout.log", Stderr: "stderr.log"}
+	stdin, stdout, stderr, err := redirectStreams(jobDir, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeStreams(stdin, stdout, stderr)
+
+	got, err := io.ReadAll(stdin)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("stdin = %q, %v", got, err)
+	}
+
+	stdout.Write([]byte("out"))
+	stderr.Write([]byte("err"))
+	closeStreams(stdin, stdout, stderr)
+
+	if b, err := os.ReadFile(filepath.Join(jobDir, "out/stdout.log")); err != nil || string(b) != "out" {
+		t.Fatalf("stdout file = %q, %v", b, err)
+	}
+	if b, err := os.ReadFile(filepath.Join(jobDir, "stderr.log")); err != nil || string(b) != "err" {
+		t.Fatalf("stderr file = %q, %v", b, err)
+	}
+}
+
+func TestRedirectStreamsLeavesUnsetFieldsNil(t *testing.T) {
+	jobDir := t.TempDir()
+	stdin, stdout, stderr, err := redirectStreams(jobDir, &api.ExecutableJob{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdin != nil || stdout != nil || stderr != nil {
+		t.Fatalf("stdin=%v stdout=%v stderr=%v, want all nil", stdin, stdout, stderr)
+	}
+}
+
+func TestCappedWriterStopsAtLimitAndAppendsMarker(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &cappedWriter{f: f, limit: 5}
+
+	if _, err := w.Write([]byte("hello world")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("more")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got[:5]) != "hello" {
+		t.Fatalf("expected the first 5 bytes to be kept, got %q", got)
+	}
+	if !strings.Contains(string(got), "truncated after 5 bytes") {
+		t.Fatalf("expected a truncation marker, got %q", got)
+	}
+	if strings.Contains(string(got), "more") {
+		t.Fatalf("expected bytes written after the limit to be dropped, got %q", got)
+	}
+}
+
+func TestMaxStdioBytesFromEnvInvalidFallsBackToDefault(t *testing.T) {
+	t.Setenv("FLOWY_MAX_STDIO_BYTES", "not-a-number")
+	if got := maxStdioBytesFromEnv(); got != defaultMaxStdioBytes {
+		t.Errorf("maxStdioBytesFromEnv() = %d, want default %d", got, defaultMaxStdioBytes)
+	}
+}
+
+func TestMaxStdioBytesFromEnvOverride(t *testing.T) {
+	t.Setenv("FLOWY_MAX_STDIO_BYTES", "1024")
+	if got := maxStdioBytesFromEnv(); got != 1024 {
+		t.Errorf("maxStdioBytesFromEnv() = %d, want 1024", got)
+	}
+}
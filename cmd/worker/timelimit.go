@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// timeLimitError marks a job failure caused by ToolTimeLimit expiring
+// rather than the tool itself failing, so callers can report it
+// distinctly (see reportJobFailed) instead of as an ordinary run error.
+type timeLimitError struct {
+	err error
+}
+
+func timeLimitFail(err error) error {
+	return &timeLimitError{err: err}
+}
+
+func (e *timeLimitError) Error() string { return e.err.Error() }
+func (e *timeLimitError) Unwrap() error { return e.err }
+
+// timeLimitGrace is how long a job gets to exit cleanly after SIGTERM,
+// once its ToolTimeLimit expires, before the worker escalates to SIGKILL.
+// Configurable via FLOWY_TIMELIMIT_GRACE (seconds), since a tool that
+// traps SIGTERM to flush output needs more than a token grace period.
+var timeLimitGrace = timeLimitGraceFromEnv()
+
+const defaultTimeLimitGrace = 30 * time.Second
+
+func timeLimitGraceFromEnv() time.Duration {
+	if v := envOr("FLOWY_TIMELIMIT_GRACE", ""); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultTimeLimitGrace
+}
+
+// runCommand starts cmd and waits for it to exit, or, when timelimitSeconds
+// is positive, enforces that limit itself: on expiry it sends SIGTERM, then
+// SIGKILL after timeLimitGrace if the process still hasn't exited. The
+// returned timedOut reports whether the limit fired, so the caller can
+// still attempt to collect whatever partial outputs the job produced
+// before it was killed, rather than only reporting a bare failure.
+//
+// jobID is always job.JobID, used to identify cmd in watchForCancellation's
+// log lines; speculative additionally races a poll of the server's
+// cancellation endpoint against cmd, for a speculative duplicate shard (see
+// api.ExecutableJob.SpeculativeCopy), one of possibly several copies of the
+// same shard the scheduler dispatched to different workers to mitigate a
+// straggler: it may be killed mid-run if another copy finishes first. The
+// returned cancelled reports whether that happened, so the caller neither
+// retries nor reports a spurious failure for losing a race it was never
+// meant to win. The returned preempted reports whether cmd was instead
+// killed because this worker is being reclaimed (see preemptionActive),
+// regardless of whether the job was speculative, so the caller can report
+// it for requeue elsewhere rather than as an ordinary failure.
+func runCommand(cmd *exec.Cmd, timelimitSeconds int, jobID string, speculative bool) (timedOut, cancelled, preempted bool, err error) {
+	if err := cmd.Start(); err != nil {
+		return false, false, false, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var wasCancelled, wasPreempted atomic.Bool
+	stop := make(chan struct{})
+	var watcher sync.WaitGroup
+	watcher.Add(1)
+	go func() {
+		defer watcher.Done()
+		watchForCancellation(cmd, jobID, speculative, stop, &wasCancelled, &wasPreempted)
+	}()
+	// Order matters: close(stop) must run before watcher.Wait(), so it's
+	// deferred last (defers run LIFO) - otherwise Wait would block forever
+	// for a watcher that's waiting on stop to be told to exit.
+	defer watcher.Wait()
+	defer close(stop)
+
+	if timelimitSeconds <= 0 {
+		err := <-done
+		return false, wasCancelled.Load(), wasPreempted.Load(), err
+	}
+
+	select {
+	case err := <-done:
+		return false, wasCancelled.Load(), wasPreempted.Load(), err
+	case <-time.After(time.Duration(timelimitSeconds) * time.Second):
+	}
+
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case err := <-done:
+		return true, wasCancelled.Load(), wasPreempted.Load(), err
+	case <-time.After(timeLimitGrace):
+	}
+
+	_ = cmd.Process.Kill()
+	err = <-done
+	return true, wasCancelled.Load(), wasPreempted.Load(), err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunCommandNoLimitRunsToCompletion(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 0")
+	timedOut, _, _, err := runCommand(cmd, 0, "job-1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timedOut {
+		t.Fatal("expected timedOut = false")
+	}
+}
+
+func TestRunCommandKillsProcessOnTimeout(t *testing.T) {
+	prevGrace := timeLimitGrace
+	timeLimitGrace = 200 * time.Millisecond
+	defer func() { timeLimitGrace = prevGrace }()
+
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 5")
+	start := time.Now()
+	timedOut, _, _, err := runCommand(cmd, 1, "job-1", false)
+	elapsed := time.Since(start)
+
+	if !timedOut {
+		t.Fatal("expected timedOut = true")
+	}
+	if err == nil {
+		t.Fatal("expected an error from a killed process")
+	}
+	if elapsed > 3*time.Second {
+		t.Fatalf("runCommand took %v, expected SIGKILL well before sleep 5 finished", elapsed)
+	}
+}
+
+func TestRunCommandTerminatesCleanlyWithinGrace(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 5")
+	start := time.Now()
+	timedOut, _, _, err := runCommand(cmd, 1, "job-1", false)
+	elapsed := time.Since(start)
+
+	if !timedOut {
+		t.Fatal("expected timedOut = true")
+	}
+	if err == nil {
+		t.Fatal("expected an error from a signaled process")
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("runCommand took %v, expected SIGTERM to end the sleep well before its grace period", elapsed)
+	}
+}
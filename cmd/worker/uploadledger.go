@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+// uploadLedgerFileName is the per-job file tracking uploads this worker
+// has already completed, so a retried job (same JobID, same jobDir) can
+// pick up where a previous attempt's publishOutputs left off instead of
+// re-uploading every file in a large Directory output from scratch.
+const uploadLedgerFileName = ".flowy-upload-ledger.json"
+
+// uploadLedgerEntry records one completed upload: the checksum it was
+// uploaded under (an entry whose checksum no longer matches the local
+// file isn't trusted - see uploadLedger.lookup) and the location it
+// landed at.
+type uploadLedgerEntry struct {
+	Checksum string `json:"checksum"`
+	Location string `json:"location"`
+}
+
+// uploadLedger tracks which destination keys under a job have already
+// been uploaded, persisted to disk so it survives the worker process
+// exiting between a failed attempt and a retry.
+type uploadLedger struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]uploadLedgerEntry
+}
+
+// loadUploadLedger reads jobDir's upload ledger, or starts a fresh empty
+// one when none exists yet - the common case, a job's first attempt.
+func loadUploadLedger(jobDir string) (*uploadLedger, error) {
+	l := &uploadLedger{path: filepath.Join(jobDir, uploadLedgerFileName), entries: map[string]uploadLedgerEntry{}}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.entries); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// lookup reports the location key was already uploaded to, if its ledger
+// entry's checksum still matches checksum. A changed (or empty) checksum
+// means the local file isn't the one a previous attempt uploaded, so it
+// must be uploaded again rather than trusted.
+func (l *uploadLedger) lookup(key, checksum string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[key]
+	if !ok || checksum == "" || entry.Checksum != checksum {
+		return "", false
+	}
+	return entry.Location, true
+}
+
+// record saves key's completed upload and persists the ledger to disk,
+// so an interrupted job can resume from this point without re-uploading
+// it.
+func (l *uploadLedger) record(key, checksum, location string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[key] = uploadLedgerEntry{Checksum: checksum, Location: location}
+	data, err := json.Marshal(l.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, data, 0o644)
+}
+
+// verify confirms every entry l recorded is still present in fm, warning
+// about (rather than failing the job for) any that aren't: the ledger is
+// an optimization for skipping redundant uploads on retry, not a second
+// source of truth for what publishOutputs actually collected this run.
+func (l *uploadLedger) verify(fm internal.FileManager) {
+	l.mu.Lock()
+	entries := make(map[string]uploadLedgerEntry, len(l.entries))
+	for key, entry := range l.entries {
+		entries[key] = entry
+	}
+	l.mu.Unlock()
+
+	for key, entry := range entries {
+		if _, exists, err := fm.Stat(entry.Location); err != nil || !exists {
+			logger.Warn("upload ledger entry missing from backend", "key", key, "location", entry.Location, "error", err)
+		}
+	}
+}
+
+// remove deletes the ledger file, once publishOutputs has finished
+// successfully and there is nothing left to resume.
+func (l *uploadLedger) remove() error {
+	err := os.Remove(l.path)
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// resumableUpload wraps upload so a key already recorded in ledger at a
+// matching checksum, and still actually present in fm, is skipped rather
+// than re-uploaded - the core of resuming a large Directory output's
+// upload after a worker crash or network failure partway through. A
+// ledger entry whose object has since disappeared from the backend (e.g.
+// an operator emptied the bucket) is not trusted: the file is uploaded
+// again and the ledger corrected.
+func resumableUpload(fm internal.FileManager, ledger *uploadLedger, upload func(local, key, checksum string) (string, error)) func(local, key, checksum string) (string, error) {
+	return func(local, key, checksum string) (string, error) {
+		if location, ok := ledger.lookup(key, checksum); ok {
+			if _, exists, err := fm.Stat(location); err == nil && exists {
+				return location, nil
+			}
+		}
+
+		location, err := upload(local, key, checksum)
+		if err != nil {
+			return "", err
+		}
+		if err := ledger.record(key, checksum, location); err != nil {
+			logger.Warn("recording upload ledger entry", "key", key, "error", err)
+		}
+		return location, nil
+	}
+}
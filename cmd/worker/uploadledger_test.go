@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bioflowy/flowy/internal"
+)
+
+func TestResumableUploadSkipsAlreadyUploadedKey(t *testing.T) {
+	jobDir := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(dst, []byte("payload"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, err := loadUploadLedger(jobDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm := internal.NewLocalFileManager()
+
+	calls := 0
+	base := func(local, key, checksum string) (string, error) {
+		calls++
+		return dst, nil
+	}
+	upload := resumableUpload(fm, ledger, base)
+
+	if _, err := upload("local", "out.txt", "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 after first upload", calls)
+	}
+
+	// A second attempt with the same key and checksum, against a fresh
+	// ledger loaded from disk (simulating a retried job), must not
+	// re-upload.
+	reloaded, err := loadUploadLedger(jobDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := resumableUpload(fm, reloaded, base)
+	if _, err := resumed("local", "out.txt", "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 after a resumed upload of the same key", calls)
+	}
+}
+
+func TestResumableUploadReuploadsOnChecksumMismatch(t *testing.T) {
+	jobDir := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "out.txt")
+	os.WriteFile(dst, []byte("payload"), 0o644)
+
+	ledger, _ := loadUploadLedger(jobDir)
+	fm := internal.NewLocalFileManager()
+
+	calls := 0
+	base := func(local, key, checksum string) (string, error) {
+		calls++
+		return dst, nil
+	}
+	upload := resumableUpload(fm, ledger, base)
+
+	upload("local", "out.txt", "sha1$abc")
+	upload("local", "out.txt", "sha1$def")
+
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2 when the checksum changes between attempts", calls)
+	}
+}
+
+func TestResumableUploadReuploadsWhenObjectMissingFromBackend(t *testing.T) {
+	jobDir := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "out.txt")
+	os.WriteFile(dst, []byte("payload"), 0o644)
+
+	ledger, _ := loadUploadLedger(jobDir)
+	fm := internal.NewLocalFileManager()
+
+	calls := 0
+	base := func(local, key, checksum string) (string, error) {
+		calls++
+		return dst, nil
+	}
+	upload := resumableUpload(fm, ledger, base)
+	upload("local", "out.txt", "sha1$abc")
+
+	// Simulate an operator removing the previously-uploaded object out
+	// from under the ledger.
+	os.Remove(dst)
+
+	upload("local", "out.txt", "sha1$abc")
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2 once the ledger's recorded object disappears from the backend", calls)
+	}
+}
+
+func TestUploadLedgerVerifyWarnsWithoutError(t *testing.T) {
+	jobDir := t.TempDir()
+	ledger, _ := loadUploadLedger(jobDir)
+	if err := ledger.record("missing.txt", "sha1$abc", filepath.Join(t.TempDir(), "missing.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	fm := internal.NewLocalFileManager()
+	ledger.verify(fm)
+}
+
+func TestUploadLedgerRemoveIsIdempotent(t *testing.T) {
+	jobDir := t.TempDir()
+	ledger, _ := loadUploadLedger(jobDir)
+	if err := ledger.record("a.txt", "sha1$abc", "somewhere"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.remove(); err != nil {
+		t.Fatalf("remove() on an already-removed ledger returned %v, want nil", err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+// collectResourceUsage builds a ResourceUsage from cmd's rusage (as
+// populated by the kernel in cmd.ProcessState once the process exits) and
+// the wall-clock duration since start. It covers both the direct-exec and
+// docker-CLI-wrapped cases: for docker, the rusage is that of the `docker
+// run` client process rather than the container, which is the best a
+// worker outside the container can see without querying the docker stats
+// API.
+func collectResourceUsage(cmd *exec.Cmd, start time.Time) *api.ResourceUsage {
+	usage := &api.ResourceUsage{WallSeconds: time.Since(start).Seconds()}
+	if cmd.ProcessState == nil {
+		return usage
+	}
+
+	usage.UserCPUSeconds = cmd.ProcessState.UserTime().Seconds()
+	usage.SysCPUSeconds = cmd.ProcessState.SystemTime().Seconds()
+
+	if rusage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+		usage.MaxRSSBytes, usage.BytesRead, usage.BytesWritten = rusageFields(rusage)
+	}
+	usage.EstimatedCostUSD = estimateJobCost(usage)
+	return usage
+}
@@ -0,0 +1,10 @@
+package main
+
+import "syscall"
+
+// rusageFields extracts platform-specific rusage counters. On Darwin,
+// unlike Linux, Maxrss is already reported in bytes; Inblock/Oublock
+// remain 512-byte blocks.
+func rusageFields(rusage *syscall.Rusage) (maxRSSBytes, bytesRead, bytesWritten int64) {
+	return rusage.Maxrss, int64(rusage.Inblock) * 512, int64(rusage.Oublock) * 512
+}
@@ -0,0 +1,9 @@
+package main
+
+import "syscall"
+
+// rusageFields extracts platform-specific rusage counters. On Linux,
+// Maxrss is reported in KB and Inblock/Oublock in 512-byte blocks.
+func rusageFields(rusage *syscall.Rusage) (maxRSSBytes, bytesRead, bytesWritten int64) {
+	return rusage.Maxrss * 1024, int64(rusage.Inblock) * 512, int64(rusage.Oublock) * 512
+}
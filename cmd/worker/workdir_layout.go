@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workdirLayout selects how executeJob names a job's working directory
+// under the worker's root workdir.
+type workdirLayout string
+
+const (
+	// workdirLayoutFlat names a job's directory after its JobID directly
+	// (workdir/<jobId>), the worker's long-standing default.
+	workdirLayoutFlat workdirLayout = "flat"
+	// workdirLayoutNextflow mimics Nextflow's "work/ab/cdef0123.../" hash
+	// directory layout and its ".command.sh"/".command.log" convention,
+	// for sites whose downstream tooling (resume caches, log scrapers)
+	// already expects that structure.
+	workdirLayoutNextflow workdirLayout = "nextflow"
+)
+
+// configuredWorkdirLayout is read once from FLOWY_WORKDIR_LAYOUT at
+// startup; an unrecognized or unset value falls back to workdirLayoutFlat.
+var configuredWorkdirLayout = parseWorkdirLayout(os.Getenv("FLOWY_WORKDIR_LAYOUT"))
+
+func parseWorkdirLayout(v string) workdirLayout {
+	if workdirLayout(v) == workdirLayoutNextflow {
+		return workdirLayoutNextflow
+	}
+	return workdirLayoutFlat
+}
+
+// jobDirFor returns the directory a job's inputs are staged into and its
+// command runs from, under workdir, following configuredWorkdirLayout. The
+// nextflow layout hashes jobID rather than using it directly, matching
+// Nextflow's own "work/ab/cdef0123.../" scheme, so jobs spread evenly
+// across the 256 two-character prefix directories no matter how jobIDs
+// happen to be formed.
+func jobDirFor(workdir, jobID string) string {
+	if configuredWorkdirLayout != workdirLayoutNextflow {
+		return filepath.Join(workdir, jobID)
+	}
+	sum := sha256.Sum256([]byte(jobID))
+	digest := hex.EncodeToString(sum[:])
+	return filepath.Join(workdir, digest[:2], digest[2:])
+}
+
+// writeNextflowCommandScript writes jobDir/.command.sh containing command,
+// a no-op outside the nextflow layout. Nextflow's task runner always
+// leaves the literal script it ran next to a task's other files; keeping
+// that convention lets tooling built against Nextflow work directories
+// (log scrapers, resume caches) find it in the same place.
+func writeNextflowCommandScript(jobDir string, command []string) error {
+	if configuredWorkdirLayout != workdirLayoutNextflow {
+		return nil
+	}
+	script := "#!/bin/sh\n" + strings.Join(command, " ") + "\n"
+	return os.WriteFile(filepath.Join(jobDir, ".command.sh"), []byte(script), 0o755)
+}
+
+// openNextflowCommandLog opens jobDir/.command.log for appending, or
+// returns a nil *os.File outside the nextflow layout. Callers should tee
+// (see teeWriter) a job's own configured Stdout/Stderr through it, so
+// .command.log captures the same combined output Nextflow's task runner
+// would have collected there.
+func openNextflowCommandLog(jobDir string) (*os.File, error) {
+	if configuredWorkdirLayout != workdirLayoutNextflow {
+		return nil, nil
+	}
+	return os.OpenFile(filepath.Join(jobDir, ".command.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// teeWriter returns a Writer that duplicates writes to both w and extra,
+// or just w unchanged when extra is nil (a *os.File holding a nil pointer
+// is deliberately treated as absent too, since openNextflowCommandLog
+// returns one outside the nextflow layout rather than a bare nil
+// interface).
+func teeWriter(w io.Writer, extra *os.File) io.Writer {
+	if extra == nil {
+		return w
+	}
+	if w == nil {
+		return extra
+	}
+	return io.MultiWriter(w, extra)
+}
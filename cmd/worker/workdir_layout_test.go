@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJobDirForFlatLayout(t *testing.T) {
+	prev := configuredWorkdirLayout
+	configuredWorkdirLayout = workdirLayoutFlat
+	defer func() { configuredWorkdirLayout = prev }()
+
+	got := jobDirFor("/work", "job-123")
+	want := filepath.Join("/work", "job-123")
+	if got != want {
+		t.Fatalf("jobDirFor = %q, want %q", got, want)
+	}
+}
+
+func TestJobDirForNextflowLayout(t *testing.T) {
+	prev := configuredWorkdirLayout
+	configuredWorkdirLayout = workdirLayoutNextflow
+	defer func() { configuredWorkdirLayout = prev }()
+
+	got := jobDirFor("/work", "job-123")
+	prefixDir := filepath.Dir(got)
+	if filepath.Dir(prefixDir) != "/work" || len(filepath.Base(prefixDir)) != 2 {
+		t.Fatalf("jobDirFor = %q, want /work/<2-char hash>/<rest>", got)
+	}
+
+	if got2 := jobDirFor("/work", "job-123"); got2 != got {
+		t.Fatalf("jobDirFor is not deterministic: %q != %q", got2, got)
+	}
+	if other := jobDirFor("/work", "job-456"); other == got {
+		t.Fatalf("jobDirFor gave the same directory for two different jobIDs: %q", got)
+	}
+}
+
+func TestWriteNextflowCommandScript(t *testing.T) {
+	prev := configuredWorkdirLayout
+	configuredWorkdirLayout = workdirLayoutNextflow
+	defer func() { configuredWorkdirLayout = prev }()
+
+	dir := t.TempDir()
+	if err := writeNextflowCommandScript(dir, []string{"echo", "hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".command.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("echo hello world")) {
+		t.Fatalf(".command.sh = %q, want it to contain the command", data)
+	}
+}
+
+func TestWriteNextflowCommandScriptNoopOutsideNextflowLayout(t *testing.T) {
+	prev := configuredWorkdirLayout
+	configuredWorkdirLayout = workdirLayoutFlat
+	defer func() { configuredWorkdirLayout = prev }()
+
+	dir := t.TempDir()
+	if err := writeNextflowCommandScript(dir, []string{"echo", "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".command.sh")); !os.IsNotExist(err) {
+		t.Fatalf("expected no .command.sh to be written outside the nextflow layout, stat err = %v", err)
+	}
+}
+
+func TestOpenNextflowCommandLogNoopOutsideNextflowLayout(t *testing.T) {
+	prev := configuredWorkdirLayout
+	configuredWorkdirLayout = workdirLayoutFlat
+	defer func() { configuredWorkdirLayout = prev }()
+
+	f, err := openNextflowCommandLog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != nil {
+		t.Fatalf("expected a nil *os.File outside the nextflow layout, got %v", f)
+	}
+}
+
+func TestOpenNextflowCommandLogAppendsAcrossCalls(t *testing.T) {
+	prev := configuredWorkdirLayout
+	configuredWorkdirLayout = workdirLayoutNextflow
+	defer func() { configuredWorkdirLayout = prev }()
+
+	dir := t.TempDir()
+	f1, err := openNextflowCommandLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f1.WriteString("first\n")
+	f1.Close()
+
+	f2, err := openNextflowCommandLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2.WriteString("second\n")
+	f2.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, ".command.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Fatalf(".command.log = %q, want appended content from both calls", data)
+	}
+}
+
+func TestTeeWriter(t *testing.T) {
+	if got := teeWriter(nil, nil); got != nil {
+		t.Fatalf("teeWriter(nil, nil) = %v, want nil", got)
+	}
+
+	var buf bytes.Buffer
+	if got := teeWriter(&buf, nil); got != &buf {
+		t.Fatalf("teeWriter(w, nil) should return w unchanged")
+	}
+
+	extraPath := filepath.Join(t.TempDir(), "extra.log")
+	extra, err := os.Create(extraPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer extra.Close()
+
+	var primary bytes.Buffer
+	w := teeWriter(&primary, extra)
+	if _, err := w.Write([]byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if primary.String() != "hi" {
+		t.Fatalf("primary = %q, want hi", primary.String())
+	}
+	extra.Sync()
+	data, err := os.ReadFile(extraPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hi" {
+		t.Fatalf("extra file = %q, want hi", data)
+	}
+}
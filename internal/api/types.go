@@ -0,0 +1,687 @@
+// Package api holds the wire types shared by the flowy server, workers, and
+// cwlclient. They mirror the JSON payloads exchanged over the server's HTTP
+// API and are kept dependency-free so both cmd/worker and cmd/cwlclient can
+// import them without pulling in execution or staging logic.
+package api
+
+import "encoding/json"
+
+// MapperEnt describes one entry in a job's path mapping: where an input or
+// output currently lives (Resolved) and where it must be staged to or
+// collected from on the worker (Target). Target may include subdirectories
+// (CWL's InitialWorkDirRequirement "entryname").
+type MapperEnt struct {
+	Resolved string `json:"resolved"`
+	Target   string `json:"target"`
+	Type     string `json:"type"`
+	Staged   bool   `json:"staged"`
+	// Writable marks an InitialWorkDirRequirement entry the tool is
+	// allowed to modify in place; per the CWL spec, staged entries are
+	// otherwise read-only. Ignored for entries that aren't Staged.
+	Writable bool `json:"writable,omitempty"`
+	// InplaceUpdate marks a Writable staged Directory entry whose changes
+	// should be synced back to Resolved once the job finishes (CWL's
+	// InplaceUpdateRequirement), rather than collected as a regular job
+	// output. Ignored unless Writable and Staged are also set.
+	InplaceUpdate bool `json:"inplaceUpdate,omitempty"`
+	// Listing holds a Directory literal's own contents, used when
+	// Resolved is empty: a Directory literal has no backing directory to
+	// copy from, so it is materialized entry by entry instead.
+	Listing []MapperEnt `json:"listing,omitempty"`
+	// Contents holds a File literal's inline content, used when Resolved
+	// is empty and Type is "File": a File literal has no backing location
+	// to copy or download from, so it is written to Target directly.
+	Contents string `json:"contents,omitempty"`
+	// SharedInput marks a File entry whose Resolved already points at a
+	// read-only path in the worker's own shared-inputs cache (see
+	// stageSharedInputs), rather than a remote location or another job's
+	// private directory. Staging such an entry symlinks Target straight
+	// at Resolved instead of copying or downloading it, since every job
+	// in the batch that shares it is guaranteed to only read it.
+	SharedInput bool `json:"sharedInput,omitempty"`
+}
+
+// File is the CWL File object as exchanged over the API.
+type File struct {
+	Class          string `json:"class"`
+	Location       string `json:"location,omitempty"`
+	Path           string `json:"path,omitempty"`
+	Basename       string `json:"basename,omitempty"`
+	Checksum       string `json:"checksum,omitempty"`
+	Size           int64  `json:"size,omitempty"`
+	Contents       string `json:"contents,omitempty"`
+	SecondaryFiles []File `json:"secondaryFiles,omitempty"`
+	Format         string `json:"format,omitempty"`
+}
+
+// Directory is the CWL Directory object as exchanged over the API.
+type Directory struct {
+	Class    string        `json:"class"`
+	Location string        `json:"location,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Basename string        `json:"basename,omitempty"`
+	Listing  []interface{} `json:"listing,omitempty"`
+}
+
+// ExecutableJob is the payload the server hands a worker to run one CWL
+// CommandLineTool invocation.
+type ExecutableJob struct {
+	JobID         string            `json:"jobId"`
+	Tool          string            `json:"tool"`
+	Command       []string          `json:"command"`
+	Env           map[string]string `json:"env"`
+	Stdin         string            `json:"stdin,omitempty"`
+	Stdout        string            `json:"stdout,omitempty"`
+	Stderr        string            `json:"stderr,omitempty"`
+	DockerImage   *string           `json:"dockerImage,omitempty"`
+	Mapping       []MapperEnt       `json:"mapping"`
+	Timelimit     int               `json:"timelimit,omitempty"`
+	Networkaccess bool              `json:"networkaccess"`
+	// WorkdirCleanup and TmpdirCleanup override the worker's default
+	// cleanup policy ("always", "on-success" or "never") for this job
+	// only, e.g. to leave a failing job's workdir in place for
+	// debugging. Empty means use the worker's configured default.
+	WorkdirCleanup string `json:"workdirCleanup,omitempty"`
+	TmpdirCleanup  string `json:"tmpdirCleanup,omitempty"`
+	// Inputs is the CWL input object for this invocation, made available
+	// to OutputEval expressions as the "inputs" context variable.
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+	// OutputEval maps an output parameter name to a CWL/JavaScript
+	// expression (InlineJavascriptRequirement) evaluated locally, with
+	// "self" bound to that output's value as collected from
+	// cwl.output.json, to resolve outputEval/secondaryFiles expressions
+	// without calling back out to the server's eval endpoint.
+	OutputEval map[string]string `json:"outputEval,omitempty"`
+	// SecondaryFiles maps an output parameter name to the secondaryFiles
+	// patterns declared for it. Patterns are resolved locally (see
+	// internal/secondaryfiles) against each File or Directory collected
+	// for that output, before it is uploaded and reported back to the
+	// server.
+	SecondaryFiles map[string][]SecondaryFilePattern `json:"secondaryFiles,omitempty"`
+	// LoadListing maps an output parameter name to its
+	// LoadListingRequirement mode ("no_listing", "shallow_listing", or
+	// "deep_listing"). A Directory output the tool already wrote a
+	// "listing" for is left as-is; otherwise the worker fills it in from
+	// the local filesystem according to this mode. Absent means
+	// "no_listing".
+	LoadListing map[string]string `json:"loadListing,omitempty"`
+	// OutputBindings maps an output parameter name to its
+	// CommandOutputBinding, for outputs the worker must collect itself by
+	// globbing jobDir rather than reading them out of a tool-written
+	// cwl.output.json.
+	OutputBindings map[string]OutputBinding `json:"outputBindings,omitempty"`
+	// InputFormats maps an input parameter name to the EDAM ontology
+	// format IRIs a File value for that input is allowed to declare. An
+	// input with no entry, or whose value isn't a File object, is not
+	// checked. Validated before the job runs, so a format mismatch fails
+	// the job before wasting time on a command that can't produce a
+	// meaningful result.
+	InputFormats map[string][]string `json:"inputFormats,omitempty"`
+	// OutputSchema maps a cwl.output.json top-level output name to its
+	// declared CWL type, so loadCwlOutputJson can validate and coerce what
+	// the tool actually wrote against what the tool's own output
+	// definition promised, rather than forwarding a malformed value
+	// upstream.
+	OutputSchema map[string]OutputType `json:"outputSchema,omitempty"`
+	// Pipes maps a streamable output or input parameter name to the
+	// PipeEndpoint the server brokered for it, so a streamable output on
+	// one job can start feeding a streamable input on another before
+	// either finishes, instead of waiting for the whole file to land on
+	// disk. Absent means the parameter isn't part of a streaming
+	// connection.
+	//
+	// A consumer entry's name doubles as a regular input's target: giving
+	// Stdin that same name feeds the tool's standard input from the
+	// stream, the same way `tool-a | tool-b` pipes a CWL workflow's two
+	// CommandLineTools together, without either one's full output ever
+	// needing to be staged to a shared file first.
+	Pipes map[string]PipeEndpoint `json:"pipes,omitempty"`
+	// Priority is the scheduler's ordering hint for this job within its
+	// QueueClass: higher runs sooner. It has no effect on how the worker
+	// itself runs the job once assigned; ordering across queued jobs is
+	// entirely the server's responsibility.
+	Priority int `json:"priority,omitempty"`
+	// QueueClass names the queue this job was submitted to (e.g.
+	// "clinical" vs "batch"), matched against a worker's advertised
+	// WorkerStartedRequest.Queues so the server only assigns it to a
+	// worker willing to serve that class. Empty means the default queue.
+	QueueClass string `json:"queueClass,omitempty"`
+	// DependsOn lists the JobIDs of other jobs in the same DAG submission
+	// that must finish successfully before the server assigns this job to
+	// a worker. Referenced JobIDs are meaningful only within the same
+	// submission; the server is responsible for tracking completion and
+	// releasing dependents.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Project namespaces this job for a multi-tenant deployment: the
+	// server scopes its job listings and tool registry lookups to it, and
+	// the worker prefixes this job's uploaded outputs with it, so labs
+	// sharing one flowy deployment never see each other's jobs or
+	// outputs. Empty means the default (unnamespaced) project.
+	Project string `json:"project,omitempty"`
+	// SoftwareEnvironment describes a non-container software environment
+	// Command should run inside, for sites that don't use DockerImage.
+	// Ignored when DockerImage is set.
+	SoftwareEnvironment *SoftwareEnvironment `json:"softwareEnvironment,omitempty"`
+	// RetryPolicy, when set, has the worker itself re-run Command after a
+	// failure whose exit code is in RetryableExitCodes, up to RetryCount
+	// additional times, for tools whose transient failures (a flaky
+	// network call, a momentarily unavailable license server) are known
+	// to succeed on a plain re-run. Nil means never retry.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// VersionProbes maps a human-readable label (e.g. "samtools") to a
+	// command run, after the job completes, inside the same container or
+	// host environment Command ran in, so its captured output can be
+	// attached to the job's ReproducibilityReport. A probe that fails to
+	// run is omitted from the report rather than failing the job.
+	VersionProbes map[string][]string `json:"versionProbes,omitempty"`
+	// Cacheable opts this job out of step-level call caching when set to
+	// false, for a tool with nondeterministic output or an external side
+	// effect (e.g. sending a notification, appending to a shared ledger)
+	// that must run every time regardless of whether its inputs match a
+	// prior invocation. Nil means cacheable, the default for a CWL
+	// CommandLineTool with no such hint.
+	Cacheable *bool `json:"cacheable,omitempty"`
+	// CacheKeyExtra is extra material - typically lifted from a CWL hint
+	// - mixed into this job's cache key alongside its Command, Mapping,
+	// Env, and DockerImage, for a step whose correct caching depends on
+	// something not otherwise visible to the worker (e.g. an upstream
+	// database's schema version, or a reference data release tag).
+	// Ignored when Cacheable is false.
+	CacheKeyExtra string `json:"cacheKeyExtra,omitempty"`
+	// Labels are free-form key=value annotations set at submission time
+	// (cwlclient submit/array's -label flag), carried through execution
+	// unchanged. The worker attaches them to uploaded output objects as
+	// tags (see publishOutputs) and to its metrics as extra dimensions,
+	// and echoes them back in JobFinishedRequest so the server can index
+	// jobs by them for cost attribution and search (flowy-cmd job list
+	// -label). Empty means unlabeled.
+	Labels map[string]string `json:"labels,omitempty"`
+	// ReferenceBundles names pre-registered shared datasets (reference
+	// genomes, aligner indexes) this job needs, by ID, instead of listing
+	// them as ordinary Mapping entries. The worker resolves each ID
+	// against the server's reference-bundle registry and stages it once
+	// into a dedicated shared cache, so a hundred jobs against the same
+	// genome don't each stage their own private copy.
+	ReferenceBundles []string `json:"referenceBundles,omitempty"`
+	// SpeculativeCopy marks this job as a duplicate of a shard already
+	// dispatched to another worker, sent speculatively because that
+	// shard has been running far beyond the scatter step's median
+	// runtime. The worker executing it periodically polls the server
+	// (see cmd/worker/speculative.go) and kills its own run if the
+	// original - or another copy - finishes first, so only one copy's
+	// outputs are ever published.
+	SpeculativeCopy bool `json:"speculativeCopy,omitempty"`
+	// CheckpointDir names a directory, relative to the job's working
+	// directory, that a long-running tool writes its own checkpoints
+	// into (e.g. a week-long assembly's intermediate state). When set,
+	// the worker periodically uploads its contents while the job is
+	// still running, and restores whatever was last uploaded into it
+	// before starting the command - so a retry after the job was killed
+	// (a time limit, a preemption, a worker crash) resumes from the
+	// tool's own checkpoint instead of starting over. Empty means the
+	// tool has no checkpoint contract.
+	CheckpointDir string `json:"checkpointDir,omitempty"`
+}
+
+// ReferenceBundleManifest describes one registered reference bundle, as
+// returned by the server's reference-bundle registry
+// (GET /api/reference-bundles/<id>). A worker fetches this once per
+// bundle ID and uses it to populate and verify that bundle's entry in its
+// shared cache.
+type ReferenceBundleManifest struct {
+	ID    string                `json:"id"`
+	Files []ReferenceBundleFile `json:"files"`
+}
+
+// ReferenceBundleFile is one file within a ReferenceBundleManifest. It is
+// downloaded from Location and staged at RelPath (relative to the
+// bundle's cache directory), then verified against Checksum, in the same
+// "<algorithm>$<hex digest>" format CWL File.checksum and CASKey already
+// use elsewhere in this repo.
+type ReferenceBundleFile struct {
+	RelPath  string `json:"relPath"`
+	Location string `json:"location"`
+	Checksum string `json:"checksum"`
+}
+
+// RetryPolicy configures automatic re-execution of a failed job's
+// Command, carried from the client's submission through to the worker
+// that runs it.
+type RetryPolicy struct {
+	// RetryCount is the maximum number of additional attempts after the
+	// first, so a job with RetryCount 2 runs at most 3 times total.
+	RetryCount int `json:"retryCount"`
+	// RetryableExitCodes is the set of process exit codes that trigger a
+	// retry. An exit code not in this set (including 0, which never
+	// retries) fails the job on its first occurrence, same as if no
+	// RetryPolicy were set.
+	RetryableExitCodes []int `json:"retryableExitCodes,omitempty"`
+}
+
+// SoftwareEnvironment is a CWL SoftwareRequirement resolved to something a
+// worker without container support can actually activate: an existing
+// conda environment, an inline conda environment.yml to build and cache,
+// or a list of Lmod modules to load. At most one of CondaEnv, CondaYAML,
+// or Modules is expected to be set.
+type SoftwareEnvironment struct {
+	// CondaEnv names an existing conda environment (by name or path) to
+	// activate.
+	CondaEnv string `json:"condaEnv,omitempty"`
+	// CondaYAML is an inline conda environment.yml the worker resolves -
+	// creating and caching the environment, keyed by a hash of this
+	// content - and then activates, for tools whose SoftwareRequirement
+	// declares packages rather than naming a pre-built environment.
+	CondaYAML string `json:"condaYaml,omitempty"`
+	// Modules lists Lmod module names to load, in order, before running
+	// Command.
+	Modules []string `json:"modules,omitempty"`
+}
+
+// PipeEndpoint describes one end of a streamed connection between a
+// streamable CWL output and a streamable CWL input, as brokered by the
+// server across the two jobs' ExecutableJob payloads. The producer side
+// listens on Address and streams to Consumers connections (see
+// internal/pipe.Serve); the consumer side dials Address and reads the
+// stream (see internal/pipe.Dial).
+type PipeEndpoint struct {
+	// Role is "producer" or "consumer".
+	Role string `json:"role"`
+	// Address is the host:port the producer listens on and every
+	// consumer dials, chosen by the server so it resolves between
+	// whichever two workers it assigned the producer and consumer jobs
+	// to.
+	Address string `json:"address"`
+	// Consumers is the number of consumer connections the producer must
+	// accept before it starts streaming. Only meaningful for Role
+	// "producer".
+	Consumers int `json:"consumers,omitempty"`
+}
+
+// OutputType is a (possibly nested) CWL output type, as declared in a
+// tool's outputs section: File, Directory, a primitive ("string", "int",
+// "long", "float", "double", "boolean"), or an array of one of those.
+type OutputType struct {
+	Type     string      `json:"type"`
+	Items    *OutputType `json:"items,omitempty"`
+	Optional bool        `json:"optional,omitempty"`
+}
+
+// OutputBinding is a CWL CommandOutputBinding, evaluated worker-side to
+// collect an output that has no supporting cwl.output.json entry.
+type OutputBinding struct {
+	// Glob patterns are matched against jobDir in order and their
+	// results concatenated (see internal/glob). A pattern may itself be a
+	// CWL/JavaScript expression (e.g. built from an input parameter the
+	// server could not resolve ahead of time); it is evaluated worker-side
+	// against inputs/runtime before matching, and an expression returning
+	// an array of strings contributes each one as its own pattern in the
+	// order returned.
+	Glob []string `json:"glob,omitempty"`
+	// Array reports whether the output parameter's type is an array, so
+	// a glob matching zero files yields an empty array rather than a
+	// missing output, and more than one match is kept instead of being
+	// an error.
+	Array bool `json:"array,omitempty"`
+	// Required reports whether a glob matching zero files is an error
+	// for this output, per the CWL spec's non-array, non-optional case.
+	Required bool `json:"required,omitempty"`
+	// Format sets the "format" field on every File this binding collects.
+	// A literal value must be a well-formed EDAM ontology IRI (see
+	// internal/format); a CWL/JavaScript expression is evaluated with
+	// "self" bound to the File object just collected, and its result is
+	// validated the same way.
+	Format string `json:"format,omitempty"`
+}
+
+// PlannedOutputLocation describes where an output parameter's value is
+// expected to be published once its job actually runs, computed from its
+// OutputBinding without running the job, so downstream systems can
+// register expectations or set up lifecycle policies ahead of
+// completion. It's part of the /debug/plan response a worker returns for
+// a job it hasn't executed yet.
+type PlannedOutputLocation struct {
+	Name string `json:"name"`
+	// Location is the full destination (outputBaseURL-prefixed key) when
+	// Exact is true, or just the destination directory's prefix
+	// otherwise, since a wildcard glob's actual match - and therefore its
+	// final basename - isn't knowable ahead of execution.
+	Location string `json:"location,omitempty"`
+	// Exact reports whether Location is the output's actual final
+	// destination (its OutputBinding.Glob had exactly one pattern with no
+	// wildcard metacharacters) rather than just a directory hint.
+	Exact bool `json:"exact"`
+}
+
+// BulkJobSubmission is the request body for POST /api/jobs/bulk: many jobs
+// submitted in one HTTP round trip, for clients (e.g. cwlclient submit)
+// that would otherwise need one call per job to submit a batch of
+// hundreds.
+type BulkJobSubmission struct {
+	Jobs []ExecutableJob `json:"jobs"`
+}
+
+// BulkJobSubmissionResult reports one submitted job's outcome, in the
+// same order as the BulkJobSubmission.Jobs it came from, so a batch's
+// partial failures can be attributed to the jobs that actually failed
+// rather than the whole batch being treated as having failed.
+type BulkJobSubmissionResult struct {
+	JobID   string `json:"jobId"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkJobSubmissionResponse is the response body for POST /api/jobs/bulk.
+type BulkJobSubmissionResponse struct {
+	Results []BulkJobSubmissionResult `json:"results"`
+}
+
+// JobBatchResponse is the response body for GET /api/worker/next-jobs,
+// the batch counterpart to next-job. A worker that opts into batched
+// polling (FLOWY_WORKER_BATCH_SIZE > 1) uses it to pull several queued
+// jobs from the same submission at once, so it can detect fileitems
+// shared across scatter shards (see stageSharedInputs) and stage each
+// one only once instead of once per shard.
+type JobBatchResponse struct {
+	Jobs []ExecutableJob `json:"jobs"`
+}
+
+// SecondaryFilePattern is one secondaryFiles entry for a CWL output: a
+// literal, "^"-prefixed, or full CWL/JavaScript expression pattern,
+// whether a missing match is an error, and any patterns to apply in turn
+// to the file this one matches.
+type SecondaryFilePattern struct {
+	Pattern        string                 `json:"pattern"`
+	Required       bool                   `json:"required"`
+	SecondaryFiles []SecondaryFilePattern `json:"secondaryFiles,omitempty"`
+}
+
+// WorkerStartedRequest is posted to the server once at worker startup so
+// it can schedule jobs whose container images must match the worker's CPU
+// architecture (e.g. arm64-only images on Graviton/Apple Silicon workers).
+type WorkerStartedRequest struct {
+	Name        string `json:"name"`
+	Os          string `json:"os"`
+	Arch        string `json:"arch"`
+	TotalMemory int64  `json:"totalMemory,omitempty"`
+	// Queues lists the queue classes this worker is willing to serve, so
+	// the server can prefer assigning a job to a worker that advertised
+	// its QueueClass (e.g. keeping a "clinical" queue's workers free of
+	// batch reprocessing jobs). An empty list means this worker serves
+	// the default queue only.
+	Queues []string `json:"queues,omitempty"`
+}
+
+// WorkerHeartbeatRequest is posted periodically while a worker is running
+// so the server can track its liveness and react to the conditions it
+// reports, independent of whatever job (if any) it is currently running.
+type WorkerHeartbeatRequest struct {
+	Name string `json:"name"`
+	// DiskPressure reports whether this worker has paused accepting new
+	// jobs because its workdir or download cache crossed a configured
+	// free-space threshold, so the server can avoid assigning it work
+	// until a later heartbeat reports the condition has cleared.
+	DiskPressure bool `json:"diskPressure,omitempty"`
+	// BackendHealth reports this worker's FileManager backends' recent
+	// reliability (call/error counts, last success), so the server can
+	// surface an ailing NFS mount or S3 endpoint before it fails a pile
+	// of jobs. Omitted entries mean that backend hasn't handled a call
+	// yet.
+	BackendHealth []BackendHealthReport `json:"backendHealth,omitempty"`
+	// Preemptible reports whether this worker runs on a spot/preemptible
+	// node that can be reclaimed by the cloud provider with little or no
+	// notice, set once at startup via FLOWY_WORKER_PREEMPTIBLE. The
+	// server can use this to prefer steadier workers for jobs a
+	// preemption would be especially costly to lose.
+	Preemptible bool `json:"preemptible,omitempty"`
+}
+
+// BackendHealthReport is one FileManager backend's health, as reported in
+// a WorkerHeartbeatRequest; it mirrors internal.BackendHealth, duplicated
+// here since internal/api stays free of dependencies on other internal
+// packages.
+type BackendHealthReport struct {
+	// Backend names which FileManager this reports on (e.g. "input" or
+	// "output").
+	Backend     string `json:"backend"`
+	Calls       int64  `json:"calls"`
+	Errors      int64  `json:"errors"`
+	LastSuccess int64  `json:"lastSuccess,omitempty"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// WorkerHeartbeatResponse is returned from a heartbeat post so the worker
+// can detect and correct for clock skew against the server, the same way
+// a signed payload's timestamp window or a job's recorded wall-clock
+// timing would otherwise silently drift out of sync with the server's own
+// view of time.
+type WorkerHeartbeatResponse struct {
+	// ServerTime is the server's clock at the moment it handled the
+	// request, as Unix seconds.
+	ServerTime int64 `json:"serverTime"`
+}
+
+// JobFinishedRequest is posted back to the server once a job completes,
+// successfully or not.
+type JobFinishedRequest struct {
+	JobID    string                 `json:"jobId"`
+	Success  bool                   `json:"success"`
+	ExitCode int                    `json:"exitCode"`
+	Outputs  map[string]interface{} `json:"outputs,omitempty"`
+	ErrorMsg string                 `json:"errorMsg,omitempty"`
+	// ErrorCode is the failure's internal/errors.Code (e.g. "staging",
+	// "transfer", "eval", "output_collection", "spec_violation"), set
+	// whenever the worker can classify cause as one of those categories,
+	// so the server can facet and alert on failures by category instead
+	// of parsing ErrorMsg. Empty for a failure that isn't one of them
+	// (e.g. the tool's own non-zero exit).
+	ErrorCode string         `json:"errorCode,omitempty"`
+	Usage     *ResourceUsage `json:"usage,omitempty"`
+	// PermanentFail reports whether cause is a failure retrying the job
+	// cannot fix (e.g. the tool's own output doesn't match its declared
+	// schema), so the server should not requeue it the way it would a
+	// transient infrastructure error. Only meaningful when Success is
+	// false.
+	PermanentFail bool `json:"permanentFail,omitempty"`
+	// TimeLimitExceeded reports whether the job was killed for exceeding
+	// its ToolTimeLimit rather than failing on its own. Outputs may still
+	// be populated in this case, with whatever the tool managed to
+	// produce before it was killed. Only meaningful when Success is
+	// false.
+	TimeLimitExceeded bool `json:"timeLimitExceeded,omitempty"`
+	// Requeue reports whether the job was killed because this worker is
+	// being preempted (see WorkerHeartbeatRequest.Preemptible) rather
+	// than failing on its own, so the server should put it straight back
+	// on the queue for another worker instead of charging it toward
+	// RetryPolicy or reporting it to whoever is watching the job as a
+	// failure. Only meaningful when Success is false.
+	Requeue bool `json:"requeue,omitempty"`
+	// Project echoes the job's ExecutableJob.Project, so the server can
+	// attribute this usage record's CPU time and storage to the right
+	// project for quota accounting.
+	Project string `json:"project,omitempty"`
+	// Labels echoes the job's ExecutableJob.Labels, so the server can
+	// index a finished job by them the same way it does Project.
+	Labels map[string]string `json:"labels,omitempty"`
+	// SoftwareEnvironment reports what the worker actually resolved and
+	// activated for the job's ExecutableJob.SoftwareEnvironment, for
+	// provenance: CondaEnv holds the environment the job ran under (the
+	// cache path of one built from CondaYAML, which is never echoed back
+	// here, or the name or path exactly as given in CondaEnv), and Modules
+	// the modules loaded. Nil when the job had no SoftwareEnvironment
+	// configured.
+	SoftwareEnvironment *SoftwareEnvironment `json:"softwareEnvironment,omitempty"`
+	// ImageDigest is the pinned "image@sha256:..." digest the job's
+	// DockerImage was actually run at, resolved once at execution time so
+	// results stay reproducible and auditable even if a mutable tag is
+	// later repointed. Empty when the job had no DockerImage, or digest
+	// resolution failed and it ran by tag instead.
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// WorkerName identifies which worker produced this report, so a
+	// verifier can look up the matching registered key for
+	// ResultSignature. Empty when the worker has result signing disabled.
+	WorkerName string `json:"workerName,omitempty"`
+	// ResultSignature is the base64-encoded Ed25519 signature of
+	// CanonicalResultsPayload(Outputs), signed with the worker's own
+	// per-worker key, so a client reading Outputs back from intermediate
+	// storage can detect tampering without trusting that storage. Empty
+	// when the worker has result signing disabled.
+	ResultSignature string `json:"resultSignature,omitempty"`
+	// Attempt is how many times the worker ran Command before reporting
+	// this outcome: 1 means it succeeded or failed on the first try, 2
+	// means one RetryPolicy retry was spent, and so on. Always at least 1
+	// once a job has actually run.
+	Attempt int `json:"attempt,omitempty"`
+	// Reproducibility captures what actually ran for this job (tool
+	// versions, container digest, host OS/kernel/CPU, locale), so a
+	// published analysis can state exactly what produced it. Nil only
+	// when the worker predates this field.
+	Reproducibility *ReproducibilityReport `json:"reproducibility,omitempty"`
+	// CacheKey is the content-addressed key the worker computed for this
+	// job from its Command, Mapping, Env, DockerImage, and
+	// CacheKeyExtra, so a call-caching subsystem can record or look up
+	// results by it without recomputing the hash itself. Empty when the
+	// job's ExecutableJob.Cacheable was false.
+	CacheKey string `json:"cacheKey,omitempty"`
+}
+
+// ReproducibilityReport captures the execution environment a job actually
+// ran in, attached to its JobFinishedRequest so published results can
+// state exactly what ran where.
+type ReproducibilityReport struct {
+	// ToolVersions maps each of the job's ExecutableJob.VersionProbes
+	// labels to that probe's captured output. A label whose probe failed
+	// to run is omitted.
+	ToolVersions map[string]string `json:"toolVersions,omitempty"`
+	// ContainerDigest is the same pinned "image@sha256:..." digest as
+	// JobFinishedRequest.ImageDigest, repeated here so the report is
+	// self-contained. Empty for a job that ran directly on the host.
+	ContainerDigest string `json:"containerDigest,omitempty"`
+	OS              string `json:"os,omitempty"`
+	Kernel          string `json:"kernel,omitempty"`
+	CPUModel        string `json:"cpuModel,omitempty"`
+	Locale          string `json:"locale,omitempty"`
+}
+
+// CanonicalResultsPayload returns the exact byte sequence a worker signs
+// and a verifier re-derives to check ResultSignature: outputs marshaled as
+// JSON, which encoding/json already serializes with map keys in sorted
+// order, making it reproducible on both ends without a separate
+// canonicalization step.
+func CanonicalResultsPayload(outputs map[string]interface{}) ([]byte, error) {
+	return json.Marshal(outputs)
+}
+
+// ProjectQuota is a project's configured resource limits and current
+// consumption, as returned by the server's per-project quota endpoint (GET
+// /api/projects/<project>/quota). A zero limit means that dimension is
+// unlimited.
+type ProjectQuota struct {
+	Project           string  `json:"project"`
+	CPUHourLimit      float64 `json:"cpuHourLimit,omitempty"`
+	CPUHoursUsed      float64 `json:"cpuHoursUsed"`
+	StorageLimitBytes int64   `json:"storageLimitBytes,omitempty"`
+	StorageUsedBytes  int64   `json:"storageUsedBytes"`
+}
+
+// ProjectCostSummary aggregates ResourceUsage.EstimatedCostUSD across every
+// finished job submitted under Project, as returned by the server's cost
+// export endpoint (GET /api/costs) for `flowy-cmd cost export`.
+type ProjectCostSummary struct {
+	Project               string  `json:"project"`
+	JobCount              int     `json:"jobCount"`
+	TotalEstimatedCostUSD float64 `json:"totalEstimatedCostUsd"`
+}
+
+// CostReport is the server's reply to GET /api/costs.
+type CostReport struct {
+	Projects []ProjectCostSummary `json:"projects"`
+}
+
+// ExportRequest asks the server to copy a completed job's output files to
+// Target (e.g. an s3://bucket/prefix), used when the destination isn't one
+// the client itself can reach or has credentials for. The server responds
+// with the job's output JSON rewritten to point at the new locations.
+type ExportRequest struct {
+	Target string `json:"target"`
+}
+
+// WorkerKeyResponse is returned by the server's registered-worker-key
+// lookup (GET /api/workers/<name>/key), used by cwlclient to verify a
+// JobFinishedRequest.ResultSignature against the worker named in it.
+type WorkerKeyResponse struct {
+	// ResultSigningKey is the worker's base64-encoded Ed25519 public key,
+	// as it submitted in its registerWorker request. Empty if the named
+	// worker never registered one (e.g. it has result signing disabled).
+	ResultSigningKey string `json:"resultSigningKey,omitempty"`
+}
+
+// OutputRetentionCandidate describes one job's output prefix as considered
+// by the server's output garbage collector (GET/POST /api/outputs/gc):
+// CreatedAt is RFC 3339 so this package stays free of a "time" dependency.
+// Protected is set for outputs a registered dataset still references, and
+// the collector must never remove those regardless of age.
+type OutputRetentionCandidate struct {
+	JobID     string `json:"jobId"`
+	Project   string `json:"project,omitempty"`
+	Prefix    string `json:"prefix"`
+	CreatedAt string `json:"createdAt"`
+	Protected bool   `json:"protected"`
+}
+
+// JobOrderSubmission asks the server to compile Inputs against the tool
+// named by Tool (a path or registered identifier, as the server's tool
+// registry expects) and run it, the same way a normal CWL job order
+// submission would, without the client having to produce a fully resolved
+// ExecutableJob itself. Used by `cwlclient array` to submit one job order
+// per row of a sample sheet.
+type JobOrderSubmission struct {
+	Tool    string                 `json:"tool"`
+	Inputs  map[string]interface{} `json:"inputs"`
+	Project string                 `json:"project,omitempty"`
+	// Label identifies this submission in logs and in the response to
+	// JobOrderSubmission's caller (e.g. a sample ID), distinct from the
+	// server-assigned JobID.
+	Label string `json:"label,omitempty"`
+	// ToolCommitHash records the commit Tool was resolved from, when Tool
+	// was a "git+url#ref:path" reference rather than a plain path, so the
+	// exact version of the tool that ran can be recovered later even if
+	// the named ref (a branch or tag) has since moved.
+	ToolCommitHash string `json:"toolCommitHash,omitempty"`
+	// Labels are free-form key/value annotations carried onto the
+	// ExecutableJob(s) the server compiles from this submission, for cost
+	// attribution and search; see ExecutableJob.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// JobOrderResponse is the server's reply to a JobOrderSubmission, naming
+// the JobID it assigned so the caller can later poll or export it.
+type JobOrderResponse struct {
+	JobID string `json:"jobId"`
+}
+
+// JobGraphNode is one job in a DAG submission's dependency graph, as
+// returned by the server's per-job graph endpoint (GET
+// /api/jobs/<jobId>/graph) so a client can render the whole DAG a job
+// belongs to along with each node's current status.
+type JobGraphNode struct {
+	JobID     string   `json:"jobId"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Status is one of "pending", "running", "succeeded", or "failed".
+	Status string `json:"status"`
+}
+
+// JobSummary is one job's entry in a GET /api/jobs listing, as decoded by
+// `flowy-cmd job list` - deliberately flatter than JobFinishedRequest since
+// a listing shows many jobs at once rather than one job's full detail.
+type JobSummary struct {
+	JobID   string `json:"jobId"`
+	Status  string `json:"status"`
+	Project string `json:"project,omitempty"`
+	// Labels are the job's annotation labels; see ExecutableJob.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// JobListResponse is the server's reply to GET /api/jobs.
+type JobListResponse struct {
+	Jobs []JobSummary `json:"jobs"`
+}
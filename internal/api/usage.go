@@ -0,0 +1,18 @@
+package api
+
+// ResourceUsage captures what a job cost to run, for capacity planning and
+// (eventually) per-project quota enforcement. It is attached to
+// JobFinishedRequest and rendered by `flowy-cmd job show`.
+type ResourceUsage struct {
+	WallSeconds    float64 `json:"wallSeconds"`
+	UserCPUSeconds float64 `json:"userCpuSeconds"`
+	SysCPUSeconds  float64 `json:"sysCpuSeconds"`
+	MaxRSSBytes    int64   `json:"maxRssBytes"`
+	BytesRead      int64   `json:"bytesRead,omitempty"`
+	BytesWritten   int64   `json:"bytesWritten,omitempty"`
+	// EstimatedCostUSD is the worker's estimate of what this job cost to
+	// run, derived from the other fields here against its configured
+	// pricing model (see cmd/worker/cost.go). Zero when no pricing model
+	// is configured, not necessarily because the job was free.
+	EstimatedCostUSD float64 `json:"estimatedCostUsd,omitempty"`
+}
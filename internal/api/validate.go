@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PayloadError describes a problem decoding or validating a worker API
+// payload, with enough context for an operator to find and fix a bad job
+// submission instead of trawling a bare encoding/json error or a stack
+// trace: which job it was (when recoverable), the dotted path to the
+// offending field, and a redacted excerpt of the raw payload around it.
+type PayloadError struct {
+	// JobID is "" when it could not be parsed out of the payload (e.g. any
+	// syntax error, since nothing is decoded from a document that is not
+	// valid JSON).
+	JobID string
+	// Field is the dotted path to the offending field (e.g.
+	// "mapping[2].type" or "outputSchema[bams].items.type"), or "" for
+	// an error that isn't field-specific.
+	Field   string
+	Snippet string
+	Err     error
+}
+
+func (e *PayloadError) Error() string {
+	msg := e.Err.Error()
+	if e.JobID != "" {
+		msg = fmt.Sprintf("job %s: %s", e.JobID, msg)
+	}
+	if e.Field != "" {
+		msg = fmt.Sprintf("%s (field %s)", msg, e.Field)
+	}
+	if e.Snippet != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Snippet)
+	}
+	return msg
+}
+
+func (e *PayloadError) Unwrap() error { return e.Err }
+
+// DecodeExecutableJob unmarshals body into an ExecutableJob and validates
+// it, wrapping any failure as a *PayloadError with a friendly field path
+// and a redacted snippet of the raw payload, instead of callers having to
+// decode encoding/json's own terse error text themselves.
+func DecodeExecutableJob(body []byte) (*ExecutableJob, error) {
+	var job ExecutableJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		perr := explainUnmarshalError(body, err)
+		// Unmarshal keeps decoding past a type mismatch, so JobID is
+		// known here whenever the payload carried one.
+		perr.JobID = job.JobID
+		return nil, perr
+	}
+	if err := ValidateExecutableJob(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// explainUnmarshalError turns a raw encoding/json error into a
+// *PayloadError carrying a field path (when the stdlib error names one)
+// and a redacted snippet of body around where it occurred.
+func explainUnmarshalError(body []byte, err error) *PayloadError {
+	perr := &PayloadError{Err: err}
+	var offset int64 = -1
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		perr.Field = typeErr.Field
+		offset = typeErr.Offset
+	}
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		offset = syntaxErr.Offset
+	}
+	if offset >= 0 {
+		perr.Snippet = redactedSnippet(body, offset)
+	}
+	return perr
+}
+
+// snippetRadius bounds how much of the raw payload surrounds an offset is
+// included in a PayloadError's Snippet: enough to show the offending value
+// in context without dumping an entire (possibly large) job payload into
+// a log line or error message.
+const snippetRadius = 40
+
+// redactedSnippet returns a bounded excerpt of body centered on offset,
+// with values that look like they belong to a sensitive key (secret,
+// token, password, credential, apikey) masked, so a payload error never
+// leaks a credential embedded in a job's Env or SoftwareEnvironment into
+// a log line. Masking runs over the whole body before the excerpt is cut,
+// so a value whose key falls outside the excerpt is masked too; each
+// masked value keeps its length, so offset still points at the same byte.
+func redactedSnippet(body []byte, offset int64) string {
+	masked := sensitiveValuePattern.ReplaceAllStringFunc(string(body), func(m string) string {
+		prefix := sensitiveValuePattern.FindStringSubmatch(m)[1]
+		return prefix + strings.Repeat("*", len(m)-len(prefix)-1) + `"`
+	})
+	start := offset - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := offset + snippetRadius
+	if end > int64(len(masked)) {
+		end = int64(len(masked))
+	}
+	if start > int64(len(masked)) {
+		return ""
+	}
+	return masked[start:end]
+}
+
+// sensitiveValuePattern matches a `"someSecretKey": "value"` pair whose
+// key looks credential-related, capturing everything up through the
+// opening quote of the value so redactedSnippet can mask just the value.
+var sensitiveValuePattern = regexp.MustCompile(`(?i)("(?:[^"]*(?:secret|token|password|credential|apikey)[^"]*"\s*:\s*)")[^"]*"`)
+
+// knownMapperEntTypes are the staging/collection types the worker knows
+// how to stage (see cmd/worker/stage.go's stageEntry). Any other value in
+// a MapperEnt's Type is almost certainly a client sending a newer CWL
+// type the worker hasn't been taught yet, or a typo - better surfaced
+// before staging starts than mid-way through it.
+var knownMapperEntTypes = map[string]bool{"File": true, "Directory": true}
+
+// knownOutputTypeNames are the scalar CWL output type names
+// outputschema.Validate knows how to check, plus "array" for an
+// OutputType whose Items names the element type.
+var knownOutputTypeNames = map[string]bool{
+	"File": true, "Directory": true, "string": true, "boolean": true,
+	"int": true, "long": true, "float": true, "double": true, "array": true,
+}
+
+// ValidateExecutableJob checks job for the kinds of malformed payload that
+// would otherwise only surface once staging or output collection is
+// already underway: a missing JobID or Command, and any MapperEnt or
+// OutputType naming a type the worker doesn't recognize.
+func ValidateExecutableJob(job *ExecutableJob) error {
+	if job.JobID == "" {
+		return &PayloadError{Err: fmt.Errorf("missing required field"), Field: "jobId"}
+	}
+	if len(job.Command) == 0 {
+		return &PayloadError{JobID: job.JobID, Err: fmt.Errorf("missing required field"), Field: "command"}
+	}
+	for i, ent := range job.Mapping {
+		if err := validateMapperEnt(ent, fmt.Sprintf("mapping[%d]", i)); err != nil {
+			return &PayloadError{JobID: job.JobID, Field: err.field, Err: err.err}
+		}
+	}
+	for name, t := range job.OutputSchema {
+		if err := validateOutputType(t, fmt.Sprintf("outputSchema[%s]", name)); err != nil {
+			return &PayloadError{JobID: job.JobID, Field: err.field, Err: err.err}
+		}
+	}
+	return nil
+}
+
+// fieldError pairs a dotted field path with the problem found there, kept
+// unexported since it only threads context from the recursive validators
+// above back up to ValidateExecutableJob, which turns it into the
+// *PayloadError callers actually see.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func validateMapperEnt(ent MapperEnt, path string) *fieldError {
+	if !knownMapperEntTypes[ent.Type] {
+		return &fieldError{field: path + ".type", err: fmt.Errorf("unknown staging type %q", ent.Type)}
+	}
+	for i, child := range ent.Listing {
+		if err := validateMapperEnt(child, fmt.Sprintf("%s.listing[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateOutputType(t OutputType, path string) *fieldError {
+	if !knownOutputTypeNames[t.Type] {
+		return &fieldError{field: path + ".type", err: fmt.Errorf("unknown output type %q", t.Type)}
+	}
+	if t.Type == "array" {
+		if t.Items == nil {
+			return &fieldError{field: path + ".items", err: fmt.Errorf("array output type missing items")}
+		}
+		return validateOutputType(*t.Items, path+".items")
+	}
+	return nil
+}
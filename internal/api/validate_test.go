@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateExecutableJobRejectsMissingJobID(t *testing.T) {
+	job := &ExecutableJob{Command: []string{"echo"}}
+	err := ValidateExecutableJob(job)
+	if err == nil {
+		t.Fatal("expected error for missing jobId")
+	}
+	var perr *PayloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PayloadError, got %T", err)
+	}
+	if perr.Field != "jobId" {
+		t.Fatalf("Field = %q, want %q", perr.Field, "jobId")
+	}
+}
+
+func TestValidateExecutableJobRejectsUnknownMappingType(t *testing.T) {
+	job := &ExecutableJob{
+		JobID:   "job-1",
+		Command: []string{"echo"},
+		Mapping: []MapperEnt{{Target: "in.txt", Type: "Socket"}},
+	}
+	err := ValidateExecutableJob(job)
+	var perr *PayloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PayloadError, got %v", err)
+	}
+	if perr.JobID != "job-1" || perr.Field != "mapping[0].type" {
+		t.Fatalf("perr = %+v", perr)
+	}
+}
+
+func TestValidateExecutableJobRejectsUnknownNestedListingType(t *testing.T) {
+	job := &ExecutableJob{
+		JobID:   "job-1",
+		Command: []string{"echo"},
+		Mapping: []MapperEnt{{
+			Type:    "Directory",
+			Listing: []MapperEnt{{Type: "Pipe"}},
+		}},
+	}
+	err := ValidateExecutableJob(job)
+	var perr *PayloadError
+	if !errors.As(err, &perr) || perr.Field != "mapping[0].listing[0].type" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestValidateExecutableJobRejectsUnknownOutputType(t *testing.T) {
+	job := &ExecutableJob{
+		JobID:        "job-1",
+		Command:      []string{"echo"},
+		OutputSchema: map[string]OutputType{"result": {Type: "blob"}},
+	}
+	err := ValidateExecutableJob(job)
+	var perr *PayloadError
+	if !errors.As(err, &perr) || perr.Field != "outputSchema[result].type" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestValidateExecutableJobChecksArrayItems(t *testing.T) {
+	job := &ExecutableJob{
+		JobID:        "job-1",
+		Command:      []string{"echo"},
+		OutputSchema: map[string]OutputType{"results": {Type: "array", Items: &OutputType{Type: "bam"}}},
+	}
+	err := ValidateExecutableJob(job)
+	var perr *PayloadError
+	if !errors.As(err, &perr) || perr.Field != "outputSchema[results].items.type" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestValidateExecutableJobAcceptsValidJob(t *testing.T) {
+	job := &ExecutableJob{
+		JobID:   "job-1",
+		Command: []string{"echo", "hi"},
+		Mapping: []MapperEnt{{Type: "File", Target: "in.txt"}},
+		OutputSchema: map[string]OutputType{
+			"out": {Type: "array", Items: &OutputType{Type: "File"}},
+		},
+	}
+	if err := ValidateExecutableJob(job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDecodeExecutableJobRejectsMalformedJSONWithSnippet(t *testing.T) {
+	body := []byte(`{"jobId": "job-1", "command": ["echo"], "timelimit": "not-a-number"}`)
+	_, err := DecodeExecutableJob(body)
+	if err == nil {
+		t.Fatal("expected error for type-mismatched field")
+	}
+	var perr *PayloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PayloadError, got %T", err)
+	}
+	if perr.Field != "timelimit" {
+		t.Fatalf("Field = %q, want %q", perr.Field, "timelimit")
+	}
+	if perr.Snippet == "" {
+		t.Fatal("expected a non-empty snippet")
+	}
+}
+
+func TestDecodeExecutableJobRedactsSecretLookingValues(t *testing.T) {
+	body := []byte(`{"jobId": "job-1", "command": ["echo"], "apiToken": "sk-super-secret-value", "timelimit": "bad"}`)
+	_, err := DecodeExecutableJob(body)
+	var perr *PayloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PayloadError, got %v", err)
+	}
+	if strings.Contains(perr.Snippet, "sk-super-secret-value") {
+		t.Fatalf("snippet leaked secret value: %q", perr.Snippet)
+	}
+}
+
+func TestDecodeExecutableJobRunsSchemaValidationAfterDecode(t *testing.T) {
+	body := []byte(`{"jobId": "job-1", "command": ["echo"], "mapping": [{"type": "Socket"}]}`)
+	_, err := DecodeExecutableJob(body)
+	var perr *PayloadError
+	if !errors.As(err, &perr) || perr.Field != "mapping[0].type" {
+		t.Fatalf("err = %v", err)
+	}
+}
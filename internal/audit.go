@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord is one entry in the data-movement audit log: every download,
+// upload, copy, and delete a FileManager performs, with enough detail to
+// answer "where did this job's data go" in a regulated environment.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"` // download, upload, copy, delete
+	Source    string    `json:"source,omitempty"`
+	Dest      string    `json:"dest,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Duration  float64   `json:"durationSeconds"`
+	JobID     string    `json:"jobId,omitempty"`
+	Err       string    `json:"error,omitempty"`
+}
+
+// AuditLogger appends AuditRecords to a destination, typically a local
+// append-only file or a forwarder to an audit API.
+type AuditLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditLogger returns an AuditLogger writing newline-delimited JSON
+// records to w.
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{w: w}
+}
+
+// NewFileAuditLogger opens (creating if necessary) an append-only audit log
+// at path.
+func NewFileAuditLogger(path string) (*AuditLogger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditLogger(f), nil
+}
+
+// Record appends rec to the log. A failure to write the audit record is
+// not propagated to the caller: the data movement it describes already
+// happened, and audit logging must never be the reason a job fails.
+func (a *AuditLogger) Record(rec AuditRecord) {
+	if a == nil {
+		return
+	}
+	rec.Time = rec.Time.UTC()
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.w.Write(line)
+}
+
+// auditedFileManager wraps a FileManager, recording every Download,
+// Upload, Copy, and Remove it performs to an AuditLogger.
+type auditedFileManager struct {
+	FileManager
+	audit *AuditLogger
+	jobID string
+}
+
+// WithAudit wraps fm so every data movement it performs is recorded to
+// audit, tagged with jobID as the initiating job.
+func WithAudit(fm FileManager, audit *AuditLogger, jobID string) FileManager {
+	return &auditedFileManager{FileManager: fm, audit: audit, jobID: jobID}
+}
+
+func (a *auditedFileManager) Download(src, dst string) error {
+	start := time.Now()
+	err := a.FileManager.Download(src, dst)
+	a.record("download", src, dst, start, err)
+	return err
+}
+
+func (a *auditedFileManager) Upload(src, dst string) error {
+	start := time.Now()
+	err := a.FileManager.Upload(src, dst)
+	a.record("upload", src, dst, start, err)
+	return err
+}
+
+// UploadDedup records a dedup-aware upload the same way Upload does. It
+// forwards to the wrapped FileManager's own UploadDedup when available, and
+// otherwise falls back to a plain Upload, so wrapping a DedupFileManager
+// with WithAudit doesn't silently lose deduplication.
+func (a *auditedFileManager) UploadDedup(local, dst, checksum string) (string, error) {
+	dedup, ok := a.FileManager.(DedupUploader)
+	if !ok {
+		return dst, a.Upload(local, dst)
+	}
+	start := time.Now()
+	location, err := dedup.UploadDedup(local, dst, checksum)
+	a.record("upload", local, location, start, err)
+	return location, err
+}
+
+func (a *auditedFileManager) Copy(src, dst string) error {
+	start := time.Now()
+	err := a.FileManager.Copy(src, dst)
+	a.record("copy", src, dst, start, err)
+	return err
+}
+
+func (a *auditedFileManager) Remove(path string) error {
+	start := time.Now()
+	err := a.FileManager.Remove(path)
+	a.record("delete", path, "", start, err)
+	return err
+}
+
+// ReplicateDirectory forwards to the wrapped FileManager's own
+// ReplicateDirectory when it implements DirectoryReplicator, recording one
+// audit entry for the whole directory rather than one per file, so
+// wrapping one with WithAudit doesn't silently lose the capability.
+func (a *auditedFileManager) ReplicateDirectory(localDir, dst string) error {
+	start := time.Now()
+	replicator, ok := a.FileManager.(DirectoryReplicator)
+	if !ok {
+		err := fmt.Errorf("wrapped FileManager does not support ReplicateDirectory")
+		a.record("upload", localDir, dst, start, err)
+		return err
+	}
+	err := replicator.ReplicateDirectory(localDir, dst)
+	a.record("upload", localDir, dst, start, err)
+	return err
+}
+
+// RestoreDirectory forwards to the wrapped FileManager's own
+// RestoreDirectory when it implements DirectoryRestorer.
+func (a *auditedFileManager) RestoreDirectory(src, localDir string) error {
+	start := time.Now()
+	restorer, ok := a.FileManager.(DirectoryRestorer)
+	if !ok {
+		err := fmt.Errorf("wrapped FileManager does not support RestoreDirectory")
+		a.record("download", src, localDir, start, err)
+		return err
+	}
+	err := restorer.RestoreDirectory(src, localDir)
+	a.record("download", src, localDir, start, err)
+	return err
+}
+
+// TagObject forwards to the wrapped FileManager's own TagObject when it
+// implements ObjectTagger.
+func (a *auditedFileManager) TagObject(path string, tags map[string]string) error {
+	tagger, ok := a.FileManager.(ObjectTagger)
+	if !ok {
+		return fmt.Errorf("wrapped FileManager does not support TagObject")
+	}
+	return tagger.TagObject(path, tags)
+}
+
+func (a *auditedFileManager) record(op, src, dst string, start time.Time, err error) {
+	rec := AuditRecord{
+		Operation: op,
+		Source:    src,
+		Dest:      dst,
+		Duration:  time.Since(start).Seconds(),
+		JobID:     a.jobID,
+	}
+	if size, exists, statErr := a.FileManager.Stat(dst); statErr == nil && exists {
+		rec.Size = size
+	}
+	if err != nil {
+		rec.Err = err.Error()
+	}
+	a.audit.Record(rec)
+}
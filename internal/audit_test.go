@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithAuditRecordsDownload(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	if err := os.WriteFile(src, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fm := WithAudit(NewLocalFileManager(), NewAuditLogger(&buf), "job-1")
+	if err := fm.Download(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	line := strings.TrimSpace(buf.String())
+	var rec AuditRecord
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		t.Fatalf("unmarshal %q: %v", line, err)
+	}
+	if rec.Operation != "download" || rec.JobID != "job-1" || rec.Size != 4 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// windowsReservedNames are device names Windows treats specially regardless
+// of extension, so both "CON" and "CON.txt" are reserved. Flowy's workers
+// run on POSIX, but a basename a tool reports ends up both as an S3/local
+// output key and, after export, as a real file on whatever OS the caller
+// of cwlclient happens to be using.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// maxBasenameLength caps a sanitized basename at a length every
+// filesystem/object store this worker writes to comfortably accepts.
+// Truncation drops characters from the stem rather than the extension, so
+// two outputs differing only past the cutoff still end up with a usable
+// suffix.
+const maxBasenameLength = 200
+
+// SanitizeBasename returns a basename safe to join onto an output key or a
+// local destination path, given basename as reported by a tool (a CWL
+// File/Directory object's "basename" field, which the tool fully
+// controls). Spaces, unicode, and characters like '#', '%', '?' are left
+// untouched, since all of those are ordinary bytes to a filesystem or an
+// S3 key and this worker never interprets a location as a parsed URI; only
+// the genuinely unsafe cases are rewritten:
+//   - a path separator (forward or backward slash) anywhere in basename,
+//     which would otherwise let a malicious or buggy tool escape the
+//     output prefix it gets joined under (e.g. "../../etc/passwd")
+//   - "", ".", or ".." on their own, which name the directory rather than
+//     a file inside it once joined
+//   - control characters, which several filesystems reject outright
+//   - a Windows-reserved device name, which a client exporting outputs
+//     on Windows cannot create as a plain file
+//   - a name longer than maxBasenameLength
+func SanitizeBasename(basename string) string {
+	name := lastPathSegment(basename)
+	name = strings.Map(dropControlBytes, name)
+
+	switch name {
+	case "", ".", "..":
+		return "_"
+	}
+
+	if len(name) > maxBasenameLength {
+		ext := filepath.Ext(name)
+		if len(ext) >= maxBasenameLength {
+			ext = ""
+		}
+		name = name[:maxBasenameLength-len(ext)] + ext
+	}
+
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if windowsReservedNames[strings.ToUpper(stem)] {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// lastPathSegment returns basename's final path component, treating both
+// "/" and "\" as separators so a name copied from a Windows-authored CWL
+// document is handled the same as a POSIX one.
+func lastPathSegment(basename string) string {
+	basename = strings.ReplaceAll(basename, "\\", "/")
+	if idx := strings.LastIndexByte(basename, '/'); idx >= 0 {
+		return basename[idx+1:]
+	}
+	return basename
+}
+
+// dropControlBytes strips ASCII control characters (including DEL), which
+// several filesystems either reject in filenames or treat specially.
+func dropControlBytes(r rune) rune {
+	if r < 0x20 || r == 0x7f {
+		return -1
+	}
+	return r
+}
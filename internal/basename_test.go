@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeBasenamePassesBenignAdversarialNamesThrough(t *testing.T) {
+	benign := []string{
+		"report with spaces.txt",
+		"résumé-日本語.csv",
+		"weird#name.txt",
+		"100%done.txt",
+		"what?.txt",
+		"a.b.c.tar.gz",
+	}
+	for _, name := range benign {
+		if got := SanitizeBasename(name); got != name {
+			t.Errorf("SanitizeBasename(%q) = %q, want it unchanged", name, got)
+		}
+	}
+}
+
+func TestSanitizeBasenameStripsPathTraversal(t *testing.T) {
+	cases := map[string]string{
+		"../../etc/passwd":    "passwd",
+		"a/../../escape.txt":  "escape.txt",
+		"sub/dir/file.txt":    "file.txt",
+		`C:\Users\a\file.txt`: "file.txt",
+		"..":                  "_",
+		".":                   "_",
+		"":                    "_",
+		"/":                   "_",
+	}
+	for in, want := range cases {
+		if got := SanitizeBasename(in); got != want {
+			t.Errorf("SanitizeBasename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeBasenameDropsControlBytes(t *testing.T) {
+	got := SanitizeBasename("bad\x00name\x01.txt")
+	if strings.ContainsAny(got, "\x00\x01") {
+		t.Fatalf("SanitizeBasename left control bytes in %q", got)
+	}
+	if got != "badname.txt" {
+		t.Fatalf("got %q, want %q", got, "badname.txt")
+	}
+}
+
+func TestSanitizeBasenameRewritesWindowsReservedNames(t *testing.T) {
+	for _, name := range []string{"CON", "con.txt", "NUL", "COM1.log", "lpt9"} {
+		got := SanitizeBasename(name)
+		if got == name || !strings.HasSuffix(got, name) {
+			t.Errorf("SanitizeBasename(%q) = %q, want a disambiguated variant ending in %q", name, got, name)
+		}
+	}
+	// A name that merely contains a reserved word is not itself reserved.
+	if got := SanitizeBasename("reconstruction.txt"); got != "reconstruction.txt" {
+		t.Fatalf("got %q, want unchanged", got)
+	}
+}
+
+func TestSanitizeBasenameTruncatesLongNames(t *testing.T) {
+	long := strings.Repeat("a", 500) + ".txt"
+	got := SanitizeBasename(long)
+	if len(got) > maxBasenameLength {
+		t.Fatalf("sanitized name length = %d, want <= %d", len(got), maxBasenameLength)
+	}
+	if !strings.HasSuffix(got, ".txt") {
+		t.Fatalf("expected extension to survive truncation, got %q", got)
+	}
+}
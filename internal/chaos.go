@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// ChaosConfig configures chaosFileManager's fault injection. The zero
+// value injects nothing, so wrapping a FileManager with a zero ChaosConfig
+// is a no-op; every probability/duration field must be set explicitly to
+// turn a fault on, which keeps WithChaos safe to leave wired into a build
+// that never sets the environment variables driving it.
+type ChaosConfig struct {
+	// Seed makes injected faults reproducible across a test run: the same
+	// seed and the same sequence of Download/Upload calls always trigger
+	// the same faults.
+	Seed int64
+	// MaxDelay is the upper bound of a random delay injected before a
+	// transfer, uniformly distributed between zero and MaxDelay.
+	MaxDelay time.Duration
+	// TruncateProbability is the chance, per Download, that the written
+	// file is cut short partway through, simulating a connection that
+	// drops mid-transfer.
+	TruncateProbability float64
+	// FailProbability is the chance, per Download or Upload, that the
+	// call fails outright with a synthetic error, simulating a backend
+	// returning an error after accepting the request.
+	FailProbability float64
+}
+
+// chaosFileManager wraps a FileManager so integration tests can exercise
+// the worker's retry, requeue, and partial-output handling without a
+// genuinely flaky backend: random delays, truncated downloads, and
+// injected errors are all driven by cfg and a seeded PRNG so a failing
+// test run can be reproduced exactly.
+type chaosFileManager struct {
+	FileManager
+	cfg ChaosConfig
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// WithChaos wraps fm with cfg's fault injection. It is meant to be
+// conditionally applied behind an env var (e.g. only when
+// FLOWY_CHAOS_SEED is set) rather than compiled out, so the same binary
+// run against a test harness and in production differ only in
+// configuration, not in code path.
+func WithChaos(fm FileManager, cfg ChaosConfig) FileManager {
+	return &chaosFileManager{FileManager: fm, cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+func (c *chaosFileManager) Download(src, dst string) error {
+	c.delay()
+	if c.roll(c.cfg.FailProbability) {
+		return fmt.Errorf("chaos: injected download failure for %s", src)
+	}
+	if err := c.FileManager.Download(src, dst); err != nil {
+		return err
+	}
+	if c.roll(c.cfg.TruncateProbability) {
+		return truncateFile(dst, c.pick())
+	}
+	return nil
+}
+
+func (c *chaosFileManager) Upload(src, dst string) error {
+	c.delay()
+	if c.roll(c.cfg.FailProbability) {
+		return fmt.Errorf("chaos: injected upload failure for %s", dst)
+	}
+	return c.FileManager.Upload(src, dst)
+}
+
+// UploadDedup forwards to the wrapped FileManager's own UploadDedup when
+// available, and otherwise falls back to a plain Upload, so wrapping a
+// DedupFileManager with WithChaos doesn't silently lose deduplication.
+// Fault injection still applies either way, via the delay/roll calls
+// shared with Upload.
+func (c *chaosFileManager) UploadDedup(local, dst, checksum string) (string, error) {
+	c.delay()
+	if c.roll(c.cfg.FailProbability) {
+		return "", fmt.Errorf("chaos: injected upload failure for %s", dst)
+	}
+	dedup, ok := c.FileManager.(DedupUploader)
+	if !ok {
+		return dst, c.FileManager.Upload(local, dst)
+	}
+	return dedup.UploadDedup(local, dst, checksum)
+}
+
+// delay sleeps for a random duration between zero and cfg.MaxDelay,
+// simulating the latency spikes a real network or object store exhibits
+// under load.
+func (c *chaosFileManager) delay() {
+	if c.cfg.MaxDelay <= 0 {
+		return
+	}
+	time.Sleep(time.Duration(c.pick() * float64(c.cfg.MaxDelay)))
+}
+
+// roll reports whether a fault with the given probability should fire
+// this call, always false for a non-positive probability so a zero
+// ChaosConfig never triggers anything.
+func (c *chaosFileManager) roll(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	return c.pick() < probability
+}
+
+// pick draws the next float64 in [0,1) from the shared PRNG, guarded by a
+// mutex since FileManagers are used concurrently across jobs.
+func (c *chaosFileManager) pick() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64()
+}
+
+// truncateFile cuts path short at a random fraction of its current
+// length, leaving at least one byte so an empty destination (already
+// distinguishable as a failed transfer by size-zero checks) isn't
+// confused with a genuinely empty source file.
+func truncateFile(path string, fraction float64) error {
+	size, _, err := NewLocalFileManager().Stat(path)
+	if err != nil {
+		return err
+	}
+	if size <= 1 {
+		return nil
+	}
+	cut := int64(float64(size) * fraction)
+	if cut >= size {
+		cut = size - 1
+	}
+	if cut < 1 {
+		cut = 1
+	}
+	return os.Truncate(path, cut)
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithChaosZeroConfigIsNoop(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fm := WithChaos(NewLocalFileManager(), ChaosConfig{})
+
+	dst := filepath.Join(dir, "b.txt")
+	if err := fm.Download(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("got %q, want unmodified content", data)
+	}
+}
+
+func TestWithChaosFailProbabilityOneAlwaysFails(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+	fm := WithChaos(NewLocalFileManager(), ChaosConfig{Seed: 1, FailProbability: 1})
+
+	if err := fm.Download(src, filepath.Join(dir, "b.txt")); err == nil {
+		t.Fatal("expected an injected download failure")
+	}
+	if err := fm.Upload(src, filepath.Join(dir, "c.txt")); err == nil {
+		t.Fatal("expected an injected upload failure")
+	}
+}
+
+func TestWithChaosTruncateProbabilityOneShortensDownload(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fm := WithChaos(NewLocalFileManager(), ChaosConfig{Seed: 1, TruncateProbability: 1})
+
+	dst := filepath.Join(dir, "b.txt")
+	if err := fm.Download(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 || len(data) >= len("hello world") {
+		t.Fatalf("got %d bytes, want a truncated but non-empty file", len(data))
+	}
+}
+
+func TestWithChaosSameSeedInjectsSameFaults(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+	cfg := ChaosConfig{Seed: 42, FailProbability: 0.5}
+
+	var outcomes [2]bool
+	for i := range outcomes {
+		fm := WithChaos(NewLocalFileManager(), cfg)
+		outcomes[i] = fm.Upload(src, filepath.Join(dir, "out.txt")) != nil
+	}
+	if outcomes[0] != outcomes[1] {
+		t.Fatalf("same seed produced different outcomes: %v vs %v", outcomes[0], outcomes[1])
+	}
+}
+
+func TestWithChaosUploadDedupForwardsToWrappedDedupFileManager(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+
+	dedup := WithDedup(NewLocalFileManager(), dir+string(filepath.Separator))
+	fm := WithChaos(dedup, ChaosConfig{})
+
+	dedupUploader, ok := fm.(DedupUploader)
+	if !ok {
+		t.Fatal("expected WithChaos to preserve the DedupUploader interface")
+	}
+	dst := filepath.Join(dir, "out", "a.txt")
+	if _, err := dedupUploader.UploadDedup(src, dst, "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+	casPath := dir + string(filepath.Separator) + CASKey("sha1$abc")
+	if _, exists, err := NewLocalFileManager().Stat(casPath); err != nil || !exists {
+		t.Fatalf("expected CAS object to exist at %s, exists=%v err=%v", casPath, exists, err)
+	}
+}
@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// tempSuffix marks a file that is still being written. A process that dies
+// mid-copy leaves the ".flowy-part" file behind instead of a half-written target.
+const tempSuffix = ".flowy-part"
+
+// CopyFile copies src to dst by writing to a temporary sibling of dst and
+// renaming it into place once the copy (and its final fsync) succeeds, so a
+// crash never leaves a partially written file at dst. The source file mode
+// is preserved on the new file.
+func CopyFile(src, dst string) error {
+	return CopyFileWithOptions(src, dst, CopyOptions{})
+}
+
+// CopyFileWithOptions is CopyFile with control over which of the source's
+// metadata (beyond its mode, which is always preserved) carries over to
+// dst. It matters for tools that check input mtimes or require the
+// executable bit on staged scripts.
+func CopyFileWithOptions(src, dst string, opts CopyOptions) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := dst + tempSuffix
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := copySparse(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := applyMetadata(src, tmp, info, opts); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// CopyDir recursively copies the tree rooted at src into dst. dst is marked
+// with an IncompleteMarker for the duration of the copy so that a crash
+// partway through leaves an artifact that CleanupIncomplete will discard
+// rather than one that later runs might treat as a complete staged
+// directory.
+func CopyDir(src, dst string) error {
+	return CopyDirWithOptions(src, dst, CopyOptions{})
+}
+
+// CopyDirWithOptions is CopyDir with control over which source metadata is
+// preserved on every file in the copied tree; see CopyOptions.
+func CopyDirWithOptions(src, dst string, opts CopyOptions) error {
+	if err := MarkIncomplete(dst); err != nil {
+		return err
+	}
+
+	// First pass: create the directory tree and collect the files and
+	// symlinks to copy, so the second pass can run them concurrently
+	// without racing on MkdirAll for a shared parent.
+	type entry struct {
+		path   string
+		target string
+		info   os.FileInfo
+	}
+	var files []entry
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		files = append(files, entry{path, target, info})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	workers := opts.Parallelism
+	if workers <= 0 {
+		workers = copyDirDefaultParallelism
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		copied   int64
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan entry)
+	worker := func() {
+		defer wg.Done()
+		for e := range jobs {
+			var err error
+			if e.info.Mode()&os.ModeSymlink != 0 {
+				err = copySymlink(e.path, e.target, opts.SymlinkPolicy)
+			} else {
+				err = CopyFileWithOptions(e.path, e.target, opts)
+			}
+
+			mu.Lock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			copied++
+			if opts.Progress != nil {
+				opts.Progress(copied, int64(len(files)))
+			}
+			mu.Unlock()
+		}
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go worker()
+	}
+	for _, e := range files {
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ClearIncomplete(dst)
+}
+
+// copySymlink recreates the symlink at src as target, consulting policy
+// first when one is set. Validation resolves src's target to an absolute
+// path before checking it, so a relative link like "../../etc/passwd" is
+// judged by where it actually points, not by its literal text.
+func copySymlink(src, target string, policy *SymlinkPolicy) error {
+	link, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if policy != nil {
+		resolved, err := ResolveSymlinkTarget(src)
+		if err != nil {
+			return err
+		}
+		if err := policy.Check(resolved); err != nil {
+			return fmt.Errorf("copying symlink %s: %w", src, err)
+		}
+	}
+	return os.Symlink(link, target)
+}
+
+// copyDirDefaultParallelism bounds how many files CopyDir copies at once
+// when the caller does not request a specific degree of parallelism.
+const copyDirDefaultParallelism = 8
+
+// copySparse copies in to out, detecting runs of zero bytes and seeking
+// over them instead of writing them, so the destination file stays sparse
+// (consumes no disk blocks for those regions) when the underlying
+// filesystem supports holes.
+func copySparse(out *os.File, in io.Reader) (int64, error) {
+	buf := make([]byte, 256*1024)
+	var written int64
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if isAllZero(buf[:n]) {
+				if _, serr := out.Seek(int64(n), io.SeekCurrent); serr != nil {
+					return written, serr
+				}
+			} else if _, werr := out.Write(buf[:n]); werr != nil {
+				return written, werr
+			}
+			written += int64(n)
+		}
+		if err == io.EOF {
+			// Seeking past the end of a file without writing leaves it
+			// truncated unless we extend it explicitly.
+			if truncErr := out.Truncate(written); truncErr != nil {
+				return written, truncErr
+			}
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+func isAllZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyFileRename(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(dst + tempSuffix); !os.IsNotExist(err) {
+		t.Fatalf("temp file left behind: %v", err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestCopyDirClearsIncompleteMarker(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "f.txt"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if IsIncomplete(dst) {
+		t.Fatal("dst still marked incomplete after successful CopyDir")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "sub", "f.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyDirRejectsSymlinkEscapingAllowedRoots(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	outside := filepath.Join(dir, "outside.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(src, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CopyOptions{SymlinkPolicy: &SymlinkPolicy{AllowSymlinks: true, AllowedRoots: []string{src}}}
+	if err := CopyDirWithOptions(src, dst, opts); err == nil {
+		t.Fatal("expected a symlink escaping the allowed root to be rejected")
+	}
+}
+
+func TestCopyDirAllowsSymlinkWithinAllowedRoot(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(src, "real.txt")
+	if err := os.WriteFile(target, []byte("ok"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(src, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CopyOptions{SymlinkPolicy: &SymlinkPolicy{AllowSymlinks: true, AllowedRoots: []string{src}}}
+	if err := CopyDirWithOptions(src, dst, opts); err != nil {
+		t.Fatalf("expected an in-tree symlink to be allowed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanupIncompleteRemovesStaleDirs(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(root, "job-1")
+	if err := MarkIncomplete(stale); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(root, "job-2")
+	if err := os.MkdirAll(valid, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CleanupIncomplete(root); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale incomplete dir to be removed, got err=%v", err)
+	}
+	if _, err := os.Stat(valid); err != nil {
+		t.Fatalf("valid dir should survive cleanup: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// pointerMagic prefixes a pointer object's content so DedupFileManager can
+// tell a pointer apart from real file content while resolving a download,
+// without having to download the whole object first.
+const pointerMagic = "flowy-dedup-pointer-v1\n"
+
+// CASKey derives the content-addressed key a checksum (as reported on a
+// CWL File object, e.g. "sha1$<hex>") is stored under.
+func CASKey(checksum string) string {
+	algo, hex, ok := strings.Cut(checksum, "$")
+	if !ok {
+		algo, hex = "sha1", checksum
+	}
+	return fmt.Sprintf("cas/%s/%s", algo, hex)
+}
+
+// DedupUploader is implemented by FileManagers that can deduplicate
+// uploads by content checksum; callers that care should check for it and
+// fall back to plain Upload otherwise.
+type DedupUploader interface {
+	FileManager
+	// UploadDedup uploads local to a content-addressed location derived
+	// from checksum, skipping the upload entirely when that content is
+	// already stored, and returns the location callers should record for
+	// dst (which may not be where the bytes actually live).
+	UploadDedup(local, dst, checksum string) (string, error)
+}
+
+// DedupFileManager wraps a FileManager so that identical content uploaded
+// under different keys (the common case for CWL scatter steps producing
+// many copies of the same intermediate file) is stored exactly once: the
+// first UploadDedup for a given checksum lands at its CAS key, and every
+// later UploadDedup for that checksum instead writes a small pointer
+// object at dst. Download follows a pointer to its real content
+// transparently, so a caller that doesn't know about dedup doesn't need
+// to.
+type DedupFileManager struct {
+	FileManager
+	// casRoot is prefixed onto every CAS key, the same way callers prefix
+	// an output base URL onto a plain key, so CAS objects land in the
+	// same bucket/root as the pointers that reference them.
+	casRoot string
+}
+
+// WithDedup wraps fm with checksum-addressed upload deduplication. casRoot
+// is prefixed onto derived CAS keys; pass the same base URL/root the
+// caller already prefixes onto its own upload keys (e.g. "s3://bucket/" or
+// "" for a shared local root).
+func WithDedup(fm FileManager, casRoot string) *DedupFileManager {
+	return &DedupFileManager{FileManager: fm, casRoot: casRoot}
+}
+
+func (d *DedupFileManager) UploadDedup(local, dst, checksum string) (string, error) {
+	casKey := d.casRoot + CASKey(checksum)
+	_, exists, err := d.FileManager.Stat(casKey)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if isLocalPath(casKey) {
+			if err := ensureParentDir(casKey); err != nil {
+				return "", err
+			}
+		}
+		if err := d.FileManager.Upload(local, casKey); err != nil {
+			return "", err
+		}
+	}
+	if err := d.writePointer(dst, casKey); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// writePointer uploads a small pointer object at dst naming casKey, so dst
+// keeps working as the stable, human-readable location recorded on the
+// CWL File object.
+func (d *DedupFileManager) writePointer(dst, casKey string) error {
+	tmp, err := os.CreateTemp(scratchDir, "flowy-dedup-pointer-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(pointerMagic + casKey); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if isLocalPath(dst) {
+		if err := ensureParentDir(dst); err != nil {
+			return err
+		}
+	}
+	return d.FileManager.Upload(tmp.Name(), dst)
+}
+
+// isLocalPath reports whether path names a location on the local
+// filesystem rather than an object in a remote store behind a URL scheme
+// (e.g. "s3://..."), so dedup only tries to pre-create parent directories
+// where that concept applies.
+func isLocalPath(path string) bool {
+	return !strings.Contains(path, "://")
+}
+
+// Download resolves src transparently: if it names a pointer object, the
+// real content at its CAS key is downloaded to dst instead. resolvePointer
+// only reads as many bytes as pointerMagic needs via Open, so this adds no
+// meaningful overhead for the (overwhelmingly common) non-pointer case.
+func (d *DedupFileManager) Download(src, dst string) error {
+	casKey, isPointer, err := d.resolvePointer(src)
+	if err != nil {
+		return err
+	}
+	if isPointer {
+		src = casKey
+	}
+	return d.FileManager.Download(src, dst)
+}
+
+func (d *DedupFileManager) resolvePointer(src string) (casKey string, isPointer bool, err error) {
+	r, err := d.Open(src)
+	if err != nil {
+		return "", false, err
+	}
+	defer r.Close()
+
+	buf := make([]byte, len(pointerMagic))
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", false, err
+	}
+	if n < len(pointerMagic) || string(buf) != pointerMagic {
+		return "", false, nil
+	}
+
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return "", false, err
+	}
+	return string(rest), true, nil
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCASKeySplitsAlgorithmAndHex(t *testing.T) {
+	if got, want := CASKey("sha1$abc123"), "cas/sha1/abc123"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestUploadDedupStoresContentOnce(t *testing.T) {
+	dir := t.TempDir()
+	dedup := WithDedup(NewLocalFileManager(), dir+string(filepath.Separator))
+
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst1 := filepath.Join(dir, "out", "a.txt")
+	if _, err := dedup.UploadDedup(src, dst1, "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	src2 := filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(src2, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst2 := filepath.Join(dir, "out", "b.txt")
+	if _, err := dedup.UploadDedup(src2, dst2, "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	casPath := dir + string(filepath.Separator) + CASKey("sha1$abc")
+	if _, exists, err := dedup.Stat(casPath); err != nil || !exists {
+		t.Fatalf("expected CAS object to exist at %s, exists=%v err=%v", casPath, exists, err)
+	}
+
+	for _, dst := range []string{dst1, dst2} {
+		data, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data[:len(pointerMagic)]) != pointerMagic {
+			t.Fatalf("expected %s to be a pointer object", dst)
+		}
+	}
+}
+
+func TestDownloadResolvesPointerTransparently(t *testing.T) {
+	dir := t.TempDir()
+	dedup := WithDedup(NewLocalFileManager(), dir+string(filepath.Separator))
+
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pointerDst := filepath.Join(dir, "out", "a.txt")
+	if _, err := dedup.UploadDedup(src, pointerDst, "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	downloaded := filepath.Join(dir, "downloaded.txt")
+	if err := dedup.Download(pointerDst, downloaded); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(downloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("got %q, want %q", data, "hello")
+	}
+}
+
+func TestDownloadPassesThroughNonPointerContent(t *testing.T) {
+	dedup := WithDedup(NewLocalFileManager(), "")
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("plain content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "b.txt")
+	if err := dedup.Download(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "plain content" {
+		t.Fatalf("got %q", data)
+	}
+}
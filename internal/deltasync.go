@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// FileSnapshot is a cheap point-in-time fingerprint of one file: a size or
+// mtime change is treated as a modification, matching rsync's own default
+// quick-check rather than re-reading and hashing file contents.
+type FileSnapshot struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// SnapshotTree walks root and returns a FileSnapshot for every regular file
+// beneath it, keyed by its path relative to root. DiffSnapshots compares
+// two such snapshots to find what changed, without needing to touch file
+// contents a second time.
+func SnapshotTree(root string) (map[string]FileSnapshot, error) {
+	snapshot := make(map[string]FileSnapshot)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		snapshot[rel] = FileSnapshot{Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// DiffSnapshots compares a directory's before and after snapshots, as
+// returned by SnapshotTree, and returns which relative paths were added or
+// modified (changed) and which were deleted (removed), so a caller can
+// re-sync just that delta instead of the directory's entire contents.
+func DiffSnapshots(before, after map[string]FileSnapshot) (changed, removed []string) {
+	for rel, a := range after {
+		b, ok := before[rel]
+		if !ok || b.Size != a.Size || !b.ModTime.Equal(a.ModTime) {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range before {
+		if _, ok := after[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+	return changed, removed
+}
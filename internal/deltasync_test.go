@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFileAt(t *testing.T, path string, data string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffSnapshotsDetectsChangedAndRemoved(t *testing.T) {
+	root := t.TempDir()
+	stamp := time.Unix(1700000000, 0)
+	writeFileAt(t, filepath.Join(root, "unchanged.txt"), "same", stamp)
+	writeFileAt(t, filepath.Join(root, "modified.txt"), "before", stamp)
+	writeFileAt(t, filepath.Join(root, "gone.txt"), "bye", stamp)
+
+	before, err := SnapshotTree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(root, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFileAt(t, filepath.Join(root, "modified.txt"), "after-change", stamp.Add(time.Minute))
+	writeFileAt(t, filepath.Join(root, "new.txt"), "new", stamp)
+
+	after, err := SnapshotTree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, removed := DiffSnapshots(before, after)
+	if !containsString(changed, "modified.txt") || !containsString(changed, "new.txt") {
+		t.Fatalf("expected modified.txt and new.txt in changed, got %v", changed)
+	}
+	if containsString(changed, "unchanged.txt") {
+		t.Fatalf("unchanged.txt should not be reported as changed, got %v", changed)
+	}
+	if len(removed) != 1 || removed[0] != "gone.txt" {
+		t.Fatalf("expected only gone.txt in removed, got %v", removed)
+	}
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// downloadDedupFileManager wraps a FileManager so that repeated or
+// concurrent Downloads of the same src share a single underlying transfer
+// instead of each fetching it independently: the first caller downloads
+// into a shared, content-keyed cache directory, and every other caller -
+// whether waiting concurrently or arriving later - hardlinks dst from that
+// cache entry instead of re-fetching. The cache is keyed on src alone,
+// since none of this repo's FileManager backends expose an object's etag;
+// a source object rewritten in place under the same URL will serve stale
+// cached bytes for the life of the worker process.
+type downloadDedupFileManager struct {
+	FileManager
+	cacheRoot string
+
+	mu       sync.Mutex
+	inFlight map[string]*downloadOnce
+}
+
+// downloadOnce tracks one in-progress cache-filling download, so
+// goroutines that ask for the same src while it's downloading wait on done
+// instead of starting a redundant transfer of their own.
+type downloadOnce struct {
+	done chan struct{}
+	err  error
+}
+
+// WithDownloadDedup wraps fm so concurrent or repeated Downloads of the
+// same src, across any number of jobs staging against fm, result in one
+// underlying transfer. cacheRoot is a local directory dedicated to this
+// cache; it is created on demand.
+func WithDownloadDedup(fm FileManager, cacheRoot string) FileManager {
+	return &downloadDedupFileManager{FileManager: fm, cacheRoot: cacheRoot, inFlight: map[string]*downloadOnce{}}
+}
+
+func (d *downloadDedupFileManager) Download(src, dst string) error {
+	cached := d.cachePath(src)
+
+	d.mu.Lock()
+	if once, loading := d.inFlight[src]; loading {
+		d.mu.Unlock()
+		<-once.done
+		if once.err != nil {
+			return once.err
+		}
+		return linkOrCopy(cached, dst)
+	}
+	if _, err := os.Stat(cached); err == nil {
+		d.mu.Unlock()
+		return linkOrCopy(cached, dst)
+	}
+	once := &downloadOnce{done: make(chan struct{})}
+	d.inFlight[src] = once
+	d.mu.Unlock()
+
+	once.err = d.fetchIntoCache(src, cached)
+	close(once.done)
+
+	d.mu.Lock()
+	delete(d.inFlight, src)
+	d.mu.Unlock()
+
+	if once.err != nil {
+		return once.err
+	}
+	return linkOrCopy(cached, dst)
+}
+
+// cachePath returns the path a download of src is cached at: cacheRoot
+// keyed by a hash of src, since src may be an arbitrarily long URL and
+// hashing keeps the cache a flat, filesystem-safe directory of fixed-width
+// names.
+func (d *downloadDedupFileManager) cachePath(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return filepath.Join(d.cacheRoot, hex.EncodeToString(sum[:]))
+}
+
+// fetchIntoCache downloads src through the wrapped FileManager into a temp
+// file beside cached and renames it into place, so a download that fails
+// or is interrupted partway never leaves a corrupt entry other jobs could
+// hardlink from.
+func (d *downloadDedupFileManager) fetchIntoCache(src, cached string) error {
+	if err := ensureParentDir(cached); err != nil {
+		return err
+	}
+	tmp := cached + tempSuffix
+	if err := d.FileManager.Download(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, cached)
+}
+
+// EvictLRUUntil removes entries from cacheRoot, oldest-modified first,
+// until freeBytes(cacheRoot) reports at least minFreeBytes or the cache is
+// empty, returning the number of bytes freed. It is intended to be called
+// by a disk watcher responding to disk pressure, not from the download
+// path itself: evicting an entry another goroutine is mid-hardlink from
+// would be a race, so callers must only invoke this when no downloads are
+// known to be in flight, e.g. while new job intake is paused.
+func EvictLRUUntil(cacheRoot string, minFreeBytes int64, freeBytes func(string) (int64, error)) (int64, error) {
+	entries, err := os.ReadDir(cacheRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	type cacheEntry struct {
+		path    string
+		modTime int64
+	}
+	var candidates []cacheEntry
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, cacheEntry{path: filepath.Join(cacheRoot, e.Name()), modTime: info.ModTime().UnixNano()})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].modTime < candidates[j].modTime })
+
+	var freed int64
+	for _, c := range candidates {
+		free, err := freeBytes(cacheRoot)
+		if err != nil {
+			return freed, err
+		}
+		if free >= minFreeBytes {
+			break
+		}
+		info, err := os.Stat(c.path)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(c.path); err != nil {
+			continue
+		}
+		freed += info.Size()
+	}
+	return freed, nil
+}
+
+// linkOrCopy hardlinks dst to cached, falling back to a full copy when the
+// two paths aren't on the same filesystem (os.Link returns a cross-device
+// error in that case), so a misconfigured cache root degrades to ordinary
+// copying instead of failing every staging.
+func linkOrCopy(cached, dst string) error {
+	if err := ensureParentDir(dst); err != nil {
+		return err
+	}
+	if err := os.Link(cached, dst); err == nil {
+		return nil
+	}
+	return CopyFile(cached, dst)
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingFileManager counts real Downloads, so tests can assert dedup
+// actually avoided redundant transfers rather than just checking output
+// correctness.
+type countingFileManager struct {
+	FileManager
+	downloads int32
+}
+
+func (c *countingFileManager) Download(src, dst string) error {
+	atomic.AddInt32(&c.downloads, 1)
+	return c.FileManager.Download(src, dst)
+}
+
+func TestWithDownloadDedupDownloadsOnceForConcurrentRequests(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "input.txt")
+	if err := os.WriteFile(src, []byte("shared content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	base := &countingFileManager{FileManager: NewLocalFileManager()}
+	fm := WithDownloadDedup(base, t.TempDir())
+
+	var wg sync.WaitGroup
+	dstDir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dst := filepath.Join(dstDir, fmt.Sprintf("job-%d", i), "input.txt")
+			if err := fm.Download(src, dst); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&base.downloads); got != 1 {
+		t.Fatalf("expected exactly one underlying download, got %d", got)
+	}
+	for i := 0; i < 10; i++ {
+		dst := filepath.Join(dstDir, fmt.Sprintf("job-%d", i), "input.txt")
+		data, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "shared content" {
+			t.Fatalf("job %d got %q", i, data)
+		}
+	}
+}
+
+func TestWithDownloadDedupHardlinksFromCache(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "input.txt")
+	if err := os.WriteFile(src, []byte("hardlink me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheRoot := t.TempDir()
+	fm := WithDownloadDedup(NewLocalFileManager(), cacheRoot)
+	dst1 := filepath.Join(t.TempDir(), "a.txt")
+	dst2 := filepath.Join(t.TempDir(), "b.txt")
+	if err := fm.Download(src, dst1); err != nil {
+		t.Fatal(err)
+	}
+	if err := fm.Download(src, dst2); err != nil {
+		t.Fatal(err)
+	}
+
+	info1, err := os.Stat(dst1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2, err := os.Stat(dst2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(info1, info2) {
+		t.Fatal("expected both destinations to be hardlinks of the same cache entry")
+	}
+}
+
+func TestWithDownloadDedupSecondCallerSkipsDownload(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "input.txt")
+	if err := os.WriteFile(src, []byte("cached"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	base := &countingFileManager{FileManager: NewLocalFileManager()}
+	fm := WithDownloadDedup(base, t.TempDir())
+
+	dst1 := filepath.Join(t.TempDir(), "a.txt")
+	if err := fm.Download(src, dst1); err != nil {
+		t.Fatal(err)
+	}
+	dst2 := filepath.Join(t.TempDir(), "b.txt")
+	if err := fm.Download(src, dst2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt32(&base.downloads); got != 1 {
+		t.Fatalf("expected the second Download to reuse the cache entry, got %d underlying downloads", got)
+	}
+}
+
+func TestEvictLRUUntilRemovesOldestEntriesFirst(t *testing.T) {
+	cacheRoot := t.TempDir()
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("entry-%d", i)
+		path := filepath.Join(cacheRoot, name)
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Unix(1000+int64(i), 0)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	// Report scarce free space until exactly one entry has been evicted,
+	// then plenty, so the eviction loop stops after removing only the
+	// single oldest entry.
+	calls := 0
+	freeBytes := func(string) (int64, error) {
+		calls++
+		if calls <= 1 {
+			return 0, nil
+		}
+		return 1 << 30, nil
+	}
+
+	freed, err := EvictLRUUntil(cacheRoot, 1<<20, freeBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freed != 4 {
+		t.Fatalf("freed = %d, want 4", freed)
+	}
+	if _, err := os.Stat(filepath.Join(cacheRoot, names[0])); !os.IsNotExist(err) {
+		t.Fatalf("expected the oldest entry %s to be evicted", names[0])
+	}
+	for _, name := range names[1:] {
+		if _, err := os.Stat(filepath.Join(cacheRoot, name)); err != nil {
+			t.Fatalf("expected %s to remain cached: %v", name, err)
+		}
+	}
+}
+
+func TestEvictLRUUntilOnMissingCacheRoot(t *testing.T) {
+	freed, err := EvictLRUUntil(filepath.Join(t.TempDir(), "missing"), 1<<20, func(string) (int64, error) { return 0, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freed != 0 {
+		t.Fatalf("freed = %d, want 0", freed)
+	}
+}
@@ -0,0 +1,153 @@
+// Package errors defines the worker's failure categories as distinct,
+// wrapped error types - StagingError, TransferError, EvalError,
+// OutputCollectionError, and SpecViolation - so code that needs to
+// classify a failure (for retry decisions, for reporting to the server,
+// for metrics) can do it with errors.As against a type instead of
+// matching substrings of an error's message, which breaks the moment the
+// underlying message wording changes.
+package errors
+
+import "fmt"
+
+// Code is the machine-stable name a typed error in this package reports
+// via its Code method, suitable for a log field or a reporting API
+// instead of the full, free-text Error() string.
+type Code string
+
+const (
+	CodeStaging          Code = "staging"
+	CodeTransfer         Code = "transfer"
+	CodeEval             Code = "eval"
+	CodeOutputCollection Code = "output_collection"
+	CodeSpecViolation    Code = "spec_violation"
+)
+
+// Coded is implemented by every error type in this package.
+type Coded interface {
+	Code() Code
+}
+
+// StagingError marks a failure preparing a job's working directory before
+// its tool runs - resolving staging order, a Target collision, an
+// unreadable local source - as distinct from TransferError's narrower
+// "a FileManager call itself failed" and from the tool's own run failure.
+type StagingError struct {
+	// Target is the staged entry's destination path, when known.
+	Target string
+	Err    error
+}
+
+// Staging wraps err as a StagingError for the staging entry destined for
+// target (empty if the failure isn't tied to one particular entry).
+func Staging(target string, err error) error {
+	return &StagingError{Target: target, Err: err}
+}
+
+func (e *StagingError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("staging: %v", e.Err)
+	}
+	return fmt.Sprintf("staging %s: %v", e.Target, e.Err)
+}
+
+func (e *StagingError) Unwrap() error { return e.Err }
+func (e *StagingError) Code() Code    { return CodeStaging }
+
+// TransferError marks a failure in a FileManager's own Download, Upload,
+// Copy, or Remove call - a network error, a permission error, a missing
+// remote object - naming the backend and path involved so a reporting
+// pipeline can tell a flaky S3 bucket from a flaky NFS mount without
+// parsing the message.
+type TransferError struct {
+	Backend string
+	Op      string // "download", "upload", "copy", "remove"
+	Path    string
+	Err     error
+}
+
+// Transfer wraps err as a TransferError for op against path on backend.
+func Transfer(backend, op, path string, err error) error {
+	return &TransferError{Backend: backend, Op: op, Path: path, Err: err}
+}
+
+func (e *TransferError) Error() string {
+	return fmt.Sprintf("%s %s %s: %v", e.Backend, e.Op, e.Path, e.Err)
+}
+
+func (e *TransferError) Unwrap() error { return e.Err }
+func (e *TransferError) Code() Code    { return CodeTransfer }
+
+// EvalError marks a failure evaluating a CWL JavaScript expression -
+// ValueFrom, outputEval, a glob pattern, a format expression - whether a
+// syntax error in the expression itself or an exception thrown while
+// running it, as distinct from a bug in the worker evaluating it.
+type EvalError struct {
+	Expression string
+	Err        error
+}
+
+// Eval wraps err as an EvalError for the expression that produced it.
+func Eval(expression string, err error) error {
+	return &EvalError{Expression: expression, Err: err}
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("evaluating %q: %v", e.Expression, e.Err)
+}
+
+func (e *EvalError) Unwrap() error { return e.Err }
+func (e *EvalError) Code() Code    { return CodeEval }
+
+// OutputCollectionError marks a failure collecting a job's outputs after
+// its tool already ran - a missing or malformed cwl.output.json, a value
+// that fails its declared OutputSchema - distinct from the tool's own run
+// failure, since a tool can exit 0 and still produce this.
+type OutputCollectionError struct {
+	// Output is the output parameter's name, when the failure is tied to
+	// one; empty for a failure in cwl.output.json as a whole.
+	Output string
+	Err    error
+}
+
+// OutputCollection wraps err as an OutputCollectionError for output
+// (empty if the failure isn't tied to one particular output).
+func OutputCollection(output string, err error) error {
+	return &OutputCollectionError{Output: output, Err: err}
+}
+
+func (e *OutputCollectionError) Error() string {
+	if e.Output == "" {
+		return fmt.Sprintf("collecting outputs: %v", e.Err)
+	}
+	return fmt.Sprintf("collecting output %q: %v", e.Output, e.Err)
+}
+
+func (e *OutputCollectionError) Unwrap() error { return e.Err }
+func (e *OutputCollectionError) Code() Code    { return CodeOutputCollection }
+
+// SpecViolation marks a job definition that violates the CWL spec itself
+// (e.g. InitialWorkDirRequirement naming the same entryname twice), rather
+// than a runtime failure - never worth retrying, since the same
+// definition violates the spec identically every time.
+type SpecViolation struct {
+	// Rule names the requirement or clause violated, when known (e.g.
+	// "InitialWorkDirRequirement").
+	Rule string
+	Err  error
+}
+
+// Spec wraps err as a SpecViolation of rule (empty if no single named
+// rule covers it).
+func Spec(rule string, err error) error {
+	return &SpecViolation{Rule: rule, Err: err}
+}
+
+func (e *SpecViolation) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("spec violation: %v", e.Err)
+	}
+	return fmt.Sprintf("spec violation (%s): %v", e.Rule, e.Err)
+}
+
+func (e *SpecViolation) Unwrap() error { return e.Err }
+func (e *SpecViolation) Code() Code    { return CodeSpecViolation }
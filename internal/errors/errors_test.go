@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStagingErrorUnwrapsAndReportsCode(t *testing.T) {
+	cause := fmt.Errorf("permission denied")
+	err := Staging("in/reads.fq", cause)
+
+	var staged *StagingError
+	if !errors.As(err, &staged) {
+		t.Fatalf("expected *StagingError, got %T", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("expected Unwrap to reach cause")
+	}
+	if staged.Code() != CodeStaging {
+		t.Fatalf("Code() = %q, want %q", staged.Code(), CodeStaging)
+	}
+}
+
+func TestTransferErrorDistinguishesFromStagingError(t *testing.T) {
+	err := Transfer("s3", "download", "s3://bucket/key", fmt.Errorf("connection reset"))
+
+	var staged *StagingError
+	if errors.As(err, &staged) {
+		t.Fatal("TransferError should not match *StagingError")
+	}
+	var transfer *TransferError
+	if !errors.As(err, &transfer) {
+		t.Fatalf("expected *TransferError, got %T", err)
+	}
+	if transfer.Code() != CodeTransfer {
+		t.Fatalf("Code() = %q, want %q", transfer.Code(), CodeTransfer)
+	}
+}
+
+func TestEvalErrorKeepsExpressionAndCause(t *testing.T) {
+	cause := fmt.Errorf("ReferenceError: x is not defined")
+	err := Eval("$(inputs.x)", cause)
+
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) {
+		t.Fatalf("expected *EvalError, got %T", err)
+	}
+	if evalErr.Expression != "$(inputs.x)" {
+		t.Fatalf("Expression = %q", evalErr.Expression)
+	}
+	if evalErr.Code() != CodeEval {
+		t.Fatalf("Code() = %q, want %q", evalErr.Code(), CodeEval)
+	}
+}
+
+func TestOutputCollectionErrorWithAndWithoutOutputName(t *testing.T) {
+	named := OutputCollection("bams", fmt.Errorf("want File, got string"))
+	if got, want := named.Error(), `collecting output "bams": want File, got string`; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+
+	unnamed := OutputCollection("", fmt.Errorf("not a JSON object"))
+	if got, want := unnamed.Error(), "collecting outputs: not a JSON object"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestSpecViolationCode(t *testing.T) {
+	err := Spec("InitialWorkDirRequirement", fmt.Errorf("duplicate entryname %q", "out.txt"))
+	var violation *SpecViolation
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected *SpecViolation, got %T", err)
+	}
+	if violation.Code() != CodeSpecViolation {
+		t.Fatalf("Code() = %q, want %q", violation.Code(), CodeSpecViolation)
+	}
+}
+
+func TestAllTypesImplementCoded(t *testing.T) {
+	var errs = []error{
+		Staging("t", fmt.Errorf("x")),
+		Transfer("s3", "download", "k", fmt.Errorf("x")),
+		Eval("e", fmt.Errorf("x")),
+		OutputCollection("o", fmt.Errorf("x")),
+		Spec("r", fmt.Errorf("x")),
+	}
+	for _, err := range errs {
+		if _, ok := err.(Coded); !ok {
+			t.Fatalf("%T does not implement Coded", err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package internal
+
+import "io"
+
+// FileManager abstracts the storage backend a worker stages inputs from and
+// publishes outputs to. Implementations exist for the local filesystem
+// (shared NFS-style mounts) and S3-compatible object stores.
+type FileManager interface {
+	// Download copies the object at src to the local path dst.
+	Download(src, dst string) error
+
+	// Upload copies the local path src to the object at dst.
+	Upload(src, dst string) error
+
+	// Copy copies src to dst within the same backend, without a local
+	// round-trip where the backend supports it.
+	Copy(src, dst string) error
+
+	// Remove deletes the object or directory at path.
+	Remove(path string) error
+
+	// Open returns a reader for the object at path.
+	Open(path string) (io.ReadCloser, error)
+
+	// Stat reports whether path exists and, if so, its size.
+	Stat(path string) (size int64, exists bool, err error)
+}
+
+// MetadataPreservingFileManager is implemented by FileManagers that can
+// carry source metadata (timestamps, user xattrs) across a transfer instead
+// of just bytes and mode. Callers that care about it should check for this
+// interface and fall back to the plain Download/Upload otherwise.
+type MetadataPreservingFileManager interface {
+	FileManager
+	DownloadWithOptions(src, dst string, opts CopyOptions) error
+	UploadWithOptions(src, dst string, opts CopyOptions) error
+}
+
+// DirectoryReplicator is implemented by FileManagers that can replicate an
+// entire local directory to dst in one call, instead of a caller walking
+// it and Uploading file by file. Backends built on a collection/prefix
+// primitive (e.g. iRODS's "put collection") can batch the transfer this
+// way; callers that care should check for this interface and fall back to
+// walking the directory themselves otherwise.
+type DirectoryReplicator interface {
+	FileManager
+	// ReplicateDirectory uploads every file under localDir to dst,
+	// preserving localDir's relative layout.
+	ReplicateDirectory(localDir, dst string) error
+}
+
+// DirectoryRestorer is implemented by FileManagers that can restore an
+// entire remote directory to a local path in one call, the download
+// counterpart to DirectoryReplicator, for backends built on a
+// collection/prefix primitive that can list what's there instead of a
+// caller needing to already know every file it expects to find. Callers
+// that care should check for this interface and treat a backend without
+// it as simply not supporting restore.
+type DirectoryRestorer interface {
+	FileManager
+	// RestoreDirectory downloads every entry under the remote directory
+	// src into localDir, preserving src's relative layout.
+	RestoreDirectory(src, localDir string) error
+}
+
+// RangePrefixReader is implemented by FileManagers that can read just a
+// byte-range prefix of a remote object, via an HTTP Range request or
+// equivalent, instead of downloading the whole object. LoadContents uses
+// this to support CWL's loadContents without a full download when the
+// backend supports it; callers that care should check for this interface
+// and fall back to Open otherwise.
+type RangePrefixReader interface {
+	FileManager
+	// OpenRangePrefix returns a reader for path's first n bytes.
+	OpenRangePrefix(path string, n int64) (io.ReadCloser, error)
+}
+
+// ObjectTagger is implemented by FileManagers that can attach searchable
+// key/value tags to an already-uploaded object, distinct from opaque
+// user metadata carried by MetadataPreservingFileManager, for backends
+// whose object store supports first-class tagging (e.g. S3's tag-set
+// API) that's indexed and filterable independently of the object's own
+// content or headers. Callers that care should check for this interface
+// and treat a backend without it as simply not supporting tags.
+type ObjectTagger interface {
+	FileManager
+	// TagObject attaches tags to the object at path, replacing any tags
+	// previously set on it.
+	TagObject(path string, tags map[string]string) error
+}
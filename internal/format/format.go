@@ -0,0 +1,40 @@
+// Package format validates CWL File "format" values. Per the CWL spec a
+// format is an ontology IRI; this worker only recognizes EDAM
+// (http://edamontology.org/format_<n>), since that's the ontology CWL's
+// own examples and conformance tests use, rather than accepting arbitrary
+// free-text strings.
+package format
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var edamPattern = regexp.MustCompile(`^http://edamontology\.org/format_[0-9]+$`)
+
+// Validate reports an error if iri is not a well-formed EDAM ontology
+// format IRI.
+func Validate(iri string) error {
+	if !edamPattern.MatchString(iri) {
+		return fmt.Errorf("format %q is not a valid EDAM ontology IRI (want http://edamontology.org/format_<n>)", iri)
+	}
+	return nil
+}
+
+// Matches reports whether have satisfies one of allowed. An empty allowed
+// list means no format was required, so anything matches. CWL formally
+// resolves this via ontology subclass matching (e.g. a more specific
+// format satisfying a more general one); this worker only supports exact
+// IRI equality, which covers the common case of a tool declaring the same
+// format its inputs were produced with.
+func Matches(have string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if have == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package format
+
+import "testing"
+
+func TestValidateAcceptsWellFormedEDAMIRI(t *testing.T) {
+	if err := Validate("http://edamontology.org/format_1930"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRejectsNonEDAMValue(t *testing.T) {
+	if err := Validate("text/plain"); err == nil {
+		t.Fatal("expected error for non-EDAM format")
+	}
+}
+
+func TestMatchesNoAllowedListAcceptsAnything(t *testing.T) {
+	if !Matches("http://edamontology.org/format_1930", nil) {
+		t.Fatal("expected match with no allowed formats")
+	}
+}
+
+func TestMatchesExactIRI(t *testing.T) {
+	allowed := []string{"http://edamontology.org/format_1929", "http://edamontology.org/format_1930"}
+	if !Matches("http://edamontology.org/format_1930", allowed) {
+		t.Fatal("expected match")
+	}
+	if Matches("http://edamontology.org/format_2330", allowed) {
+		t.Fatal("expected no match")
+	}
+}
@@ -0,0 +1,150 @@
+// Package glob implements CWL's glob output binding: matching one or more
+// patterns against a directory with the extensions CWL requires beyond
+// filepath.Glob's plain POSIX-style matching ("**" recursive directory
+// wildcards and "{a,b,c}" brace alternatives), and concatenating multiple
+// patterns' results in the order given.
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Match evaluates patterns against baseDir (each pattern is resolved
+// relative to baseDir) and returns every matching path. Patterns are
+// matched in order and their results concatenated, as CWL's
+// CommandOutputBinding.glob requires for a list of patterns; each
+// individual pattern's own matches are sorted and de-duplicated first,
+// since filesystem read order is not guaranteed.
+func Match(baseDir string, patterns []string) ([]string, error) {
+	var results []string
+	for _, pattern := range patterns {
+		matches, err := matchOne(baseDir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, matches...)
+	}
+	return results, nil
+}
+
+func matchOne(baseDir, pattern string) ([]string, error) {
+	var all []string
+	for _, expanded := range expandBraces(pattern) {
+		matches, err := globPattern(baseDir, expanded)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, matches...)
+	}
+	sort.Strings(all)
+	return dedupeSorted(all), nil
+}
+
+// expandBraces expands "{a,b,c}" alternation in pattern into every
+// combination, matching shell brace expansion for the flat, non-nested
+// case CWL glob patterns actually use. A pattern with no "{" is returned
+// unchanged.
+func expandBraces(pattern string) []string {
+	start := strings.IndexByte(pattern, '{')
+	if start < 0 {
+		return []string{pattern}
+	}
+	end := strings.IndexByte(pattern[start:], '}')
+	if end < 0 {
+		return []string{pattern}
+	}
+	end += start
+
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	var out []string
+	for _, alt := range strings.Split(pattern[start+1:end], ",") {
+		out = append(out, expandBraces(prefix+alt+suffix)...)
+	}
+	return out
+}
+
+// globPattern matches one already brace-expanded pattern against baseDir.
+// Patterns without "**" are delegated to filepath.Glob directly; those
+// with "**" are matched segment by segment so a "**" segment can expand to
+// zero or more directories, which filepath.Glob has no way to express.
+func globPattern(baseDir, pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(filepath.Join(baseDir, pattern))
+	}
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	return matchSegments(baseDir, segments)
+}
+
+// matchSegments matches the path segments of a "**"-containing pattern
+// against dir, one segment at a time.
+func matchSegments(dir string, segments []string) ([]string, error) {
+	if len(segments) == 0 {
+		return []string{dir}, nil
+	}
+
+	seg, rest := segments[0], segments[1:]
+	if seg != "**" {
+		matched, err := filepath.Glob(filepath.Join(dir, seg))
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			return matched, nil
+		}
+		var out []string
+		for _, m := range matched {
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				sub, err := matchSegments(m, rest)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, sub...)
+			}
+		}
+		return out, nil
+	}
+
+	// "**" matches zero directories (i.e. the rest of the pattern applied
+	// to dir itself)...
+	matches, err := matchSegments(dir, rest)
+	if err != nil {
+		return nil, err
+	}
+
+	// ...or one or more, by recursing into every subdirectory with "**"
+	// still in front of the remaining pattern.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return matches, nil
+		}
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		nested, err := matchSegments(filepath.Join(dir, e.Name()), segments)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, nested...)
+	}
+	return matches, nil
+}
+
+// dedupeSorted removes adjacent duplicates from a sorted slice, in place.
+func dedupeSorted(sorted []string) []string {
+	out := sorted[:0]
+	var prev string
+	for i, s := range sorted {
+		if i == 0 || s != prev {
+			out = append(out, s)
+		}
+		prev = s
+	}
+	return out
+}
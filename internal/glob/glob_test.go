@@ -0,0 +1,97 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func mkfile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatchPlainPattern(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+	mkfile(t, filepath.Join(dir, "b.log"))
+
+	got, err := Match(dir, []string{"*.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.txt")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMatchConcatenatesPatternsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+	mkfile(t, filepath.Join(dir, "b.log"))
+
+	got, err := Match(dir, []string{"*.log", "*.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "b.log"), filepath.Join(dir, "a.txt")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMatchBraceExpansion(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+	mkfile(t, filepath.Join(dir, "a.log"))
+	mkfile(t, filepath.Join(dir, "a.csv"))
+
+	got, err := Match(dir, []string{"a.{txt,log}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "a.txt")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMatchDoubleStarRecursesAllDepths(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+	mkfile(t, filepath.Join(dir, "sub", "b.txt"))
+	mkfile(t, filepath.Join(dir, "sub", "deeper", "c.txt"))
+	mkfile(t, filepath.Join(dir, "sub", "deeper", "c.log"))
+
+	got, err := Match(dir, []string{"**/*.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "sub", "b.txt"),
+		filepath.Join(dir, "sub", "deeper", "c.txt"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMatchNoResultsReturnsEmpty(t *testing.T) {
+	dir := t.TempDir()
+
+	got, err := Match(dir, []string{"*.missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v, want empty", got)
+	}
+}
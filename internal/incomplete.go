@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// IncompleteMarker is the sentinel file dropped inside a directory while it
+// is being staged or published. Its presence means the directory must be
+// treated as invalid by anything other than the process that created it.
+const IncompleteMarker = ".flowy-incomplete"
+
+// MarkIncomplete drops an IncompleteMarker inside dir, creating dir if
+// necessary. Callers must remove the marker (via ClearIncomplete) once every
+// entry underneath dir has been fully written.
+func MarkIncomplete(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, IncompleteMarker), nil, 0o644)
+}
+
+// ClearIncomplete removes the IncompleteMarker from dir, signalling that
+// staging or publication of dir finished successfully.
+func ClearIncomplete(dir string) error {
+	err := os.Remove(filepath.Join(dir, IncompleteMarker))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// IsIncomplete reports whether dir still carries an IncompleteMarker.
+func IsIncomplete(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, IncompleteMarker))
+	return err == nil
+}
+
+// CleanupIncomplete walks root and removes any direct child directory that
+// still carries an IncompleteMarker, along with its contents. It is meant to
+// run once at worker startup so that artifacts left behind by a crash are
+// never mistaken for valid staged or published data.
+func CleanupIncomplete(root string) error {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if IsIncomplete(dir) {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// BackendHealth summarizes one FileManager backend's recent reliability, so
+// it can be surfaced in the worker heartbeat: an operator watching the
+// server's view of its fleet spots an ailing NFS mount or S3 endpoint from
+// a rising error rate or a stale LastSuccess, before it causes a pile of
+// jobs to fail outright.
+type BackendHealth struct {
+	// Backend names which FileManager this health snapshot describes
+	// (e.g. "input" or "output"), matching the label InstrumentedFileManager
+	// reports its metrics under.
+	Backend string
+	Calls   int64
+	Errors  int64
+	// LastSuccess is the zero Time if no call has ever succeeded.
+	LastSuccess time.Time
+	// LastError is the most recent call's error text, or "" if the most
+	// recent call succeeded or none has been made yet.
+	LastError string
+}
+
+// ErrorRate returns Errors/Calls, or 0 if no calls have been made.
+func (h BackendHealth) ErrorRate() float64 {
+	if h.Calls == 0 {
+		return 0
+	}
+	return float64(h.Errors) / float64(h.Calls)
+}
+
+// InstrumentedFileManager wraps a FileManager with per-backend call
+// counters and latency totals (exported via internal/metrics) and an
+// in-memory rolling summary (Health) cheap enough to read on every
+// heartbeat. backend labels both, so a worker that staged from a local
+// mount and published to S3 can tell which one is struggling.
+type InstrumentedFileManager struct {
+	FileManager
+	backend string
+
+	mu      sync.Mutex
+	calls   int64
+	errors  int64
+	lastOK  time.Time
+	lastErr string
+}
+
+// WithMetrics wraps fm so every call through it is counted, timed, and
+// folded into a BackendHealth summary labeled backend.
+func WithMetrics(fm FileManager, backend string) *InstrumentedFileManager {
+	return &InstrumentedFileManager{FileManager: fm, backend: backend}
+}
+
+// Health returns a snapshot of m's calls so far.
+func (m *InstrumentedFileManager) Health() BackendHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return BackendHealth{
+		Backend:     m.backend,
+		Calls:       m.calls,
+		Errors:      m.errors,
+		LastSuccess: m.lastOK,
+		LastError:   m.lastErr,
+	}
+}
+
+// record updates the metrics registry and the Health summary for a call to
+// op that started at start and returned err.
+func (m *InstrumentedFileManager) record(op string, start time.Time, err error) {
+	metrics.FileManagerCallsTotal.Inc("backend", m.backend, "op", op)
+	metrics.FileManagerSecondsTotal.Add(int64(time.Since(start).Seconds()), "backend", m.backend, "op", op)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if err != nil {
+		m.errors++
+		m.lastErr = err.Error()
+		metrics.FileManagerErrorsTotal.Inc("backend", m.backend, "op", op)
+		return
+	}
+	m.lastOK = time.Now()
+	m.lastErr = ""
+}
+
+func (m *InstrumentedFileManager) Download(src, dst string) error {
+	start := time.Now()
+	err := m.FileManager.Download(src, dst)
+	m.record("Download", start, err)
+	return err
+}
+
+func (m *InstrumentedFileManager) Upload(src, dst string) error {
+	start := time.Now()
+	err := m.FileManager.Upload(src, dst)
+	m.record("Upload", start, err)
+	return err
+}
+
+func (m *InstrumentedFileManager) Copy(src, dst string) error {
+	start := time.Now()
+	err := m.FileManager.Copy(src, dst)
+	m.record("Copy", start, err)
+	return err
+}
+
+func (m *InstrumentedFileManager) Remove(path string) error {
+	start := time.Now()
+	err := m.FileManager.Remove(path)
+	m.record("Remove", start, err)
+	return err
+}
+
+func (m *InstrumentedFileManager) Open(path string) (io.ReadCloser, error) {
+	start := time.Now()
+	r, err := m.FileManager.Open(path)
+	m.record("Open", start, err)
+	return r, err
+}
+
+func (m *InstrumentedFileManager) Stat(path string) (int64, bool, error) {
+	start := time.Now()
+	size, exists, err := m.FileManager.Stat(path)
+	m.record("Stat", start, err)
+	return size, exists, err
+}
+
+// UploadDedup forwards to the wrapped FileManager's own UploadDedup when
+// available, and otherwise falls back to a plain Upload, the same
+// fallback chaosFileManager.UploadDedup uses, so wrapping a
+// DedupFileManager with WithMetrics doesn't silently lose deduplication.
+func (m *InstrumentedFileManager) UploadDedup(local, dst, checksum string) (string, error) {
+	start := time.Now()
+	dedup, ok := m.FileManager.(DedupUploader)
+	var location string
+	var err error
+	if ok {
+		location, err = dedup.UploadDedup(local, dst, checksum)
+	} else {
+		location = dst
+		err = m.FileManager.Upload(local, dst)
+	}
+	m.record("UploadDedup", start, err)
+	return location, err
+}
+
+// ReplicateDirectory forwards to the wrapped FileManager's own
+// ReplicateDirectory when it implements DirectoryReplicator, instrumented
+// the same way Upload is, so wrapping one with WithMetrics doesn't
+// silently lose the capability.
+func (m *InstrumentedFileManager) ReplicateDirectory(localDir, dst string) error {
+	start := time.Now()
+	replicator, ok := m.FileManager.(DirectoryReplicator)
+	if !ok {
+		err := fmt.Errorf("%s FileManager does not support ReplicateDirectory", m.backend)
+		m.record("ReplicateDirectory", start, err)
+		return err
+	}
+	err := replicator.ReplicateDirectory(localDir, dst)
+	m.record("ReplicateDirectory", start, err)
+	return err
+}
+
+// RestoreDirectory forwards to the wrapped FileManager's own
+// RestoreDirectory when it implements DirectoryRestorer, instrumented the
+// same way Download is.
+func (m *InstrumentedFileManager) RestoreDirectory(src, localDir string) error {
+	start := time.Now()
+	restorer, ok := m.FileManager.(DirectoryRestorer)
+	if !ok {
+		err := fmt.Errorf("%s FileManager does not support RestoreDirectory", m.backend)
+		m.record("RestoreDirectory", start, err)
+		return err
+	}
+	err := restorer.RestoreDirectory(src, localDir)
+	m.record("RestoreDirectory", start, err)
+	return err
+}
+
+// TagObject forwards to the wrapped FileManager's own TagObject when it
+// implements ObjectTagger.
+func (m *InstrumentedFileManager) TagObject(path string, tags map[string]string) error {
+	tagger, ok := m.FileManager.(ObjectTagger)
+	if !ok {
+		return fmt.Errorf("%s FileManager does not support TagObject", m.backend)
+	}
+	return tagger.TagObject(path, tags)
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstrumentedFileManagerRecordsSuccessAndFailure(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "copy.txt")
+
+	m := WithMetrics(NewLocalFileManager(), "test")
+	if err := m.Upload(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Download("does/not/exist", filepath.Join(dir, "missing.txt")); err == nil {
+		t.Fatal("expected Download of a nonexistent source to fail")
+	}
+
+	health := m.Health()
+	if health.Backend != "test" {
+		t.Fatalf("Backend = %q, want %q", health.Backend, "test")
+	}
+	if health.Calls != 2 {
+		t.Fatalf("Calls = %d, want 2", health.Calls)
+	}
+	if health.Errors != 1 {
+		t.Fatalf("Errors = %d, want 1", health.Errors)
+	}
+	if health.LastSuccess.IsZero() {
+		t.Fatal("LastSuccess should be set after a successful call")
+	}
+	if health.LastError == "" {
+		t.Fatal("LastError should be set after the failed call")
+	}
+	if got, want := health.ErrorRate(), 0.5; got != want {
+		t.Fatalf("ErrorRate() = %v, want %v", got, want)
+	}
+}
+
+func TestInstrumentedFileManagerUploadDedupForwardsToWrappedDedupUploader(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+
+	m := WithMetrics(WithDedup(NewLocalFileManager(), dir+string(filepath.Separator)), "test")
+	dst := filepath.Join(dir, "out", "a.txt")
+	if _, err := m.UploadDedup(src, dst, "sha1$abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	casPath := dir + string(filepath.Separator) + CASKey("sha1$abc")
+	if _, exists, err := m.Stat(casPath); err != nil || !exists {
+		t.Fatalf("expected CAS object to exist at %s, exists=%v err=%v", casPath, exists, err)
+	}
+	if health := m.Health(); health.Calls == 0 {
+		t.Fatal("UploadDedup should be counted as a call")
+	}
+}
+
+func TestInstrumentedFileManagerUploadDedupFallsBackToUploadWithoutDedup(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+	dst := filepath.Join(dir, "copy.txt")
+
+	m := WithMetrics(NewLocalFileManager(), "test")
+	location, err := m.UploadDedup(src, dst, "sha1$abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location != dst {
+		t.Fatalf("location = %q, want %q", location, dst)
+	}
+	if _, exists, err := m.Stat(dst); err != nil || !exists {
+		t.Fatalf("expected plain upload to land at %s, exists=%v err=%v", dst, exists, err)
+	}
+}
+
+func TestInstrumentedFileManagerRecordsOpenErrors(t *testing.T) {
+	m := WithMetrics(NewLocalFileManager(), "test")
+	if _, err := m.Open(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("expected Open of a nonexistent file to fail")
+	}
+	health := m.Health()
+	if health.Errors != 1 {
+		t.Fatalf("Errors = %d, want 1", health.Errors)
+	}
+}
@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IRODSAPI is the subset of an iRODS data-management client (e.g.
+// go-irodsclient) IRODSFileManager needs. It is declared here, rather
+// than importing a concrete client library, the same way S3API is for
+// S3FileManager: this repo doesn't carry a dependency on any particular
+// iRODS client, so a site wires a real one in behind this interface, and
+// tests substitute an in-memory fake.
+type IRODSAPI interface {
+	GetObject(path string) (io.ReadCloser, error)
+	PutObject(path string, body io.Reader, size int64) error
+	CopyObject(src, dst string) error
+	DeleteObject(path string) error
+	DeleteCollection(path string) error
+	// Stat reports path's size and whether it exists, and whether it
+	// names a collection (iRODS's term for a directory) rather than a
+	// data object.
+	Stat(path string) (size int64, exists bool, isCollection bool, err error)
+	// ListCollection returns the paths of every entry directly inside the
+	// collection at path (not recursive).
+	ListCollection(path string) ([]string, error)
+	// AddMetadata attaches an iRODS AVU (Attribute-Value-Unit) triple to
+	// path, the mechanism iRODS uses for arbitrary object metadata -
+	// recording a CWL output's checksum or provenance alongside whatever
+	// cataloguing a genomics archive already keeps in iRODS.
+	AddMetadata(path, attribute, value, unit string) error
+}
+
+// IRODSFileManager implements FileManager (and DirectoryReplicator) against
+// an iRODS zone. Paths passed to it are irods://zone/collection/... URLs,
+// passed through to the API unchanged.
+type IRODSFileManager struct {
+	api IRODSAPI
+}
+
+// NewIRODSFileManager returns a FileManager backed by api.
+func NewIRODSFileManager(api IRODSAPI) *IRODSFileManager {
+	return &IRODSFileManager{api: api}
+}
+
+// Download copies the object or collection at src to the local path dst,
+// recursing into dst as a directory when src names a collection.
+func (m *IRODSFileManager) Download(src, dst string) error {
+	_, exists, isCollection, err := m.api.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return os.ErrNotExist
+	}
+	if isCollection {
+		return m.RestoreDirectory(src, dst)
+	}
+
+	r, err := m.api.GetObject(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := ensureParentDir(dst); err != nil {
+		return err
+	}
+	tmp := dst + tempSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// RestoreDirectory downloads every entry under the collection at src into
+// localDir, recursing into nested collections and mirroring each entry's
+// base name, satisfying DirectoryRestorer so a caller restoring a job
+// checkpoint can hand the whole remote tree to iRODS in one call instead
+// of needing to already know every file it expects to find there. It is
+// also Download's own directory case, since a bare collection path is
+// just as valid a Download src as a data object.
+func (m *IRODSFileManager) RestoreDirectory(src, dst string) error {
+	entries, err := m.api.ListCollection(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry, "/")
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		if err := m.Download(entry, filepath.Join(dst, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *IRODSFileManager) Upload(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return m.ReplicateDirectory(src, dst)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.api.PutObject(dst, f, info.Size())
+}
+
+// ReplicateDirectory uploads every file under localDir to dst, preserving
+// localDir's relative layout, satisfying DirectoryReplicator so a caller
+// publishing a CWL Directory output can hand the whole tree to iRODS in
+// one call instead of walking it and calling Upload per file.
+func (m *IRODSFileManager) ReplicateDirectory(localDir, dst string) error {
+	return filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		return m.Upload(path, strings.TrimSuffix(dst, "/")+"/"+filepath.ToSlash(rel))
+	})
+}
+
+func (m *IRODSFileManager) Copy(src, dst string) error {
+	return m.api.CopyObject(src, dst)
+}
+
+func (m *IRODSFileManager) Remove(path string) error {
+	_, exists, isCollection, err := m.api.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if isCollection {
+		return m.api.DeleteCollection(path)
+	}
+	return m.api.DeleteObject(path)
+}
+
+func (m *IRODSFileManager) Open(path string) (io.ReadCloser, error) {
+	return m.api.GetObject(path)
+}
+
+func (m *IRODSFileManager) Stat(path string) (int64, bool, error) {
+	size, exists, _, err := m.api.Stat(path)
+	return size, exists, err
+}
+
+// Tag attaches an AVU (Attribute-Value-Unit) triple to path, for recording
+// provenance or other metadata on a produced output alongside iRODS's own
+// cataloguing.
+func (m *IRODSFileManager) Tag(path, attribute, value, unit string) error {
+	return m.api.AddMetadata(path, attribute, value, unit)
+}
@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeIRODSObject is one stored data object in fakeIRODSAPI.
+type fakeIRODSObject struct {
+	data     []byte
+	metadata map[string]string
+}
+
+// fakeIRODSAPI is an in-memory IRODSAPI substitute, standing in for a real
+// iRODS zone the same way fakeS3API stands in for a bucket: IRODSFileManager
+// has no concrete go-irodsclient wired into it, so tests exercise it against
+// this fake instead.
+type fakeIRODSAPI struct {
+	objects     map[string]fakeIRODSObject
+	collections map[string]bool
+}
+
+func newFakeIRODSAPI() *fakeIRODSAPI {
+	return &fakeIRODSAPI{
+		objects:     map[string]fakeIRODSObject{},
+		collections: map[string]bool{},
+	}
+}
+
+func (f *fakeIRODSAPI) GetObject(path string) (io.ReadCloser, error) {
+	obj, ok := f.objects[path]
+	if !ok {
+		return nil, fmt.Errorf("no such data object: %s", path)
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (f *fakeIRODSAPI) PutObject(path string, body io.Reader, size int64) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	f.objects[path] = fakeIRODSObject{data: data, metadata: f.objects[path].metadata}
+	return nil
+}
+
+func (f *fakeIRODSAPI) CopyObject(src, dst string) error {
+	obj, ok := f.objects[src]
+	if !ok {
+		return fmt.Errorf("no such data object: %s", src)
+	}
+	f.objects[dst] = obj
+	return nil
+}
+
+func (f *fakeIRODSAPI) DeleteObject(path string) error {
+	delete(f.objects, path)
+	return nil
+}
+
+func (f *fakeIRODSAPI) DeleteCollection(path string) error {
+	delete(f.collections, path)
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			delete(f.objects, key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeIRODSAPI) Stat(path string) (int64, bool, bool, error) {
+	if f.collections[path] {
+		return 0, true, true, nil
+	}
+	if obj, ok := f.objects[path]; ok {
+		return int64(len(obj.data)), true, false, nil
+	}
+	return 0, false, false, nil
+}
+
+func (f *fakeIRODSAPI) ListCollection(path string) ([]string, error) {
+	if !f.collections[path] {
+		return nil, fmt.Errorf("no such collection: %s", path)
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	var entries []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) && !strings.Contains(strings.TrimPrefix(key, prefix), "/") {
+			entries = append(entries, key)
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakeIRODSAPI) AddMetadata(path, attribute, value, unit string) error {
+	obj, ok := f.objects[path]
+	if !ok {
+		return fmt.Errorf("no such data object: %s", path)
+	}
+	if obj.metadata == nil {
+		obj.metadata = map[string]string{}
+	}
+	obj.metadata[attribute] = value
+	f.objects[path] = obj
+	return nil
+}
+
+func TestIRODSFileManagerUploadDownloadRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(src, []byte("hello irods"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api := newFakeIRODSAPI()
+	fm := NewIRODSFileManager(api)
+
+	if err := fm.Upload(src, "irods://zone/home/project/in.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	size, exists, err := fm.Stat("irods://zone/home/project/in.txt")
+	if err != nil || !exists || size != int64(len("hello irods")) {
+		t.Fatalf("Stat() = (%d, %v, %v), want (11, true, nil)", size, exists, err)
+	}
+
+	dst := filepath.Join(dir, "out.txt")
+	if err := fm.Download("irods://zone/home/project/in.txt", dst); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello irods" {
+		t.Fatalf("downloaded content = %q, want %q", got, "hello irods")
+	}
+}
+
+func TestIRODSFileManagerReplicateDirectoryPreservesLayout(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644)
+	os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0o644)
+
+	api := newFakeIRODSAPI()
+	fm := NewIRODSFileManager(api)
+
+	if err := fm.ReplicateDirectory(dir, "irods://zone/home/project/out"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"irods://zone/home/project/out/a.txt", "irods://zone/home/project/out/sub/b.txt"} {
+		if _, exists, err := fm.Stat(path); err != nil || !exists {
+			t.Fatalf("Stat(%q) = exists=%v err=%v, want exists", path, exists, err)
+		}
+	}
+}
+
+func TestIRODSFileManagerRemoveDistinguishesCollectionFromObject(t *testing.T) {
+	api := newFakeIRODSAPI()
+	fm := NewIRODSFileManager(api)
+
+	api.objects["irods://zone/home/project/a.txt"] = fakeIRODSObject{data: []byte("a")}
+	if err := fm.Remove("irods://zone/home/project/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists, _ := fm.Stat("irods://zone/home/project/a.txt"); exists {
+		t.Fatal("expected object to be removed")
+	}
+
+	api.collections["irods://zone/home/project/coll"] = true
+	api.objects["irods://zone/home/project/coll/a.txt"] = fakeIRODSObject{data: []byte("a")}
+	if err := fm.Remove("irods://zone/home/project/coll"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists, _ := fm.Stat("irods://zone/home/project/coll/a.txt"); exists {
+		t.Fatal("expected collection removal to take its contents with it")
+	}
+}
+
+func TestIRODSFileManagerTagAddsMetadata(t *testing.T) {
+	api := newFakeIRODSAPI()
+	fm := NewIRODSFileManager(api)
+	api.objects["irods://zone/home/project/out.txt"] = fakeIRODSObject{data: []byte("x")}
+
+	if err := fm.Tag("irods://zone/home/project/out.txt", "checksum", "sha1$abc", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.objects["irods://zone/home/project/out.txt"].metadata["checksum"]; got != "sha1$abc" {
+		t.Fatalf("metadata[checksum] = %q, want %q", got, "sha1$abc")
+	}
+}
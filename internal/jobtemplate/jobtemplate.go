@@ -0,0 +1,74 @@
+// Package jobtemplate renders a CWL job order (a tool's input object) from
+// a Go text/template and a flat set of placeholder values, for callers
+// that fan a tool out across many rows of external metadata (a sample
+// sheet, a dropped file's name) rather than taking one hand-written job
+// order per run. It is shared by cmd/cwlclient's "array" subcommand and
+// cmd/trigger.
+package jobtemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Render executes tmpl against data and parses the result as a flat YAML
+// mapping (see ParseSimpleYAMLMapping) into a CWL job order.
+func Render(tmpl *template.Template, data interface{}) (map[string]interface{}, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return ParseSimpleYAMLMapping(buf.String())
+}
+
+// ParseSimpleYAMLMapping parses a restricted YAML subset: one "key: value"
+// pair per non-blank, non-comment top-level line, with no nested
+// indentation. A value starting with "{" or "[" is parsed as JSON (for CWL
+// File/Directory objects and arrays); anything else is kept as a string,
+// except for a quoted string (unquoted) or a value that parses cleanly as
+// an integer or float. This covers the common case of a job order
+// template whose placeholders fill in File paths and scalar parameters,
+// without pulling in a full YAML parser neither caller otherwise needs.
+func ParseSimpleYAMLMapping(text string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		idx := strings.Index(trimmed, ":")
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid line %q: expected \"key: value\"", trimmed)
+		}
+		key := strings.TrimSpace(trimmed[:idx])
+		value := strings.TrimSpace(trimmed[idx+1:])
+		result[key] = parseScalar(value)
+	}
+	return result, nil
+}
+
+// parseScalar converts one mapping value from its rendered text into the
+// most specific type ParseSimpleYAMLMapping's restricted grammar supports.
+func parseScalar(value string) interface{} {
+	if strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			return parsed
+		}
+		return value
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if fl, err := strconv.ParseFloat(value, 64); err == nil {
+		return fl
+	}
+	return value
+}
@@ -0,0 +1,58 @@
+package jobtemplate
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestParseSimpleYAMLMapping(t *testing.T) {
+	inline := `sample_id: sample1
+fastq: {"class": "File", "path": "/data/s1.fq"}
+count: 3
+threshold: 0.5
+`
+	got, err := ParseSimpleYAMLMapping(inline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["sample_id"] != "sample1" {
+		t.Fatalf("sample_id = %v, want sample1", got["sample_id"])
+	}
+	if got["count"] != 3 {
+		t.Fatalf("count = %v (%T), want int 3", got["count"], got["count"])
+	}
+	if got["threshold"] != 0.5 {
+		t.Fatalf("threshold = %v, want 0.5", got["threshold"])
+	}
+	file, ok := got["fastq"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("fastq = %v, want a File mapping", got["fastq"])
+	}
+	if file["path"] != "/data/s1.fq" {
+		t.Fatalf("fastq.path = %v, want /data/s1.fq", file["path"])
+	}
+}
+
+func TestParseSimpleYAMLMappingRejectsMissingColon(t *testing.T) {
+	if _, err := ParseSimpleYAMLMapping("not-a-mapping-line"); err == nil {
+		t.Fatal("expected an error for a line without a colon")
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := template.New("t").Parse("sample_id: {{.sample_id}}\nfastq: \"{{.fastq}}\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]string{"sample_id": "sample1", "fastq": "/data/s1.fq"}
+
+	got, err := Render(tmpl, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"sample_id": "sample1", "fastq": "/data/s1.fq"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Render = %+v, want %+v", got, want)
+	}
+}
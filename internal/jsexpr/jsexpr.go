@@ -0,0 +1,149 @@
+// Package jsexpr evaluates CWL parameter references and JavaScript
+// expressions (InlineJavascriptRequirement) using an embedded JS engine,
+// so the worker can resolve outputEval/secondaryFiles expressions itself
+// instead of calling back out to the server's eval endpoint for every one.
+package jsexpr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// Context supplies the CWL expression variables: the tool's input object,
+// the value the expression is being applied to (e.g. an output File being
+// built), and the runtime object (outdir, tmpdir, cores, ...).
+type Context struct {
+	Inputs  map[string]interface{}
+	Self    interface{}
+	Runtime map[string]interface{}
+}
+
+// Evaluate resolves expr, which may be a literal string, a single
+// "$(...)" parameter reference/expression, a "${...}" function body, or a
+// string interpolating any number of "$(...)" expressions. A lone
+// "$(...)" or "${...}" expression (nothing else in expr) returns its
+// native JS value; anything interpolated with surrounding text is
+// stringified, matching the CWL expression-parsing rules.
+func Evaluate(expr string, ctx Context) (interface{}, error) {
+	segments, err := parseSegments(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 1 && segments[0].isExpr {
+		return evalJS(segments[0].code, segments[0].isBlock, ctx)
+	}
+
+	var b strings.Builder
+	for _, seg := range segments {
+		if !seg.isExpr {
+			b.WriteString(seg.code)
+			continue
+		}
+		v, err := evalJS(seg.code, seg.isBlock, ctx)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(stringify(v))
+	}
+	return b.String(), nil
+}
+
+func evalJS(code string, isBlock bool, ctx Context) (interface{}, error) {
+	vm := goja.New()
+	if err := vm.Set("inputs", ctx.Inputs); err != nil {
+		return nil, err
+	}
+	if err := vm.Set("self", ctx.Self); err != nil {
+		return nil, err
+	}
+	if err := vm.Set("runtime", ctx.Runtime); err != nil {
+		return nil, err
+	}
+
+	src := code
+	if isBlock {
+		src = "(function(){" + code + "})()"
+	}
+	v, err := vm.RunString(src)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating CWL expression %q: %w", code, err)
+	}
+	return v.Export(), nil
+}
+
+func stringify(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+type segment struct {
+	code    string
+	isExpr  bool
+	isBlock bool
+}
+
+// parseSegments splits expr into literal-text and expression segments,
+// scanning for "$(" / "${" and matching the corresponding ")" / "}" while
+// tracking nesting depth and skipping delimiters inside JS string literals,
+// since an expression body may itself contain parens or braces.
+func parseSegments(expr string) ([]segment, error) {
+	var segs []segment
+	i := 0
+	for i < len(expr) {
+		if expr[i] == '$' && i+1 < len(expr) && (expr[i+1] == '(' || expr[i+1] == '{') {
+			open := expr[i+1]
+			close := byte(')')
+			if open == '{' {
+				close = '}'
+			}
+			start := i + 2
+			j := start
+			depth := 1
+			var inString byte
+			for j < len(expr) && depth > 0 {
+				c := expr[j]
+				switch {
+				case inString != 0:
+					if c == '\\' {
+						j++
+					} else if c == inString {
+						inString = 0
+					}
+				case c == '"' || c == '\'':
+					inString = c
+				case c == open:
+					depth++
+				case c == close:
+					depth--
+				}
+				j++
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("unterminated expression in %q", expr)
+			}
+			segs = append(segs, segment{code: expr[start : j-1], isExpr: true, isBlock: open == '{'})
+			i = j
+			continue
+		}
+
+		start := i
+		for i < len(expr) && expr[i] != '$' {
+			i++
+		}
+		if i > start {
+			segs = append(segs, segment{code: expr[start:i]})
+			continue
+		}
+		// expr[i] == '$' but not followed by '(' or '{'.
+		segs = append(segs, segment{code: "$"})
+		i++
+	}
+	return segs, nil
+}
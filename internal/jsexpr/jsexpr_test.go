@@ -0,0 +1,88 @@
+package jsexpr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateLiteral(t *testing.T) {
+	v, err := Evaluate("no expressions here", Context{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "no expressions here" {
+		t.Fatalf("got %v", v)
+	}
+}
+
+func TestEvaluateSingleExpressionReturnsNativeType(t *testing.T) {
+	v, err := Evaluate("$(self.size)", Context{Self: map[string]interface{}{"size": int64(42)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v.(int64); !ok || n != 42 {
+		t.Fatalf("got %#v", v)
+	}
+}
+
+func TestEvaluateInterpolatesIntoString(t *testing.T) {
+	v, err := Evaluate("result_$(inputs.name).txt", Context{Inputs: map[string]interface{}{"name": "sample1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "result_sample1.txt" {
+		t.Fatalf("got %v", v)
+	}
+}
+
+func TestEvaluateFunctionBody(t *testing.T) {
+	v, err := Evaluate("${ return self.basename + '.bai'; }", Context{Self: map[string]interface{}{"basename": "aligned.bam"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "aligned.bam.bai" {
+		t.Fatalf("got %v", v)
+	}
+}
+
+func TestEvaluateUsesRuntime(t *testing.T) {
+	v, err := Evaluate("$(runtime.outdir)", Context{Runtime: map[string]interface{}{"outdir": "/tmp/job-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "/tmp/job-1" {
+		t.Fatalf("got %v", v)
+	}
+}
+
+func TestEvaluateNestedParens(t *testing.T) {
+	v, err := Evaluate("$(Math.max(1, 2))", Context{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v.(int64); !ok || n != 2 {
+		t.Fatalf("got %#v", v)
+	}
+}
+
+func TestEvaluateArrayResult(t *testing.T) {
+	v, err := Evaluate("$([self, self])", Context{Self: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, []interface{}{"x", "x"}) {
+		t.Fatalf("got %#v", v)
+	}
+}
+
+func TestEvaluateSyntaxErrorPropagates(t *testing.T) {
+	if _, err := Evaluate("$(this is not valid js)", Context{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestEvaluateUnterminatedExpression(t *testing.T) {
+	if _, err := Evaluate("$(self.size", Context{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,116 @@
+// Package listing implements CWL LoadListingRequirement's directory
+// listing collection: given a local directory, it builds the "listing"
+// array a Directory output object carries, honoring the "no_listing",
+// "shallow_listing", and "deep_listing" modes.
+package listing
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Mode is one of CWL's LoadListingRequirement values.
+type Mode string
+
+const (
+	NoListing      Mode = "no_listing"
+	ShallowListing Mode = "shallow_listing"
+	DeepListing    Mode = "deep_listing"
+)
+
+// Entry is one File or Directory discovered while listing a directory.
+type Entry struct {
+	Class    string
+	Path     string
+	Basename string
+	Size     int64
+	Contents string
+	// Listing holds e's own children, populated only for Directory
+	// entries discovered under DeepListing.
+	Listing []Entry
+}
+
+// Collect lists dir according to mode. NoListing returns (nil, nil)
+// without touching the filesystem. ShallowListing lists dir's immediate
+// children only; DeepListing recurses into every child Directory,
+// guarding against symlink cycles by tracking each directory's resolved
+// real path and refusing to descend into one already visited.
+//
+// When loadContentsLimit is greater than zero, each File entry's first
+// loadContentsLimit bytes are read into Contents, matching CWL's
+// loadContents behavior; zero disables it. The worker does not currently
+// request this (see cmd/worker/outputjson.go), but Collect supports it so
+// a future per-output loadContents flag has no listing-side work to do.
+func Collect(dir string, mode Mode, loadContentsLimit int64) ([]Entry, error) {
+	if mode == NoListing {
+		return nil, nil
+	}
+	return collect(dir, mode, loadContentsLimit, map[string]bool{})
+}
+
+func collect(dir string, mode Mode, loadContentsLimit int64, visited map[string]bool) ([]Entry, error) {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		real = dir
+	}
+	if visited[real] {
+		return nil, nil
+	}
+	visited[real] = true
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", dir, err)
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		path := filepath.Join(dir, de.Name())
+
+		if de.IsDir() {
+			entry := Entry{Class: "Directory", Path: path, Basename: de.Name()}
+			if mode == DeepListing {
+				nested, err := collect(path, mode, loadContentsLimit, visited)
+				if err != nil {
+					return nil, err
+				}
+				entry.Listing = nested
+			}
+			entries = append(entries, entry)
+			continue
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", path, err)
+		}
+		entry := Entry{Class: "File", Path: path, Basename: de.Name(), Size: info.Size()}
+		if loadContentsLimit > 0 {
+			contents, err := readContents(path, loadContentsLimit)
+			if err != nil {
+				return nil, err
+			}
+			entry.Contents = contents
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readContents reads up to limit bytes of path.
+func readContents(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, limit)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
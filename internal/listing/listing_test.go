@@ -0,0 +1,115 @@
+package listing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mkfile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectNoListing(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+
+	entries, err := Collect(dir, NoListing, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries != nil {
+		t.Fatalf("entries = %+v, want nil", entries)
+	}
+}
+
+func TestCollectShallowListingDoesNotRecurse(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mkfile(t, filepath.Join(sub, "b.txt"))
+
+	entries, err := Collect(dir, ShallowListing, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	for _, e := range entries {
+		if e.Class == "Directory" && e.Listing != nil {
+			t.Fatalf("shallow listing recursed into %+v", e)
+		}
+	}
+}
+
+func TestCollectDeepListingRecurses(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mkfile(t, filepath.Join(sub, "b.txt"))
+
+	entries, err := Collect(dir, DeepListing, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Class != "Directory" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if len(entries[0].Listing) != 1 || entries[0].Listing[0].Basename != "b.txt" {
+		t.Fatalf("nested listing = %+v", entries[0].Listing)
+	}
+}
+
+func TestCollectDeepListingGuardsSymlinkCycles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	loop := filepath.Join(sub, "loop")
+	if err := os.Symlink(dir, loop); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	done := make(chan struct{})
+	var entries []Entry
+	var err error
+	go func() {
+		entries, err = Collect(dir, DeepListing, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Collect did not terminate, symlink cycle not guarded")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
+
+func TestCollectAppliesLoadContentsLimit(t *testing.T) {
+	dir := t.TempDir()
+	mkfile(t, filepath.Join(dir, "a.txt"))
+
+	entries, err := Collect(dir, ShallowListing, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Contents != "da" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
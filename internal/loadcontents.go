@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrLoadContentsLimitExceeded is returned by LoadContents when path's
+// size, as reported by the backend, is greater than the requested limit.
+// A file already staged locally can have loadContents silently truncate
+// its first limit bytes (see internal/listing.readContents), since
+// reading it costs nothing extra either way; for a file still living in a
+// remote backend, reading a whole multi-gigabyte object just to discover
+// it's too large to use would defeat the point of loadContents, so
+// LoadContents instead checks the backend's reported size up front and
+// fails deterministically instead of truncating.
+var ErrLoadContentsLimitExceeded = errors.New("file exceeds loadContents size limit")
+
+// LoadContents reads up to limit bytes of path from fm for CWL's
+// loadContents behavior. When fm can report path's size (via Stat) and it
+// exceeds limit, LoadContents fails with ErrLoadContentsLimitExceeded
+// without reading any of the object's content. When fm implements
+// RangePrefixReader, only the requested prefix is ever transferred, so an
+// S3-located output doesn't require a full download just to populate its
+// Contents field.
+func LoadContents(fm FileManager, path string, limit int64) (string, error) {
+	if size, exists, err := fm.Stat(path); err == nil && exists && size > limit {
+		return "", fmt.Errorf("%s: %w (size %d > limit %d)", path, ErrLoadContentsLimitExceeded, size, limit)
+	}
+
+	var r io.ReadCloser
+	var err error
+	if ranged, ok := fm.(RangePrefixReader); ok {
+		r, err = ranged.OpenRangePrefix(path, limit)
+	} else {
+		r, err = fm.Open(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	buf := make([]byte, limit)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadContentsUsesRangePrefixWhenAvailable(t *testing.T) {
+	api := newFakeS3API()
+	api.objects["bucket"] = map[string]fakeS3Object{
+		"small.txt": {data: []byte("hello world")},
+	}
+	fm := NewS3FileManager(api)
+
+	got, err := LoadContents(fm, "s3://bucket/small.txt", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello world" {
+		t.Fatalf("LoadContents() = %q, want %q", got, "hello world")
+	}
+}
+
+func TestLoadContentsFailsDeterministicallyWhenObjectExceedsLimit(t *testing.T) {
+	api := newFakeS3API()
+	api.objects["bucket"] = map[string]fakeS3Object{
+		"big.txt": {data: make([]byte, 128)},
+	}
+	fm := NewS3FileManager(api)
+
+	_, err := LoadContents(fm, "s3://bucket/big.txt", 64)
+	if !errors.Is(err, ErrLoadContentsLimitExceeded) {
+		t.Fatalf("LoadContents() error = %v, want ErrLoadContentsLimitExceeded", err)
+	}
+}
+
+func TestLoadContentsFallsBackToOpenWithoutRangeSupport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("plain local content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadContents(NewLocalFileManager(), path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "plain local content" {
+		t.Fatalf("LoadContents() = %q, want %q", got, "plain local content")
+	}
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalFileManager implements FileManager against a shared filesystem (e.g.
+// an NFS mount visible to both the server and every worker).
+type LocalFileManager struct{}
+
+// NewLocalFileManager returns a FileManager backed by the local filesystem.
+func NewLocalFileManager() *LocalFileManager {
+	return &LocalFileManager{}
+}
+
+func (m *LocalFileManager) Download(src, dst string) error {
+	return CopyFile(src, dst)
+}
+
+func (m *LocalFileManager) Upload(src, dst string) error {
+	return CopyFile(src, dst)
+}
+
+func (m *LocalFileManager) DownloadWithOptions(src, dst string, opts CopyOptions) error {
+	return CopyFileWithOptions(src, dst, opts)
+}
+
+func (m *LocalFileManager) UploadWithOptions(src, dst string, opts CopyOptions) error {
+	return CopyFileWithOptions(src, dst, opts)
+}
+
+func (m *LocalFileManager) Copy(src, dst string) error {
+	return CopyFile(src, dst)
+}
+
+func (m *LocalFileManager) Remove(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (m *LocalFileManager) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func (m *LocalFileManager) Stat(path string) (int64, bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return info.Size(), true, nil
+}
+
+// ensure the directory for path exists before a write.
+func ensureParentDir(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0o755)
+}
@@ -0,0 +1,47 @@
+// Package logging provides the worker and cwlclient's leveled, structured
+// logger. It wraps log/slog so call sites attach job/tool/worker fields
+// consistently instead of mixing fmt.Println and log.Default().Printf with
+// ad-hoc formatting.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Format selects the on-disk/console representation of log records.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// New returns a slog.Logger writing to os.Stderr at minLevel in the given
+// format. Callers add per-job context with WithJob rather than constructing
+// a new logger.
+func New(minLevel slog.Level, format Format) *slog.Logger {
+	return NewWithWriter(minLevel, format, os.Stderr)
+}
+
+// NewWithWriter is New with the destination made explicit, for callers
+// that need their own log written somewhere other than stderr (e.g. a
+// RotatingFile) rather than relying on an external log rotation daemon.
+func NewWithWriter(minLevel slog.Level, format Format, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: minLevel}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(handler)
+}
+
+// WithJob returns a logger with jobId, tool, and worker fields attached to
+// every record it emits, so a job's log lines can be grepped out of a
+// worker's combined output.
+func WithJob(l *slog.Logger, jobID, tool, worker string) *slog.Logger {
+	return l.With("jobId", jobID, "tool", tool, "worker", worker)
+}
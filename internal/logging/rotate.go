@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an io.WriteCloser over a log file that rotates itself by
+// size, for hosts running the worker without an external rotation daemon
+// (e.g. logrotate) already watching its log. Once a write would push the
+// file past maxBytes, the current file is renamed to a ".1" suffix
+// (shifting any earlier ".N" backups up by one, dropping whichever falls
+// past maxBackups) and a fresh file opened in its place.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+// NewRotatingFile opens (creating if necessary) path for appending, ready
+// to rotate once it grows past maxBytes. A maxBackups of 0 rotates by
+// truncating rather than keeping any history.
+func NewRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &RotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups, f: f, size: info.Size()}, nil
+}
+
+// Write implements io.Writer, rotating first if p would push the file past
+// maxBytes. A single write is never itself split across the rotation
+// boundary, so one record always lands entirely in one file or the next.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, fmt.Errorf("rotating log file %s: %w", r.path, err)
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+	}
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, backupName(r.path, 1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		// No history kept: start the new file empty rather than
+		// appending to content that's already past the size limit.
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(r.path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	r.f = f
+	r.size = 0
+	return nil
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Close closes the underlying file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileRotatesOnceOverLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "worker.log")
+
+	rf, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	if _, err := rf.Write([]byte("1234567890")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rf.Write([]byte("next")); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("expected a .1 backup after rotation: %v", err)
+	}
+	if string(backup) != "1234567890" {
+		t.Errorf("backup content = %q, want the full first write", backup)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != "next" {
+		t.Errorf("current content = %q, want %q", current, "next")
+	}
+}
+
+func TestRotatingFileDropsOldestBackupPastMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "worker.log")
+
+	rf, err := NewRotatingFile(path, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"a", "b", "c"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backup, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != "b" {
+		t.Errorf("backup content = %q, want the second write (only one backup kept)", backup)
+	}
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Error("expected no .2 backup when maxBackups is 1")
+	}
+}
+
+func TestRotatingFileZeroMaxBackupsTruncatesOnly(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "worker.log")
+
+	rf, err := NewRotatingFile(path, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	if _, err := rf.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rf.Write([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("expected no backup file when maxBackups is 0")
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != "b" {
+		t.Errorf("current content = %q, want %q", current, "b")
+	}
+}
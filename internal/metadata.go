@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"os"
+)
+
+// CopyOptions controls which metadata CopyFile and CopyDir preserve beyond
+// the file mode, which is always preserved.
+type CopyOptions struct {
+	// PreserveTimestamps applies the source's mtime (and atime) to the
+	// copy, matching tools that check input mtimes (e.g. make-style
+	// build rules) rather than relying on checksum alone.
+	PreserveTimestamps bool
+	// PreserveXattrs copies "user." namespace extended attributes from
+	// the source onto the copy.
+	PreserveXattrs bool
+	// Parallelism bounds how many files CopyDirWithOptions copies
+	// concurrently. Zero uses copyDirDefaultParallelism.
+	Parallelism int
+	// Progress, when set, is called after each file in a CopyDir tree
+	// finishes copying, with the running count and the total file count,
+	// so staging of large reference bundles can be observed.
+	Progress func(copied, total int64)
+	// SymlinkPolicy, when set, validates every symlink CopyDirWithOptions
+	// encounters before recreating it at the destination; a symlink whose
+	// resolved target the policy rejects fails the copy instead of being
+	// silently recreated. Nil skips validation entirely, preserving the
+	// historical behavior of copying every symlink as-is.
+	SymlinkPolicy *SymlinkPolicy
+}
+
+// applyMetadata applies info's mtime/atime and src's user xattrs to dst
+// according to opts. It is best-effort for xattrs: a filesystem that does
+// not support them should not fail an otherwise successful copy.
+func applyMetadata(src, dst string, info os.FileInfo, opts CopyOptions) error {
+	if opts.PreserveTimestamps {
+		mtime := info.ModTime()
+		if err := os.Chtimes(dst, mtime, mtime); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveXattrs {
+		copyXattrs(src, dst)
+	}
+	return nil
+}
+
+// splitXattrNames splits the NUL-separated name list returned by
+// syscall.Listxattr, keeping only the "user." namespace.
+func splitXattrNames(buf []byte) []string {
+	var names []string
+	start := 0
+	for i, b := range buf {
+		if b != 0 {
+			continue
+		}
+		name := string(buf[start:i])
+		start = i + 1
+		if len(name) >= 5 && name[:5] == "user." {
+			names = append(names, name)
+		}
+	}
+	return names
+}
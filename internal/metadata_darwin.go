@@ -0,0 +1,7 @@
+package internal
+
+// copyXattrs is a no-op on Darwin: the stdlib syscall package doesn't
+// expose Listxattr/Getxattr/Setxattr there (only golang.org/x/sys/unix
+// does, which this module avoids depending on). PreserveXattrs is
+// documented as best-effort, so silently skipping is acceptable here.
+func copyXattrs(src, dst string) {}
@@ -0,0 +1,30 @@
+package internal
+
+import "syscall"
+
+// copyXattrs copies every "user." namespace extended attribute from src to
+// dst. Errors are ignored: xattr support varies by filesystem and must
+// never turn a successful data copy into a failed one.
+func copyXattrs(src, dst string) {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil || size <= 0 {
+		return
+	}
+	names := make([]byte, size)
+	n, err := syscall.Listxattr(src, names)
+	if err != nil {
+		return
+	}
+	for _, name := range splitXattrNames(names[:n]) {
+		valSize, err := syscall.Getxattr(src, name, nil)
+		if err != nil || valSize <= 0 {
+			continue
+		}
+		val := make([]byte, valSize)
+		vn, err := syscall.Getxattr(src, name, val)
+		if err != nil {
+			continue
+		}
+		_ = syscall.Setxattr(dst, name, val[:vn], 0)
+	}
+}
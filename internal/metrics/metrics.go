@@ -0,0 +1,144 @@
+// Package metrics collects the worker's operational counters and exposes
+// them in the Prometheus text exposition format over HTTP, without pulling
+// in the full client_golang dependency graph for what is, today, a small
+// fixed set of gauges and counters.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value, such as jobs_succeeded_total.
+type Counter struct {
+	name, help string
+	mu         sync.Mutex
+	values     map[string]*int64 // keyed by serialized labels, "" for unlabeled
+}
+
+func newCounter(name, help string) *Counter {
+	return &Counter{name: name, help: help, values: map[string]*int64{}}
+}
+
+// Inc increments the counter for the given label values (name/value pairs),
+// creating that label combination on first use.
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add increments the counter for the given label values by delta.
+func (c *Counter) Add(delta int64, labels ...string) {
+	key := labelKey(labels)
+	c.mu.Lock()
+	v, ok := c.values[key]
+	if !ok {
+		var zero int64
+		v = &zero
+		c.values[key] = v
+	}
+	c.mu.Unlock()
+	atomic.AddInt64(v, delta)
+}
+
+func (c *Counter) writeTo(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s %d\n", c.name, k, atomic.LoadInt64(c.values[k]))
+	}
+}
+
+// Gauge is a value that can go up or down, such as jobs_running.
+type Gauge struct {
+	name, help string
+	value      int64
+}
+
+func newGauge(name, help string) *Gauge {
+	return &Gauge{name: name, help: help}
+}
+
+func (g *Gauge) Inc()        { atomic.AddInt64(&g.value, 1) }
+func (g *Gauge) Dec()        { atomic.AddInt64(&g.value, -1) }
+func (g *Gauge) Set(v int64) { atomic.StoreInt64(&g.value, v) }
+
+func (g *Gauge) writeTo(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.name, g.help, g.name, g.name, atomic.LoadInt64(&g.value))
+}
+
+// labelKey renders label name/value pairs as Prometheus's "{k="v",...}"
+// label suffix, or "" when there are no labels.
+func labelKey(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", labels[i], labels[i+1])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Registry is the set of metrics the worker exposes on /metrics.
+var (
+	JobsRunning             = newGauge("flowy_worker_jobs_running", "Number of jobs currently executing on this worker.")
+	JobsSucceededTotal      = newCounter("flowy_worker_jobs_succeeded_total", "Number of jobs that completed successfully.")
+	JobsFailedTotal         = newCounter("flowy_worker_jobs_failed_total", "Number of jobs that failed to execute or reported a non-zero exit.")
+	StagingBytesTotal       = newCounter("flowy_worker_staging_bytes_total", "Bytes copied into job workdirs while staging inputs.")
+	StagingSeconds          = newCounter("flowy_worker_staging_duration_seconds_sum", "Cumulative seconds spent staging job inputs.")
+	UploadBytesTotal        = newCounter("flowy_worker_upload_bytes_total", "Bytes uploaded while publishing job outputs.")
+	UploadSeconds           = newCounter("flowy_worker_upload_duration_seconds_sum", "Cumulative seconds spent uploading job outputs.")
+	S3APICallsTotal         = newCounter("flowy_worker_s3_api_calls_total", "Number of S3 API calls issued, labeled by operation.")
+	QueueWaitSeconds        = newCounter("flowy_worker_queue_wait_seconds_sum", "Cumulative seconds jobs spent queued before this worker picked them up.")
+	TransferErrorsTotal     = newCounter("flowy_worker_transfer_errors_total", "Transfer failures, labeled by backend.")
+	TotalMemoryBytes        = newGauge("flowy_worker_total_memory_bytes", "Total physical memory installed on this worker's host.")
+	PipeBytesTotal          = newCounter("flowy_worker_pipe_bytes_total", "Bytes broadcast from a streamable output to its pipe consumers.")
+	PipeActiveConsumers     = newGauge("flowy_worker_pipe_active_consumers", "Number of streaming pipe consumers currently connected to this worker.")
+	S3RetriesTotal          = newCounter("flowy_worker_s3_retries_total", "Number of S3 API calls retried after a failure or timeout, labeled by operation.")
+	S3CircuitBreakerOpen    = newGauge("flowy_worker_s3_circuit_breaker_open", "Whether the S3 circuit breaker is currently open (1) or closed (0), short-circuiting calls to a backend that has been failing.")
+	FileManagerCallsTotal   = newCounter("flowy_worker_filemanager_calls_total", "Number of FileManager calls issued, labeled by backend and operation.")
+	FileManagerSecondsTotal = newCounter("flowy_worker_filemanager_duration_seconds_sum", "Cumulative seconds spent in FileManager calls, labeled by backend and operation.")
+	FileManagerErrorsTotal  = newCounter("flowy_worker_filemanager_errors_total", "Number of FileManager calls that returned an error, labeled by backend and operation.")
+)
+
+// Handler serves every registered metric in the Prometheus text exposition
+// format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		JobsRunning.writeTo(w)
+		JobsSucceededTotal.writeTo(w)
+		JobsFailedTotal.writeTo(w)
+		StagingBytesTotal.writeTo(w)
+		StagingSeconds.writeTo(w)
+		UploadBytesTotal.writeTo(w)
+		UploadSeconds.writeTo(w)
+		S3APICallsTotal.writeTo(w)
+		QueueWaitSeconds.writeTo(w)
+		TransferErrorsTotal.writeTo(w)
+		TotalMemoryBytes.writeTo(w)
+		PipeBytesTotal.writeTo(w)
+		PipeActiveConsumers.writeTo(w)
+		S3RetriesTotal.writeTo(w)
+		S3CircuitBreakerOpen.writeTo(w)
+		FileManagerCallsTotal.writeTo(w)
+		FileManagerSecondsTotal.writeTo(w)
+		FileManagerErrorsTotal.writeTo(w)
+	})
+}
@@ -0,0 +1,105 @@
+// Package outputschema validates and coerces a job's collected output
+// values against the tool's declared CWL output types before they are
+// reported back to the server, so a tool that wrote a malformed
+// cwl.output.json fails clearly, with the offending key path, instead of
+// the malformed value being forwarded upstream unchanged.
+package outputschema
+
+import "fmt"
+
+// Type is a (possibly nested) CWL output type: a scalar's own name
+// ("File", "Directory", "string", "int", "long", "float", "double", or
+// "boolean"), an array's element type, and whether a missing value is
+// allowed.
+type Type struct {
+	Name     string
+	Items    *Type
+	Optional bool
+}
+
+// Validate checks value against t and returns its coerced form, or an
+// error naming path (e.g. "bam" or "bams[2]") and what was wrong. JSON
+// decodes every number as float64; Validate coerces int/long outputs back
+// to int64 so a downstream consumer sees the type CWL declared rather than
+// JSON's single untyped number representation.
+func Validate(value interface{}, t Type, path string) (interface{}, error) {
+	if value == nil {
+		if t.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: missing required output", path)
+	}
+
+	switch t.Name {
+	case "File":
+		return validateClass(value, "File", path)
+	case "Directory":
+		return validateClass(value, "Directory", path)
+	case "string":
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("%s: want string, got %T", path, value)
+		}
+		return value, nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("%s: want boolean, got %T", path, value)
+		}
+		return value, nil
+	case "int", "long":
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s: want %s, got %T", path, t.Name, value)
+		}
+		if f != float64(int64(f)) {
+			return nil, fmt.Errorf("%s: want %s, got non-integer %v", path, t.Name, f)
+		}
+		return int64(f), nil
+	case "float", "double":
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s: want %s, got %T", path, t.Name, value)
+		}
+		return f, nil
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: want array, got %T", path, value)
+		}
+		if t.Items == nil {
+			return value, nil
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := Validate(item, *t.Items, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// validateClass checks value is a File/Directory object of the given
+// class, with enough structure (a class field matching, and for File a
+// location or path) for it to be usable downstream.
+func validateClass(value interface{}, class, path string) (interface{}, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: want %s object, got %T", path, class, value)
+	}
+	got, _ := obj["class"].(string)
+	if got != class {
+		return nil, fmt.Errorf("%s: want class %q, got %q", path, class, got)
+	}
+	if class == "File" {
+		loc, _ := obj["location"].(string)
+		p, _ := obj["path"].(string)
+		if loc == "" && p == "" {
+			return nil, fmt.Errorf("%s: File object has neither location nor path", path)
+		}
+	}
+	return obj, nil
+}
@@ -0,0 +1,69 @@
+package outputschema
+
+import "testing"
+
+func TestValidateCoercesIntFromFloat64(t *testing.T) {
+	v, err := Validate(float64(3), Type{Name: "int"}, "count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != int64(3) {
+		t.Fatalf("v = %#v, want int64(3)", v)
+	}
+}
+
+func TestValidateRejectsNonIntegerForIntType(t *testing.T) {
+	if _, err := Validate(float64(3.5), Type{Name: "int"}, "count"); err == nil {
+		t.Fatal("expected error for non-integer int value")
+	}
+}
+
+func TestValidateRequiresLocationOrPathOnFile(t *testing.T) {
+	value := map[string]interface{}{"class": "File", "basename": "a.txt"}
+	if _, err := Validate(value, Type{Name: "File"}, "out"); err == nil {
+		t.Fatal("expected error for File with no location or path")
+	}
+}
+
+func TestValidateAcceptsFileWithLocation(t *testing.T) {
+	value := map[string]interface{}{"class": "File", "location": "s3://bucket/a.txt"}
+	v, err := Validate(value, Type{Name: "File"}, "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.(map[string]interface{})["class"] != "File" {
+		t.Fatalf("v = %#v", v)
+	}
+}
+
+func TestValidateMissingRequiredValueErrors(t *testing.T) {
+	if _, err := Validate(nil, Type{Name: "string"}, "name"); err == nil {
+		t.Fatal("expected error for missing required output")
+	}
+}
+
+func TestValidateOptionalMissingValueIsNil(t *testing.T) {
+	v, err := Validate(nil, Type{Name: "string", Optional: true}, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Fatalf("v = %#v, want nil", v)
+	}
+}
+
+func TestValidateArrayWithPathQualifiedElementError(t *testing.T) {
+	items := []interface{}{"a", float64(2)}
+	itemType := Type{Name: "string"}
+	_, err := Validate(items, Type{Name: "array", Items: &itemType}, "names")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), "names[1]:"; !containsPrefix(got, want) {
+		t.Fatalf("error %q does not mention path %q", got, want)
+	}
+}
+
+func containsPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) && s[:len(prefix)] == prefix
+}
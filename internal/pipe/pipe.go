@@ -0,0 +1,218 @@
+// Package pipe implements the transport for CWL streamable File fan-out:
+// broadcasting one producer's bytes to any number of consumers over TCP,
+// so a streamable output on one worker can feed a streamable input on
+// another without materializing the whole file on disk on either side
+// first, and without every worker needing to be reachable by every other
+// one except through the addresses the server itself hands out.
+package pipe
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// DefaultBufferSize is the chunk size Serve reads from its source in,
+// matching io.Copy's own default so callers that don't care about tuning
+// this see the same behavior as before ServeWithBufferSize existed.
+const DefaultBufferSize = 32 * 1024
+
+// defaultQueueDepth is how many writes a consumer may fall behind the
+// pace Write is called at before Write itself blocks waiting for that
+// consumer to catch up. It bounds how much of a slow consumer's backlog
+// Broadcaster holds in memory, rather than buffering it without limit.
+const defaultQueueDepth = 8
+
+// Broadcaster is an io.WriteCloser that fans every Write out to each
+// registered consumer. A consumer added after bytes have already been
+// written only sees what's written from that point on; callers that need
+// every consumer to see the whole stream must register them all before
+// the first Write, which is exactly what Serve does.
+//
+// Each consumer is drained by its own goroutine, so one slow consumer
+// delays only the delivery of writes already queued for it, not delivery
+// to any other consumer. Write still applies backpressure: once a
+// consumer's own queue is full, Write blocks until that consumer catches
+// up, rather than buffering the whole stream in memory on its behalf.
+type Broadcaster struct {
+	mu         sync.Mutex
+	consumers  []*broadcastConsumer
+	queueDepth int
+}
+
+type broadcastConsumer struct {
+	w    io.WriteCloser
+	ch   chan []byte
+	done chan struct{}
+
+	errMu sync.Mutex
+	err   error
+}
+
+// NewBroadcaster returns a Broadcaster with no consumers registered yet,
+// using defaultQueueDepth as its per-consumer backpressure limit.
+func NewBroadcaster() *Broadcaster {
+	return NewBroadcasterWithQueueDepth(defaultQueueDepth)
+}
+
+// NewBroadcasterWithQueueDepth is like NewBroadcaster, but lets callers
+// tune how many writes a consumer may lag behind before Write blocks on
+// it. A depth below 1 is treated as 1, the minimum that still lets one
+// consumer be mid-write while another is queued.
+func NewBroadcasterWithQueueDepth(depth int) *Broadcaster {
+	if depth < 1 {
+		depth = 1
+	}
+	return &Broadcaster{queueDepth: depth}
+}
+
+// Add registers w as a consumer of future Writes and starts the goroutine
+// that drains writes queued for it.
+func (b *Broadcaster) Add(w io.WriteCloser) {
+	c := &broadcastConsumer{w: w, ch: make(chan []byte, b.queueDepth), done: make(chan struct{})}
+	b.mu.Lock()
+	b.consumers = append(b.consumers, c)
+	b.mu.Unlock()
+
+	metrics.PipeActiveConsumers.Inc()
+	go c.drain()
+}
+
+// drain writes everything queued for c to its underlying writer until its
+// channel is closed or a Write fails. On failure it keeps consuming (and
+// discarding) anything still queued so a slow-to-notice Broadcaster.Write
+// never blocks forever on a consumer that has already given up.
+func (c *broadcastConsumer) drain() {
+	defer close(c.done)
+	for p := range c.ch {
+		if c.failed() {
+			continue
+		}
+		if _, err := c.w.Write(p); err != nil {
+			c.setErr(err)
+			continue
+		}
+		metrics.PipeBytesTotal.Add(int64(len(p)))
+	}
+}
+
+func (c *broadcastConsumer) setErr(err error) {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *broadcastConsumer) failed() bool {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	return c.err != nil
+}
+
+// Write fans p out to every registered consumer and returns once each has
+// either accepted it into its queue or already failed. It returns the
+// first error reported by any consumer, but a failing consumer never
+// prevents delivery to the others.
+func (b *Broadcaster) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	consumers := append([]*broadcastConsumer(nil), b.consumers...)
+	b.mu.Unlock()
+
+	// Consumers run on independent goroutines and may still be writing
+	// p's backing array after Write returns, so each queued chunk needs
+	// its own copy rather than sharing the caller's buffer.
+	chunk := append([]byte(nil), p...)
+
+	var firstErr error
+	for _, c := range consumers {
+		if err := c.firstErr(); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		c.ch <- chunk
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return len(p), nil
+}
+
+func (c *broadcastConsumer) firstErr() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	return c.err
+}
+
+// Close closes every registered consumer, returning the first error any
+// of them reports, whether from an earlier Write or from Close itself. It
+// waits for each consumer's drain goroutine to finish first, so no write
+// is still in flight when Close returns.
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	consumers := append([]*broadcastConsumer(nil), b.consumers...)
+	b.mu.Unlock()
+
+	var firstErr error
+	for _, c := range consumers {
+		close(c.ch)
+		<-c.done
+		if err := c.w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := c.firstErr(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		metrics.PipeActiveConsumers.Dec()
+	}
+	return firstErr
+}
+
+// Serve accepts exactly consumers connections on ln, then copies source
+// into all of them at once via a Broadcaster, so every consumer receives
+// the same bytes as they're produced rather than each waiting for source
+// to finish first. It returns once source is fully copied or copying to
+// any consumer fails; ln itself is left open for the caller to close.
+func Serve(ln net.Listener, source io.Reader, consumers int) error {
+	return ServeWithBufferSize(ln, source, consumers, DefaultBufferSize)
+}
+
+// ServeWithBufferSize is Serve with the chunk size it reads source in
+// made explicit, for callers that need to trade off latency (a consumer
+// sees a chunk only once it's been read in full) against syscall
+// overhead. bufferSize below 1 falls back to DefaultBufferSize.
+func ServeWithBufferSize(ln net.Listener, source io.Reader, consumers, bufferSize int) error {
+	if consumers < 1 {
+		return fmt.Errorf("pipe.Serve: consumers must be at least 1, got %d", consumers)
+	}
+	if bufferSize < 1 {
+		bufferSize = DefaultBufferSize
+	}
+
+	b := NewBroadcaster()
+	for i := 0; i < consumers; i++ {
+		conn, err := ln.Accept()
+		if err != nil {
+			b.Close()
+			return fmt.Errorf("pipe.Serve: accepting consumer %d/%d: %w", i+1, consumers, err)
+		}
+		b.Add(conn)
+	}
+
+	_, err := io.CopyBuffer(b, source, make([]byte, bufferSize))
+	if closeErr := b.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Dial connects to a producer's Serve at addr, returning the connection as
+// the consumer's read side of the stream.
+func Dial(addr string) (net.Conn, error) {
+	return net.Dial("tcp", addr)
+}
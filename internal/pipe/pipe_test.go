@@ -0,0 +1,231 @@
+package pipe
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeBuffer) Close() error {
+	c.closed = true
+	return nil
+}
+
+// lockedCloseBuffer is closeBuffer with its Write/String/Len guarded by a
+// mutex, for tests that read from a consumer while a broadcastConsumer's
+// drain goroutine may still be writing to it concurrently - a plain
+// closeBuffer is only safe to read once the writer side is known to be
+// done (e.g. after Close returns).
+type lockedCloseBuffer struct {
+	mu  sync.Mutex
+	buf closeBuffer
+}
+
+func (c *lockedCloseBuffer) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
+
+func (c *lockedCloseBuffer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Close()
+}
+
+func (c *lockedCloseBuffer) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.String()
+}
+
+func (c *lockedCloseBuffer) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Len()
+}
+
+func TestBroadcasterFansOutToEveryConsumer(t *testing.T) {
+	a, b := &closeBuffer{}, &closeBuffer{}
+	bc := NewBroadcaster()
+	bc.Add(a)
+	bc.Add(b)
+
+	if _, err := bc.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.String() != "hello" || b.String() != "hello" {
+		t.Fatalf("expected both consumers to see the write, got %q and %q", a.String(), b.String())
+	}
+	if !a.closed || !b.closed {
+		t.Fatal("expected Close to close every consumer")
+	}
+}
+
+func TestServeStreamsToEveryDialedConsumer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	const consumers = 2
+	source := strings.NewReader("streamed output")
+
+	done := make(chan error, 1)
+	go func() { done <- Serve(ln, source, consumers) }()
+
+	var wg sync.WaitGroup
+	results := make([]string, consumers)
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := Dial(ln.Addr().String())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			got, err := io.ReadAll(conn)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = string(got)
+		}(i)
+	}
+	wg.Wait()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for i, got := range results {
+		if got != "streamed output" {
+			t.Errorf("consumer %d: got %q, want %q", i, got, "streamed output")
+		}
+	}
+}
+
+func TestServeRejectsFewerThanOneConsumer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if err := Serve(ln, strings.NewReader(""), 0); err == nil {
+		t.Fatal("expected an error for zero consumers")
+	}
+}
+
+// blockingWriter never completes a Write until release is closed, so it
+// stands in for a slow consumer without needing a real slow network peer.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func (w *blockingWriter) Close() error { return nil }
+
+func TestBroadcasterSlowConsumerDoesNotBlockFastOne(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := &lockedCloseBuffer{}
+
+	bc := NewBroadcaster()
+	bc.Add(slow)
+	bc.Add(fast)
+
+	if _, err := bc.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.After(time.Second)
+	for fast.String() != "hello" {
+		select {
+		case <-deadline:
+			t.Fatal("fast consumer never received the write while the slow one was blocked")
+		default:
+		}
+	}
+
+	close(slow.release)
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBroadcasterFailingConsumerDoesNotBlockWriteQueue(t *testing.T) {
+	bc := NewBroadcasterWithQueueDepth(1)
+	bc.Add(&failingWriter{})
+	ok := &lockedCloseBuffer{}
+	bc.Add(ok)
+
+	var sawErr bool
+	for i := 0; i < 50 && ok.Len() < 50; i++ {
+		if _, err := bc.Write([]byte("x")); err != nil {
+			sawErr = true
+		}
+	}
+	if !sawErr {
+		t.Fatal("expected the failing consumer's error to eventually surface from Write")
+	}
+
+	if err := bc.Close(); err == nil {
+		t.Fatal("expected Close to report the failing consumer's error")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errWriteFailed }
+func (failingWriter) Close() error              { return nil }
+
+var errWriteFailed = errors.New("write failed")
+
+func TestServeWithBufferSizeStreamsToEveryDialedConsumer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	source := strings.NewReader("streamed with a tiny buffer")
+	done := make(chan error, 1)
+	go func() { done <- ServeWithBufferSize(ln, source, 1, 4) }()
+
+	conn, err := Dial(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "streamed with a tiny buffer" {
+		t.Fatalf("got %q", string(got))
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
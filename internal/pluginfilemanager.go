@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// pluginRequest is one call sent to a PluginFileManager's subprocess, as a
+// single line of JSON on its stdin.
+type pluginRequest struct {
+	Op   string `json:"op"`
+	Src  string `json:"src,omitempty"`
+	Dst  string `json:"dst,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// pluginResponse is the subprocess's reply to a pluginRequest, as a single
+// line of JSON on its stdout. Download, Upload, Copy, and Remove only use
+// OK/Error; Stat also sets Size/Exists; Open sets TempPath to a local file
+// the plugin wrote the object's content to, which PluginFileManager reads
+// and cleans up on the caller's behalf.
+type pluginResponse struct {
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Exists   bool   `json:"exists,omitempty"`
+	TempPath string `json:"tempPath,omitempty"`
+}
+
+// PluginFileManager implements FileManager by delegating every call to a
+// long-lived external process over a JSON-over-stdio protocol, so a site
+// can add a proprietary storage backend (iRODS, an internal object store)
+// without forking this repo: the plugin binary only needs to speak
+// pluginRequest/pluginResponse, in whatever language is convenient.
+//
+// Download, Upload, Copy, and Remove's src/dst/path arguments are passed
+// through unchanged; a plugin gets to decide what scheme(s) it expects
+// them to look like (its own URLs, typically). Open works by asking the
+// plugin to stage the object's content at a local temp path the worker can
+// read directly, since the stdio protocol carries requests and replies,
+// not arbitrary byte streams.
+//
+// Calls are serialized: the protocol is one line in, one line out, so two
+// goroutines calling through the same PluginFileManager concurrently could
+// otherwise interleave their requests.
+type PluginFileManager struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+
+	mu sync.Mutex
+}
+
+// NewPluginFileManager starts command with args and returns a
+// PluginFileManager that speaks to it over stdin/stdout. The process is
+// left running until Close is called; stderr is inherited so the plugin's
+// own diagnostics reach the worker's log.
+func NewPluginFileManager(command string, args ...string) (*PluginFileManager, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting plugin %s: %w", command, err)
+	}
+
+	return &PluginFileManager{cmd: cmd, stdin: stdin, stdout: bufio.NewScanner(stdout)}, nil
+}
+
+// Close stops the plugin process, closing its stdin first so a
+// well-behaved plugin can exit on its own before it is waited on.
+func (p *PluginFileManager) Close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
+
+// call sends req to the plugin and returns its decoded response. Only one
+// call runs at a time; see PluginFileManager's doc comment.
+func (p *PluginFileManager) call(req pluginRequest) (pluginResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return pluginResponse{}, err
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		return pluginResponse{}, fmt.Errorf("writing to plugin: %w", err)
+	}
+
+	if !p.stdout.Scan() {
+		if err := p.stdout.Err(); err != nil {
+			return pluginResponse{}, fmt.Errorf("reading from plugin: %w", err)
+		}
+		return pluginResponse{}, fmt.Errorf("plugin closed its output unexpectedly")
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(p.stdout.Bytes(), &resp); err != nil {
+		return pluginResponse{}, fmt.Errorf("decoding plugin response: %w", err)
+	}
+	if !resp.OK {
+		return pluginResponse{}, fmt.Errorf("plugin: %s", resp.Error)
+	}
+	return resp, nil
+}
+
+func (p *PluginFileManager) Download(src, dst string) error {
+	_, err := p.call(pluginRequest{Op: "download", Src: src, Dst: dst})
+	return err
+}
+
+func (p *PluginFileManager) Upload(src, dst string) error {
+	_, err := p.call(pluginRequest{Op: "upload", Src: src, Dst: dst})
+	return err
+}
+
+func (p *PluginFileManager) Copy(src, dst string) error {
+	_, err := p.call(pluginRequest{Op: "copy", Src: src, Dst: dst})
+	return err
+}
+
+func (p *PluginFileManager) Remove(path string) error {
+	_, err := p.call(pluginRequest{Op: "remove", Path: path})
+	return err
+}
+
+func (p *PluginFileManager) Stat(path string) (int64, bool, error) {
+	resp, err := p.call(pluginRequest{Op: "stat", Path: path})
+	if err != nil {
+		return 0, false, err
+	}
+	return resp.Size, resp.Exists, nil
+}
+
+// Open asks the plugin to stage path's content at a local temp file, then
+// returns a reader over it that removes that temp file once closed.
+func (p *PluginFileManager) Open(path string) (io.ReadCloser, error) {
+	resp, err := p.call(pluginRequest{Op: "open", Path: path})
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(resp.TempPath)
+	if err != nil {
+		return nil, err
+	}
+	return &pluginTempFile{File: f, tempPath: resp.TempPath}, nil
+}
+
+// pluginTempFile deletes its backing temp file once closed, so a caller
+// reading a plugin-staged Open result doesn't leak one file per call.
+type pluginTempFile struct {
+	*os.File
+	tempPath string
+}
+
+func (f *pluginTempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.tempPath)
+	return err
+}
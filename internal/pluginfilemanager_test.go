@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePluginScript is a minimal stand-in plugin: it reads one JSON request
+// per line and replies to "stat" with a fixed size/exists and to anything
+// else with an error, enough to exercise PluginFileManager's protocol
+// plumbing without needing a real external backend.
+const fakePluginScript = `
+while IFS= read -r line; do
+  case "$line" in
+    *'"op":"stat"'*) printf '{"ok":true,"size":42,"exists":true}\n' ;;
+    *) printf '{"ok":false,"error":"unsupported op"}\n' ;;
+  esac
+done
+`
+
+func newFakePlugin(t *testing.T) *PluginFileManager {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(script, []byte(fakePluginScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fm, err := NewPluginFileManager("sh", script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fm.Close() })
+	return fm
+}
+
+func TestPluginFileManagerStatRoundTrips(t *testing.T) {
+	fm := newFakePlugin(t)
+
+	size, exists, err := fm.Stat("proprietary://bucket/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 42 || !exists {
+		t.Fatalf("Stat() = (%d, %v), want (42, true)", size, exists)
+	}
+}
+
+func TestPluginFileManagerSurfacesPluginErrors(t *testing.T) {
+	fm := newFakePlugin(t)
+
+	if err := fm.Remove("proprietary://bucket/key"); err == nil {
+		t.Fatal("expected Remove to surface the plugin's error response")
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stubFileManager records every call it receives, standing in for a
+// plugin-backed FileManager without needing a real external process.
+type stubFileManager struct {
+	FileManager
+	calls []string
+}
+
+func (s *stubFileManager) Download(src, dst string) error {
+	s.calls = append(s.calls, "download:"+src)
+	return nil
+}
+
+func (s *stubFileManager) Upload(src, dst string) error {
+	s.calls = append(s.calls, "upload:"+dst)
+	return nil
+}
+
+func TestRoutingFileManagerDispatchesByScheme(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+
+	proprietary := &stubFileManager{FileManager: NewLocalFileManager()}
+	router := NewFileManager(map[string]FileManager{"proprietary": proprietary}, NewLocalFileManager())
+
+	if err := router.Upload(src, "proprietary://bucket/key"); err != nil {
+		t.Fatal(err)
+	}
+	if len(proprietary.calls) != 1 || proprietary.calls[0] != "upload:proprietary://bucket/key" {
+		t.Fatalf("proprietary.calls = %v, want one upload call", proprietary.calls)
+	}
+
+	dst := filepath.Join(dir, "b.txt")
+	if err := router.Upload(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if len(proprietary.calls) != 1 {
+		t.Fatalf("a plain local path should not route to the proprietary backend, got calls %v", proprietary.calls)
+	}
+	if _, exists, err := router.Stat(dst); err != nil || !exists {
+		t.Fatalf("expected local fallback to actually write %s, exists=%v err=%v", dst, exists, err)
+	}
+}
+
+func TestRoutingFileManagerUploadDedupFallsBackWithoutDedupSupport(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0o644)
+	dst := filepath.Join(dir, "out.txt")
+
+	router := NewFileManager(nil, NewLocalFileManager())
+	location, err := router.UploadDedup(src, dst, "sha1$abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location != dst {
+		t.Fatalf("location = %q, want %q", location, dst)
+	}
+	if _, exists, err := router.Stat(dst); err != nil || !exists {
+		t.Fatalf("expected plain upload fallback to land at %s, exists=%v err=%v", dst, exists, err)
+	}
+}
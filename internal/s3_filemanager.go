@@ -0,0 +1,318 @@
+package internal
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// Object metadata keys S3FileManager uses to round-trip the file metadata
+// CopyOptions asks it to preserve, since S3 has no native mode/mtime
+// concept of its own.
+const (
+	metaKeyMode  = "flowy-mode"
+	metaKeyMtime = "flowy-mtime"
+)
+
+// S3API is the subset of the S3 client surface S3FileManager needs. It is
+// declared here, rather than importing the AWS SDK's concrete client, so
+// tests can substitute an in-memory fake.
+type S3API interface {
+	PutObject(bucket, key string, body io.Reader, size int64, metadata map[string]string) error
+	GetObject(bucket, key string) (io.ReadCloser, error)
+	// GetObjectRange returns a reader for the first length bytes of
+	// bucket/key, via an HTTP Range request, for callers (loadContents)
+	// that only need a prefix of a potentially large object.
+	GetObjectRange(bucket, key string, length int64) (io.ReadCloser, error)
+	GetObjectMetadata(bucket, key string) (map[string]string, error)
+	CopyObject(bucket, srcKey, dstKey string) error
+	DeleteObject(bucket, key string) error
+	DeletePrefix(bucket, prefix string) error
+	HeadObject(bucket, key string) (size int64, exists bool, err error)
+	// PutObjectTags replaces bucket/key's tag set via S3's tagging API
+	// (distinct from PutObject's metadata, which rides along with the
+	// object body rather than being independently searchable).
+	PutObjectTags(bucket, key string, tags map[string]string) error
+}
+
+// S3CompatOptions works around quirks of non-AWS S3-compatible backends
+// (MinIO, Ceph RGW) that implement the same API surface but not quite the
+// same semantics.
+type S3CompatOptions struct {
+	// DirectoryMarkerFallback retries a HeadObject that reports an object
+	// missing with a trailing "/" appended to the key, since some
+	// backends store a CWL Directory's marker as a zero-byte object at
+	// "key/" rather than making a bare "key" HeadObject resolve it the
+	// way AWS S3 does.
+	DirectoryMarkerFallback bool
+}
+
+// S3FileManager implements FileManager against an S3-compatible bucket.
+// Paths passed to it are s3://bucket/key URLs.
+type S3FileManager struct {
+	api     S3API
+	opts    S3CompatOptions
+	retry   S3RetryOptions
+	breaker *circuitBreaker
+}
+
+// NewS3FileManager returns a FileManager backed by api, with no
+// compatibility quirks enabled and DefaultS3RetryOptions' retry policy;
+// this is correct for AWS S3 itself.
+func NewS3FileManager(api S3API) *S3FileManager {
+	return NewS3FileManagerWithRetry(api, S3CompatOptions{}, DefaultS3RetryOptions())
+}
+
+// NewS3FileManagerWithOptions returns a FileManager backed by api, with
+// opts' S3-compatible quirk handling enabled and DefaultS3RetryOptions'
+// retry policy. Use this instead of NewS3FileManager when the bucket is
+// hosted on MinIO, Ceph RGW, or another non-AWS S3-compatible backend.
+func NewS3FileManagerWithOptions(api S3API, opts S3CompatOptions) *S3FileManager {
+	return NewS3FileManagerWithRetry(api, opts, DefaultS3RetryOptions())
+}
+
+// NewS3FileManagerWithRetry returns a FileManager backed by api, with
+// opts' S3-compatible quirk handling and retry' retry/timeout/circuit
+// breaker policy, for a site whose backend needs a policy other than
+// DefaultS3RetryOptions (e.g. a self-hosted MinIO known to throttle
+// harder, or a test wanting retries disabled entirely).
+func NewS3FileManagerWithRetry(api S3API, opts S3CompatOptions, retry S3RetryOptions) *S3FileManager {
+	return &S3FileManager{
+		api:     api,
+		opts:    opts,
+		retry:   retry,
+		breaker: newCircuitBreaker(retry.BreakerThreshold, retry.BreakerCooldown),
+	}
+}
+
+// splitS3URL splits an "s3://bucket/key" URL into its bucket and key.
+func splitS3URL(url string) (bucket, key string) {
+	trimmed := url[len("s3://"):]
+	idx := strings.IndexByte(trimmed, '/')
+	if idx < 0 {
+		return trimmed, ""
+	}
+	return trimmed[:idx], trimmed[idx+1:]
+}
+
+func (m *S3FileManager) Download(src, dst string) error {
+	return m.DownloadWithOptions(src, dst, CopyOptions{})
+}
+
+// DownloadWithOptions is Download with control over which of the source
+// object's metadata (mode, mtime, xattrs) is applied to dst; see
+// CopyOptions. S3 has no native xattr concept, so PreserveXattrs has no
+// effect here.
+func (m *S3FileManager) DownloadWithOptions(src, dst string, opts CopyOptions) error {
+	bucket, key := splitS3URL(src)
+	metrics.S3APICallsTotal.Inc("op", "GetObject")
+	var r io.ReadCloser
+	if err := m.call("GetObject", func() error {
+		var err error
+		r, err = m.api.GetObject(bucket, key)
+		return err
+	}); err != nil {
+		return err
+	}
+	defer r.Close()
+
+	mode := os.FileMode(0o644)
+	var mtime time.Time
+	if opts.PreserveTimestamps {
+		metrics.S3APICallsTotal.Inc("op", "GetObjectMetadata")
+		var meta map[string]string
+		metaErr := m.call("GetObjectMetadata", func() error {
+			var err error
+			meta, err = m.api.GetObjectMetadata(bucket, key)
+			return err
+		})
+		if metaErr == nil {
+			if v, ok := meta[metaKeyMode]; ok {
+				if parsed, err := strconv.ParseUint(v, 8, 32); err == nil {
+					mode = os.FileMode(parsed)
+				}
+			}
+			if v, ok := meta[metaKeyMtime]; ok {
+				if parsed, err := time.Parse(time.RFC3339, v); err == nil {
+					mtime = parsed
+				}
+			}
+		}
+	}
+
+	if err := ensureParentDir(dst); err != nil {
+		return err
+	}
+	tmp := dst + tempSuffix
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if !mtime.IsZero() {
+		if err := os.Chtimes(tmp, mtime, mtime); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return os.Rename(tmp, dst)
+}
+
+// Upload writes src under a staging key derived from dst and only
+// CopyObjects it to the real dst key once the upload completes, so a reader
+// can never observe a half-uploaded object at the final key.
+func (m *S3FileManager) Upload(src, dst string) error {
+	return m.UploadWithOptions(src, dst, CopyOptions{})
+}
+
+// UploadWithOptions is Upload with control over which of src's metadata is
+// mapped onto the resulting object's S3 metadata; see CopyOptions.
+// PreserveXattrs has no effect: S3 objects have no xattr concept to map
+// them onto.
+func (m *S3FileManager) UploadWithOptions(src, dst string, opts CopyOptions) error {
+	bucket, key := splitS3URL(dst)
+	stagingKey := key + tempSuffix
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]string{
+		metaKeyMode: strconv.FormatUint(uint64(info.Mode().Perm()), 8),
+	}
+	if opts.PreserveTimestamps {
+		metadata[metaKeyMtime] = info.ModTime().UTC().Format(time.RFC3339)
+	}
+
+	metrics.S3APICallsTotal.Inc("op", "PutObject")
+	if err := m.call("PutObject", func() error {
+		// Seek back to the start on every attempt, including retries:
+		// a failed attempt may have already read part of f.
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return m.api.PutObject(bucket, stagingKey, f, info.Size(), metadata)
+	}); err != nil {
+		m.api.DeleteObject(bucket, stagingKey)
+		return err
+	}
+	metrics.S3APICallsTotal.Inc("op", "CopyObject")
+	if err := m.call("CopyObject", func() error { return m.api.CopyObject(bucket, stagingKey, key) }); err != nil {
+		m.api.DeleteObject(bucket, stagingKey)
+		return err
+	}
+	return m.call("DeleteObject", func() error { return m.api.DeleteObject(bucket, stagingKey) })
+}
+
+func (m *S3FileManager) Copy(src, dst string) error {
+	bucket, srcKey := splitS3URL(src)
+	dstBucket, dstKey := splitS3URL(dst)
+	if dstBucket != bucket {
+		// Cross-bucket copies need a local round trip.
+		tmp, err := os.CreateTemp(scratchDir, "flowy-s3-copy-*")
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := m.Download(src, tmp.Name()); err != nil {
+			return err
+		}
+		return m.Upload(tmp.Name(), dst)
+	}
+	metrics.S3APICallsTotal.Inc("op", "CopyObject")
+	return m.call("CopyObject", func() error { return m.api.CopyObject(bucket, srcKey, dstKey) })
+}
+
+func (m *S3FileManager) Remove(path string) error {
+	bucket, key := splitS3URL(path)
+	metrics.S3APICallsTotal.Inc("op", "DeletePrefix")
+	return m.call("DeletePrefix", func() error { return m.api.DeletePrefix(bucket, key) })
+}
+
+func (m *S3FileManager) Open(path string) (io.ReadCloser, error) {
+	bucket, key := splitS3URL(path)
+	metrics.S3APICallsTotal.Inc("op", "GetObject")
+	var r io.ReadCloser
+	err := m.call("GetObject", func() error {
+		var err error
+		r, err = m.api.GetObject(bucket, key)
+		return err
+	})
+	return r, err
+}
+
+// OpenRangePrefix returns a reader for the first n bytes of path, without
+// transferring the rest of the object, satisfying RangePrefixReader so
+// LoadContents can populate a large S3-located output's Contents field
+// without downloading it in full.
+func (m *S3FileManager) OpenRangePrefix(path string, n int64) (io.ReadCloser, error) {
+	bucket, key := splitS3URL(path)
+	metrics.S3APICallsTotal.Inc("op", "GetObjectRange")
+	var r io.ReadCloser
+	err := m.call("GetObjectRange", func() error {
+		var err error
+		r, err = m.api.GetObjectRange(bucket, key, n)
+		return err
+	})
+	return r, err
+}
+
+// TagObject replaces path's S3 tag set, satisfying ObjectTagger so
+// callers can attach searchable labels to an uploaded object without
+// re-uploading it.
+func (m *S3FileManager) TagObject(path string, tags map[string]string) error {
+	bucket, key := splitS3URL(path)
+	metrics.S3APICallsTotal.Inc("op", "PutObjectTags")
+	return m.call("PutObjectTags", func() error {
+		return m.api.PutObjectTags(bucket, key, tags)
+	})
+}
+
+func (m *S3FileManager) Stat(path string) (int64, bool, error) {
+	bucket, key := splitS3URL(path)
+	metrics.S3APICallsTotal.Inc("op", "HeadObject")
+	var size int64
+	var exists bool
+	err := m.call("HeadObject", func() error {
+		var err error
+		size, exists, err = m.api.HeadObject(bucket, key)
+		return err
+	})
+	if err != nil || exists {
+		return size, exists, err
+	}
+	if !m.opts.DirectoryMarkerFallback || strings.HasSuffix(key, "/") {
+		return size, exists, err
+	}
+	metrics.S3APICallsTotal.Inc("op", "HeadObject")
+	err = m.call("HeadObject", func() error {
+		var err error
+		size, exists, err = m.api.HeadObject(bucket, key+"/")
+		return err
+	})
+	return size, exists, err
+}
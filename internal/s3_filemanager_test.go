@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeS3Object is one stored object in fakeS3API.
+type fakeS3Object struct {
+	data     []byte
+	metadata map[string]string
+	tags     map[string]string
+}
+
+// fakeS3API is an in-memory S3API substitute, standing in for the MinIO
+// or Ceph RGW bucket this repo has no network-level test harness for
+// (S3FileManager has no concrete AWS SDK client wired into it at all; the
+// interface exists precisely so tests can exercise the full
+// stage/execute/upload cycle against a fake like this one without a real
+// bucket). It optionally omits a bare-key HeadObject for a directory
+// marker, only resolving it at "key/", reproducing the MinIO/Ceph quirk
+// S3CompatOptions.DirectoryMarkerFallback works around.
+type fakeS3API struct {
+	objects                map[string]map[string]fakeS3Object // bucket -> key -> object
+	suppressBareDirHeadFor string                             // a key this fake only resolves with a trailing "/"
+}
+
+func newFakeS3API() *fakeS3API {
+	return &fakeS3API{objects: map[string]map[string]fakeS3Object{}}
+}
+
+func (f *fakeS3API) PutObject(bucket, key string, body io.Reader, size int64, metadata map[string]string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if f.objects[bucket] == nil {
+		f.objects[bucket] = map[string]fakeS3Object{}
+	}
+	f.objects[bucket][key] = fakeS3Object{data: data, metadata: metadata}
+	return nil
+}
+
+func (f *fakeS3API) GetObject(bucket, key string) (io.ReadCloser, error) {
+	obj, ok := f.objects[bucket][key]
+	if !ok {
+		return nil, fmt.Errorf("no such object: s3://%s/%s", bucket, key)
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (f *fakeS3API) GetObjectRange(bucket, key string, length int64) (io.ReadCloser, error) {
+	obj, ok := f.objects[bucket][key]
+	if !ok {
+		return nil, fmt.Errorf("no such object: s3://%s/%s", bucket, key)
+	}
+	data := obj.data
+	if int64(len(data)) > length {
+		data = data[:length]
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *fakeS3API) GetObjectMetadata(bucket, key string) (map[string]string, error) {
+	obj, ok := f.objects[bucket][key]
+	if !ok {
+		return nil, fmt.Errorf("no such object: s3://%s/%s", bucket, key)
+	}
+	return obj.metadata, nil
+}
+
+func (f *fakeS3API) CopyObject(bucket, srcKey, dstKey string) error {
+	obj, ok := f.objects[bucket][srcKey]
+	if !ok {
+		return fmt.Errorf("no such object: s3://%s/%s", bucket, srcKey)
+	}
+	f.objects[bucket][dstKey] = obj
+	return nil
+}
+
+func (f *fakeS3API) DeleteObject(bucket, key string) error {
+	delete(f.objects[bucket], key)
+	return nil
+}
+
+func (f *fakeS3API) DeletePrefix(bucket, prefix string) error {
+	for key := range f.objects[bucket] {
+		if key == prefix || strings.HasPrefix(key, prefix+"/") {
+			delete(f.objects[bucket], key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeS3API) PutObjectTags(bucket, key string, tags map[string]string) error {
+	obj, ok := f.objects[bucket][key]
+	if !ok {
+		return fmt.Errorf("no such object: s3://%s/%s", bucket, key)
+	}
+	obj.tags = tags
+	f.objects[bucket][key] = obj
+	return nil
+}
+
+func (f *fakeS3API) HeadObject(bucket, key string) (int64, bool, error) {
+	if key == f.suppressBareDirHeadFor {
+		return 0, false, nil
+	}
+	obj, ok := f.objects[bucket][key]
+	if !ok {
+		return 0, false, nil
+	}
+	return int64(len(obj.data)), true, nil
+}
+
+func TestS3FileManagerUploadDownloadRoundTrip(t *testing.T) {
+	api := newFakeS3API()
+	fm := NewS3FileManager(api)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello minio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fm.Upload(src, "s3://bucket/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.objects["bucket"]["a.txt"+tempSuffix]; ok {
+		t.Fatal("expected the staging key to be cleaned up after Upload")
+	}
+
+	dst := filepath.Join(dir, "downloaded.txt")
+	if err := fm.Download("s3://bucket/a.txt", dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello minio" {
+		t.Fatalf("got %q", data)
+	}
+}
+
+func TestS3FileManagerTagObject(t *testing.T) {
+	api := newFakeS3API()
+	fm := NewS3FileManager(api)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fm.Upload(src, "s3://bucket/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fm.TagObject("s3://bucket/a.txt", map[string]string{"project": "alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.objects["bucket"]["a.txt"].tags["project"]; got != "alpha" {
+		t.Fatalf("tags[\"project\"] = %q, want %q", got, "alpha")
+	}
+}
+
+func TestS3FileManagerCopyCrossBucketRoundTrips(t *testing.T) {
+	api := newFakeS3API()
+	fm := NewS3FileManager(api)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("data"), 0o644)
+	if err := fm.Upload(src, "s3://bucket-a/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fm.Copy("s3://bucket-a/a.txt", "s3://bucket-b/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists, _ := fm.Stat("s3://bucket-b/a.txt"); !exists {
+		t.Fatal("expected the object to exist in the destination bucket")
+	}
+}
+
+func TestS3FileManagerStatWithoutCompatOptionsMissesDirectoryMarker(t *testing.T) {
+	api := newFakeS3API()
+	api.objects["bucket"] = map[string]fakeS3Object{"dir/": {data: []byte{}}}
+	api.suppressBareDirHeadFor = "dir"
+	fm := NewS3FileManager(api)
+
+	if _, exists, _ := fm.Stat("s3://bucket/dir"); exists {
+		t.Fatal("expected a bare-key HeadObject to miss the directory marker without DirectoryMarkerFallback")
+	}
+}
+
+func TestS3FileManagerStatDirectoryMarkerFallbackFindsTrailingSlashKey(t *testing.T) {
+	api := newFakeS3API()
+	api.objects["bucket"] = map[string]fakeS3Object{"dir/": {data: []byte{}}}
+	api.suppressBareDirHeadFor = "dir"
+	fm := NewS3FileManagerWithOptions(api, S3CompatOptions{DirectoryMarkerFallback: true})
+
+	if _, exists, err := fm.Stat("s3://bucket/dir"); err != nil || !exists {
+		t.Fatalf("exists=%v err=%v, want the fallback to find s3://bucket/dir/", exists, err)
+	}
+}
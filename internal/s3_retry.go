@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/metrics"
+)
+
+// S3RetryOptions configures how S3FileManager tolerates a flaky or
+// throttling S3-compatible backend: bounded retries with backoff for a
+// single call, a timeout so one stuck call cannot stall a job forever,
+// and a circuit breaker that stops hammering a backend that is clearly
+// down rather than retrying every call out to MaxRetries.
+type S3RetryOptions struct {
+	// MaxRetries is how many additional attempts a failed call gets,
+	// beyond its first. Zero disables retrying.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry; each
+	// subsequent retry's delay doubles, capped at MaxBackoff. This is
+	// the "adaptive rate limiting" half of the policy: a backend that's
+	// throttling gets backed off from harder the longer it keeps
+	// failing, instead of being retried at a fixed interval.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// CallTimeout bounds how long a single attempt may run before it is
+	// treated as failed (and retried, or returned once attempts are
+	// exhausted). Zero means no timeout.
+	CallTimeout time.Duration
+	// BreakerThreshold is how many consecutive calls, after their own
+	// retries are exhausted, must fail before the circuit breaker opens
+	// and starts short-circuiting calls instead of attempting them.
+	// Zero disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before it lets
+	// one trial call through to check whether the backend has recovered.
+	BreakerCooldown time.Duration
+}
+
+// DefaultS3RetryOptions is the policy S3FileManager uses when none is
+// supplied: a handful of short, backed-off retries, a generous per-call
+// timeout, and a breaker that trips only after a sustained run of
+// failures rather than a single blip.
+func DefaultS3RetryOptions() S3RetryOptions {
+	return S3RetryOptions{
+		MaxRetries:       4,
+		InitialBackoff:   200 * time.Millisecond,
+		MaxBackoff:       10 * time.Second,
+		CallTimeout:      60 * time.Second,
+		BreakerThreshold: 8,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// errCircuitOpen is wrapped with the operation name and returned when the
+// circuit breaker short-circuits a call without attempting it.
+var errCircuitOpen = errors.New("s3 circuit breaker open: backend has been failing, call not attempted")
+
+// errS3CallTimedOut is wrapped with the operation name and returned when
+// an attempt doesn't complete within S3RetryOptions.CallTimeout.
+var errS3CallTimedOut = errors.New("s3 call timed out")
+
+// circuitBreaker is a consecutive-failure breaker: once threshold
+// consecutive failures are recorded it opens for cooldown, rejecting
+// every call without attempting it; once cooldown has elapsed it lets
+// exactly one trial call through, closing again on success or reopening
+// on failure.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu            sync.Mutex
+	failures      int
+	openUntil     time.Time
+	trialInFlight bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may proceed right now, reserving the
+// single trial slot when the breaker is past its cooldown but hasn't yet
+// confirmed the backend has recovered.
+func (b *circuitBreaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) {
+		return false
+	}
+	if b.trialInFlight {
+		return false
+	}
+	b.trialInFlight = true
+	return true
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trialInFlight = false
+	metrics.S3CircuitBreakerOpen.Set(0)
+}
+
+func (b *circuitBreaker) recordFailure() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trialInFlight = false
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		metrics.S3CircuitBreakerOpen.Set(1)
+	}
+}
+
+// call runs fn with S3FileManager's configured retry, timeout, and
+// circuit-breaker policy, under the label op (an S3 operation name,
+// matching the metrics.S3APICallsTotal labels the caller already used).
+// The returned error, once retries and the breaker are accounted for, is
+// an ordinary error - not wrapped as a permanent failure - so the
+// worker's existing retry policy (see cmd/worker/permanentfail.go) treats
+// a sustained S3 outage as a temporary, retryable job failure rather than
+// one retrying can never fix.
+func (m *S3FileManager) call(op string, fn func() error) error {
+	if !m.breaker.allow() {
+		return fmt.Errorf("%s: %w", op, errCircuitOpen)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= m.retry.MaxRetries; attempt++ {
+		if attempt > 0 {
+			metrics.S3RetriesTotal.Inc("op", op)
+			time.Sleep(backoffDelay(m.retry, attempt))
+		}
+		lastErr = m.attempt(fn)
+		if lastErr == nil {
+			m.breaker.recordSuccess()
+			return nil
+		}
+	}
+	m.breaker.recordFailure()
+	return fmt.Errorf("%s: %w", op, lastErr)
+}
+
+// attempt runs fn once, bounded by m.retry.CallTimeout when set. fn runs
+// on its own goroutine so a call that never returns (rather than
+// returning an error) can't block the caller past the timeout; that
+// goroutine is abandoned rather than cancelled, since S3API has no
+// context-aware surface to cancel it through.
+func (m *S3FileManager) attempt(fn func() error) error {
+	if m.retry.CallTimeout <= 0 {
+		return fn()
+	}
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(m.retry.CallTimeout):
+		return errS3CallTimedOut
+	}
+}
+
+// backoffDelay returns attempt's backoff delay (attempt 1 is the first
+// retry), doubling from InitialBackoff and capped at MaxBackoff.
+func backoffDelay(opts S3RetryOptions, attempt int) time.Duration {
+	delay := time.Duration(float64(opts.InitialBackoff) * math.Pow(2, float64(attempt-1)))
+	if opts.MaxBackoff > 0 && delay > opts.MaxBackoff {
+		delay = opts.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingS3API fails every call with failErr until it has returned
+// failUntil errors for that operation, then delegates to the embedded
+// fakeS3API - a stand-in for a backend that throttles for a while and
+// then recovers.
+type failingS3API struct {
+	*fakeS3API
+	failUntil int32
+	failErr   error
+	calls     int32
+}
+
+func (f *failingS3API) PutObject(bucket, key string, body io.Reader, size int64, metadata map[string]string) error {
+	if atomic.AddInt32(&f.calls, 1) <= f.failUntil {
+		return f.failErr
+	}
+	return f.fakeS3API.PutObject(bucket, key, body, size, metadata)
+}
+
+func noRetryDelay() S3RetryOptions {
+	return S3RetryOptions{MaxRetries: 4, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+func TestS3FileManagerRetriesTransientFailure(t *testing.T) {
+	dir := t.TempDir()
+	src := dir + "/src.txt"
+	if err := os.WriteFile(src, []byte("payload"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &failingS3API{fakeS3API: newFakeS3API(), failUntil: 2, failErr: errors.New("throttled")}
+	fm := NewS3FileManagerWithRetry(api, S3CompatOptions{}, noRetryDelay())
+
+	if err := fm.Upload(src, "s3://bucket/out.txt"); err != nil {
+		t.Fatalf("Upload() = %v, want nil after retries absorb the transient failures", err)
+	}
+	if api.calls != 3 {
+		t.Fatalf("calls = %d, want 3 (2 failures + 1 success)", api.calls)
+	}
+}
+
+func TestS3FileManagerGivesUpAfterMaxRetries(t *testing.T) {
+	dir := t.TempDir()
+	src := dir + "/src.txt"
+	os.WriteFile(src, []byte("payload"), 0o644)
+
+	api := &failingS3API{fakeS3API: newFakeS3API(), failUntil: 100, failErr: errors.New("throttled")}
+	fm := NewS3FileManagerWithRetry(api, S3CompatOptions{}, S3RetryOptions{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	if err := fm.Upload(src, "s3://bucket/out.txt"); err == nil {
+		t.Fatal("Upload() = nil, want an error once retries are exhausted")
+	}
+	if api.calls != 3 {
+		t.Fatalf("calls = %d, want 3 (1 initial attempt + 2 retries)", api.calls)
+	}
+}
+
+func TestS3FileManagerCallTimeoutTreatsHungCallAsFailure(t *testing.T) {
+	api := &blockingS3API{fakeS3API: newFakeS3API(), release: make(chan struct{})}
+	defer close(api.release)
+
+	fm := NewS3FileManagerWithRetry(api, S3CompatOptions{}, S3RetryOptions{
+		MaxRetries: 0, CallTimeout: 10 * time.Millisecond,
+	})
+
+	if _, _, err := fm.Stat("s3://bucket/out.txt"); !errors.Is(err, errS3CallTimedOut) {
+		t.Fatalf("err = %v, want errS3CallTimedOut", err)
+	}
+}
+
+// blockingS3API's HeadObject blocks until release is closed, standing in
+// for a call that never returns.
+type blockingS3API struct {
+	*fakeS3API
+	release chan struct{}
+}
+
+func (b *blockingS3API) HeadObject(bucket, key string) (int64, bool, error) {
+	<-b.release
+	return b.fakeS3API.HeadObject(bucket, key)
+}
+
+func TestS3FileManagerCircuitBreakerOpensAfterThresholdAndShortCircuits(t *testing.T) {
+	api := &failingS3API{fakeS3API: newFakeS3API(), failUntil: 100, failErr: errors.New("throttled")}
+	fm := NewS3FileManagerWithRetry(api, S3CompatOptions{}, S3RetryOptions{
+		MaxRetries: 0, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		BreakerThreshold: 2, BreakerCooldown: time.Hour,
+	})
+
+	src := t.TempDir() + "/src.txt"
+	os.WriteFile(src, []byte("payload"), 0o644)
+
+	for i := 0; i < 2; i++ {
+		if err := fm.Upload(src, "s3://bucket/out.txt"); err == nil {
+			t.Fatal("expected upload to fail against a backend that always errors")
+		}
+	}
+	callsBeforeOpen := api.calls
+
+	if err := fm.Upload(src, "s3://bucket/out.txt"); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("err = %v, want errCircuitOpen once the breaker has tripped", err)
+	}
+	if api.calls != callsBeforeOpen {
+		t.Fatalf("calls = %d, want %d (breaker should short-circuit without calling the backend)", api.calls, callsBeforeOpen)
+	}
+}
+
+func TestS3FileManagerCircuitBreakerClosesAfterCooldownOnSuccess(t *testing.T) {
+	api := &failingS3API{fakeS3API: newFakeS3API(), failUntil: 2, failErr: errors.New("throttled")}
+	fm := NewS3FileManagerWithRetry(api, S3CompatOptions{}, S3RetryOptions{
+		MaxRetries: 0, BreakerThreshold: 2, BreakerCooldown: time.Millisecond,
+	})
+
+	src := t.TempDir() + "/src.txt"
+	os.WriteFile(src, []byte("payload"), 0o644)
+
+	for i := 0; i < 2; i++ {
+		fm.Upload(src, "s3://bucket/out.txt")
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if err := fm.Upload(src, "s3://bucket/out.txt"); err != nil {
+		t.Fatalf("Upload() = %v, want the trial call after cooldown to succeed and close the breaker", err)
+	}
+}
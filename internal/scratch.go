@@ -0,0 +1,18 @@
+package internal
+
+// scratchDir is the directory this package's FileManager implementations
+// use for their own internal temp files (a dedup pointer object, an S3
+// cross-bucket copy's local round trip) in place of the OS's default temp
+// directory. Configured once at startup via SetScratchDir; the zero value
+// keeps using the OS default, matching os.CreateTemp/os.MkdirTemp's own
+// behavior for an empty dir argument.
+var scratchDir string
+
+// SetScratchDir points this package's FileManager implementations' own
+// internal temp files at dir instead of the OS's default temp directory -
+// e.g. to put scratch I/O on a larger disk, or small transient files on a
+// tmpfs mount. It is not related to a job's own workdir, which is always
+// rooted under the worker's -workdir.
+func SetScratchDir(dir string) {
+	scratchDir = dir
+}
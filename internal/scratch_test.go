@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetScratchDirIsUsedForDedupPointerTempFiles(t *testing.T) {
+	defer SetScratchDir("")
+
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "a.txt")
+	if err := os.WriteFile(src, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir() + string(filepath.Separator)
+	dedup := WithDedup(NewLocalFileManager(), root)
+
+	SetScratchDir(filepath.Join(t.TempDir(), "does-not-exist"))
+	if _, err := dedup.UploadDedup(src, root+"out.txt", "sha1$scratchmissing"); err == nil {
+		t.Fatal("expected UploadDedup to fail when the configured scratch dir doesn't exist")
+	}
+
+	SetScratchDir(t.TempDir())
+	if _, err := dedup.UploadDedup(src, root+"out2.txt", "sha1$scratchvalid"); err != nil {
+		t.Fatalf("expected UploadDedup to succeed with a valid scratch dir, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package secondaryfiles implements CWL's secondaryFiles discovery rules:
+// resolving a primary File or Directory's sibling paths from one or more
+// patterns (literal suffixes, "^"-prefixed extension-stripping patterns, or
+// full CWL/JavaScript expressions), recursively for secondary files that
+// themselves declare secondaryFiles.
+package secondaryfiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bioflowy/flowy/internal/jsexpr"
+)
+
+// Pattern is one secondaryFiles entry: a CWL pattern or expression, whether
+// a missing match is an error, and any patterns to apply, in turn, to the
+// secondary file this one matches.
+type Pattern struct {
+	Expr           string
+	Required       bool
+	SecondaryFiles []Pattern
+}
+
+// Entry is one discovered secondary file or directory.
+type Entry struct {
+	Path           string
+	Basename       string
+	IsDir          bool
+	SecondaryFiles []Entry
+}
+
+// Collect resolves patterns against primaryPath (a File or Directory's
+// local path; the distinction doesn't matter to pattern resolution) and
+// returns every secondary entry found on disk. inputs and runtimeCtx are
+// made available to expression patterns under their usual CWL variable
+// names; "self" is bound to the primary's own File/Directory object.
+func Collect(primaryPath string, patterns []Pattern, inputs, runtimeCtx map[string]interface{}) ([]Entry, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	self := selfObject(primaryPath)
+	var entries []Entry
+	for _, p := range patterns {
+		isExpr := strings.Contains(p.Expr, "$(") || strings.Contains(p.Expr, "${")
+		values, err := resolvePattern(p.Expr, self, inputs, runtimeCtx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			var secPath string
+			if isExpr {
+				// An expression's result is a complete replacement
+				// basename (or relative path) in the primary's own
+				// directory, not a suffix to append.
+				secPath = filepath.Join(filepath.Dir(primaryPath), v)
+			} else {
+				secPath = applyPattern(primaryPath, v)
+			}
+			info, err := os.Stat(secPath)
+			if err != nil {
+				if p.Required {
+					return nil, fmt.Errorf("required secondaryFile %q not found for %s", secPath, primaryPath)
+				}
+				continue
+			}
+
+			entry := Entry{Path: secPath, Basename: filepath.Base(secPath), IsDir: info.IsDir()}
+			if len(p.SecondaryFiles) > 0 {
+				nested, err := Collect(secPath, p.SecondaryFiles, inputs, runtimeCtx)
+				if err != nil {
+					return nil, err
+				}
+				entry.SecondaryFiles = nested
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// resolvePattern evaluates a pattern expression to one or more pattern
+// strings. A bare literal (no "$(" or "${") is returned as-is; an
+// expression may return a single string or an array of strings.
+func resolvePattern(expr string, self, inputs, runtimeCtx map[string]interface{}) ([]string, error) {
+	if !strings.Contains(expr, "$(") && !strings.Contains(expr, "${") {
+		return []string{expr}, nil
+	}
+	v, err := jsexpr.Evaluate(expr, jsexpr.Context{Self: self, Inputs: inputs, Runtime: runtimeCtx})
+	if err != nil {
+		return nil, err
+	}
+	switch t := v.(type) {
+	case string:
+		return []string{t}, nil
+	case []interface{}:
+		values := make([]string, 0, len(t))
+		for _, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("secondaryFiles expression %q returned a non-string array element %#v", expr, item)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("secondaryFiles expression %q returned %#v, want a string or array of strings", expr, v)
+	}
+}
+
+// applyPattern resolves one pattern string against primaryPath. A pattern
+// starting with one or more '^' strips that many extensions from the
+// primary's basename before appending the rest of the pattern ("^^.bai"
+// strips two extensions, e.g. "sample.fastq.gz" -> "sample" + ".bai");
+// anything else is appended directly to the unmodified basename.
+func applyPattern(primaryPath, pattern string) string {
+	carets := 0
+	for carets < len(pattern) && pattern[carets] == '^' {
+		carets++
+	}
+	suffix := pattern[carets:]
+
+	base := filepath.Base(primaryPath)
+	for i := 0; i < carets; i++ {
+		ext := filepath.Ext(base)
+		if ext == "" {
+			break
+		}
+		base = strings.TrimSuffix(base, ext)
+	}
+	return filepath.Join(filepath.Dir(primaryPath), base+suffix)
+}
+
+// selfObject builds the CWL File/Directory object a pattern expression
+// sees as "self".
+func selfObject(path string) map[string]interface{} {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return map[string]interface{}{
+		"path":     path,
+		"basename": base,
+		"dirname":  filepath.Dir(path),
+		"nameext":  ext,
+		"nameroot": strings.TrimSuffix(base, ext),
+	}
+}
@@ -0,0 +1,142 @@
+package secondaryfiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func touch(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCollectLiteralSuffix(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "aligned.bam")
+	touch(t, dir, "aligned.bam.bai")
+
+	entries, err := Collect(primary, []Pattern{{Expr: ".bai", Required: true}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Basename != "aligned.bam.bai" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
+
+func TestCollectCaretStripsOneExtension(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "sample.fastq.gz")
+	touch(t, dir, "sample.fastq.idx")
+
+	entries, err := Collect(primary, []Pattern{{Expr: "^.idx", Required: true}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Basename != "sample.fastq.idx" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
+
+func TestCollectDoubleCaretStripsTwoExtensions(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "sample.fastq.gz")
+	touch(t, dir, "sample.dict")
+
+	entries, err := Collect(primary, []Pattern{{Expr: "^^.dict", Required: true}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Basename != "sample.dict" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
+
+func TestCollectExpressionPattern(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "aligned.bam")
+	touch(t, dir, "aligned.bam.bai")
+
+	entries, err := Collect(primary, []Pattern{{Expr: "$(self.basename + '.bai')", Required: true}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Basename != "aligned.bam.bai" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
+
+func TestCollectMixedRequiredInArray(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "aligned.bam")
+	touch(t, dir, "aligned.bam.bai")
+
+	entries, err := Collect(primary, []Pattern{
+		{Expr: ".bai", Required: true},
+		{Expr: ".missing", Required: false},
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
+
+func TestCollectRequiredMissingIsError(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "aligned.bam")
+
+	if _, err := Collect(primary, []Pattern{{Expr: ".bai", Required: true}}, nil, nil); err == nil {
+		t.Fatal("expected an error for a missing required secondary file")
+	}
+}
+
+func TestCollectNestedSecondaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	primary := touch(t, dir, "aligned.bam")
+	touch(t, dir, "aligned.bam.bai")
+	touch(t, dir, "aligned.bam.bai.md5")
+
+	entries, err := Collect(primary, []Pattern{{
+		Expr:     ".bai",
+		Required: true,
+		SecondaryFiles: []Pattern{
+			{Expr: ".md5", Required: true},
+		},
+	}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].SecondaryFiles) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if entries[0].SecondaryFiles[0].Basename != "aligned.bam.bai.md5" {
+		t.Fatalf("nested entry = %+v", entries[0].SecondaryFiles[0])
+	}
+}
+
+func TestCollectDirectoryPrimary(t *testing.T) {
+	dir := t.TempDir()
+	primaryDir := filepath.Join(dir, "results")
+	if err := os.Mkdir(primaryDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	siblingDir := filepath.Join(dir, "results.metadata")
+	if err := os.Mkdir(siblingDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Collect(primaryDir, []Pattern{{Expr: ".metadata", Required: true}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].IsDir || entries[0].Basename != "results.metadata" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
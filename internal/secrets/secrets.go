@@ -0,0 +1,100 @@
+// Package secrets resolves named secrets a job's environment references
+// into real values at exec time, so credentials never have to travel
+// through the server's job payload or land in a worker's logs.
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Provider resolves a secret name to its value.
+type Provider interface {
+	Resolve(name string) (string, error)
+}
+
+// EnvFileProvider resolves secrets from a local KEY=VALUE file, the
+// simplest provider and the default for single-node deployments. Vault and
+// AWS Secrets Manager providers implement the same interface and are
+// selected by worker configuration.
+type EnvFileProvider struct {
+	values map[string]string
+}
+
+// NewEnvFileProvider parses path as a KEY=VALUE file (blank lines and
+// lines starting with '#' are ignored).
+func NewEnvFileProvider(path string) (*EnvFileProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &EnvFileProvider{values: values}, nil
+}
+
+func (p *EnvFileProvider) Resolve(name string) (string, error) {
+	v, ok := p.values[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", name)
+	}
+	return v, nil
+}
+
+// SecretRef marks an Env value the worker must resolve through a Provider
+// rather than pass through literally. Job payloads use it as
+// `{"$secret": "DB_PASSWORD"}` in place of a plain string value; see
+// ResolveEnv.
+const SecretRefPrefix = "$secret:"
+
+// ResolveEnv returns a copy of env with every SecretRefPrefix-tagged value
+// resolved through provider. Resolved values (and the names that produced
+// them) are returned separately as redact targets, so callers can strip
+// them from logs and failure reports before anything is printed.
+func ResolveEnv(env map[string]string, provider Provider) (resolved map[string]string, redact []string, err error) {
+	resolved = make(map[string]string, len(env))
+	for k, v := range env {
+		if !strings.HasPrefix(v, SecretRefPrefix) {
+			resolved[k] = v
+			continue
+		}
+		secretName := strings.TrimPrefix(v, SecretRefPrefix)
+		value, err := provider.Resolve(secretName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving secret for env %q: %w", k, err)
+		}
+		resolved[k] = value
+		redact = append(redact, value)
+	}
+	return resolved, redact, nil
+}
+
+// Redact replaces every occurrence of each secret value in s with "***",
+// so logs and failure reports never echo a resolved credential.
+func Redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, "***")
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package secrets
+
+import "testing"
+
+type mapProvider map[string]string
+
+func (m mapProvider) Resolve(name string) (string, error) {
+	return m[name], nil
+}
+
+func TestResolveEnvAndRedact(t *testing.T) {
+	provider := mapProvider{"DB_PASSWORD": "s3cr3t"}
+	env := map[string]string{
+		"PATH":        "/usr/bin",
+		"DB_PASSWORD": "$secret:DB_PASSWORD",
+	}
+
+	resolved, redact, err := ResolveEnv(env, provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved["DB_PASSWORD"] != "s3cr3t" {
+		t.Fatalf("DB_PASSWORD = %q", resolved["DB_PASSWORD"])
+	}
+	if resolved["PATH"] != "/usr/bin" {
+		t.Fatalf("PATH = %q", resolved["PATH"])
+	}
+
+	msg := Redact("connection failed, password was s3cr3t", redact)
+	if msg != "connection failed, password was ***" {
+		t.Fatalf("Redact result: %q", msg)
+	}
+}
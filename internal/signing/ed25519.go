@@ -0,0 +1,69 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// GenerateKeyPair creates a new Ed25519 key pair for a party (e.g. a
+// worker) that needs to sign payloads a third party, with no shared
+// secret, can later verify: unlike Sign/Verify's symmetric HMAC, only the
+// public half needs to be distributed for verification.
+func GenerateKeyPair() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// SignDetached returns the base64-encoded Ed25519 signature of payload
+// under priv.
+func SignDetached(priv ed25519.PrivateKey, payload []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload))
+}
+
+// VerifyDetached reports whether signature is a valid base64-encoded
+// Ed25519 signature of payload under pub.
+func VerifyDetached(pub ed25519.PublicKey, payload []byte, signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, payload, sig)
+}
+
+// EncodePublicKey base64-encodes pub for transmission or storage (e.g. in
+// a worker registration request or a registry of trusted worker keys).
+func EncodePublicKey(pub ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+// DecodePublicKey reverses EncodePublicKey, rejecting input that doesn't
+// decode to a valid Ed25519 public key length.
+func DecodePublicKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("decoding public key: want %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// EncodePrivateKey base64-encodes priv for local persistence (e.g. a
+// worker's result-signing key surviving process restarts).
+func EncodePrivateKey(priv ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(priv)
+}
+
+// DecodePrivateKey reverses EncodePrivateKey.
+func DecodePrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding private key: %w", err)
+	}
+	if len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("decoding private key: want %d bytes, got %d", ed25519.PrivateKeySize, len(raw))
+	}
+	return ed25519.PrivateKey(raw), nil
+}
@@ -0,0 +1,65 @@
+package signing
+
+import "testing"
+
+func TestSignDetachedAndVerifyDetached(t *testing.T) {
+	pub, priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	payload := []byte(`{"out":"result-1"}`)
+
+	sig := SignDetached(priv, payload)
+	if !VerifyDetached(pub, payload, sig) {
+		t.Fatal("VerifyDetached rejected a signature SignDetached produced")
+	}
+	if VerifyDetached(pub, []byte(`{"out":"result-2"}`), sig) {
+		t.Fatal("VerifyDetached accepted a signature for the wrong payload")
+	}
+
+	otherPub, _, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	if VerifyDetached(otherPub, payload, sig) {
+		t.Fatal("VerifyDetached accepted a signature under the wrong public key")
+	}
+}
+
+func TestEncodeDecodePublicKey(t *testing.T) {
+	pub, _, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	decoded, err := DecodePublicKey(EncodePublicKey(pub))
+	if err != nil {
+		t.Fatalf("DecodePublicKey: %v", err)
+	}
+	if !decoded.Equal(pub) {
+		t.Fatal("DecodePublicKey(EncodePublicKey(pub)) != pub")
+	}
+	if _, err := DecodePublicKey("not-valid-base64!!"); err == nil {
+		t.Fatal("expected an error decoding invalid base64")
+	}
+	_, priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	if _, err := DecodePublicKey(EncodePrivateKey(priv)); err == nil {
+		t.Fatal("expected an error decoding a wrong-length key")
+	}
+}
+
+func TestEncodeDecodePrivateKey(t *testing.T) {
+	_, priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	decoded, err := DecodePrivateKey(EncodePrivateKey(priv))
+	if err != nil {
+		t.Fatalf("DecodePrivateKey: %v", err)
+	}
+	if !decoded.Equal(priv) {
+		t.Fatal("DecodePrivateKey(EncodePrivateKey(priv)) != priv")
+	}
+}
@@ -0,0 +1,19 @@
+package signing
+
+import "testing"
+
+func TestSignAndVerify(t *testing.T) {
+	key := []byte("test-key")
+	payload := []byte(`{"jobId":"job-1"}`)
+
+	sig := Sign(key, payload)
+	if !Verify(key, payload, sig) {
+		t.Fatal("Verify rejected a signature Sign produced")
+	}
+	if Verify(key, []byte(`{"jobId":"job-2"}`), sig) {
+		t.Fatal("Verify accepted a signature for the wrong payload")
+	}
+	if Verify([]byte("wrong-key"), payload, sig) {
+		t.Fatal("Verify accepted a signature under the wrong key")
+	}
+}
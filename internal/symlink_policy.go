@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy decides whether a symlink's resolved target is safe to
+// follow when copying a directory tree that may be under the control of an
+// untrusted tool. Without it, CopyDir faithfully recreates whatever link a
+// tool planted, including one that points outside the tree being copied
+// (e.g. at /etc/passwd, or at another job's private data). The zero value
+// denies every symlink, so a caller must opt in explicitly.
+type SymlinkPolicy struct {
+	// AllowSymlinks must be true for Check to ever allow a symlink.
+	AllowSymlinks bool
+	// AllowedRoots, when AllowSymlinks is true, restricts which absolute
+	// directories a symlink's resolved target may point inside. Empty
+	// means any target not excluded by DeniedRoots is allowed.
+	AllowedRoots []string
+	// DeniedRoots lists absolute directories a target must not point
+	// inside, checked before AllowedRoots so an explicit deny always wins
+	// over a broader allow.
+	DeniedRoots []string
+}
+
+// Check reports whether target, an already-resolved absolute path, may be
+// followed as a symlink's destination under p.
+func (p SymlinkPolicy) Check(target string) error {
+	if !p.AllowSymlinks {
+		return fmt.Errorf("symlink target %q is not allowed: symlinks are denied by policy", target)
+	}
+	for _, denied := range p.DeniedRoots {
+		if withinRoot(target, denied) {
+			return fmt.Errorf("symlink target %q is inside denied root %q", target, denied)
+		}
+	}
+	if len(p.AllowedRoots) == 0 {
+		return nil
+	}
+	for _, root := range p.AllowedRoots {
+		if withinRoot(target, root) {
+			return nil
+		}
+	}
+	return fmt.Errorf("symlink target %q is outside every allowed root", target)
+}
+
+// withinRoot reports whether path is root itself or somewhere underneath
+// it. Both must already be absolute and clean for the comparison to be
+// meaningful.
+func withinRoot(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// ResolveSymlinkTarget reads the symlink at linkPath and returns the
+// absolute path it points to, resolving a relative link against linkPath's
+// own directory the way the OS would. It does not require the target to
+// exist, so it also covers a symlink planted by a tool that points at a
+// path that was never created.
+func ResolveSymlinkTarget(linkPath string) (string, error) {
+	link, err := os.Readlink(linkPath)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(link) {
+		return filepath.Clean(link), nil
+	}
+	return filepath.Clean(filepath.Join(filepath.Dir(linkPath), link)), nil
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymlinkPolicyDeniesByDefault(t *testing.T) {
+	var p SymlinkPolicy
+	if err := p.Check("/anywhere"); err == nil {
+		t.Fatal("expected the zero-value policy to deny every symlink")
+	}
+}
+
+func TestSymlinkPolicyAllowsWithinAllowedRoot(t *testing.T) {
+	p := SymlinkPolicy{AllowSymlinks: true, AllowedRoots: []string{"/allowed"}}
+	if err := p.Check("/allowed/sub/file.txt"); err != nil {
+		t.Fatalf("expected target inside allowed root to pass: %v", err)
+	}
+	if err := p.Check("/allowed"); err != nil {
+		t.Fatalf("expected the root itself to pass: %v", err)
+	}
+}
+
+func TestSymlinkPolicyRejectsOutsideAllowedRoot(t *testing.T) {
+	p := SymlinkPolicy{AllowSymlinks: true, AllowedRoots: []string{"/allowed"}}
+	if err := p.Check("/etc/passwd"); err == nil {
+		t.Fatal("expected target outside allowed root to be rejected")
+	}
+	if err := p.Check("/allowed-but-not-really/file.txt"); err == nil {
+		t.Fatal("expected a sibling with a shared prefix to be rejected")
+	}
+}
+
+func TestSymlinkPolicyDeniedRootWinsOverAllowedRoot(t *testing.T) {
+	p := SymlinkPolicy{AllowSymlinks: true, AllowedRoots: []string{"/allowed"}, DeniedRoots: []string{"/allowed/secret"}}
+	if err := p.Check("/allowed/secret/file.txt"); err == nil {
+		t.Fatal("expected the denied subtree to be rejected even though it is inside an allowed root")
+	}
+}
+
+func TestResolveSymlinkTargetResolvesRelativeLinks(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "sub", "link.txt")
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../real.txt", link); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveSymlinkTarget(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "real.txt"); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,22 @@
+// Package sysinfo reports host capacity figures the worker needs for
+// scheduling and metrics, behind platform-specific implementations since
+// the kernel interfaces for this differ between Linux and Darwin.
+package sysinfo
+
+// TotalMemoryBytes returns the total physical memory installed on the
+// host.
+func TotalMemoryBytes() (int64, error) {
+	return totalMemoryBytes()
+}
+
+// FreeDiskBytes returns the free space available to an unprivileged
+// process on the filesystem containing path.
+func FreeDiskBytes(path string) (int64, error) {
+	return freeDiskBytes(path)
+}
+
+// CPUModel returns a human-readable model name for the host's CPU, for
+// attaching to a job's reproducibility report.
+func CPUModel() (string, error) {
+	return cpuModel()
+}
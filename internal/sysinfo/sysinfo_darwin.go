@@ -0,0 +1,39 @@
+package sysinfo
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// totalMemoryBytes shells out to sysctl since Darwin has no Sysinfo
+// syscall and this repo has no dependency that wraps the BSD sysctl(3)
+// call directly.
+func totalMemoryBytes() (int64, error) {
+	out, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return 0, fmt.Errorf("reading hw.memsize via sysctl: %w", err)
+	}
+	return strconv.ParseInt(string(bytes.TrimSpace(out)), 10, 64)
+}
+
+func freeDiskBytes(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// cpuModel shells out to sysctl for the same reason totalMemoryBytes
+// does: Darwin has no syscall this package can read CPU identification
+// from directly.
+func cpuModel() (string, error) {
+	out, err := exec.Command("sysctl", "-n", "machdep.cpu.brand_string").Output()
+	if err != nil {
+		return "", fmt.Errorf("reading machdep.cpu.brand_string via sysctl: %w", err)
+	}
+	return string(bytes.TrimSpace(out)), nil
+}
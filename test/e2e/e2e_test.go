@@ -0,0 +1,221 @@
+//go:build e2e
+
+// Package e2e runs a small matrix of representative jobs (docker and
+// non-docker tools, a writable InitialWorkDir entry) against a real
+// worker binary, started via docker-compose.yml alongside MinIO and
+// cmd/e2eserver (this repo's stand-in for the flowy server's
+// worker-facing API, since the real server lives outside this repo).
+//
+// It is opt-in, not part of the default `go test ./...` run: build with
+// the "e2e" tag, have docker and the compose plugin installed, and set
+// FLOWY_E2E=1, e.g.:
+//
+//	FLOWY_E2E=1 go test -tags e2e ./test/e2e/...
+//
+// A run brings the stack up with `docker compose up --build`, submits
+// each case's job straight to e2eserver's /api/jobs, polls for the
+// worker to report it finished, and asserts on the outputs it collected
+// (including their checksums), then tears the stack down.
+package e2e
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/bioflowy/flowy/internal/api"
+)
+
+const e2eServerURL = "http://localhost:8080"
+
+func sha1Sum(data string) string {
+	h := sha1.Sum([]byte(data))
+	return "sha1$" + hex.EncodeToString(h[:])
+}
+
+// TestE2EMatrix exercises the worker against a matrix of representative
+// tools. The S3-backed leg of the matrix it's meant to eventually cover is
+// not yet runnable: cmd/worker never wires baseOutputFileManager up to an
+// S3FileManager (see cmd/worker/filemanager.go), so there is, today, no
+// way to drive this worker's outputs through MinIO at all. That leg is
+// left as a documented gap rather than faked.
+func TestE2EMatrix(t *testing.T) {
+	if os.Getenv("FLOWY_E2E") == "" {
+		t.Skip("set FLOWY_E2E=1 to run the docker-compose-backed e2e matrix")
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker not found in PATH")
+	}
+
+	up := exec.Command("docker", "compose", "-f", "docker-compose.yml", "up", "--build", "-d")
+	up.Stdout = os.Stdout
+	up.Stderr = os.Stderr
+	if err := up.Run(); err != nil {
+		t.Fatalf("docker compose up: %v", err)
+	}
+	t.Cleanup(func() {
+		down := exec.Command("docker", "compose", "-f", "docker-compose.yml", "down", "-v")
+		down.Stdout = os.Stdout
+		down.Stderr = os.Stderr
+		down.Run()
+	})
+
+	waitForServer(t, 60*time.Second)
+
+	for _, tc := range matrixCases() {
+		tc := tc
+		t.Run(tc.id, func(t *testing.T) {
+			runCase(t, tc)
+		})
+	}
+}
+
+type matrixCase struct {
+	id             string
+	job            api.ExecutableJob
+	wantOutputName string
+	wantContent    string
+}
+
+// matrixCases builds the representative job matrix: a non-docker tool, a
+// docker tool, and a tool that appends to a file staged writable via
+// InitialWorkDir, each collected through a glob OutputBinding so its
+// checksum can be asserted against known content. Streaming (pipe-backed)
+// outputs are covered by cmd/worker/pipe_test.go's
+// TestReceivePipeInputsFeedsStdin instead of here, since exercising a
+// genuinely streamed output end-to-end needs a second client process
+// consuming the pipe mid-job, not just a job submit/poll round trip.
+func matrixCases() []matrixCase {
+	return []matrixCase{
+		{
+			id: "non-docker-shell",
+			job: api.ExecutableJob{
+				JobID:   "e2e-non-docker-shell",
+				Command: []string{"sh", "-c", "printf %s hello > out.txt"},
+				OutputBindings: map[string]api.OutputBinding{
+					"out": {Glob: []string{"out.txt"}},
+				},
+			},
+			wantOutputName: "out",
+			wantContent:    "hello",
+		},
+		{
+			id: "docker-busybox",
+			job: api.ExecutableJob{
+				JobID:       "e2e-docker-busybox",
+				Command:     []string{"sh", "-c", "printf %s hi-from-docker > out.txt"},
+				DockerImage: strPtr("busybox:1.36"),
+				OutputBindings: map[string]api.OutputBinding{
+					"out": {Glob: []string{"out.txt"}},
+				},
+			},
+			wantOutputName: "out",
+			wantContent:    "hi-from-docker",
+		},
+		{
+			id: "writable-initworkdir",
+			job: api.ExecutableJob{
+				JobID:   "e2e-writable-initworkdir",
+				Command: []string{"sh", "-c", "printf append >> seed.txt"},
+				Mapping: []api.MapperEnt{
+					{
+						Target:   "seed.txt",
+						Type:     "File",
+						Contents: "seed",
+						Staged:   true,
+						Writable: true,
+					},
+				},
+				OutputBindings: map[string]api.OutputBinding{
+					"seed": {Glob: []string{"seed.txt"}},
+				},
+			},
+			wantOutputName: "seed",
+			wantContent:    "seedappend",
+		},
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func runCase(t *testing.T, tc matrixCase) {
+	t.Helper()
+
+	body, err := json.Marshal(tc.job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(e2eServerURL+"/api/jobs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("submitting job: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("submitting job: server returned %s", resp.Status)
+	}
+
+	result, err := pollJob(tc.job.JobID, 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("job failed: %s", result.ErrorMsg)
+	}
+
+	out, ok := result.Outputs[tc.wantOutputName].(map[string]interface{})
+	if !ok {
+		t.Fatalf("outputs[%q] = %#v, want a File object", tc.wantOutputName, result.Outputs[tc.wantOutputName])
+	}
+	want := sha1Sum(tc.wantContent)
+	if got := out["checksum"]; got != want {
+		t.Fatalf("checksum = %v, want %v", got, want)
+	}
+}
+
+func pollJob(jobID string, timeout time.Duration) (*api.JobFinishedRequest, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := http.Get(e2eServerURL + "/api/jobs/" + jobID)
+		if err != nil {
+			return nil, err
+		}
+		var result api.JobFinishedRequest
+		decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		if result.Success || result.ErrorMsg != "" {
+			return &result, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("job %s did not complete within %s", jobID, timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// waitForServer polls e2eserver's next-job endpoint until it responds,
+// giving the compose stack time to build and start.
+func waitForServer(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := http.Get(e2eServerURL + "/api/worker/next-job")
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("e2eserver did not become reachable within %s: %v", timeout, err)
+		}
+		time.Sleep(time.Second)
+	}
+}